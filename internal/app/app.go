@@ -2,35 +2,71 @@ package app
 
 import (
 	"context"
+	"flag"
+	"os"
 
 	"p2pos/internal/config"
 	"p2pos/internal/database"
 	"p2pos/internal/events"
 	"p2pos/internal/logging"
+	"p2pos/internal/membership"
 	"p2pos/internal/network"
 	"p2pos/internal/scheduler"
+	"p2pos/internal/update"
 )
 
-func Run(_ []string) error {
+const startupEventBacklog = 256
+
+func Run(args []string) error {
+	fs := flag.NewFlagSet("p2pos", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	relayPath := fs.String("relay-snapshot", "", "snapshot file from sign-snapshot to relay to the cluster once the node is healthy")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	var relay *membership.Snapshot
+	if *relayPath != "" {
+		snapshot, err := readSnapshotFile(*relayPath)
+		if err != nil {
+			return err
+		}
+		relay = &snapshot
+	}
+
 	logging.Log("APP", "version", map[string]string{
 		"version": config.AppVersion,
 	})
 
+	update.CleanupPreviousBinary()
+
 	if err := database.Init(); err != nil {
 		return err
 	}
 
 	eventBus := events.NewBus()
+	// Services subscribe after the node starts connecting; keep early events
+	// (connects, heartbeats) so they are replayed once those services attach.
+	eventBus.RetainStartup(startupEventBacklog)
 	configStore := config.NewStore(eventBus)
+	configStore.SetKeyStore(database.NewNodeKeyRepository())
 	if err := configStore.Init(); err != nil {
 		return err
 	}
+	if err := logging.Configure(configStore.LogLevel(), configStore.LogFormat()); err != nil {
+		return err
+	}
+
+	if configStore.ManualStart() {
+		if err := manualStartSupported(); err != nil {
+			return err
+		}
+	}
 
 	netNode, err := network.NewNode(configStore, eventBus)
 	if err != nil {
 		return err
 	}
-	defer netNode.Close()
+	defer netNode.Shutdown("app-exit")
 
 	if err := netNode.LogLocalAddrs(); err != nil {
 		return err
@@ -38,6 +74,9 @@ func Run(_ []string) error {
 	if err := setupMembership(configStore, netNode); err != nil {
 		return err
 	}
+	if configStore.ManualStart() {
+		netNode.Pause()
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -45,14 +84,28 @@ func Run(_ []string) error {
 	stopShutdownBridge := startShutdownBridge(ctx, cancel, eventBus, shutdownNotifier)
 	defer stopShutdownBridge()
 
-	startRuntimeServices(ctx, eventBus, netNode)
+	startRuntimeServices(ctx, eventBus, netNode, configStore)
+	eventBus.EndStartup()
+
+	if netNode.Paused() {
+		stopResumeSignal := startResumeSignal(ctx, netNode)
+		err := netNode.WaitResumed(ctx)
+		stopResumeSignal()
+		if err != nil {
+			return nil
+		}
+	}
 
 	jobScheduler := scheduler.New()
+	netNode.SetTaskStatsProvider(jobScheduler)
 	if err := registerScheduledTasks(ctx, jobScheduler, netNode, configStore, shutdownNotifier); err != nil {
 		return err
 	}
 
 	jobScheduler.Start(ctx)
+	if relay != nil {
+		startSnapshotRelay(ctx, netNode, *relay)
+	}
 	<-ctx.Done()
 
 	logging.Log("APP", "shutdown", map[string]string{
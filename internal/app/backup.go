@@ -0,0 +1,280 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"p2pos/internal/config"
+	"p2pos/internal/database"
+	"p2pos/internal/membership"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// backupFormatVersion 2 added the signed membership snapshot; version 1
+// archives are still restored, with members only.
+const backupFormatVersion = 2
+
+// backupArchive is the on-disk backup. Payload is kept as raw JSON so the
+// signature covers exactly the bytes that were marshaled.
+type backupArchive struct {
+	Payload json.RawMessage `json:"payload"`
+	Sig     string          `json:"sig"`
+}
+
+type backupPayload struct {
+	Version        int             `json:"version"`
+	CreatedAt      time.Time       `json:"created_at"`
+	PeerID         string          `json:"peer_id"`
+	NodePrivateKey string          `json:"node_private_key"`
+	Config         config.Config   `json:"config"`
+	Members        []string        `json:"members"`
+	Peers          []database.Peer `json:"peers"`
+	// Snapshot is the last applied signed snapshot, admin proof included,
+	// so a restored node can validate and serve membership on its own.
+	Snapshot *membership.Snapshot `json:"snapshot,omitempty"`
+}
+
+func RunBackup(args []string) error {
+	fs := flag.NewFlagSet("backup", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	configPath := fs.String("config", "config.json", "config file to back up")
+	dbPath := fs.String("db", "", "sqlite database to back up (default: sqlite.db next to the executable)")
+	out := fs.String("out", "p2pos-backup.json", "backup archive to write")
+	omitSecrets := fs.Bool("omit-secrets", false, "strip admin proof, forge auth and private network secret from the config")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return fmt.Errorf("load config failed: %w", err)
+	}
+	if cfg.EphemeralIdentity {
+		return fmt.Errorf("ephemeral_identity is set; there is no node key to back up")
+	}
+	priv, err := decodeNodeKey(cfg.NodePrivateKey)
+	if err != nil {
+		return err
+	}
+	peerID, err := peer.IDFromPrivateKey(priv)
+	if err != nil {
+		return err
+	}
+
+	if *dbPath == "" {
+		if *dbPath, err = database.DefaultPath(); err != nil {
+			return err
+		}
+	}
+	if err := database.InitAt(*dbPath); err != nil {
+		return fmt.Errorf("open database failed: %w", err)
+	}
+	repo := database.NewPeerRepository()
+	members, err := repo.ListMemberIDs(context.Background())
+	if err != nil {
+		return err
+	}
+	peers, err := repo.ListPeerStatuses(context.Background())
+	if err != nil {
+		return err
+	}
+	snapshot, err := loadBackupSnapshot()
+	if err != nil {
+		return err
+	}
+
+	payload := backupPayload{
+		Version:        backupFormatVersion,
+		CreatedAt:      time.Now().UTC(),
+		PeerID:         peerID.String(),
+		NodePrivateKey: cfg.NodePrivateKey,
+		Config:         *cfg,
+		Members:        members,
+		Peers:          peers,
+		Snapshot:       snapshot,
+	}
+	payload.Config.NodePrivateKey = ""
+	if *omitSecrets {
+		payload.Config.AdminProof = config.AdminProof{}
+		payload.Config.AutoTLS.ForgeAuth = ""
+		payload.Config.PrivateNetwork.Secret = ""
+	}
+
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	sig, err := priv.Sign(raw)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(backupArchive{
+		Payload: raw,
+		Sig:     base64.StdEncoding.EncodeToString(sig),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	// The archive contains the node private key.
+	if err := os.WriteFile(*out, append(data, '\n'), 0600); err != nil {
+		return err
+	}
+
+	fmt.Printf("BACKUP_FILE=%s\n", *out)
+	fmt.Printf("BACKUP_PEER_ID=%s\n", peerID.String())
+	fmt.Printf("BACKUP_MEMBERS=%d\n", len(members))
+	fmt.Printf("BACKUP_PEERS=%d\n", len(peers))
+	fmt.Printf("BACKUP_SNAPSHOT=%t\n", snapshot != nil)
+	return nil
+}
+
+func RunRestore(args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	in := fs.String("in", "p2pos-backup.json", "backup archive to restore")
+	dir := fs.String("dir", ".", "target data dir for config.json and sqlite.db")
+	force := fs.Bool("force", false, "overwrite existing config.json/sqlite.db in the target dir")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	payload, err := readBackup(*in)
+	if err != nil {
+		return err
+	}
+
+	configPath := filepath.Join(*dir, "config.json")
+	dbPath := filepath.Join(*dir, "sqlite.db")
+	if !*force {
+		for _, path := range []string{configPath, dbPath} {
+			if _, err := os.Stat(path); err == nil {
+				return fmt.Errorf("%s already exists, use -force to overwrite", path)
+			}
+		}
+	}
+	if err := os.MkdirAll(*dir, 0755); err != nil {
+		return err
+	}
+
+	if err := database.InitAt(dbPath); err != nil {
+		return fmt.Errorf("open database failed: %w", err)
+	}
+	if err := database.NewPeerRepository().ImportPeers(context.Background(), payload.Peers); err != nil {
+		return fmt.Errorf("restore peers failed: %w", err)
+	}
+	if payload.Snapshot != nil {
+		raw, err := json.Marshal(payload.Snapshot)
+		if err != nil {
+			return err
+		}
+		if err := database.NewRuntimeResumeRepository().SaveSnapshot(context.Background(), string(raw)); err != nil {
+			return fmt.Errorf("restore snapshot failed: %w", err)
+		}
+	}
+
+	cfg := payload.Config
+	cfg.NodePrivateKey = payload.NodePrivateKey
+	if err := config.Save(configPath, cfg); err != nil {
+		return fmt.Errorf("write config failed: %w", err)
+	}
+
+	fmt.Printf("RESTORE_DIR=%s\n", *dir)
+	fmt.Printf("RESTORE_PEER_ID=%s\n", payload.PeerID)
+	fmt.Printf("RESTORE_MEMBERS=%d\n", len(payload.Members))
+	fmt.Printf("RESTORE_PEERS=%d\n", len(payload.Peers))
+	fmt.Printf("RESTORE_SNAPSHOT=%t\n", payload.Snapshot != nil)
+	return nil
+}
+
+// readBackup loads an archive and verifies it was signed by the node key it
+// carries, so a restored node keeps the backed-up peer ID.
+func readBackup(path string) (backupPayload, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return backupPayload{}, err
+	}
+	var archive backupArchive
+	if err := json.Unmarshal(data, &archive); err != nil {
+		return backupPayload{}, fmt.Errorf("decode backup failed: %w", err)
+	}
+	var payload backupPayload
+	if err := json.Unmarshal(archive.Payload, &payload); err != nil {
+		return backupPayload{}, fmt.Errorf("decode backup payload failed: %w", err)
+	}
+	if payload.Version < 1 || payload.Version > backupFormatVersion {
+		return backupPayload{}, fmt.Errorf("unsupported backup version %d", payload.Version)
+	}
+
+	priv, err := decodeNodeKey(payload.NodePrivateKey)
+	if err != nil {
+		return backupPayload{}, err
+	}
+	peerID, err := peer.IDFromPrivateKey(priv)
+	if err != nil {
+		return backupPayload{}, err
+	}
+	if peerID.String() != payload.PeerID {
+		return backupPayload{}, fmt.Errorf("backup peer_id %s does not match node key (%s)", payload.PeerID, peerID)
+	}
+	sig, err := base64.StdEncoding.DecodeString(archive.Sig)
+	if err != nil {
+		return backupPayload{}, fmt.Errorf("decode backup sig failed: %w", err)
+	}
+	// The archive is written indented, which re-indents the payload too;
+	// the signature covers its compact form.
+	var signed bytes.Buffer
+	if err := json.Compact(&signed, archive.Payload); err != nil {
+		return backupPayload{}, fmt.Errorf("decode backup payload failed: %w", err)
+	}
+	ok, err := priv.GetPublic().Verify(signed.Bytes(), sig)
+	if err != nil || !ok {
+		return backupPayload{}, errors.New("backup signature invalid")
+	}
+	return payload, nil
+}
+
+// loadBackupSnapshot returns the snapshot the node last applied, or nil if
+// it never applied a signed one.
+func loadBackupSnapshot() (*membership.Snapshot, error) {
+	saved, ok, err := database.NewRuntimeResumeRepository().Load(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("load membership snapshot failed: %w", err)
+	}
+	if !ok || saved.Snapshot == "" {
+		return nil, nil
+	}
+	var snapshot membership.Snapshot
+	if err := json.Unmarshal([]byte(saved.Snapshot), &snapshot); err != nil {
+		return nil, fmt.Errorf("decode membership snapshot failed: %w", err)
+	}
+	return &snapshot, nil
+}
+
+func decodeNodeKey(b64 string) (crypto.PrivKey, error) {
+	if b64 == "" {
+		return nil, errors.New("node_private_key is empty")
+	}
+	raw, err := base64.StdEncoding.DecodeString(b64)
+	if err != nil {
+		return nil, fmt.Errorf("decode node_private_key failed: %w", err)
+	}
+	priv, err := crypto.UnmarshalPrivateKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal node_private_key failed: %w", err)
+	}
+	return priv, nil
+}
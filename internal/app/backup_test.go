@@ -0,0 +1,123 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"p2pos/internal/config"
+	"p2pos/internal/database"
+	"p2pos/internal/membership"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestBackupRestoreRoundTrip(t *testing.T) {
+	src := t.TempDir()
+	dst := filepath.Join(t.TempDir(), "restored")
+
+	configPath, peerID := writeTestConfig(t, src, nil)
+
+	dbPath := filepath.Join(src, "sqlite.db")
+	if err := database.InitAt(dbPath); err != nil {
+		t.Fatal(err)
+	}
+	members := []string{peerID.String(), "12D3KooWMember"}
+	if err := database.NewPeerRepository().SyncMembers(context.Background(), members); err != nil {
+		t.Fatal(err)
+	}
+	validFrom := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	snapshot := membership.Snapshot{
+		ClusterID:    testClusterID,
+		IssuedAt:     time.Now().UTC().Truncate(time.Second),
+		IssuerPeerID: peerID.String(),
+		Members:      members,
+		AdminProof: membership.AdminProof{
+			ClusterID: testClusterID,
+			PeerID:    peerID.String(),
+			Role:      "admin",
+			ValidFrom: validFrom,
+			ValidTo:   validFrom.Add(48 * time.Hour),
+			Sig:       "proof-sig",
+		},
+		Sig: "snapshot-sig",
+	}
+	persistResumeSnapshot(database.NewRuntimeResumeRepository(), snapshot)
+
+	archive := filepath.Join(src, "backup.json")
+	if err := RunBackup([]string{"-config", configPath, "-db", dbPath, "-out", archive}); err != nil {
+		t.Fatalf("backup: %v", err)
+	}
+	if err := RunRestore([]string{"-in", archive, "-dir", dst}); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+
+	restored, err := config.Load(filepath.Join(dst, "config.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	restoredKey, err := decodeNodeKey(restored.NodePrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restoredID, err := peer.IDFromPrivateKey(restoredKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restoredID != peerID {
+		t.Fatalf("restored peer ID %s, want %s", restoredID, peerID)
+	}
+
+	// RunRestore leaves the restored database open.
+	gotMembers, err := database.NewPeerRepository().ListMemberIDs(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gotMembers) != len(members) {
+		t.Fatalf("restored members %v, want %v", gotMembers, members)
+	}
+	saved, ok, err := database.NewRuntimeResumeRepository().Load(context.Background())
+	if err != nil || !ok {
+		t.Fatalf("restored snapshot missing: ok=%v err=%v", ok, err)
+	}
+	var got membership.Snapshot
+	if err := json.Unmarshal([]byte(saved.Snapshot), &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, snapshot) {
+		t.Fatalf("restored snapshot %+v, want %+v", got, snapshot)
+	}
+}
+
+func TestRestoreRejectsTamperedBackup(t *testing.T) {
+	dir := t.TempDir()
+	configPath, _ := writeTestConfig(t, dir, nil)
+	archive := filepath.Join(dir, "backup.json")
+	if err := RunBackup([]string{"-config", configPath, "-db", filepath.Join(dir, "sqlite.db"), "-out", archive}); err != nil {
+		t.Fatalf("backup: %v", err)
+	}
+
+	payload, err := readBackup(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload.Members = append(payload.Members, "12D3KooWIntruder")
+	tampered, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(backupArchive{Payload: tampered, Sig: "AAAA"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(archive, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readBackup(archive); err == nil {
+		t.Fatal("tampered backup was accepted")
+	}
+}
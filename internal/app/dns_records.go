@@ -0,0 +1,167 @@
+package app
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"strings"
+
+	"p2pos/internal/config"
+	"p2pos/internal/network"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	multiaddr "github.com/multiformats/go-multiaddr"
+)
+
+// RunDNSRecords prints the TXT values to publish under _dnsaddr.<domain> so
+// that other nodes can bootstrap from this one through a dns init
+// connection. Direct addresses come from announce_addrs, or from listen with
+// wildcard hosts replaced by -host; -relay adds a circuit address through
+// each given relay.
+func RunDNSRecords(args []string) error {
+	fs := flag.NewFlagSet("dns-records", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	configPath := fs.String("config", "config.json", "config file of the node to publish")
+	host := fs.String("host", "", "public IP or DNS name to use for wildcard listen hosts")
+	relays := fs.String("relay", "", "comma-separated relay multiaddrs (with /p2p/<relay id>) to publish circuit addresses through")
+	domain := fs.String("domain", "", "bootstrap domain, only used to print the record name")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return fmt.Errorf("load config failed: %w", err)
+	}
+	if cfg.EphemeralIdentity {
+		return fmt.Errorf("ephemeral_identity is set; the peer id changes every start")
+	}
+	priv, err := decodeNodeKey(cfg.NodePrivateKey)
+	if err != nil {
+		return err
+	}
+	id, err := peer.IDFromPrivateKey(priv)
+	if err != nil {
+		return err
+	}
+
+	addrs, err := directAddrs(cfg, strings.TrimSpace(*host))
+	if err != nil {
+		return err
+	}
+	for _, raw := range splitList(*relays) {
+		relay, err := multiaddr.NewMultiaddr(raw)
+		if err != nil {
+			return fmt.Errorf("invalid relay %q: %w", raw, err)
+		}
+		if _, err := relay.ValueForProtocol(multiaddr.P_P2P); err != nil {
+			return fmt.Errorf("relay %q needs a /p2p/<relay id> suffix", raw)
+		}
+		circuit, err := multiaddr.NewMultiaddr(relay.String() + "/p2p-circuit")
+		if err != nil {
+			return err
+		}
+		addrs = append(addrs, circuit)
+	}
+	if len(addrs) == 0 {
+		return fmt.Errorf("no publishable addresses; set announce_addrs, pass -host or -relay")
+	}
+
+	if name := strings.TrimSuffix(strings.TrimSpace(*domain), "."); name != "" {
+		if !strings.HasPrefix(name, "_dnsaddr.") {
+			name = "_dnsaddr." + name
+		}
+		fmt.Fprintf(os.Stderr, "TXT records for %s:\n", name)
+	}
+	for _, addr := range addrs {
+		record, err := network.DNSAddrRecord(addr, id)
+		if err != nil {
+			return err
+		}
+		fmt.Println(record)
+	}
+	return nil
+}
+
+func directAddrs(cfg *config.Config, host string) ([]multiaddr.Multiaddr, error) {
+	out := make([]multiaddr.Multiaddr, 0)
+	if len(cfg.AnnounceAddrs) > 0 {
+		for _, raw := range cfg.AnnounceAddrs {
+			addr, err := multiaddr.NewMultiaddr(strings.TrimSpace(raw))
+			if err != nil {
+				return nil, fmt.Errorf("invalid announce_addrs entry %q: %w", raw, err)
+			}
+			out = append(out, addr)
+		}
+		return out, nil
+	}
+
+	quic := cfg.PrivateNetwork.Secret == ""
+	for _, listen := range cfg.Listen.Values() {
+		listenHost, port, err := net.SplitHostPort(strings.TrimSpace(listen))
+		if err != nil {
+			// Unix sockets and bare ports are not reachable from DNS.
+			continue
+		}
+		if ip, err := netip.ParseAddr(listenHost); err == nil && !ip.IsUnspecified() && !ip.IsLoopback() && !ip.IsLinkLocalUnicast() {
+			out = append(out, hostAddrs(listenHost, port, quic)...)
+			continue
+		}
+		if host == "" {
+			fmt.Fprintf(os.Stderr, "skip listen %s: wildcard, loopback or link-local host, pass -host\n", listen)
+			continue
+		}
+		out = append(out, hostAddrs(host, port, quic)...)
+	}
+	return dedupeAddrs(out), nil
+}
+
+// hostAddrs returns the tcp and, unless the node runs a private network
+// (which QUIC cannot join), quic-v1 addresses for host and port.
+func hostAddrs(host, port string, quic bool) []multiaddr.Multiaddr {
+	prefix := "/dns/" + host
+	if ip, err := netip.ParseAddr(host); err == nil {
+		prefix = "/ip6/" + ip.String()
+		if ip.Unmap().Is4() {
+			prefix = "/ip4/" + ip.Unmap().String()
+		}
+	}
+	raw := []string{fmt.Sprintf("%s/tcp/%s", prefix, port)}
+	if quic {
+		raw = append(raw, fmt.Sprintf("%s/udp/%s/quic-v1", prefix, port))
+	}
+	out := make([]multiaddr.Multiaddr, 0, len(raw))
+	for _, r := range raw {
+		if addr, err := multiaddr.NewMultiaddr(r); err == nil {
+			out = append(out, addr)
+		}
+	}
+	return out
+}
+
+func dedupeAddrs(in []multiaddr.Multiaddr) []multiaddr.Multiaddr {
+	seen := make(map[string]struct{}, len(in))
+	out := make([]multiaddr.Multiaddr, 0, len(in))
+	for _, addr := range in {
+		if _, ok := seen[addr.String()]; ok {
+			continue
+		}
+		seen[addr.String()] = struct{}{}
+		out = append(out, addr)
+	}
+	return out
+}
+
+func splitList(raw string) []string {
+	out := make([]string, 0)
+	for _, part := range strings.Split(raw, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
@@ -0,0 +1,111 @@
+package app
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"p2pos/internal/config"
+	"p2pos/internal/membership"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+const testClusterID = "test"
+
+func newTestKey(t *testing.T) (crypto.PrivKey, peer.ID) {
+	t.Helper()
+	priv, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := peer.IDFromPrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return priv, id
+}
+
+func encodeKey(t *testing.T, priv crypto.PrivKey) string {
+	t.Helper()
+	raw, err := crypto.MarshalPrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(raw)
+}
+
+// writeTestConfig writes dir/config.json for a new node key and returns the
+// path and the node's peer ID. edit, if set, adjusts the config first.
+func writeTestConfig(t *testing.T, dir string, edit func(*config.Config)) (string, peer.ID) {
+	t.Helper()
+	priv, id := newTestKey(t)
+	cfg := config.Default()
+	cfg.NodePrivateKey = encodeKey(t, priv)
+	if edit != nil {
+		edit(&cfg)
+	}
+	path := filepath.Join(dir, "config.json")
+	if err := config.Save(path, cfg); err != nil {
+		t.Fatal(err)
+	}
+	return path, id
+}
+
+// testAdmin is an offline admin key with a proof from a test system key.
+type testAdmin struct {
+	systemPub string
+	priv      crypto.PrivKey
+	id        peer.ID
+	proof     membership.AdminProof
+}
+
+func newTestAdmin(t *testing.T) testAdmin {
+	t.Helper()
+	systemPriv, _ := newTestKey(t)
+	rawPub, err := crypto.MarshalPublicKey(systemPriv.GetPublic())
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, id := newTestKey(t)
+	proof, err := membership.SignAdminProof(systemPriv, membership.AdminProof{
+		ClusterID: testClusterID,
+		PeerID:    id.String(),
+		Role:      "admin",
+		ValidFrom: time.Now().UTC().Add(-time.Hour),
+		ValidTo:   time.Now().UTC().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testAdmin{
+		systemPub: base64.StdEncoding.EncodeToString(rawPub),
+		priv:      priv,
+		id:        id,
+		proof:     proof,
+	}
+}
+
+// configure points cfg at the admin's cluster and system key.
+func (a testAdmin) configure(cfg *config.Config) {
+	cfg.ClusterID = testClusterID
+	cfg.SystemPubKey = a.systemPub
+}
+
+func (a testAdmin) sign(t *testing.T, members ...string) membership.Snapshot {
+	t.Helper()
+	snapshot, err := membership.SignSnapshot(a.priv, membership.Snapshot{
+		ClusterID:    testClusterID,
+		IssuedAt:     time.Now().UTC(),
+		IssuerPeerID: a.id.String(),
+		Members:      members,
+		AdminProof:   a.proof,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return snapshot
+}
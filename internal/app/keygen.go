@@ -7,6 +7,9 @@ import (
 	"os"
 	"time"
 
+	"p2pos/internal/config"
+	"p2pos/internal/membership"
+
 	"github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/multiformats/go-multibase"
@@ -20,12 +23,13 @@ func RunKeygen(args []string) error {
 	clusterID := fs.String("cluster-id", "default", "cluster id")
 	adminValidTo := fs.String("admin-valid-to", "9999-12-31T00:00:00Z", "admin proof valid_to (RFC3339/RFC3339Nano)")
 	nodePriv := fs.String("node-priv", "", "existing node private key (base64), optional")
+	keyType := fs.String("key-type", config.KeyTypeEd25519, "key type for generated keys (ed25519|secp256k1)")
 
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
 
-	nodePrivKey, nodePrivB64, nodePeerID, err := ensureNodeKey(*nodePriv)
+	nodePrivKey, nodePrivB64, nodePeerID, err := ensureNodeKey(*nodePriv, *keyType)
 	if err != nil {
 		return err
 	}
@@ -38,10 +42,11 @@ func RunKeygen(args []string) error {
 		return nil
 	}
 
-	sysPriv, sysPub, err := crypto.GenerateEd25519Key(nil)
+	sysPriv, err := config.GeneratePrivateKey(*keyType)
 	if err != nil {
 		return err
 	}
+	sysPub := sysPriv.GetPublic()
 	sysPrivB64, err := marshalPrivB64(sysPriv)
 	if err != nil {
 		return err
@@ -51,7 +56,7 @@ func RunKeygen(args []string) error {
 		return err
 	}
 
-	adminPriv, _, err := crypto.GenerateEd25519Key(nil)
+	adminPriv, err := config.GeneratePrivateKey(*keyType)
 	if err != nil {
 		return err
 	}
@@ -70,17 +75,16 @@ func RunKeygen(args []string) error {
 		return fmt.Errorf("admin-valid-to invalid: %w", err)
 	}
 
-	payload := []byte(fmt.Sprintf("%s|%s|admin|%s|%s",
-		*clusterID,
-		adminPeerID.String(),
-		validFrom.UTC().Format(time.RFC3339Nano),
-		validTo.UTC().Format(time.RFC3339Nano),
-	))
-	sig, err := sysPriv.Sign(payload)
+	proof, err := membership.SignAdminProof(sysPriv, membership.AdminProof{
+		ClusterID: *clusterID,
+		PeerID:    adminPeerID.String(),
+		Role:      "admin",
+		ValidFrom: validFrom,
+		ValidTo:   validTo,
+	})
 	if err != nil {
 		return err
 	}
-	sigB64 := base64.StdEncoding.EncodeToString(sig)
 
 	fmt.Printf("SYSTEM_PRIV_B64=%s\n", sysPrivB64)
 	fmt.Printf("SYSTEM_PUB_B64=%s\n", sysPubB64)
@@ -92,13 +96,14 @@ func RunKeygen(args []string) error {
 	fmt.Printf("ADMIN_PROOF_ROLE=admin\n")
 	fmt.Printf("ADMIN_PROOF_VALID_FROM=%s\n", validFrom.UTC().Format(time.RFC3339Nano))
 	fmt.Printf("ADMIN_PROOF_VALID_TO=%s\n", validTo.UTC().Format(time.RFC3339Nano))
-	fmt.Printf("ADMIN_PROOF_SIG=%s\n", sigB64)
+	fmt.Printf("ADMIN_PROOF_ALG=%s\n", proof.Alg)
+	fmt.Printf("ADMIN_PROOF_SIG=%s\n", proof.Sig)
 
 	_ = nodePrivKey
 	return nil
 }
 
-func ensureNodeKey(nodePrivB64 string) (crypto.PrivKey, string, peer.ID, error) {
+func ensureNodeKey(nodePrivB64, keyType string) (crypto.PrivKey, string, peer.ID, error) {
 	if nodePrivB64 != "" {
 		raw, err := base64.StdEncoding.DecodeString(nodePrivB64)
 		if err != nil {
@@ -115,7 +120,7 @@ func ensureNodeKey(nodePrivB64 string) (crypto.PrivKey, string, peer.ID, error)
 		return priv, nodePrivB64, id, nil
 	}
 
-	priv, _, err := crypto.GenerateEd25519Key(nil)
+	priv, err := config.GeneratePrivateKey(keyType)
 	if err != nil {
 		return nil, "", "", err
 	}
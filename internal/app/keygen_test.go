@@ -0,0 +1,83 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"p2pos/internal/membership"
+)
+
+// runKeygen runs RunKeygen and returns its KEY=VALUE output.
+func runKeygen(t *testing.T, args ...string) map[string]string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	runErr := RunKeygen(args)
+	os.Stdout = stdout
+	w.Close()
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	r.Close()
+	if runErr != nil {
+		t.Fatal(runErr)
+	}
+
+	out := make(map[string]string)
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		if key, value, ok := strings.Cut(scanner.Text(), "="); ok {
+			out[key] = value
+		}
+	}
+	return out
+}
+
+func TestKeygenAdminProofVerifies(t *testing.T) {
+	for _, keyType := range []string{"ed25519", "secp256k1"} {
+		t.Run(keyType, func(t *testing.T) {
+			out := runKeygen(t, "-new-system", "-cluster-id", testClusterID, "-key-type", keyType)
+			validFrom, err := time.Parse(time.RFC3339Nano, out["ADMIN_PROOF_VALID_FROM"])
+			if err != nil {
+				t.Fatal(err)
+			}
+			validTo, err := time.Parse(time.RFC3339Nano, out["ADMIN_PROOF_VALID_TO"])
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The proof as an operator copies it into config.json.
+			proof := membership.AdminProof{
+				ClusterID: out["ADMIN_PROOF_CLUSTER_ID"],
+				PeerID:    out["ADMIN_PROOF_PEER_ID"],
+				Role:      out["ADMIN_PROOF_ROLE"],
+				ValidFrom: validFrom,
+				ValidTo:   validTo,
+				Alg:       out["ADMIN_PROOF_ALG"],
+				Sig:       out["ADMIN_PROOF_SIG"],
+			}
+			admin := out["ADMIN_PEER_ID"]
+			manager, err := membership.NewManager(testClusterID, out["SYSTEM_PUB_B64"], admin, []string{admin})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := manager.ValidateAdminProof(proof, admin); err != nil {
+				t.Fatalf("keygen's admin proof does not verify: %v", err)
+			}
+
+			proof.ValidTo = proof.ValidTo.Add(time.Second)
+			if err := manager.ValidateAdminProof(proof, admin); err == nil {
+				t.Fatal("admin proof with an edited valid_to verified")
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package app
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"p2pos/internal/config"
+	"p2pos/internal/database"
+	"p2pos/internal/events"
+	"p2pos/internal/network"
+)
+
+// RunExportMembership writes the node's last signed membership snapshot to a
+// file that any node of the cluster can import. It reads the data dir only
+// and can run next to a live node.
+func RunExportMembership(args []string) error {
+	fs := flag.NewFlagSet("export-membership", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	dbPath := fs.String("db", "", "sqlite database of the node (default: sqlite.db next to the executable)")
+	out := fs.String("out", "membership-snapshot.json", "snapshot file to write")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	node, err := openOfflineNode(*dbPath, false)
+	if err != nil {
+		return err
+	}
+	defer node.Close()
+
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if err := node.ExportMembership(f); err != nil {
+		f.Close()
+		os.Remove(*out)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("EXPORT_FILE=%s\n", *out)
+	return nil
+}
+
+// RunImportMembership applies a snapshot written by export-membership to the
+// node's data dir, with the usual signature, admin proof and freshness
+// checks. The node starts from it on its next run, which is how a new
+// bootstrap node learns the cluster. Stop the node first: a running node
+// keeps its own snapshot in memory.
+func RunImportMembership(args []string) error {
+	fs := flag.NewFlagSet("import-membership", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	dbPath := fs.String("db", "", "sqlite database of the node (default: sqlite.db next to the executable)")
+	in := fs.String("in", "membership-snapshot.json", "snapshot file to import")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	node, err := openOfflineNode(*dbPath, true)
+	if err != nil {
+		return err
+	}
+	defer node.Close()
+
+	f, err := os.Open(*in)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := node.ImportMembership(f); err != nil {
+		return err
+	}
+	fmt.Printf("IMPORT_FILE=%s\n", *in)
+	return nil
+}
+
+// openOfflineNode loads config.json and the database like Run and builds a
+// node that listens on loopback only and never dials, with the stored
+// membership restored. With persist set, snapshots it applies are saved
+// back to the database.
+func openOfflineNode(dbPath string, persist bool) (*network.Node, error) {
+	var err error
+	if dbPath == "" {
+		if dbPath, err = database.DefaultPath(); err != nil {
+			return nil, err
+		}
+	}
+	if err := database.InitAt(dbPath); err != nil {
+		return nil, fmt.Errorf("open database failed: %w", err)
+	}
+
+	bus := events.NewBus()
+	configStore := config.NewStore(bus)
+	configStore.SetKeyStore(database.NewNodeKeyRepository())
+	if err := configStore.Init(); err != nil {
+		return nil, err
+	}
+	node, err := network.NewNode(offlineConfig{configStore}, bus)
+	if err != nil {
+		return nil, err
+	}
+
+	peerRepo, manager, err := newMembershipManager(configStore, node)
+	if err != nil {
+		node.Close()
+		return nil, err
+	}
+	resumeRepo := database.NewRuntimeResumeRepository()
+	if saved, ok := loadRuntimeResume(resumeRepo); ok {
+		restoreSnapshot(saved, manager)
+	}
+	if persist {
+		node.SetMembershipAppliedHandler(persistMembership(peerRepo, resumeRepo))
+	}
+	node.SetMembershipManager(manager)
+	return node, nil
+}
+
+// offlineConfig keeps a node built from the real config off the network: a
+// random loopback port, no business listeners, unix sockets or AutoTLS, and
+// private mode so it offers no relay or NAT service.
+type offlineConfig struct {
+	*config.Store
+}
+
+func (offlineConfig) ListenAddresses() []string         { return []string{"127.0.0.1:0"} }
+func (offlineConfig) BusinessListenAddresses() []string { return nil }
+func (offlineConfig) NetworkMode() string               { return "private" }
+func (offlineConfig) AutoTLSMode() string               { return "off" }
+func (offlineConfig) UnixTransport() bool               { return false }
+func (offlineConfig) AnnounceAddrs() []string           { return nil }
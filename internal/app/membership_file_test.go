@@ -0,0 +1,84 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"p2pos/internal/database"
+	"p2pos/internal/membership"
+)
+
+func TestExportImportMembership(t *testing.T) {
+	admin := newTestAdmin(t)
+
+	src := t.TempDir()
+	_, srcID := writeTestConfig(t, src, admin.configure)
+	srcDB := filepath.Join(src, "sqlite.db")
+	if err := database.InitAt(srcDB); err != nil {
+		t.Fatal(err)
+	}
+	snapshot := admin.sign(t, srcID.String())
+	persistResumeSnapshot(database.NewRuntimeResumeRepository(), snapshot)
+
+	file := filepath.Join(t.TempDir(), "membership-snapshot.json")
+	t.Chdir(src)
+	if err := RunExportMembership([]string{"-db", srcDB, "-out", file}); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+
+	dst := t.TempDir()
+	writeTestConfig(t, dst, admin.configure)
+	dstDB := filepath.Join(dst, "sqlite.db")
+	t.Chdir(dst)
+	if err := RunImportMembership([]string{"-db", dstDB, "-in", file}); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+
+	// RunImportMembership leaves the destination database open.
+	saved, ok, err := database.NewRuntimeResumeRepository().Load(context.Background())
+	if err != nil || !ok {
+		t.Fatalf("imported snapshot not saved: ok=%v err=%v", ok, err)
+	}
+	var got membership.Snapshot
+	if err := json.Unmarshal([]byte(saved.Snapshot), &got); err != nil {
+		t.Fatal(err)
+	}
+	if membership.Hash(got) != membership.Hash(snapshot) {
+		t.Fatalf("imported snapshot %+v, want %+v", got, snapshot)
+	}
+	members, err := database.NewPeerRepository().ListMemberIDs(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(members) != len(snapshot.Members) {
+		t.Fatalf("imported members %v, want %v", members, snapshot.Members)
+	}
+
+	if err := RunImportMembership([]string{"-db", dstDB, "-in", file}); err == nil {
+		t.Fatal("importing the same snapshot again should report it is not newer")
+	}
+}
+
+func TestImportMembershipRejectsForeignAdmin(t *testing.T) {
+	admin := newTestAdmin(t)
+	other := newTestAdmin(t)
+
+	file := filepath.Join(t.TempDir(), "membership-snapshot.json")
+	raw, err := json.Marshal(other.sign(t, other.id.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, raw, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := t.TempDir()
+	writeTestConfig(t, dst, admin.configure)
+	t.Chdir(dst)
+	if err := RunImportMembership([]string{"-db", filepath.Join(dst, "sqlite.db"), "-in", file}); err == nil {
+		t.Fatal("imported a snapshot whose admin proof the system key did not sign")
+	}
+}
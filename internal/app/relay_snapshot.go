@@ -0,0 +1,52 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"p2pos/internal/logging"
+	"p2pos/internal/membership"
+	"p2pos/internal/network"
+)
+
+// readSnapshotFile loads a snapshot written by sign-snapshot.
+func readSnapshotFile(path string) (membership.Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return membership.Snapshot{}, err
+	}
+	var snapshot membership.Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return membership.Snapshot{}, fmt.Errorf("decode snapshot %s failed: %w", path, err)
+	}
+	return snapshot, nil
+}
+
+// relaySnapshotWhenHealthy waits until node is healthy, then relays a
+// snapshot signed offline through RelayMembershipSnapshot.
+func relaySnapshotWhenHealthy(ctx context.Context, node *network.Node, snapshot membership.Snapshot) (network.PushReport, error) {
+	if err := node.WaitForState(ctx, network.RuntimeStateHealthy); err != nil {
+		return network.PushReport{}, err
+	}
+	return node.RelayMembershipSnapshot(ctx, snapshot)
+}
+
+func startSnapshotRelay(ctx context.Context, node *network.Node, snapshot membership.Snapshot) {
+	go func() {
+		report, err := relaySnapshotWhenHealthy(ctx, node, snapshot)
+		if err != nil {
+			logging.Log("MEMBERSHIP", "relay_snapshot_failed", map[string]string{
+				"issuer": snapshot.IssuerPeerID,
+				"reason": err.Error(),
+			})
+			return
+		}
+		logging.Log("MEMBERSHIP", "relay_snapshot_pushed", map[string]string{
+			"issuer": snapshot.IssuerPeerID,
+			"acked":  fmt.Sprintf("%d", report.Acked),
+			"quorum": fmt.Sprintf("%d", report.Quorum),
+		})
+	}()
+}
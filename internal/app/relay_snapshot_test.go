@@ -0,0 +1,80 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"p2pos/internal/membership"
+	"p2pos/internal/network"
+	"p2pos/internal/network/nettest"
+)
+
+func TestRelaySignedSnapshotFile(t *testing.T) {
+	admin := newTestAdmin(t)
+	cluster := nettest.NewCluster(t, 3)
+	members := make([]string, 0, len(cluster.Nodes))
+	for _, node := range cluster.Nodes {
+		members = append(members, node.Host.ID().String())
+	}
+	// Relaying needs the system key to check the admin proof.
+	for i, node := range cluster.Nodes {
+		manager, err := membership.NewManager(testClusterID, admin.systemPub, node.Host.ID().String(), members)
+		if err != nil {
+			t.Fatal(err)
+		}
+		node.SetMembershipManager(manager)
+		cluster.Managers[i] = manager
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := cluster.ConnectAll(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// What sign-snapshot writes on the admin's machine.
+	since := time.Now().UTC()
+	signed := admin.sign(t, members...)
+	raw, err := json.MarshalIndent(signed, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "membership-snapshot.json")
+	if err := os.WriteFile(file, raw, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot, err := readSnapshotFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := relaySnapshotWhenHealthy(ctx, cluster.Nodes[0], snapshot)
+	if err != nil {
+		t.Fatalf("relay: %v", err)
+	}
+	if report.Acked < report.Quorum {
+		t.Fatalf("relay acked %d of quorum %d", report.Acked, report.Quorum)
+	}
+	if err := cluster.WaitForMembership(ctx, since); err != nil {
+		t.Fatal(err)
+	}
+	for i, manager := range cluster.Managers {
+		if got := manager.Snapshot().IssuerPeerID; got != admin.id.String() {
+			t.Fatalf("node %d holds a snapshot from %s, want the offline admin", i, got)
+		}
+		if manager.IsMember(admin.id.String()) {
+			t.Fatalf("node %d counts the offline admin as a member", i)
+		}
+	}
+
+	if _, err := relaySnapshotWhenHealthy(ctx, cluster.Nodes[1], snapshot); err == nil {
+		t.Fatal("relaying a snapshot that is not newer should fail")
+	}
+	if state := cluster.Nodes[0].RuntimeState(); state != network.RuntimeStateHealthy {
+		t.Fatalf("node 0 is %s after the relay", state)
+	}
+}
@@ -0,0 +1,38 @@
+//go:build !windows
+
+package app
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	"p2pos/internal/logging"
+	"p2pos/internal/network"
+)
+
+func manualStartSupported() error {
+	return nil
+}
+
+// startResumeSignal resumes a manual_start node on SIGUSR1.
+func startResumeSignal(ctx context.Context, node *network.Node) func() {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGUSR1)
+	logging.Log("APP", "awaiting_resume", map[string]string{
+		"signal": "SIGUSR1",
+		"pid":    strconv.Itoa(os.Getpid()),
+	})
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-sigChan:
+			node.Resume()
+		}
+	}()
+	return func() {
+		signal.Stop(sigChan)
+	}
+}
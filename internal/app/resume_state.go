@@ -0,0 +1,74 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+
+	"p2pos/internal/database"
+	"p2pos/internal/logging"
+	"p2pos/internal/membership"
+	"p2pos/internal/network"
+)
+
+// loadRuntimeResume returns the saved row; ok is false when there is none or
+// it cannot be read. Failures only cost the head start.
+func loadRuntimeResume(repo *database.RuntimeResumeRepository) (database.RuntimeResume, bool) {
+	saved, ok, err := repo.Load(context.Background())
+	if err != nil {
+		logging.Log("DB", "runtime_resume_load_failed", map[string]string{
+			"reason": err.Error(),
+		})
+		return database.RuntimeResume{}, false
+	}
+	return saved, ok
+}
+
+// restoreSnapshot applies the last signed snapshot the node saved, so it can
+// serve and validate membership before anyone republishes.
+func restoreSnapshot(saved database.RuntimeResume, manager *membership.Manager) {
+	if saved.Snapshot == "" {
+		return
+	}
+	var snapshot membership.Snapshot
+	if err := json.Unmarshal([]byte(saved.Snapshot), &snapshot); err != nil {
+		logging.Log("MEMBERSHIP", "resume_snapshot_invalid", map[string]string{
+			"reason": err.Error(),
+		})
+		return
+	}
+	if _, err := manager.Restore(snapshot); err != nil {
+		logging.Log("MEMBERSHIP", "resume_snapshot_rejected", map[string]string{
+			"reason": err.Error(),
+		})
+	}
+}
+
+// persistRuntimeState saves every settled state. Starting and recovering
+// are transient and would hide the state the node had reached.
+func persistRuntimeState(repo *database.RuntimeResumeRepository, node *network.Node) {
+	node.OnStateChange(func(_, next network.RuntimeState, _ string) {
+		if next == network.RuntimeStateStarting || next == network.RuntimeStateRecovering {
+			return
+		}
+		if err := repo.SaveState(context.Background(), string(next)); err != nil {
+			logging.Log("DB", "runtime_resume_save_failed", map[string]string{
+				"reason": err.Error(),
+			})
+		}
+	})
+}
+
+func persistResumeSnapshot(repo *database.RuntimeResumeRepository, snapshot membership.Snapshot) {
+	if snapshot.Sig == "" {
+		return
+	}
+	raw, err := json.Marshal(snapshot)
+	if err != nil {
+		return
+	}
+	if err := repo.SaveSnapshot(context.Background(), string(raw)); err != nil {
+		logging.Log("DB", "runtime_resume_save_failed", map[string]string{
+			"reason": err.Error(),
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package app
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestManualStartSupported(t *testing.T) {
+	err := manualStartSupported()
+	if runtime.GOOS == "windows" {
+		if err == nil {
+			t.Fatal("manual_start must be refused on windows, which has no resume signal")
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("manual_start refused on %s: %v", runtime.GOOS, err)
+	}
+}
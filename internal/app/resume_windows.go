@@ -0,0 +1,22 @@
+//go:build windows
+
+package app
+
+import (
+	"context"
+	"errors"
+
+	"p2pos/internal/network"
+)
+
+// manualStartSupported refuses manual_start on Windows: there is no signal
+// to resume the paused node with, and resuming right away would silently
+// defeat the setting.
+func manualStartSupported() error {
+	return errors.New("manual_start is not supported on windows (no resume signal); set it to false")
+}
+
+// startResumeSignal is never reached on Windows; see manualStartSupported.
+func startResumeSignal(_ context.Context, _ *network.Node) func() {
+	return func() {}
+}
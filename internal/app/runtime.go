@@ -8,6 +8,7 @@ import (
 	"syscall"
 	"time"
 
+	"p2pos/internal/audit"
 	"p2pos/internal/config"
 	"p2pos/internal/database"
 	"p2pos/internal/events"
@@ -16,6 +17,7 @@ import (
 	"p2pos/internal/network"
 	"p2pos/internal/presence"
 	"p2pos/internal/scheduler"
+	"p2pos/internal/statehook"
 	"p2pos/internal/status"
 	"p2pos/internal/tasks"
 	"p2pos/internal/update"
@@ -56,12 +58,55 @@ func startShutdownBridge(ctx context.Context, cancel context.CancelFunc, bus *ev
 	}
 }
 
-func startRuntimeServices(ctx context.Context, bus *events.Bus, node *network.Node) {
+func startRuntimeServices(ctx context.Context, bus *events.Bus, node *network.Node, cfg *config.Store) {
 	node.StartShutdownHandler(ctx)
 	peerRepo := database.NewPeerRepository()
-	peerPresence := presence.NewService(bus, peerRepo, node.Host.ID().String())
+	presenceCfg := cfg.Get().Presence
+	peerRepo.SetObserverAuthority(!presenceCfg.EqualObservers)
+	peerRepo.SetMinObservers(presenceCfg.MinObservers)
+	peerRepo.SetClusterID(cfg.Get().ClusterID)
+	peerPresence := presence.NewService(bus, peerRepo, node.Host.ID().String(), presence.Options{
+		OfflineGrace:  time.Duration(presenceCfg.OfflineGraceSeconds) * time.Second,
+		FlapThreshold: presenceCfg.FlapThreshold,
+		FlapWindow:    time.Duration(presenceCfg.FlapWindowSeconds) * time.Second,
+	})
 	peerPresence.Start(ctx)
 	node.SetStatusProvider(status.NewService(peerRepo))
+	node.SetLivenessCheck(database.Ping)
+	current := cfg.Get()
+	if hook := statehook.NewService(current.StateChangeWebhook, current.StateChangeCommand); hook != nil {
+		hook.Start(ctx)
+		node.OnStateChange(func(prev, next network.RuntimeState, reason string) {
+			hook.Notify(statehook.Transition{
+				PeerID: node.Host.ID().String(),
+				Prev:   string(prev),
+				Next:   string(next),
+				Reason: reason,
+				At:     time.Now().UTC(),
+			})
+		})
+	}
+	membershipAudit := audit.NewService(bus, database.NewMembershipAuditRepository())
+	membershipAudit.Start(ctx)
+	node.SetAuditProvider(membershipAudit)
+	if cfg.EphemeralIdentity() {
+		logging.Log("AUDIT", "admin_chain_ephemeral_identity", map[string]string{
+			"warning": "chain signatures change key every restart",
+		})
+	}
+	adminAudit, err := audit.NewChainLogger(database.NewAdminAuditRepository(), cfg.NodePrivateKey())
+	if err != nil {
+		logging.Log("AUDIT", "admin_chain_disabled", map[string]string{
+			"reason": err.Error(),
+		})
+		return
+	}
+	if err := adminAudit.VerifyAuditChain(ctx); err != nil {
+		logging.Log("AUDIT", "admin_chain_invalid", map[string]string{
+			"reason": err.Error(),
+		})
+	}
+	node.SetAdminAuditor(adminAudit)
 }
 
 func registerScheduledTasks(
@@ -73,29 +118,92 @@ func registerScheduledTasks(
 ) error {
 	logging.Log("APP", "start_update_checker", nil)
 	updater := update.NewService(cfg, shutdown)
+	if auditor := node.AdminAuditor(); auditor != nil {
+		updater.SetAuditor(auditor)
+	}
 	if err := s.Register(tasks.NewUpdateCheckTask(updater, 3*time.Minute)); err != nil {
 		return err
 	}
 
-	resolver := network.NewConfigResolver(node.Host.ID(), cfg, network.NewNetDNSResolver())
+	resolver := network.NewConfigResolver(node.Host.ID(), cfg, network.NewNetDNSResolver(network.DNSOptions{
+		DoHTimeout: cfg.DNSDoHTimeout(),
+		MinTTL:     cfg.DNSMinTTL(),
+	}))
 	node.StartBootstrap(ctx, resolver, time.Minute)
 
 	if err := s.Register(tasks.NewMembershipSyncTask(node)); err != nil {
 		return err
 	}
+	node.StartAdminSync(ctx, cfg.MembershipIntendedMembers())
 	if err := s.Register(tasks.NewHeartbeatTask(node)); err != nil {
 		return err
 	}
+	if err := s.Register(tasks.NewPinnedPeersTask(node)); err != nil {
+		return err
+	}
+	if err := s.Register(tasks.NewMemberReconnectTask(node)); err != nil {
+		return err
+	}
+	if err := s.Register(tasks.NewPeerstoreReconcileTask(node)); err != nil {
+		return err
+	}
+	if err := s.Register(tasks.NewRecordRetentionTask(database.NewRecordRepository(), cfg.RecordRetention())); err != nil {
+		return err
+	}
 
 	return nil
 }
 
 func setupMembership(cfg *config.Store, node *network.Node) error {
+	peerRepo, manager, err := newMembershipManager(cfg, node)
+	if err != nil {
+		return err
+	}
+	// The last applied signed snapshot is always kept, so restarts and
+	// restored backups start from it; resume_runtime_state only adds the
+	// saved runtime state on top.
+	resumeRepo := database.NewRuntimeResumeRepository()
+	saved, ok := loadRuntimeResume(resumeRepo)
+	if ok {
+		restoreSnapshot(saved, manager)
+	}
+	if cfg.ResumeRuntimeState() {
+		if ok {
+			node.ResumeFrom(network.RuntimeState(saved.State))
+		}
+		persistRuntimeState(resumeRepo, node)
+	}
+	node.SetMembershipAppliedHandler(persistMembership(peerRepo, resumeRepo))
+	node.SetMembershipManager(manager)
+	return nil
+}
+
+// newMembershipManager builds the membership manager from the stored members
+// and config, and installs the admin proof on node.
+func newMembershipManager(cfg *config.Store, node *network.Node) (*database.PeerRepository, *membership.Manager, error) {
 	current := cfg.Get()
 	peerRepo := database.NewPeerRepository()
+	peerRepo.SetClusterID(current.ClusterID)
+	if err := peerRepo.AdoptUnscopedPeers(context.Background()); err != nil {
+		return nil, nil, err
+	}
 	storedMembers, err := peerRepo.ListMemberIDs(context.Background())
 	if err != nil {
-		return err
+		return nil, nil, err
+	}
+
+	if cfg.EphemeralIdentity() {
+		// Membership and admin proofs bind to a peer ID, which an ephemeral
+		// node loses on every restart.
+		logging.Log("MEMBERSHIP", "ephemeral_identity", map[string]string{
+			"warning": "membership and admin features will not survive a restart",
+		})
+	}
+	storedMembers, invalidMembers := membership.SplitPeerIDs(storedMembers)
+	for _, id := range invalidMembers {
+		logging.Log("MEMBERSHIP", "stored_member_invalid", map[string]string{
+			"peer_id": id,
+		})
 	}
 
 	manager, err := membership.NewManager(
@@ -105,28 +213,39 @@ func setupMembership(cfg *config.Store, node *network.Node) error {
 		storedMembers,
 	)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
+	manager.SetMaxMembers(cfg.MembershipMaxMembers())
+	manager.SetMaxSnapshotAge(cfg.MembershipMaxSnapshotAge())
+	manager.SetRequireIssuerMember(cfg.MembershipIssuerPolicy() == config.MembershipIssuerRefuse)
 	proof, ok, err := cfg.AdminProof()
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 	if ok {
+		if cfg.EphemeralIdentity() {
+			return nil, nil, fmt.Errorf("admin_proof cannot be used with ephemeral_identity")
+		}
 		if proof.PeerID != node.Host.ID().String() {
-			return fmt.Errorf("admin_proof peer_id does not match local peer_id")
+			return nil, nil, fmt.Errorf("admin_proof peer_id does not match local peer_id")
 		}
 		if err := manager.ValidateAdminProof(*proof, proof.PeerID); err != nil {
-			return err
+			return nil, nil, err
 		}
 		node.SetAdminProof(proof)
 	}
-	node.SetMembershipAppliedHandler(func(snapshot membership.Snapshot) {
+	return peerRepo, manager, nil
+}
+
+// persistMembership stores every applied snapshot: its members for the peer
+// table and the signed snapshot itself for restarts and backups.
+func persistMembership(peerRepo *database.PeerRepository, resumeRepo *database.RuntimeResumeRepository) func(membership.Snapshot) {
+	return func(snapshot membership.Snapshot) {
 		if err := peerRepo.SyncMembers(context.Background(), snapshot.Members); err != nil {
 			logging.Log("DB", "sync_members_failed", map[string]string{
 				"reason": err.Error(),
 			})
 		}
-	})
-	node.SetMembershipManager(manager)
-	return nil
+		persistResumeSnapshot(resumeRepo, snapshot)
+	}
 }
@@ -0,0 +1,55 @@
+package app
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"p2pos/internal/config"
+	"p2pos/internal/membership"
+)
+
+// RunSignBootstrap signs one init_connections entry with the system key and
+// prints it as JSON, ready to paste into config.json on nodes that set
+// require_signed_bootstrap.
+func RunSignBootstrap(args []string) error {
+	fs := flag.NewFlagSet("sign-bootstrap", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	systemPriv := fs.String("system-priv", "", "system private key (base64)")
+	clusterID := fs.String("cluster-id", "default", "cluster id")
+	kind := fs.String("type", "multiaddr", "connection type (dns|multiaddr|http|file)")
+	address := fs.String("address", "", "connection address")
+	expectedPeerID := fs.String("expected-peer-id", "", "peer id the entry must resolve to")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	priv, err := decodeNodeKey(*systemPriv)
+	if err != nil {
+		return fmt.Errorf("system-priv invalid: %w", err)
+	}
+	entry, err := membership.SignBootstrapEntry(priv, membership.BootstrapEntry{
+		ClusterID:      *clusterID,
+		Type:           *kind,
+		Address:        *address,
+		ExpectedPeerID: *expectedPeerID,
+	})
+	if err != nil {
+		return err
+	}
+
+	raw, err := json.MarshalIndent(config.Connection{
+		Type:           entry.Type,
+		Address:        entry.Address,
+		ExpectedPeerID: entry.ExpectedPeerID,
+		Sig:            entry.Sig,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(raw))
+	return nil
+}
@@ -0,0 +1,86 @@
+package app
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"p2pos/internal/config"
+	"p2pos/internal/membership"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// RunSignSnapshot signs a membership snapshot with an admin key kept off the
+// nodes. A node started with -relay-snapshot <file> applies and fans it out
+// through RelayMembershipSnapshot without ever holding the admin key. The
+// admin key never runs a node, so it issues the snapshot without being one of
+// its members; nodes with membership.issuer_policy "refuse" reject that.
+func RunSignSnapshot(args []string) error {
+	fs := flag.NewFlagSet("sign-snapshot", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	configPath := fs.String("config", "config.json", "config file holding cluster_id and the admin_proof for the admin key")
+	adminPriv := fs.String("admin-priv", "", "admin private key (base64)")
+	members := fs.String("members", "", "comma-separated member peer ids; the admin key is not added, it only issues")
+	out := fs.String("out", "membership-snapshot.json", "signed snapshot to write")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return fmt.Errorf("load config failed: %w", err)
+	}
+	proof, ok, err := cfg.ParsedAdminProof()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("admin_proof not configured in %s", *configPath)
+	}
+
+	priv, err := decodeNodeKey(*adminPriv)
+	if err != nil {
+		return fmt.Errorf("admin-priv invalid: %w", err)
+	}
+	adminID, err := peer.IDFromPrivateKey(priv)
+	if err != nil {
+		return err
+	}
+	if adminID.String() != proof.PeerID {
+		return fmt.Errorf("admin_proof peer_id does not match admin key")
+	}
+
+	ids, invalid := membership.SplitPeerIDs(strings.Split(*members, ","))
+	if len(invalid) > 0 {
+		return fmt.Errorf("members contains invalid peer id %q", invalid[0])
+	}
+
+	signed, err := membership.SignSnapshot(priv, membership.Snapshot{
+		ClusterID:    cfg.ClusterID,
+		IssuedAt:     time.Now().UTC(),
+		IssuerPeerID: adminID.String(),
+		Members:      ids,
+		AdminProof:   *proof,
+	})
+	if err != nil {
+		return err
+	}
+
+	raw, err := json.MarshalIndent(signed, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(*out, raw, 0o600); err != nil {
+		return err
+	}
+	fmt.Printf("SNAPSHOT_ISSUER=%s\n", signed.IssuerPeerID)
+	fmt.Printf("SNAPSHOT_ISSUED_AT=%s\n", signed.IssuedAt.Format(time.RFC3339Nano))
+	fmt.Printf("SNAPSHOT_MEMBERS=%d\n", len(signed.Members))
+	return nil
+}
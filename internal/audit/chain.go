@@ -0,0 +1,144 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"p2pos/internal/database"
+	"p2pos/internal/logging"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// Admin actions recorded in the chain.
+const (
+	ActionMembershipPublish = "membership_publish"
+	ActionMembershipRelay   = "membership_relay"
+	ActionUpdateApply       = "update_apply"
+)
+
+type ChainRepository interface {
+	Last(ctx context.Context) (database.AdminAudit, bool, error)
+	Append(ctx context.Context, entry database.AdminAudit) error
+	ListAll(ctx context.Context) ([]database.AdminAudit, error)
+}
+
+// ChainLogger appends admin actions to a tamper-evident log: every entry
+// carries the hash of the previous one and is signed with the node key.
+type ChainLogger struct {
+	mu    sync.Mutex
+	repo  ChainRepository
+	priv  crypto.PrivKey
+	actor string
+}
+
+func NewChainLogger(repo ChainRepository, priv crypto.PrivKey) (*ChainLogger, error) {
+	id, err := peer.IDFromPrivateKey(priv)
+	if err != nil {
+		return nil, err
+	}
+	return &ChainLogger{repo: repo, priv: priv, actor: id.String()}, nil
+}
+
+// RecordAdminAction appends action to the chain. Failures are logged rather
+// than returned: auditing must not block the action itself.
+func (l *ChainLogger) RecordAdminAction(ctx context.Context, action string, detail map[string]string) {
+	if l == nil {
+		return
+	}
+	if err := l.append(ctx, action, detail); err != nil {
+		logging.Log("AUDIT", "admin_persist_failed", map[string]string{
+			"action": action,
+			"reason": err.Error(),
+		})
+	}
+}
+
+func (l *ChainLogger) append(ctx context.Context, action string, detail map[string]string) error {
+	detailJSON, err := json.Marshal(detail)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	last, ok, err := l.repo.Last(ctx)
+	if err != nil {
+		return err
+	}
+	entry := database.AdminAudit{
+		Seq:       1,
+		Action:    action,
+		Actor:     l.actor,
+		Detail:    string(detailJSON),
+		CreatedAt: time.Now().UTC().Format(time.RFC3339Nano),
+	}
+	if ok {
+		entry.Seq = last.Seq + 1
+		entry.PrevHash = last.Hash
+	}
+	sum := chainHash(entry)
+	sig, err := l.priv.Sign(sum)
+	if err != nil {
+		return err
+	}
+	entry.Hash = hex.EncodeToString(sum)
+	entry.Sig = base64.StdEncoding.EncodeToString(sig)
+	if err := l.repo.Append(ctx, entry); err != nil {
+		return err
+	}
+
+	logging.Log("AUDIT", "admin_action", map[string]string{
+		"action": action,
+		"seq":    fmt.Sprintf("%d", entry.Seq),
+	})
+	return nil
+}
+
+// VerifyAuditChain walks the chain and returns an error naming the first
+// entry whose sequence, link, hash or signature does not check out.
+func (l *ChainLogger) VerifyAuditChain(ctx context.Context) error {
+	entries, err := l.repo.ListAll(ctx)
+	if err != nil {
+		return err
+	}
+	pub := l.priv.GetPublic()
+
+	prevHash := ""
+	for i, entry := range entries {
+		if entry.Seq != uint64(i+1) {
+			return fmt.Errorf("audit entry %d: sequence gap (expected %d)", entry.Seq, i+1)
+		}
+		if entry.PrevHash != prevHash {
+			return fmt.Errorf("audit entry %d: prev_hash does not link to entry %d", entry.Seq, i)
+		}
+		sum := chainHash(entry)
+		if hex.EncodeToString(sum) != entry.Hash {
+			return fmt.Errorf("audit entry %d: hash mismatch", entry.Seq)
+		}
+		sig, err := base64.StdEncoding.DecodeString(entry.Sig)
+		if err != nil {
+			return fmt.Errorf("audit entry %d: invalid signature encoding", entry.Seq)
+		}
+		if ok, err := pub.Verify(sum, sig); err != nil || !ok {
+			return fmt.Errorf("audit entry %d: signature invalid", entry.Seq)
+		}
+		prevHash = entry.Hash
+	}
+	return nil
+}
+
+func chainHash(entry database.AdminAudit) []byte {
+	payload := fmt.Sprintf("%d|%s|%s|%s|%s|%s",
+		entry.Seq, entry.PrevHash, entry.Action, entry.Actor, entry.CreatedAt, entry.Detail)
+	sum := sha256.Sum256([]byte(payload))
+	return sum[:]
+}
@@ -0,0 +1,68 @@
+package audit
+
+import (
+	"context"
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"p2pos/internal/database"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+)
+
+// memoryChain is a ChainRepository kept in a slice.
+type memoryChain struct {
+	entries []database.AdminAudit
+}
+
+func (m *memoryChain) Last(context.Context) (database.AdminAudit, bool, error) {
+	if len(m.entries) == 0 {
+		return database.AdminAudit{}, false, nil
+	}
+	return m.entries[len(m.entries)-1], true, nil
+}
+
+func (m *memoryChain) Append(_ context.Context, entry database.AdminAudit) error {
+	m.entries = append(m.entries, entry)
+	return nil
+}
+
+func (m *memoryChain) ListAll(context.Context) ([]database.AdminAudit, error) {
+	return append([]database.AdminAudit(nil), m.entries...), nil
+}
+
+func TestVerifyAuditChainDetectsMutation(t *testing.T) {
+	priv, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := &memoryChain{}
+	logger, err := NewChainLogger(repo, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	logger.RecordAdminAction(ctx, ActionMembershipPublish, map[string]string{"version": "1"})
+	logger.RecordAdminAction(ctx, ActionUpdateApply, map[string]string{"version": "20250101-1200"})
+	logger.RecordAdminAction(ctx, ActionMembershipPublish, map[string]string{"version": "2"})
+
+	if len(repo.entries) != 3 || repo.entries[1].PrevHash != repo.entries[0].Hash {
+		t.Fatalf("entries not chained: %+v", repo.entries)
+	}
+	if err := logger.VerifyAuditChain(ctx); err != nil {
+		t.Fatalf("intact chain rejected: %v", err)
+	}
+
+	repo.entries[1].Detail = `{"version":"20990101-0000"}`
+	err = logger.VerifyAuditChain(ctx)
+	if err == nil || !strings.Contains(err.Error(), "audit entry 2: hash mismatch") {
+		t.Fatalf("mutated chain: %v, want hash mismatch at entry 2", err)
+	}
+
+	repo.entries[1] = repo.entries[2]
+	repo.entries = repo.entries[:2]
+	if err := logger.VerifyAuditChain(ctx); err == nil {
+		t.Fatal("chain with a deleted entry verified")
+	}
+}
@@ -0,0 +1,105 @@
+package audit
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"p2pos/internal/database"
+	"p2pos/internal/events"
+	"p2pos/internal/logging"
+)
+
+type Record struct {
+	ClusterID    string    `json:"cluster_id"`
+	IssuerPeerID string    `json:"issuer_peer_id"`
+	ReceivedFrom string    `json:"received_from"`
+	IssuedAt     time.Time `json:"issued_at"`
+	Added        []string  `json:"added"`
+	Removed      []string  `json:"removed"`
+	RecordedAt   time.Time `json:"recorded_at"`
+}
+
+type Repository interface {
+	Append(ctx context.Context, change events.MembershipChanged) error
+	ListRecent(ctx context.Context, limit int) ([]database.MembershipAudit, error)
+}
+
+type Service struct {
+	bus  *events.Bus
+	repo Repository
+}
+
+func NewService(bus *events.Bus, repo Repository) *Service {
+	return &Service{
+		bus:  bus,
+		repo: repo,
+	}
+}
+
+func (s *Service) Start(ctx context.Context) {
+	eventCh, cancel := s.bus.Subscribe(64)
+	go func() {
+		defer cancel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case evt, ok := <-eventCh:
+				if !ok {
+					return
+				}
+				change, ok := evt.(events.MembershipChanged)
+				if !ok {
+					continue
+				}
+				logging.Log("AUDIT", "membership_changed", map[string]string{
+					"cluster_id":    change.ClusterID,
+					"issuer":        change.IssuerPeerID,
+					"received_from": change.ReceivedFrom,
+					"issued_at":     change.IssuedAt.UTC().Format(time.RFC3339Nano),
+					"added":         joinOrDash(change.Added),
+					"removed":       joinOrDash(change.Removed),
+				})
+				if err := s.repo.Append(ctx, change); err != nil {
+					logging.Log("AUDIT", "persist_failed", map[string]string{
+						"issuer": change.IssuerPeerID,
+						"reason": err.Error(),
+					})
+				}
+			}
+		}
+	}()
+}
+
+func (s *Service) Recent(ctx context.Context, limit int) ([]Record, error) {
+	if s == nil || s.repo == nil {
+		return []Record{}, nil
+	}
+
+	entries, err := s.repo.ListRecent(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]Record, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, Record{
+			ClusterID:    e.ClusterID,
+			IssuerPeerID: e.IssuerPeerID,
+			ReceivedFrom: e.ReceivedFrom,
+			IssuedAt:     e.IssuedAt,
+			Added:        e.AddedPeerIDs(),
+			Removed:      e.RemovedPeerIDs(),
+			RecordedAt:   e.RecordedAt,
+		})
+	}
+	return out, nil
+}
+
+func joinOrDash(ids []string) string {
+	if len(ids) == 0 {
+		return "-"
+	}
+	return strings.Join(ids, ",")
+}
@@ -1,14 +1,19 @@
 package config
 
 import (
+	"context"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/fs"
 	"net/url"
 	"os"
+	"sort"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"p2pos/internal/events"
@@ -19,25 +24,130 @@ import (
 )
 
 type Config struct {
-	InitConnections []Connection  `json:"init_connections"`
-	Listen          ListenConfig  `json:"listen"`
-	NetworkMode     string        `json:"network_mode"`
-	AutoTLS         AutoTLSConfig `json:"auto_tls"`
-	UpdateChannel   string        `json:"update_channel"`
-	UpdateFeedURL   string        `json:"update_feed_url"`
-	NodePrivateKey  string        `json:"node_private_key"`
-	ClusterID       string        `json:"cluster_id"`
-	SystemPubKey    string        `json:"system_pubkey"`
-	AdminProof      AdminProof    `json:"admin_proof"`
+	InitConnections        []Connection         `json:"init_connections"`
+	Listen                 ListenConfig         `json:"listen"`
+	BusinessListen         []string             `json:"business_listen"`
+	AnnounceAddrs          []string             `json:"announce_addrs"`
+	AnnounceMode           string               `json:"announce_mode"`
+	NetworkMode            string               `json:"network_mode"`
+	DialPrivateAddrs       string               `json:"dial_private_addrs"`
+	AutoTLS                AutoTLSConfig        `json:"auto_tls"`
+	UpdateChannel          string               `json:"update_channel"`
+	UpdateFeedURL          string               `json:"update_feed_url"`
+	MaxUpdateSizeBytes     int64                `json:"max_update_size_bytes"`
+	UpdateWindows          []string             `json:"update_windows"`
+	MaxUpdateVersion       string               `json:"max_update_version"`
+	UpdateMinFreeBytes     int64                `json:"update_min_free_bytes"`
+	NodePrivateKey         string               `json:"node_private_key"`
+	KeyType                string               `json:"key_type"`
+	ClusterID              string               `json:"cluster_id"`
+	SystemPubKey           string               `json:"system_pubkey"`
+	AdminProof             AdminProof           `json:"admin_proof"`
+	Heartbeat              HeartbeatConfig      `json:"heartbeat"`
+	Presence               PresenceConfig       `json:"presence"`
+	Membership             MembershipConfig     `json:"membership"`
+	Records                RecordsConfig        `json:"records"`
+	PrivateNetwork         PrivateNetworkConfig `json:"private_network"`
+	DNS                    DNSConfig            `json:"dns"`
+	Reconnect              ReconnectConfig      `json:"reconnect"`
+	ValidationBan          ValidationBanConfig  `json:"validation_ban"`
+	UnsupportedTTLSeconds  int                  `json:"unsupported_ttl_seconds"`
+	PinnedPeers            []string             `json:"pinned_peers"`
+	Region                 string               `json:"region"`
+	Tags                   map[string]string    `json:"tags"`
+	ShutdownTimeoutSeconds int                  `json:"shutdown_timeout_seconds"`
+	StartupGraceSeconds    int                  `json:"startup_grace_seconds"`
+	DisableSelfDialCheck   bool                 `json:"disable_self_dial_check"`
+	EphemeralIdentity      bool                 `json:"ephemeral_identity"`
+	RequirePersistedKey    bool                 `json:"require_persisted_key"`
+	AdminBootstrap         bool                 `json:"admin_bootstrap"`
+	ManualStart            bool                 `json:"manual_start"`
+	ResumeRuntimeState     bool                 `json:"resume_runtime_state"`
+	StateChangeWebhook     string               `json:"state_change_webhook"`
+	StateChangeCommand     string               `json:"state_change_command"`
+	RequireSignedBootstrap bool                 `json:"require_signed_bootstrap"`
+	EnableUnixTransport    bool                 `json:"enable_unix_transport"`
+	MinBootstrapPeers      int                  `json:"min_bootstrap_peers"`
+	BootstrapConcurrency   int                  `json:"bootstrap_concurrency"`
+	MinPeerVersion         string               `json:"min_peer_version"`
+	PeerVersionPolicy      string               `json:"peer_version_policy"`
+	LogLevel               string               `json:"log_level"`
+	LogFormat              string               `json:"log_format"`
+}
+
+type HeartbeatConfig struct {
+	WindowSeconds      int  `json:"window_seconds"`
+	MinIntervalSeconds int  `json:"min_interval_seconds"`
+	DisableDigest      bool `json:"disable_digest"`
+}
+
+type PresenceConfig struct {
+	OfflineGraceSeconds int `json:"offline_grace_seconds"`
+	FlapThreshold       int `json:"flap_threshold"`
+	FlapWindowSeconds   int `json:"flap_window_seconds"`
+	// EqualObservers turns off observer authority: merged peer state is
+	// then decided by timestamp alone, whoever reported it.
+	EqualObservers bool `json:"equal_observers"`
+	// MinObservers is how many distinct remote members must report a peer
+	// online before the cluster view trusts it; 1 trusts any single report.
+	MinObservers int `json:"min_observers"`
+}
+
+type MembershipConfig struct {
+	DisconnectPolicy    string `json:"disconnect_policy"`
+	MaxMembers          int    `json:"max_members"`
+	QuorumHoldSeconds   int    `json:"quorum_hold_seconds"`
+	PushConcurrency     int    `json:"push_concurrency"`
+	PushAckQuorum       int    `json:"push_ack_quorum"`
+	MaxSnapshotAgeHours int    `json:"max_snapshot_age_hours"`
+	DownAlertSeconds    int    `json:"down_alert_seconds"`
+	SelfRemovedPolicy   string `json:"self_removed_policy"`
+	IssuerPolicy        string `json:"issuer_policy"`
+	// IntendedMembers is the member set an admin node republishes at start
+	// when the cluster's snapshot differs from it and is the last one this
+	// node issued. Ignored without an admin_proof.
+	IntendedMembers []string `json:"intended_members"`
+}
+
+type RecordsConfig struct {
+	RetentionDays int `json:"retention_days"`
+}
+
+type ReconnectConfig struct {
+	BackoffBaseSeconds     int `json:"backoff_base_seconds"`
+	BackoffMaxSeconds      int `json:"backoff_max_seconds"`
+	BreakerThreshold       int `json:"breaker_threshold"`
+	BreakerCooldownSeconds int `json:"breaker_cooldown_seconds"`
+}
+
+// ValidationBanConfig sheds peers that keep sending snapshots or heartbeats
+// that fail validation: Threshold failures within WindowSeconds disconnect
+// the peer and refuse it for BanSeconds.
+type ValidationBanConfig struct {
+	Threshold     int `json:"threshold"`
+	WindowSeconds int `json:"window_seconds"`
+	BanSeconds    int `json:"ban_seconds"`
+}
+
+type DNSConfig struct {
+	DoHTimeoutSeconds int `json:"doh_timeout_seconds"`
+	MinTTLSeconds     int `json:"min_ttl_seconds"`
+}
+
+type PrivateNetworkConfig struct {
+	Secret       string `json:"secret"`
+	PublicOptOut bool   `json:"public_opt_out"`
 }
 
 type AutoTLSConfig struct {
-	Mode      string `json:"mode"`
-	Enabled   bool   `json:"enabled"`
-	UserEmail string `json:"user_email"`
-	Port      int    `json:"port"`
-	CacheDir  string `json:"cache_dir"`
-	ForgeAuth string `json:"forge_auth"`
+	Mode         string   `json:"mode"`
+	Enabled      bool     `json:"enabled"`
+	UserEmail    string   `json:"user_email"`
+	Port         int      `json:"port"`
+	CacheDir     string   `json:"cache_dir"`
+	ForgeAuth    string   `json:"forge_auth"`
+	MinVersion   string   `json:"min_version"`
+	CipherSuites []string `json:"cipher_suites"`
 }
 
 type AdminProof struct {
@@ -46,12 +156,20 @@ type AdminProof struct {
 	Role      string `json:"role"`
 	ValidFrom string `json:"valid_from"`
 	ValidTo   string `json:"valid_to"`
+	Alg       string `json:"alg,omitempty"`
 	Sig       string `json:"sig"`
 }
 
 type Connection struct {
 	Type    string `json:"type"`
 	Address string `json:"address"`
+	// ExpectedPeerID, when set, rejects bootstrap candidates resolved from
+	// Address that present any other peer ID.
+	ExpectedPeerID string `json:"expected_peer_id,omitempty"`
+	// Sig is the system key's signature over the entry, see
+	// membership.SignBootstrapEntry. It is required only when
+	// require_signed_bootstrap is set.
+	Sig string `json:"sig,omitempty"`
 }
 
 type ListenConfig []string
@@ -85,16 +203,121 @@ type Store struct {
 	bus         *events.Bus
 	cfg         Config
 	nodePrivKey crypto.PrivKey
+	keys        KeyStore
+}
+
+// KeyStore keeps the node key outside config.json, for nodes whose config
+// file is mounted read-only.
+type KeyStore interface {
+	LoadNodeKey(ctx context.Context) (string, bool, error)
+	SaveNodeKey(ctx context.Context, encoded string) error
 }
 
 const defaultConfigPath = "config.json"
 const defaultUpdateFeedURL = "https://api.github.com/repos/ZhongWwwHhh/p2pos/releases/latest"
 const defaultUpdateChannel = "stable"
 const defaultNetworkMode = "auto"
+const defaultDialPrivateAddrs = DialPrivateAuto
 const defaultClusterID = "default"
 const defaultAutoTLSCacheDir = ".autotls-cache"
 const defaultAutoTLSMode = "auto"
 const defaultAutoTLSPort = 4101
+const defaultAutoTLSMinVersion = "1.2"
+const defaultHeartbeatWindowSeconds = 300
+const defaultHeartbeatMinIntervalSeconds = 10
+const defaultPresenceOfflineGraceSeconds = 10
+const defaultPresenceFlapThreshold = 5
+const defaultPresenceFlapWindowSeconds = 120
+const defaultPresenceMinObservers = 1
+const defaultShutdownTimeoutSeconds = 10
+const defaultStartupGraceSeconds = 60
+const defaultMinBootstrapPeers = 1
+const defaultBootstrapConcurrency = 4
+const defaultKeyType = KeyTypeEd25519
+const defaultMaxUpdateSizeBytes = 256 << 20
+const defaultUpdateMinFreeBytes = 64 << 20
+const defaultAnnounceMode = "append"
+const defaultMembershipDisconnectPolicy = MembershipDisconnectWarn
+const defaultMembershipSelfRemovedPolicy = SelfRemovedContinue
+const defaultMembershipIssuerPolicy = MembershipIssuerWarn
+const defaultRecordRetentionDays = 30
+const defaultMembershipMaxMembers = 256
+const defaultMembershipQuorumHoldSeconds = 5
+const defaultMembershipDownAlertSeconds = 120
+const defaultPeerVersionPolicy = PeerVersionWarn
+const defaultLogLevel = LogLevelInfo
+const defaultLogFormat = LogFormatText
+const defaultMembershipPushConcurrency = 8
+const defaultDNSDoHTimeoutSeconds = 4
+const defaultDNSMinTTLSeconds = 60
+const defaultReconnectBackoffBaseSeconds = 5
+const defaultReconnectBackoffMaxSeconds = 300
+const defaultReconnectBreakerThreshold = 8
+const defaultReconnectBreakerCooldownSeconds = 600
+const defaultValidationBanThreshold = 5
+const defaultValidationBanWindowSeconds = 60
+const defaultValidationBanSeconds = 600
+const defaultUnsupportedTTLSeconds = 600
+
+// Node tags travel inside the signed heartbeat payload, so they are bounded
+// and may not contain the payload separators.
+const (
+	MaxNodeTags      = 16
+	MaxNodeTagLength = 64
+)
+
+const (
+	KeyTypeEd25519   = "ed25519"
+	KeyTypeSecp256k1 = "secp256k1"
+)
+
+const (
+	MembershipDisconnectWarn   = "warn"
+	MembershipDisconnectRefuse = "refuse"
+)
+
+// membership.issuer_policy: whether a snapshot whose issuer is not one of its
+// members is accepted with a warning or refused. Snapshots signed before the
+// rule existed may have a non-member issuer, so "warn" is the default.
+const (
+	MembershipIssuerWarn   = "warn"
+	MembershipIssuerRefuse = "refuse"
+)
+
+// membership.self_removed_policy: what a node does once a snapshot drops it.
+const (
+	SelfRemovedContinue = "continue"
+	SelfRemovedHold     = "hold"
+	SelfRemovedShutdown = "shutdown"
+)
+
+// dial_private_addrs: auto refuses private, loopback and link-local dial
+// targets in public network mode and allows them in private mode. Members
+// and pinned peers are dialed on such addresses under every policy.
+const (
+	DialPrivateAuto  = "auto"
+	DialPrivateAllow = "allow"
+	DialPrivateDeny  = "deny"
+)
+
+const (
+	PeerVersionWarn       = "warn"
+	PeerVersionDisconnect = "disconnect"
+)
+
+// log_level is the lowest severity written; log_format picks the plain
+// "[MODULE] action=..." lines or slog JSON.
+const (
+	LogLevelDebug = "debug"
+	LogLevelInfo  = "info"
+	LogLevelWarn  = "warn"
+	LogLevelError = "error"
+)
+
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
 
 func NewStore(bus *events.Bus) *Store {
 	return &Store{
@@ -108,10 +331,58 @@ func Default() Config {
 	return Config{
 		Listen:        ListenConfig{"0.0.0.0:4100", "[::]:4100"},
 		NetworkMode:   defaultNetworkMode,
-		AutoTLS:       AutoTLSConfig{Mode: defaultAutoTLSMode, Port: defaultAutoTLSPort, CacheDir: defaultAutoTLSCacheDir},
+		AutoTLS:       AutoTLSConfig{Mode: defaultAutoTLSMode, Port: defaultAutoTLSPort, CacheDir: defaultAutoTLSCacheDir, MinVersion: defaultAutoTLSMinVersion},
 		UpdateChannel: defaultUpdateChannel,
 		UpdateFeedURL: defaultUpdateFeedURL,
 		ClusterID:     defaultClusterID,
+		Heartbeat: HeartbeatConfig{
+			WindowSeconds:      defaultHeartbeatWindowSeconds,
+			MinIntervalSeconds: defaultHeartbeatMinIntervalSeconds,
+		},
+		Presence: PresenceConfig{
+			OfflineGraceSeconds: defaultPresenceOfflineGraceSeconds,
+			FlapThreshold:       defaultPresenceFlapThreshold,
+			FlapWindowSeconds:   defaultPresenceFlapWindowSeconds,
+			MinObservers:        defaultPresenceMinObservers,
+		},
+		Membership: MembershipConfig{
+			DisconnectPolicy:  defaultMembershipDisconnectPolicy,
+			IssuerPolicy:      defaultMembershipIssuerPolicy,
+			SelfRemovedPolicy: defaultMembershipSelfRemovedPolicy,
+			MaxMembers:        defaultMembershipMaxMembers,
+			QuorumHoldSeconds: defaultMembershipQuorumHoldSeconds,
+			DownAlertSeconds:  defaultMembershipDownAlertSeconds,
+			PushConcurrency:   defaultMembershipPushConcurrency,
+		},
+		Records: RecordsConfig{
+			RetentionDays: defaultRecordRetentionDays,
+		},
+		DNS: DNSConfig{
+			DoHTimeoutSeconds: defaultDNSDoHTimeoutSeconds,
+			MinTTLSeconds:     defaultDNSMinTTLSeconds,
+		},
+		Reconnect: ReconnectConfig{
+			BackoffBaseSeconds:     defaultReconnectBackoffBaseSeconds,
+			BackoffMaxSeconds:      defaultReconnectBackoffMaxSeconds,
+			BreakerThreshold:       defaultReconnectBreakerThreshold,
+			BreakerCooldownSeconds: defaultReconnectBreakerCooldownSeconds,
+		},
+		UnsupportedTTLSeconds: defaultUnsupportedTTLSeconds,
+		ValidationBan: ValidationBanConfig{
+			Threshold:     defaultValidationBanThreshold,
+			WindowSeconds: defaultValidationBanWindowSeconds,
+			BanSeconds:    defaultValidationBanSeconds,
+		},
+		ShutdownTimeoutSeconds: defaultShutdownTimeoutSeconds,
+		DialPrivateAddrs:       defaultDialPrivateAddrs,
+		StartupGraceSeconds:    defaultStartupGraceSeconds,
+		PeerVersionPolicy:      defaultPeerVersionPolicy,
+		LogLevel:               defaultLogLevel,
+		LogFormat:              defaultLogFormat,
+		MinBootstrapPeers:      defaultMinBootstrapPeers,
+		BootstrapConcurrency:   defaultBootstrapConcurrency,
+		MaxUpdateSizeBytes:     defaultMaxUpdateSizeBytes,
+		UpdateMinFreeBytes:     defaultUpdateMinFreeBytes,
 	}
 }
 
@@ -124,14 +395,25 @@ func (s *Store) Init() error {
 
 		cfgVal := Default()
 		if err := saveToFile(s.path, cfgVal); err != nil {
-			return err
+			if !isReadOnlyErr(err) {
+				return err
+			}
+			logging.Log("CONFIG", "config_read_only", map[string]string{
+				"path":   s.path,
+				"reason": err.Error(),
+			})
 		}
 		cfg = &cfgVal
 	}
 
 	normalized := normalize(*cfg)
 
-	nodePrivKey, normalized, err := loadOrCreatePrivateKey(normalized, s.path)
+	var nodePrivKey crypto.PrivKey
+	if normalized.EphemeralIdentity {
+		nodePrivKey, err = generateEphemeralKey(normalized.KeyType)
+	} else {
+		nodePrivKey, normalized, err = loadOrCreatePrivateKey(normalized, s.path, s.keyStore())
+	}
 	if err != nil {
 		return err
 	}
@@ -144,6 +426,20 @@ func (s *Store) Init() error {
 	return nil
 }
 
+// SetKeyStore installs where the node key goes when config.json cannot be
+// written. Call it before Init.
+func (s *Store) SetKeyStore(keys KeyStore) {
+	s.mu.Lock()
+	s.keys = keys
+	s.mu.Unlock()
+}
+
+func (s *Store) keyStore() KeyStore {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.keys
+}
+
 func (s *Store) Get() Config {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -156,12 +452,51 @@ func (s *Store) ListenAddresses() []string {
 	return append([]string(nil), s.cfg.Listen.Values()...)
 }
 
+// BusinessListenAddresses are extra host:port listeners reserved for cluster
+// protocols. When set, inbound connections on the main listeners only reach
+// relay, NAT and probe services.
+func (s *Store) BusinessListenAddresses() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]string(nil), s.cfg.BusinessListen...)
+}
+
+func (s *Store) AnnounceAddrs() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]string(nil), s.cfg.AnnounceAddrs...)
+}
+
+func (s *Store) AnnounceMode() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg.AnnounceMode
+}
+
 func (s *Store) NodePrivateKey() crypto.PrivKey {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	return s.nodePrivKey
 }
 
+func (s *Store) LogLevel() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg.LogLevel
+}
+
+func (s *Store) LogFormat() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg.LogFormat
+}
+
+func (s *Store) DialPrivateAddrs() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg.DialPrivateAddrs
+}
+
 func (s *Store) NetworkMode() string {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -198,18 +533,355 @@ func (s *Store) AutoTLSForgeAuth() string {
 	return s.cfg.AutoTLS.ForgeAuth
 }
 
+func (s *Store) AutoTLSMinVersion() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg.AutoTLS.MinVersion
+}
+
+func (s *Store) AutoTLSCipherSuites() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]string(nil), s.cfg.AutoTLS.CipherSuites...)
+}
+
+func (s *Store) HeartbeatWindow() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return time.Duration(s.cfg.Heartbeat.WindowSeconds) * time.Second
+}
+
+func (s *Store) HeartbeatMinInterval() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return time.Duration(s.cfg.Heartbeat.MinIntervalSeconds) * time.Second
+}
+
+func (s *Store) Region() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg.Region
+}
+
+func (s *Store) Tags() map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make(map[string]string, len(s.cfg.Tags))
+	for k, v := range s.cfg.Tags {
+		out[k] = v
+	}
+	return out
+}
+
+func (s *Store) HeartbeatDigest() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return !s.cfg.Heartbeat.DisableDigest
+}
+
+func (s *Store) PinnedPeers() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]string(nil), s.cfg.PinnedPeers...)
+}
+
+func (s *Store) MinPeerVersion() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg.MinPeerVersion
+}
+
+func (s *Store) PeerVersionPolicy() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg.PeerVersionPolicy
+}
+
+func (s *Store) MinBootstrapPeers() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg.MinBootstrapPeers
+}
+
+// BootstrapConcurrency bounds how many bootstrap candidates are dialed at
+// once.
+func (s *Store) BootstrapConcurrency() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg.BootstrapConcurrency
+}
+
+func (s *Store) UnixTransport() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg.EnableUnixTransport
+}
+
+// ManualStart keeps the node paused after startup until the operator
+// resumes it, see network.Node.Pause.
+func (s *Store) ManualStart() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg.ManualStart
+}
+
+// ResumeRuntimeState makes a restarted node report recovering, from its
+// saved state and snapshot, until members reconnect.
+func (s *Store) ResumeRuntimeState() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg.ResumeRuntimeState
+}
+
+// EphemeralIdentity reports whether the node key lives only in memory and is
+// replaced on every start.
+func (s *Store) EphemeralIdentity() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg.EphemeralIdentity
+}
+
+// AdminBootstrap reports whether an admin-proof holder may publish
+// membership while unconfigured, e.g. to create the cluster including itself.
+func (s *Store) AdminBootstrap() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg.AdminBootstrap
+}
+
+func (s *Store) SelfDialCheck() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return !s.cfg.DisableSelfDialCheck
+}
+
+// StartupGrace is how long a member short of quorum reports starting rather
+// than degraded after the node starts.
+func (s *Store) StartupGrace() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return time.Duration(s.cfg.StartupGraceSeconds) * time.Second
+}
+
+func (s *Store) ShutdownTimeout() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return time.Duration(s.cfg.ShutdownTimeoutSeconds) * time.Second
+}
+
 func (s *Store) UpdateChannel() string {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	return s.cfg.UpdateChannel
 }
 
+func (s *Store) UpdateMinFreeBytes() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg.UpdateMinFreeBytes
+}
+
+func (s *Store) UpdateWindows() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]string(nil), s.cfg.UpdateWindows...)
+}
+
+// MaxUpdateVersion is the newest version the updater may install; empty
+// means no ceiling.
+func (s *Store) MaxUpdateVersion() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return strings.TrimSpace(s.cfg.MaxUpdateVersion)
+}
+
+func (s *Store) MaxUpdateSizeBytes() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg.MaxUpdateSizeBytes
+}
+
+func (s *Store) DNSDoHTimeout() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return time.Duration(s.cfg.DNS.DoHTimeoutSeconds) * time.Second
+}
+
+func (s *Store) ReconnectBackoffBase() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return time.Duration(s.cfg.Reconnect.BackoffBaseSeconds) * time.Second
+}
+
+func (s *Store) ReconnectBackoffMax() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return time.Duration(s.cfg.Reconnect.BackoffMaxSeconds) * time.Second
+}
+
+func (s *Store) ReconnectBreakerThreshold() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg.Reconnect.BreakerThreshold
+}
+
+func (s *Store) ReconnectBreakerCooldown() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return time.Duration(s.cfg.Reconnect.BreakerCooldownSeconds) * time.Second
+}
+
+// ProtocolSupportTTL is how long a peer that lacks the heartbeat or status
+// protocol is skipped before it is probed again.
+func (s *Store) ProtocolSupportTTL() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return time.Duration(s.cfg.UnsupportedTTLSeconds) * time.Second
+}
+
+func (s *Store) ValidationBanThreshold() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg.ValidationBan.Threshold
+}
+
+func (s *Store) ValidationBanWindow() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return time.Duration(s.cfg.ValidationBan.WindowSeconds) * time.Second
+}
+
+func (s *Store) ValidationBanDuration() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return time.Duration(s.cfg.ValidationBan.BanSeconds) * time.Second
+}
+
+func (s *Store) DNSMinTTL() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return time.Duration(s.cfg.DNS.MinTTLSeconds) * time.Second
+}
+
+func (s *Store) RecordRetention() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return time.Duration(s.cfg.Records.RetentionDays) * 24 * time.Hour
+}
+
+// PrivateNetworkPSK derives the libp2p pre-shared key from the cluster ID,
+// system public key and private_network.secret. It returns nil when no
+// secret is configured.
+func (s *Store) PrivateNetworkPSK() []byte {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.cfg.PrivateNetwork.Secret == "" {
+		return nil
+	}
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		"p2pos-pnet",
+		s.cfg.ClusterID,
+		s.cfg.SystemPubKey,
+		s.cfg.PrivateNetwork.Secret,
+	}, "|")))
+	return sum[:]
+}
+
+func (s *Store) PrivateNetworkPublicOptOut() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg.PrivateNetwork.PublicOptOut
+}
+
+func (s *Store) MembershipMaxMembers() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg.Membership.MaxMembers
+}
+
+func (s *Store) MembershipQuorumHold() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return time.Duration(s.cfg.Membership.QuorumHoldSeconds) * time.Second
+}
+
+// MembershipDownAlert is how long a member may stay disconnected before
+// MemberDown is raised.
+func (s *Store) MembershipDownAlert() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return time.Duration(s.cfg.Membership.DownAlertSeconds) * time.Second
+}
+
+func (s *Store) MembershipPushConcurrency() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg.Membership.PushConcurrency
+}
+
+// PresenceEqualObservers reports whether merged peer state ignores observer
+// authority and is decided by timestamp alone.
+func (s *Store) PresenceEqualObservers() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg.Presence.EqualObservers
+}
+
+// PresenceMinObservers is how many distinct remote members must report a
+// peer online before the merged cluster view upgrades it from "reported".
+func (s *Store) PresenceMinObservers() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg.Presence.MinObservers
+}
+
+// MembershipPushAckQuorum is how many acks a publish waits for; 0 means a
+// majority of the peers pushed to.
+func (s *Store) MembershipPushAckQuorum() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg.Membership.PushAckQuorum
+}
+
+// MembershipMaxSnapshotAge is the oldest issued_at a received snapshot may
+// carry; 0 accepts any age.
+func (s *Store) MembershipMaxSnapshotAge() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return time.Duration(s.cfg.Membership.MaxSnapshotAgeHours) * time.Hour
+}
+
+func (s *Store) MembershipIntendedMembers() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]string(nil), s.cfg.Membership.IntendedMembers...)
+}
+
+func (s *Store) MembershipSelfRemovedPolicy() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg.Membership.SelfRemovedPolicy
+}
+
+func (s *Store) MembershipIssuerPolicy() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg.Membership.IssuerPolicy
+}
+
+func (s *Store) MembershipDisconnectPolicy() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg.Membership.DisconnectPolicy
+}
+
 func (s *Store) AdminProof() (*membership.AdminProof, bool, error) {
 	s.mu.RLock()
 	raw := s.cfg.AdminProof
 	clusterID := s.cfg.ClusterID
+	systemPubKey := s.cfg.SystemPubKey
 	s.mu.RUnlock()
-	return parseAdminProof(raw, clusterID)
+	return parseAdminProof(raw, clusterID, systemPubKey)
 }
 
 func (s *Store) Update(next Config) error {
@@ -250,19 +922,36 @@ func Load(path string) (*Config, error) {
 	return &cfg, nil
 }
 
+// Save writes cfg to path as indented JSON.
+func Save(path string, cfg Config) error {
+	return saveToFile(path, cfg)
+}
+
+// writeConfigFile is os.WriteFile; tests swap it to simulate a read-only
+// mount, which file modes cannot do for root.
+var writeConfigFile = os.WriteFile
+
 func saveToFile(path string, cfg Config) error {
 	data, err := json.MarshalIndent(cfg, "", "  ")
 	if err != nil {
 		return err
 	}
 	data = append(data, '\n')
-	return os.WriteFile(path, data, 0644)
+	return writeConfigFile(path, data, 0644)
 }
 
 func normalize(cfg Config) Config {
+	cfg.InitConnections = dedupeConnections(cfg.InitConnections)
 	if len(cfg.Listen) == 0 {
 		cfg.Listen = Default().Listen
 	}
+	announceMode := strings.ToLower(strings.TrimSpace(cfg.AnnounceMode))
+	switch announceMode {
+	case "append", "replace":
+		cfg.AnnounceMode = announceMode
+	default:
+		cfg.AnnounceMode = defaultAnnounceMode
+	}
 	mode := strings.ToLower(strings.TrimSpace(cfg.NetworkMode))
 	switch mode {
 	case "", "auto":
@@ -285,6 +974,13 @@ func normalize(cfg Config) Config {
 		cfg.UpdateChannel = defaultUpdateChannel
 	}
 	cfg.NodePrivateKey = strings.TrimSpace(cfg.NodePrivateKey)
+	keyType := strings.ToLower(strings.TrimSpace(cfg.KeyType))
+	switch keyType {
+	case KeyTypeEd25519, KeyTypeSecp256k1:
+		cfg.KeyType = keyType
+	default:
+		cfg.KeyType = defaultKeyType
+	}
 	cfg.SystemPubKey = strings.TrimSpace(cfg.SystemPubKey)
 	cfg.ClusterID = strings.TrimSpace(cfg.ClusterID)
 	if cfg.ClusterID == "" {
@@ -313,22 +1009,285 @@ func normalize(cfg Config) Config {
 	if cfg.AutoTLS.Port <= 0 || cfg.AutoTLS.Port > 65535 {
 		cfg.AutoTLS.Port = defaultAutoTLSPort
 	}
+	switch strings.TrimSpace(cfg.AutoTLS.MinVersion) {
+	case "1.2", "1.3":
+		cfg.AutoTLS.MinVersion = strings.TrimSpace(cfg.AutoTLS.MinVersion)
+	default:
+		cfg.AutoTLS.MinVersion = defaultAutoTLSMinVersion
+	}
+	if cfg.Heartbeat.WindowSeconds <= 0 {
+		cfg.Heartbeat.WindowSeconds = defaultHeartbeatWindowSeconds
+	}
+	if cfg.Heartbeat.MinIntervalSeconds <= 0 {
+		cfg.Heartbeat.MinIntervalSeconds = defaultHeartbeatMinIntervalSeconds
+	}
+	if cfg.Presence.OfflineGraceSeconds <= 0 {
+		cfg.Presence.OfflineGraceSeconds = defaultPresenceOfflineGraceSeconds
+	}
+	if cfg.Presence.FlapThreshold <= 0 {
+		cfg.Presence.FlapThreshold = defaultPresenceFlapThreshold
+	}
+	if cfg.Presence.FlapWindowSeconds <= 0 {
+		cfg.Presence.FlapWindowSeconds = defaultPresenceFlapWindowSeconds
+	}
+	if cfg.Presence.MinObservers <= 0 {
+		cfg.Presence.MinObservers = defaultPresenceMinObservers
+	}
+	if cfg.MinBootstrapPeers <= 0 {
+		cfg.MinBootstrapPeers = defaultMinBootstrapPeers
+	}
+	if cfg.BootstrapConcurrency <= 0 {
+		cfg.BootstrapConcurrency = defaultBootstrapConcurrency
+	}
+	if cfg.ShutdownTimeoutSeconds <= 0 {
+		cfg.ShutdownTimeoutSeconds = defaultShutdownTimeoutSeconds
+	}
+	if cfg.StartupGraceSeconds <= 0 {
+		cfg.StartupGraceSeconds = defaultStartupGraceSeconds
+	}
+	if cfg.MaxUpdateSizeBytes <= 0 {
+		cfg.MaxUpdateSizeBytes = defaultMaxUpdateSizeBytes
+	}
+	if cfg.UpdateMinFreeBytes <= 0 {
+		cfg.UpdateMinFreeBytes = defaultUpdateMinFreeBytes
+	}
+	cfg.PrivateNetwork.Secret = strings.TrimSpace(cfg.PrivateNetwork.Secret)
+	if cfg.DNS.DoHTimeoutSeconds <= 0 {
+		cfg.DNS.DoHTimeoutSeconds = defaultDNSDoHTimeoutSeconds
+	}
+	if cfg.DNS.MinTTLSeconds <= 0 {
+		cfg.DNS.MinTTLSeconds = defaultDNSMinTTLSeconds
+	}
+	if cfg.Reconnect.BackoffBaseSeconds <= 0 {
+		cfg.Reconnect.BackoffBaseSeconds = defaultReconnectBackoffBaseSeconds
+	}
+	if cfg.Reconnect.BackoffMaxSeconds <= 0 {
+		cfg.Reconnect.BackoffMaxSeconds = defaultReconnectBackoffMaxSeconds
+	}
+	if cfg.Reconnect.BackoffMaxSeconds < cfg.Reconnect.BackoffBaseSeconds {
+		cfg.Reconnect.BackoffMaxSeconds = cfg.Reconnect.BackoffBaseSeconds
+	}
+	if cfg.Reconnect.BreakerThreshold <= 0 {
+		cfg.Reconnect.BreakerThreshold = defaultReconnectBreakerThreshold
+	}
+	if cfg.Reconnect.BreakerCooldownSeconds <= 0 {
+		cfg.Reconnect.BreakerCooldownSeconds = defaultReconnectBreakerCooldownSeconds
+	}
+	if cfg.UnsupportedTTLSeconds <= 0 {
+		cfg.UnsupportedTTLSeconds = defaultUnsupportedTTLSeconds
+	}
+	if cfg.ValidationBan.Threshold <= 0 {
+		cfg.ValidationBan.Threshold = defaultValidationBanThreshold
+	}
+	if cfg.ValidationBan.WindowSeconds <= 0 {
+		cfg.ValidationBan.WindowSeconds = defaultValidationBanWindowSeconds
+	}
+	if cfg.ValidationBan.BanSeconds <= 0 {
+		cfg.ValidationBan.BanSeconds = defaultValidationBanSeconds
+	}
+	if cfg.Records.RetentionDays <= 0 {
+		cfg.Records.RetentionDays = defaultRecordRetentionDays
+	}
+	if cfg.Membership.MaxMembers <= 0 {
+		cfg.Membership.MaxMembers = defaultMembershipMaxMembers
+	}
+	if cfg.Membership.QuorumHoldSeconds <= 0 {
+		cfg.Membership.QuorumHoldSeconds = defaultMembershipQuorumHoldSeconds
+	}
+	if cfg.Membership.DownAlertSeconds <= 0 {
+		cfg.Membership.DownAlertSeconds = defaultMembershipDownAlertSeconds
+	}
+	if cfg.Membership.PushConcurrency <= 0 {
+		cfg.Membership.PushConcurrency = defaultMembershipPushConcurrency
+	}
+	if cfg.Membership.PushAckQuorum < 0 {
+		cfg.Membership.PushAckQuorum = 0
+	}
+	if cfg.Membership.MaxSnapshotAgeHours < 0 {
+		cfg.Membership.MaxSnapshotAgeHours = 0
+	}
+	disconnectPolicy := strings.ToLower(strings.TrimSpace(cfg.Membership.DisconnectPolicy))
+	switch disconnectPolicy {
+	case MembershipDisconnectWarn, MembershipDisconnectRefuse:
+		cfg.Membership.DisconnectPolicy = disconnectPolicy
+	default:
+		cfg.Membership.DisconnectPolicy = defaultMembershipDisconnectPolicy
+	}
+	issuerPolicy := strings.ToLower(strings.TrimSpace(cfg.Membership.IssuerPolicy))
+	switch issuerPolicy {
+	case MembershipIssuerWarn, MembershipIssuerRefuse:
+		cfg.Membership.IssuerPolicy = issuerPolicy
+	default:
+		cfg.Membership.IssuerPolicy = defaultMembershipIssuerPolicy
+	}
+	selfRemoved := strings.ToLower(strings.TrimSpace(cfg.Membership.SelfRemovedPolicy))
+	switch selfRemoved {
+	case SelfRemovedContinue, SelfRemovedHold, SelfRemovedShutdown:
+		cfg.Membership.SelfRemovedPolicy = selfRemoved
+	default:
+		cfg.Membership.SelfRemovedPolicy = defaultMembershipSelfRemovedPolicy
+	}
+	dialPrivate := strings.ToLower(strings.TrimSpace(cfg.DialPrivateAddrs))
+	switch dialPrivate {
+	case DialPrivateAuto, DialPrivateAllow, DialPrivateDeny:
+		cfg.DialPrivateAddrs = dialPrivate
+	default:
+		cfg.DialPrivateAddrs = defaultDialPrivateAddrs
+	}
+	cfg.MinPeerVersion = strings.TrimPrefix(strings.TrimSpace(cfg.MinPeerVersion), "v")
+	peerVersionPolicy := strings.ToLower(strings.TrimSpace(cfg.PeerVersionPolicy))
+	switch peerVersionPolicy {
+	case PeerVersionWarn, PeerVersionDisconnect:
+		cfg.PeerVersionPolicy = peerVersionPolicy
+	default:
+		cfg.PeerVersionPolicy = defaultPeerVersionPolicy
+	}
+	logLevel := strings.ToLower(strings.TrimSpace(cfg.LogLevel))
+	switch logLevel {
+	case LogLevelDebug, LogLevelInfo, LogLevelWarn, LogLevelError:
+		cfg.LogLevel = logLevel
+	default:
+		cfg.LogLevel = defaultLogLevel
+	}
+	logFormat := strings.ToLower(strings.TrimSpace(cfg.LogFormat))
+	switch logFormat {
+	case LogFormatText, LogFormatJSON:
+		cfg.LogFormat = logFormat
+	default:
+		cfg.LogFormat = defaultLogFormat
+	}
+	cfg.Region = strings.TrimSpace(cfg.Region)
+	if !ValidNodeTag(cfg.Region) {
+		cfg.Region = ""
+	}
+	cfg.Tags = normalizeTags(cfg.Tags)
 	return cfg
 }
 
+// ValidNodeTag reports whether value may be used as a region, tag key or tag
+// value.
+func ValidNodeTag(value string) bool {
+	return len(value) <= MaxNodeTagLength && !strings.ContainsAny(value, "|,=\n")
+}
+
+func normalizeTags(in map[string]string) map[string]string {
+	if len(in) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(in))
+	for key := range in {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	out := make(map[string]string, len(in))
+	for _, key := range keys {
+		k := strings.TrimSpace(key)
+		v := strings.TrimSpace(in[key])
+		if k == "" || !ValidNodeTag(k) || !ValidNodeTag(v) {
+			continue
+		}
+		if len(out) >= MaxNodeTags {
+			break
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// dedupeConnections keeps the first entry for each (type, address) pair, in
+// order, and warns when one address is listed under several types.
+func dedupeConnections(in []Connection) []Connection {
+	if len(in) < 2 {
+		return in
+	}
+	out := make([]Connection, 0, len(in))
+	seen := make(map[string]struct{}, len(in))
+	types := make(map[string]string, len(in))
+	for _, conn := range in {
+		kind := strings.ToLower(strings.TrimSpace(conn.Type))
+		address := strings.TrimSpace(conn.Address)
+		key := kind + "|" + address
+		if _, ok := seen[key]; ok {
+			logging.Log("CONFIG", "init_connection_duplicate", map[string]string{
+				"type":    conn.Type,
+				"address": address,
+			})
+			continue
+		}
+		seen[key] = struct{}{}
+		if prev, ok := types[address]; ok && prev != kind {
+			logging.Log("CONFIG", "init_connection_type_conflict", map[string]string{
+				"address": address,
+				"types":   prev + "," + kind,
+			})
+		} else if !ok {
+			types[address] = kind
+		}
+		out = append(out, conn)
+	}
+	return out
+}
+
 func copyConfig(cfg Config) Config {
 	next := Config{
-		InitConnections: make([]Connection, len(cfg.InitConnections)),
-		Listen:          append(ListenConfig(nil), cfg.Listen...),
-		NetworkMode:     cfg.NetworkMode,
-		AutoTLS:         cfg.AutoTLS,
-		UpdateFeedURL:   cfg.UpdateFeedURL,
-		NodePrivateKey:  cfg.NodePrivateKey,
-		ClusterID:       cfg.ClusterID,
-		SystemPubKey:    cfg.SystemPubKey,
-		AdminProof:      cfg.AdminProof,
+		InitConnections:        make([]Connection, len(cfg.InitConnections)),
+		Listen:                 append(ListenConfig(nil), cfg.Listen...),
+		BusinessListen:         append([]string(nil), cfg.BusinessListen...),
+		AnnounceAddrs:          append([]string(nil), cfg.AnnounceAddrs...),
+		AnnounceMode:           cfg.AnnounceMode,
+		NetworkMode:            cfg.NetworkMode,
+		DialPrivateAddrs:       cfg.DialPrivateAddrs,
+		AutoTLS:                cfg.AutoTLS,
+		UpdateFeedURL:          cfg.UpdateFeedURL,
+		NodePrivateKey:         cfg.NodePrivateKey,
+		KeyType:                cfg.KeyType,
+		ClusterID:              cfg.ClusterID,
+		SystemPubKey:           cfg.SystemPubKey,
+		AdminProof:             cfg.AdminProof,
+		Heartbeat:              cfg.Heartbeat,
+		Presence:               cfg.Presence,
+		Membership:             cfg.Membership,
+		Records:                cfg.Records,
+		PrivateNetwork:         cfg.PrivateNetwork,
+		DNS:                    cfg.DNS,
+		Reconnect:              cfg.Reconnect,
+		ValidationBan:          cfg.ValidationBan,
+		UnsupportedTTLSeconds:  cfg.UnsupportedTTLSeconds,
+		PinnedPeers:            append([]string(nil), cfg.PinnedPeers...),
+		Region:                 cfg.Region,
+		ShutdownTimeoutSeconds: cfg.ShutdownTimeoutSeconds,
+		StartupGraceSeconds:    cfg.StartupGraceSeconds,
+		DisableSelfDialCheck:   cfg.DisableSelfDialCheck,
+		EphemeralIdentity:      cfg.EphemeralIdentity,
+		RequirePersistedKey:    cfg.RequirePersistedKey,
+		AdminBootstrap:         cfg.AdminBootstrap,
+		ManualStart:            cfg.ManualStart,
+		ResumeRuntimeState:     cfg.ResumeRuntimeState,
+		StateChangeWebhook:     cfg.StateChangeWebhook,
+		StateChangeCommand:     cfg.StateChangeCommand,
+		RequireSignedBootstrap: cfg.RequireSignedBootstrap,
+		EnableUnixTransport:    cfg.EnableUnixTransport,
+		MinBootstrapPeers:      cfg.MinBootstrapPeers,
+		BootstrapConcurrency:   cfg.BootstrapConcurrency,
+		MinPeerVersion:         cfg.MinPeerVersion,
+		PeerVersionPolicy:      cfg.PeerVersionPolicy,
+		LogLevel:               cfg.LogLevel,
+		LogFormat:              cfg.LogFormat,
+		MaxUpdateSizeBytes:     cfg.MaxUpdateSizeBytes,
+		UpdateMinFreeBytes:     cfg.UpdateMinFreeBytes,
+		UpdateWindows:          append([]string(nil), cfg.UpdateWindows...),
+		MaxUpdateVersion:       cfg.MaxUpdateVersion,
 	}
+	next.AutoTLS.CipherSuites = append([]string(nil), cfg.AutoTLS.CipherSuites...)
+	next.Membership.IntendedMembers = append([]string(nil), cfg.Membership.IntendedMembers...)
 	copy(next.InitConnections, cfg.InitConnections)
+	if cfg.Tags != nil {
+		next.Tags = make(map[string]string, len(cfg.Tags))
+		for k, v := range cfg.Tags {
+			next.Tags[k] = v
+		}
+	}
 	return next
 }
 
@@ -359,7 +1318,15 @@ func toEventConnections(conns []Connection) []events.ConfigConnection {
 	return out
 }
 
-func parseAdminProof(raw AdminProof, clusterID string) (*membership.AdminProof, bool, error) {
+// ParsedAdminProof decodes the admin proof carried in cfg, as Store.AdminProof
+// does, for tools that read a config file without starting a node.
+func (cfg Config) ParsedAdminProof() (*membership.AdminProof, bool, error) {
+	return parseAdminProof(cfg.AdminProof, cfg.ClusterID, cfg.SystemPubKey)
+}
+
+// parseAdminProof also rejects a proof whose key type cannot match
+// systemPubKey, when one is configured, before anything tries to verify it.
+func parseAdminProof(raw AdminProof, clusterID, systemPubKey string) (*membership.AdminProof, bool, error) {
 	isEmpty := strings.TrimSpace(raw.PeerID) == "" &&
 		strings.TrimSpace(raw.Sig) == "" &&
 		strings.TrimSpace(raw.ValidFrom) == "" &&
@@ -389,14 +1356,21 @@ func parseAdminProof(raw AdminProof, clusterID string) (*membership.AdminProof,
 		return nil, false, fmt.Errorf("admin_proof missing peer_id or sig")
 	}
 
-	return &membership.AdminProof{
+	proof := &membership.AdminProof{
 		ClusterID: raw.ClusterID,
 		PeerID:    raw.PeerID,
 		Role:      raw.Role,
 		ValidFrom: validFrom,
 		ValidTo:   validTo,
+		Alg:       strings.TrimSpace(raw.Alg),
 		Sig:       raw.Sig,
-	}, true, nil
+	}
+	if strings.TrimSpace(systemPubKey) != "" {
+		if err := membership.CheckAdminProofKeyType(*proof, systemPubKey); err != nil {
+			return nil, false, fmt.Errorf("admin_proof: %w", err)
+		}
+	}
+	return proof, true, nil
 }
 
 func parseTime(raw string) (time.Time, error) {
@@ -414,9 +1388,22 @@ func parseTime(raw string) (time.Time, error) {
 	return ts.UTC(), nil
 }
 
-func loadOrCreatePrivateKey(cfg Config, path string) (crypto.PrivKey, Config, error) {
+// generateEphemeralKey creates a node key that is never written anywhere, so
+// the node boots with a fresh peer ID each time.
+func generateEphemeralKey(keyType string) (crypto.PrivKey, error) {
+	key, err := GeneratePrivateKey(keyType)
+	if err != nil {
+		return nil, err
+	}
+	logging.Log("CONFIG", "node_key_ephemeral", map[string]string{
+		"key_type": keyType,
+	})
+	return key, nil
+}
+
+func loadOrCreatePrivateKey(cfg Config, path string, keys KeyStore) (crypto.PrivKey, Config, error) {
 	generateAndPersistNodeKey := func(reason string) (crypto.PrivKey, Config, error) {
-		generatedKey, _, err := crypto.GenerateEd25519Key(nil)
+		generatedKey, err := GeneratePrivateKey(cfg.KeyType)
 		if err != nil {
 			return nil, cfg, err
 		}
@@ -428,17 +1415,37 @@ func loadOrCreatePrivateKey(cfg Config, path string) (crypto.PrivKey, Config, er
 
 		encodedPrivKey := base64.StdEncoding.EncodeToString(privKeyBytes)
 		cfg.NodePrivateKey = encodedPrivKey
-		if err := saveToFile(path, cfg); err != nil {
+		if err := persistNodeKey(cfg, path, keys); err != nil {
 			return nil, cfg, err
 		}
 
 		logging.Log("CONFIG", "node_key_generated", map[string]string{
-			"reason": reason,
+			"reason":   reason,
+			"key_type": cfg.KeyType,
 		})
 		return generatedKey, cfg, nil
 	}
 
 	if cfg.NodePrivateKey == "" {
+		key, encoded, reason, err := loadStoredNodeKey(keys, cfg.KeyType)
+		if err != nil {
+			return nil, cfg, err
+		}
+		if key != nil {
+			cfg.NodePrivateKey = encoded
+			logging.Log("CONFIG", "node_key_loaded", map[string]string{
+				"key_type": strings.ToLower(key.Type().String()),
+				"source":   "key_store",
+			})
+			return key, cfg, nil
+		}
+		if reason != "" {
+			logging.Log("CONFIG", "node_key_regenerate", map[string]string{
+				"reason": reason,
+				"source": "key_store",
+			})
+			return generateAndPersistNodeKey(reason)
+		}
 		return generateAndPersistNodeKey("missing")
 	}
 
@@ -458,6 +1465,81 @@ func loadOrCreatePrivateKey(cfg Config, path string) (crypto.PrivKey, Config, er
 		return generateAndPersistNodeKey("invalid_key")
 	}
 
-	logging.Log("CONFIG", "node_key_loaded", nil)
+	logging.Log("CONFIG", "node_key_loaded", map[string]string{
+		"key_type": strings.ToLower(loadedKey.Type().String()),
+	})
 	return loadedKey, cfg, nil
 }
+
+// persistNodeKey writes cfg, carrying a new node key, back to path. Unless
+// require_persisted_key is set, a read-only config file does not stop the
+// node: the key goes to keys if there is one, and otherwise lasts only for
+// this run.
+func persistNodeKey(cfg Config, path string, keys KeyStore) error {
+	err := saveToFile(path, cfg)
+	if err == nil || cfg.RequirePersistedKey || !isReadOnlyErr(err) {
+		return err
+	}
+	fields := map[string]string{
+		"path":      path,
+		"reason":    err.Error(),
+		"key_store": "memory",
+	}
+	if keys != nil {
+		if err := keys.SaveNodeKey(context.Background(), cfg.NodePrivateKey); err != nil {
+			fields["key_store_error"] = err.Error()
+		} else {
+			fields["key_store"] = "database"
+		}
+	}
+	logging.Log("CONFIG", "config_read_only", fields)
+	return nil
+}
+
+// loadStoredNodeKey returns the key an earlier run kept in keys because
+// config.json could not be written. A stored key that does not decode or is
+// not of keyType comes back as a reason to generate a new one; failing to
+// read keys at all is an error, so a database problem never silently
+// changes the node's identity.
+func loadStoredNodeKey(keys KeyStore, keyType string) (crypto.PrivKey, string, string, error) {
+	if keys == nil {
+		return nil, "", "", nil
+	}
+	encoded, ok, err := keys.LoadNodeKey(context.Background())
+	if err != nil {
+		return nil, "", "", fmt.Errorf("load stored node key: %w", err)
+	}
+	if !ok {
+		return nil, "", "", nil
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, "", "invalid_base64", nil
+	}
+	key, err := crypto.UnmarshalPrivateKey(raw)
+	if err != nil {
+		return nil, "", "invalid_key", nil
+	}
+	if got := strings.ToLower(key.Type().String()); got != keyType {
+		return nil, "", "key_type_mismatch", nil
+	}
+	return key, encoded, "", nil
+}
+
+func isReadOnlyErr(err error) bool {
+	return errors.Is(err, fs.ErrPermission) || errors.Is(err, syscall.EROFS)
+}
+
+// GeneratePrivateKey creates a node key of the given type (ed25519 or secp256k1).
+func GeneratePrivateKey(keyType string) (crypto.PrivKey, error) {
+	switch strings.ToLower(strings.TrimSpace(keyType)) {
+	case "", KeyTypeEd25519:
+		priv, _, err := crypto.GenerateEd25519Key(nil)
+		return priv, err
+	case KeyTypeSecp256k1:
+		priv, _, err := crypto.GenerateSecp256k1Key(nil)
+		return priv, err
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", keyType)
+	}
+}
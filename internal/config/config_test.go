@@ -0,0 +1,209 @@
+package config
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	peer "github.com/libp2p/go-libp2p/core/peer"
+)
+
+// memoryKeys is a KeyStore standing in for the node_keys table.
+type memoryKeys struct {
+	encoded string
+	loadErr error
+}
+
+func (m *memoryKeys) LoadNodeKey(context.Context) (string, bool, error) {
+	return m.encoded, m.encoded != "", m.loadErr
+}
+
+func (m *memoryKeys) SaveNodeKey(_ context.Context, encoded string) error {
+	m.encoded = encoded
+	return nil
+}
+
+// readOnlyConfig writes raw as config.json, then makes every later write to
+// it fail as it would on a read-only mount.
+func readOnlyConfig(t *testing.T, raw string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(raw), 0644); err != nil {
+		t.Fatal(err)
+	}
+	writeConfigFile = func(name string, _ []byte, _ fs.FileMode) error {
+		return &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+	t.Cleanup(func() { writeConfigFile = os.WriteFile })
+	return path
+}
+
+func initStore(t *testing.T, path string, keys KeyStore) (*Store, error) {
+	t.Helper()
+	store := NewStore(nil)
+	store.path = path
+	if keys != nil {
+		store.SetKeyStore(keys)
+	}
+	return store, store.Init()
+}
+
+func peerIDOf(t *testing.T, key crypto.PrivKey) peer.ID {
+	t.Helper()
+	id, err := peer.IDFromPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestReadOnlyConfigKeepsKeyInMemory(t *testing.T) {
+	path := readOnlyConfig(t, `{}`)
+
+	store, err := initStore(t, path, nil)
+	if err != nil {
+		t.Fatalf("read-only config stopped the node: %v", err)
+	}
+	first := peerIDOf(t, store.NodePrivateKey())
+	if again := peerIDOf(t, store.NodePrivateKey()); again != first {
+		t.Fatalf("in-memory key changed within the session: %s then %s", first, again)
+	}
+	if data, _ := os.ReadFile(path); string(data) != `{}` {
+		t.Fatalf("config.json was rewritten: %s", data)
+	}
+}
+
+func TestReadOnlyConfigKeepsKeyInKeyStore(t *testing.T) {
+	path := readOnlyConfig(t, `{}`)
+	keys := &memoryKeys{}
+
+	store, err := initStore(t, path, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys.encoded == "" {
+		t.Fatal("generated key not saved to the key store")
+	}
+	restarted, err := initStore(t, path, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a, b := peerIDOf(t, store.NodePrivateKey()), peerIDOf(t, restarted.NodePrivateKey()); a != b {
+		t.Fatalf("peer ID changed across restart: %s then %s", a, b)
+	}
+}
+
+func TestReadOnlyConfigRequirePersistedKey(t *testing.T) {
+	path := readOnlyConfig(t, `{"require_persisted_key": true}`)
+	if _, err := initStore(t, path, &memoryKeys{}); !errors.Is(err, fs.ErrPermission) {
+		t.Fatalf("Init error %v, want the permission error", err)
+	}
+}
+
+func TestStoredNodeKeyIsValidated(t *testing.T) {
+	ed, _, err := crypto.GenerateEd25519Key(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := crypto.MarshalPrivateKey(ed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edEncoded := base64.StdEncoding.EncodeToString(raw)
+
+	cases := map[string]struct {
+		config string
+		stored string
+	}{
+		"key_type_mismatch": {`{"key_type": "secp256k1"}`, edEncoded},
+		"invalid_base64":    {`{}`, "not base64!"},
+		"invalid_key":       {`{}`, base64.StdEncoding.EncodeToString([]byte("garbage"))},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			path := readOnlyConfig(t, tc.config)
+			keys := &memoryKeys{encoded: tc.stored}
+			store, err := initStore(t, path, keys)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if keys.encoded == tc.stored {
+				t.Fatal("the rejected stored key was kept")
+			}
+			if got, want := store.NodePrivateKey().Type().String(), store.Get().KeyType; !strings.EqualFold(got, want) {
+				t.Fatalf("node key type %s, want %s", got, want)
+			}
+		})
+	}
+
+	t.Run("matching", func(t *testing.T) {
+		path := readOnlyConfig(t, `{"key_type": "ed25519"}`)
+		store, err := initStore(t, path, &memoryKeys{encoded: edEncoded})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !store.NodePrivateKey().Equals(ed) {
+			t.Fatal("a valid stored key was not used")
+		}
+	})
+
+	t.Run("load_error", func(t *testing.T) {
+		path := readOnlyConfig(t, `{}`)
+		if _, err := initStore(t, path, &memoryKeys{loadErr: errors.New("database is locked")}); err == nil {
+			t.Fatal("a key store read error was swallowed")
+		}
+	})
+}
+
+func TestEphemeralIdentityIsFreshAndUnsaved(t *testing.T) {
+	raw := `{"ephemeral_identity": true}`
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(raw), 0644); err != nil {
+		t.Fatal(err)
+	}
+	writeConfigFile = func(name string, _ []byte, _ fs.FileMode) error {
+		t.Errorf("ephemeral boot wrote %s", name)
+		return nil
+	}
+	t.Cleanup(func() { writeConfigFile = os.WriteFile })
+	keys := &memoryKeys{}
+
+	first, err := initStore(t, path, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := initStore(t, path, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a, b := peerIDOf(t, first.NodePrivateKey()), peerIDOf(t, second.NodePrivateKey()); a == b {
+		t.Fatalf("two ephemeral boots share peer ID %s", a)
+	}
+	if keys.encoded != "" {
+		t.Fatal("ephemeral key saved to the key store")
+	}
+	if data, _ := os.ReadFile(path); string(data) != raw {
+		t.Fatalf("config.json changed: %s", data)
+	}
+}
+
+func TestNormalizeCollapsesDuplicateInitConnections(t *testing.T) {
+	cfg := normalize(Config{InitConnections: []Connection{
+		{Type: "dns", Address: "seeds.example"},
+		{Type: "DNS", Address: " seeds.example "},
+		{Type: "http", Address: "seeds.example"},
+		{Type: "dns", Address: "seeds.example"},
+	}})
+	if len(cfg.InitConnections) != 2 {
+		t.Fatalf("init connections %+v, want one per type and address", cfg.InitConnections)
+	}
+	if cfg.InitConnections[0].Type != "dns" || cfg.InitConnections[1].Type != "http" {
+		t.Fatalf("init connections %+v, want the first of each kept in order", cfg.InitConnections)
+	}
+}
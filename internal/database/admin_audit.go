@@ -0,0 +1,55 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// AdminAudit is one entry of the hash-chained admin action log. Rows are
+// only ever appended; Hash covers the entry and PrevHash, Sig is the node
+// key's signature over Hash.
+type AdminAudit struct {
+	ID        uint   `gorm:"primaryKey;autoIncrement"`
+	Seq       uint64 `gorm:"uniqueIndex;not null"`
+	Action    string `gorm:"index;not null"`
+	Actor     string
+	Detail    string // JSON object
+	PrevHash  string
+	Hash      string `gorm:"not null"`
+	Sig       string `gorm:"not null"`
+	CreatedAt string // RFC3339Nano, kept as text so the hashed form round-trips
+}
+
+type AdminAuditRepository struct{}
+
+func NewAdminAuditRepository() *AdminAuditRepository {
+	return &AdminAuditRepository{}
+}
+
+// Last returns the newest entry, or ok=false when the chain is empty.
+func (r *AdminAuditRepository) Last(_ context.Context) (AdminAudit, bool, error) {
+	var entry AdminAudit
+	err := DB.Order("seq desc").First(&entry).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return AdminAudit{}, false, nil
+	}
+	if err != nil {
+		return AdminAudit{}, false, err
+	}
+	return entry, true, nil
+}
+
+func (r *AdminAuditRepository) Append(_ context.Context, entry AdminAudit) error {
+	return DB.Create(&entry).Error
+}
+
+// ListAll returns the whole chain in sequence order.
+func (r *AdminAuditRepository) ListAll(_ context.Context) ([]AdminAudit, error) {
+	var entries []AdminAudit
+	if err := DB.Order("seq asc").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
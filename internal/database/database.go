@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"p2pos/internal/events"
@@ -32,6 +33,7 @@ type Peer struct {
 	LastPingAt     *time.Time `gorm:"index"`
 	Reachability   string
 	ObservedBy     string
+	ClusterID      string `gorm:"index"` // cluster whose membership listed the peer
 }
 
 type sqliteTableColumn struct {
@@ -41,14 +43,25 @@ type sqliteTableColumn struct {
 
 // Init 初始化数据库连接
 func Init() error {
+	path, err := DefaultPath()
+	if err != nil {
+		return err
+	}
+	return InitAt(path)
+}
+
+// DefaultPath returns sqlite.db next to the running executable.
+func DefaultPath() (string, error) {
 	// 获取执行文件所在目录
 	exePath, err := os.Executable()
 	if err != nil {
-		return err
+		return "", err
 	}
-	exeDir := filepath.Dir(exePath)
-	dbPath := filepath.Join(exeDir, "sqlite.db")
+	return filepath.Join(filepath.Dir(exePath), "sqlite.db"), nil
+}
 
+// InitAt opens (or creates) the database at dbPath and migrates it.
+func InitAt(dbPath string) error {
 	// 打开或创建数据库
 	database, err := gorm.Open(sqlite.Open(dbPath), &gorm.Config{
 		Logger: gormlogger.New(
@@ -69,7 +82,7 @@ func Init() error {
 	}
 
 	// 自动迁移表结构
-	if err := DB.AutoMigrate(&Peer{}); err != nil {
+	if err := DB.AutoMigrate(&Peer{}, &MembershipAudit{}, &Record{}, &AdminAudit{}, &RuntimeResume{}, &NodeKey{}); err != nil {
 		return err
 	}
 
@@ -85,6 +98,18 @@ func Init() error {
 	return nil
 }
 
+// Ping checks that the database connection is open and answering.
+func Ping(ctx context.Context) error {
+	if DB == nil {
+		return errors.New("database not initialized")
+	}
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
 func configureSQLite(db *gorm.DB) error {
 	sqlDB, err := db.DB()
 	if err != nil {
@@ -231,7 +256,8 @@ func migratePeerSchema(db *gorm.DB) error {
 				last_ping_ok NUMERIC,
 				last_ping_at DATETIME,
 				reachability TEXT,
-				observed_by TEXT
+				observed_by TEXT,
+				cluster_id TEXT
 			)
 		`).Error; err != nil {
 			return err
@@ -264,15 +290,66 @@ func migratePeerSchema(db *gorm.DB) error {
 		if err := tx.Exec(`CREATE INDEX IF NOT EXISTS idx_peers_last_seen_at ON peers(last_seen_at)`).Error; err != nil {
 			return err
 		}
+		if err := tx.Exec(`CREATE INDEX IF NOT EXISTS idx_peers_cluster_id ON peers(cluster_id)`).Error; err != nil {
+			return err
+		}
 		return nil
 	})
 }
 
 // initDefaultSettings 初始化默认设置值
-type PeerRepository struct{}
+type PeerRepository struct {
+	observerAuthority bool
+	clusterID         string
+	minObservers      int
+
+	witnessMu sync.Mutex
+	// witnesses holds, per peer, the observers whose online reports have not
+	// yet been corroborated (see SetMinObservers).
+	witnesses map[string]map[string]struct{}
+}
 
 func NewPeerRepository() *PeerRepository {
-	return &PeerRepository{}
+	return &PeerRepository{observerAuthority: true}
+}
+
+// SetObserverAuthority toggles authority weighting in MergeObservedState.
+// When off, every observer counts the same and only timestamps decide.
+func (r *PeerRepository) SetObserverAuthority(enabled bool) {
+	r.observerAuthority = enabled
+}
+
+// SetMinObservers makes MergeObservedState upgrade a peer to online only once
+// minObservers distinct observers reported it so; until then the row is
+// stored as "reported". The peer's own report is trusted directly. 1 or less
+// trusts any single report.
+func (r *PeerRepository) SetMinObservers(minObservers int) {
+	r.minObservers = minObservers
+}
+
+// SetClusterID scopes member and status listings, membership sync and
+// presence updates to clusterID, and tags rows SyncMembers writes with it.
+// Empty covers every row.
+func (r *PeerRepository) SetClusterID(clusterID string) {
+	r.clusterID = strings.TrimSpace(clusterID)
+}
+
+// AdoptUnscopedPeers assigns rows written before peers carried a cluster_id
+// to the repository's cluster.
+func (r *PeerRepository) AdoptUnscopedPeers(_ context.Context) error {
+	if r.clusterID == "" {
+		return nil
+	}
+	return DB.Model(&Peer{}).
+		Where("COALESCE(cluster_id, '') = ''").
+		Update("cluster_id", r.clusterID).Error
+}
+
+func (r *PeerRepository) scoped(tx *gorm.DB) *gorm.DB {
+	if r.clusterID == "" {
+		return tx
+	}
+	return tx.Where("cluster_id = ?", r.clusterID)
 }
 
 func normalizePeerIDs(in []string) []string {
@@ -298,7 +375,7 @@ func normalizePeerIDs(in []string) []string {
 
 func (r *PeerRepository) ListMemberIDs(_ context.Context) ([]string, error) {
 	var ids []string
-	if err := DB.Model(&Peer{}).Order("peer_id asc").Pluck("peer_id", &ids).Error; err != nil {
+	if err := r.scoped(DB.Model(&Peer{})).Order("peer_id asc").Pluck("peer_id", &ids).Error; err != nil {
 		return nil, err
 	}
 	return normalizePeerIDs(ids), nil
@@ -311,19 +388,26 @@ func (r *PeerRepository) SyncMembers(_ context.Context, members []string) error
 
 	return DB.Transaction(func(tx *gorm.DB) error {
 		if len(ids) == 0 {
-			return tx.Where("1 = 1").Delete(&Peer{}).Error
+			return r.scoped(tx.Where("1 = 1")).Delete(&Peer{}).Error
 		}
 
-		if err := tx.Where("peer_id NOT IN ?", ids).Delete(&Peer{}).Error; err != nil {
+		// Rows of other clusters are theirs to prune.
+		if err := r.scoped(tx.Where("peer_id NOT IN ?", ids)).Delete(&Peer{}).Error; err != nil {
 			return err
 		}
 
+		if r.clusterID != "" {
+			if err := tx.Model(&Peer{}).Where("peer_id IN ?", ids).Update("cluster_id", r.clusterID).Error; err != nil {
+				return err
+			}
+		}
 		for _, id := range ids {
 			p := Peer{
 				PeerID:       id,
 				LastSeenAt:   now,
 				Reachability: "offline",
 				ObservedBy:   "",
+				ClusterID:    r.clusterID,
 			}
 			if err := tx.Clauses(clause.OnConflict{
 				Columns:   []clause.Column{{Name: "peer_id"}},
@@ -337,7 +421,7 @@ func (r *PeerRepository) SyncMembers(_ context.Context, members []string) error
 }
 
 func (r *PeerRepository) UpsertLastSeen(_ context.Context, peerID, remoteAddr, observedBy, reachability string) error {
-	return DB.Model(&Peer{}).Where("peer_id = ?", peerID).Updates(map[string]interface{}{
+	return r.scoped(DB.Model(&Peer{}).Where("peer_id = ?", peerID)).Updates(map[string]interface{}{
 		"last_remote_addr": remoteAddr,
 		"last_seen_at":     time.Now().UTC(),
 		"reachability":     reachability,
@@ -369,7 +453,7 @@ func (r *PeerRepository) UpdatePingResult(_ context.Context, peerID, observedBy
 		ObservedBy:    observedBy,
 	}
 
-	return DB.Model(&Peer{}).Where("peer_id = ?", peerID).Updates(map[string]interface{}{
+	return r.scoped(DB.Model(&Peer{}).Where("peer_id = ?", peerID)).Updates(map[string]interface{}{
 		"last_ping_ok":     peer.LastPingOK,
 		"last_ping_at":     peer.LastPingAt,
 		"last_ping_rtt_ms": peer.LastPingRTTMs,
@@ -388,16 +472,29 @@ func (r *PeerRepository) UpdateReachability(_ context.Context, peerID, observedB
 		ObservedBy:   observedBy,
 	}
 
-	return DB.Model(&Peer{}).Where("peer_id = ?", peerID).Updates(map[string]interface{}{
+	return r.scoped(DB.Model(&Peer{}).Where("peer_id = ?", peerID)).Updates(map[string]interface{}{
 		"reachability": peer.Reachability,
 		"observed_by":  peer.ObservedBy,
 		"last_seen_at": peer.LastSeenAt,
 	}).Error
 }
 
+// ImportPeers replaces the peers table with peers, keeping every column.
+func (r *PeerRepository) ImportPeers(_ context.Context, peers []Peer) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&Peer{}).Error; err != nil {
+			return err
+		}
+		if len(peers) == 0 {
+			return nil
+		}
+		return tx.Create(&peers).Error
+	})
+}
+
 func (r *PeerRepository) ListPeerStatuses(_ context.Context) ([]Peer, error) {
 	var peers []Peer
-	if err := DB.Order("peer_id asc").Find(&peers).Error; err != nil {
+	if err := r.scoped(DB).Order("peer_id asc").Find(&peers).Error; err != nil {
 		return nil, err
 	}
 	return peers, nil
@@ -424,7 +521,7 @@ func (r *PeerRepository) MergeObservedState(_ context.Context, state events.Peer
 
 	return DB.Transaction(func(tx *gorm.DB) error {
 		var existing Peer
-		err := tx.Where("peer_id = ?", incoming.PeerID).First(&existing).Error
+		err := r.scoped(tx.Where("peer_id = ?", incoming.PeerID)).First(&existing).Error
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				return nil
@@ -442,32 +539,127 @@ func (r *PeerRepository) MergeObservedState(_ context.Context, state events.Peer
 			incomingUpdated = peerUpdatedAt(incoming)
 		}
 
+		reachability := r.corroborate(incoming)
+		newer := incomingUpdated.After(existingUpdated)
+		if r.observerAuthority {
+			incomingAuth, err := observerAuthority(r.scoped(tx), incoming.PeerID, incoming.ObservedBy)
+			if err != nil {
+				return err
+			}
+			existingAuth, err := observerAuthority(r.scoped(tx), existing.PeerID, existing.ObservedBy)
+			if err != nil {
+				return err
+			}
+			switch {
+			case incomingUpdated.Equal(existingUpdated):
+				newer = incomingAuth > existingAuth
+			case newer && incomingAuth < existingAuth && incoming.Reachability == "offline":
+				// A weaker observer losing sight of the peer does not
+				// override what a stronger one saw.
+				newer = false
+			}
+		}
+
 		updates := map[string]interface{}{}
 		if existing.LastRemoteAddr == "" && incoming.LastRemoteAddr != "" {
 			updates["last_remote_addr"] = incoming.LastRemoteAddr
 		}
 
-		if incomingUpdated.After(existingUpdated) {
+		if newer {
 			updates["last_seen_at"] = incoming.LastSeenAt
 			updates["last_ping_ok"] = false
 			updates["last_ping_at"] = nil
 			updates["last_ping_rtt_ms"] = nil
 			updates["observed_by"] = incoming.ObservedBy
 			if existing.Reachability != "online" && existing.Reachability != "self" {
-				updates["reachability"] = incoming.Reachability
+				updates["reachability"] = reachability
 			}
 			if incoming.LastRemoteAddr != "" {
 				updates["last_remote_addr"] = incoming.LastRemoteAddr
 			}
+			if incoming.Reachability != "online" {
+				r.forgetWitnesses(incoming.PeerID)
+			}
+		} else if existing.Reachability == reachabilityReported && reachability == "online" {
+			// An older report can still be the one that corroborates.
+			updates["reachability"] = reachability
 		}
 
 		if len(updates) == 0 {
 			return nil
 		}
-		return tx.Model(&Peer{}).Where("peer_id = ?", incoming.PeerID).Updates(updates).Error
+		return r.scoped(tx.Model(&Peer{}).Where("peer_id = ?", incoming.PeerID)).Updates(updates).Error
 	})
 }
 
+// reachabilityReported marks a peer that too few observers have reported
+// online to trust it; it does not count as online.
+const reachabilityReported = "reported"
+
+// corroborate records incoming's observer as a witness and returns the
+// reachability to store for it: an online report from anyone but the peer
+// itself stays "reported" until minObservers distinct observers made one.
+// An offline report withdraws that observer's earlier claim.
+func (r *PeerRepository) corroborate(incoming Peer) string {
+	if r.minObservers <= 1 {
+		return incoming.Reachability
+	}
+	r.witnessMu.Lock()
+	defer r.witnessMu.Unlock()
+	if incoming.Reachability != "online" {
+		delete(r.witnesses[incoming.PeerID], incoming.ObservedBy)
+		return incoming.Reachability
+	}
+	if incoming.ObservedBy == incoming.PeerID {
+		delete(r.witnesses, incoming.PeerID)
+		return incoming.Reachability
+	}
+	if r.witnesses == nil {
+		r.witnesses = make(map[string]map[string]struct{})
+	}
+	if r.witnesses[incoming.PeerID] == nil {
+		r.witnesses[incoming.PeerID] = make(map[string]struct{})
+	}
+	r.witnesses[incoming.PeerID][incoming.ObservedBy] = struct{}{}
+	if len(r.witnesses[incoming.PeerID]) < r.minObservers {
+		return reachabilityReported
+	}
+	delete(r.witnesses, incoming.PeerID)
+	return incoming.Reachability
+}
+
+func (r *PeerRepository) forgetWitnesses(peerID string) {
+	r.witnessMu.Lock()
+	delete(r.witnesses, peerID)
+	r.witnessMu.Unlock()
+}
+
+// Observer authority, lowest to highest: anyone, a member, the peer itself.
+const (
+	authorityOther = iota
+	authorityMember
+	authoritySelf
+)
+
+// observerAuthority ranks how much observedBy's view of peerID is trusted.
+// The peers table holds exactly the member set, so membership is a lookup.
+func observerAuthority(tx *gorm.DB, peerID, observedBy string) (int, error) {
+	switch {
+	case observedBy == "":
+		return authorityOther, nil
+	case observedBy == peerID:
+		return authoritySelf, nil
+	}
+	var count int64
+	if err := tx.Model(&Peer{}).Where("peer_id = ?", observedBy).Count(&count).Error; err != nil {
+		return authorityOther, err
+	}
+	if count > 0 {
+		return authorityMember, nil
+	}
+	return authorityOther, nil
+}
+
 func normalizeReachability(v string) string {
 	switch v {
 	case "online", "connected", "self":
@@ -0,0 +1,269 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"p2pos/internal/events"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// initTestDB opens a fresh, migrated database for the test.
+func initTestDB(t *testing.T) {
+	t.Helper()
+	if err := InitAt(filepath.Join(t.TempDir(), "sqlite.db")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := DB.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+}
+
+// initLegacyDB writes a peers table from before rows carried a cluster_id,
+// then opens it through InitAt so the migration runs.
+func initLegacyDB(t *testing.T, peerIDs ...string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "sqlite.db")
+	legacy, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := legacy.Exec(`CREATE TABLE peers (
+		peer_id TEXT PRIMARY KEY NOT NULL,
+		last_remote_addr TEXT,
+		last_seen_at DATETIME,
+		reachability TEXT,
+		observed_by TEXT
+	)`).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range peerIDs {
+		if err := legacy.Exec(`INSERT INTO peers (peer_id, last_seen_at, reachability, observed_by) VALUES (?, ?, 'offline', '')`, id, time.Now().UTC()).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if sqlDB, err := legacy.DB(); err == nil {
+		sqlDB.Close()
+	}
+	if err := InitAt(path); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := DB.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+}
+
+func listIDs(t *testing.T, repo *PeerRepository) []string {
+	t.Helper()
+	peers, err := repo.ListPeerStatuses(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]string, 0, len(peers))
+	for _, p := range peers {
+		ids = append(ids, p.PeerID)
+	}
+	return ids
+}
+
+func TestMigrationAdoptsLegacyPeersIntoCurrentCluster(t *testing.T) {
+	initLegacyDB(t, "peer-a", "peer-b")
+	ctx := context.Background()
+
+	blue := NewPeerRepository()
+	blue.SetClusterID("blue")
+	if err := blue.AdoptUnscopedPeers(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := listIDs(t, blue); len(got) != 2 {
+		t.Fatalf("blue lists %v after migration, want both legacy rows", got)
+	}
+
+	green := NewPeerRepository()
+	green.SetClusterID("green")
+	if got := listIDs(t, green); len(got) != 0 {
+		t.Fatalf("green lists %v, want none of blue's peers", got)
+	}
+	// Adopting again must not steal rows already scoped to blue.
+	if err := green.AdoptUnscopedPeers(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := listIDs(t, green); len(got) != 0 {
+		t.Fatalf("green adopted %v from blue", got)
+	}
+}
+
+func TestPeerWritesStayInTheirCluster(t *testing.T) {
+	initLegacyDB(t)
+	ctx := context.Background()
+
+	blue := NewPeerRepository()
+	blue.SetClusterID("blue")
+	green := NewPeerRepository()
+	green.SetClusterID("green")
+
+	if err := blue.SyncMembers(ctx, []string{"blue-1", "blue-2"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := green.SyncMembers(ctx, []string{"green-1"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := listIDs(t, blue); len(got) != 2 {
+		t.Fatalf("green's sync pruned blue: blue lists %v", got)
+	}
+	if err := green.SyncMembers(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := listIDs(t, blue); len(got) != 2 {
+		t.Fatalf("clearing green pruned blue: blue lists %v", got)
+	}
+
+	// Presence and heartbeat writes from green do not touch blue's rows.
+	if err := green.UpsertLastSeen(ctx, "blue-1", "/ip4/203.0.113.1/tcp/1", "green-1", "online"); err != nil {
+		t.Fatal(err)
+	}
+	if err := green.UpdatePingResult(ctx, "blue-1", "green-1", true, time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := green.UpdateReachability(ctx, "blue-1", "green-1", "online"); err != nil {
+		t.Fatal(err)
+	}
+	if err := green.MergeObservedState(ctx, events.PeerStateObserved{
+		PeerID:       "blue-1",
+		ObservedBy:   "green-1",
+		Reachability: "online",
+		LastSeenAt:   time.Now().Add(time.Hour),
+		ObservedAt:   time.Now().Add(time.Hour),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	peers, err := blue.ListPeerStatuses(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range peers {
+		if p.PeerID == "blue-1" && (p.Reachability != "offline" || p.ObservedBy != "" || p.LastPingOK) {
+			t.Fatalf("green updated blue's row: %+v", p)
+		}
+	}
+
+	if err := blue.UpdateReachability(ctx, "blue-1", "blue-2", "online"); err != nil {
+		t.Fatal(err)
+	}
+	peers, err = blue.ListPeerStatuses(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if peers[0].PeerID != "blue-1" || peers[0].Reachability != "online" {
+		t.Fatalf("blue could not update its own row: %+v", peers[0])
+	}
+}
+
+func TestMergeObservedStateObserverAuthority(t *testing.T) {
+	initTestDB(t)
+	ctx := context.Background()
+	repo := NewPeerRepository()
+	if err := repo.SyncMembers(ctx, []string{"a", "b", "c"}); err != nil {
+		t.Fatal(err)
+	}
+	base := time.Now().Add(time.Hour).UTC()
+	merge := func(observer, reachability string, at time.Time) {
+		t.Helper()
+		if err := repo.MergeObservedState(ctx, events.PeerStateObserved{
+			PeerID: "a", ObservedBy: observer, Reachability: reachability, LastSeenAt: at,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	observedBy := func() string {
+		t.Helper()
+		peers, err := repo.ListPeerStatuses(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return peers[0].ObservedBy
+	}
+
+	merge("b", "online", base)
+	merge("outsider", "offline", base.Add(time.Second))
+	if got := observedBy(); got != "b" {
+		t.Fatalf("a non-member's newer offline report overrode a member: observed by %q", got)
+	}
+	merge("a", "online", base)
+	if got := observedBy(); got != "a" {
+		t.Fatalf("on a tie the peer itself lost to a member: observed by %q", got)
+	}
+	merge("c", "online", base)
+	if got := observedBy(); got != "a" {
+		t.Fatalf("on a tie a member beat the peer itself: observed by %q", got)
+	}
+
+	repo.SetObserverAuthority(false)
+	merge("outsider", "offline", base.Add(time.Second))
+	if got := observedBy(); got != "outsider" {
+		t.Fatalf("with equal observers the newer report lost: observed by %q", got)
+	}
+}
+
+func TestMergeObservedStateNeedsCorroboration(t *testing.T) {
+	initTestDB(t)
+	ctx := context.Background()
+	repo := NewPeerRepository()
+	repo.SetMinObservers(2)
+	if err := repo.SyncMembers(ctx, []string{"a", "b", "c", "d"}); err != nil {
+		t.Fatal(err)
+	}
+	base := time.Now().Add(time.Hour).UTC()
+	merge := func(peerID, observer, reachability string, at time.Time) {
+		t.Helper()
+		if err := repo.MergeObservedState(ctx, events.PeerStateObserved{
+			PeerID: peerID, ObservedBy: observer, Reachability: reachability, LastSeenAt: at,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	reachability := func(peerID string) string {
+		t.Helper()
+		peers, err := repo.ListPeerStatuses(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range peers {
+			if p.PeerID == peerID {
+				return p.Reachability
+			}
+		}
+		t.Fatalf("no row for %s", peerID)
+		return ""
+	}
+
+	merge("a", "b", "online", base)
+	if got := reachability("a"); got != reachabilityReported {
+		t.Fatalf("one observer's claim stored as %q, want %q", got, reachabilityReported)
+	}
+	merge("a", "c", "online", base)
+	if got := reachability("a"); got != "online" {
+		t.Fatalf("corroborated claim stored as %q, want online", got)
+	}
+
+	// A withdrawn claim no longer counts towards corroboration.
+	merge("b", "c", "online", base)
+	merge("b", "c", "offline", base.Add(time.Second))
+	merge("b", "d", "online", base.Add(2*time.Second))
+	if got := reachability("b"); got != reachabilityReported {
+		t.Fatalf("claim corroborated by a withdrawn one stored as %q", got)
+	}
+
+	merge("c", "c", "online", base)
+	if got := reachability("c"); got != "online" {
+		t.Fatalf("the peer's own report stored as %q, want online", got)
+	}
+}
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"p2pos/internal/events"
+)
+
+// MembershipAudit records one applied membership snapshot and its member diff.
+type MembershipAudit struct {
+	ID           uint   `gorm:"primaryKey;autoIncrement"`
+	ClusterID    string `gorm:"index"`
+	IssuerPeerID string `gorm:"index"`
+	ReceivedFrom string
+	IssuedAt     time.Time `gorm:"index"`
+	Added        string    // comma-separated peer IDs
+	Removed      string    // comma-separated peer IDs
+	RecordedAt   time.Time `gorm:"index"`
+}
+
+type MembershipAuditRepository struct{}
+
+func NewMembershipAuditRepository() *MembershipAuditRepository {
+	return &MembershipAuditRepository{}
+}
+
+func (r *MembershipAuditRepository) Append(_ context.Context, change events.MembershipChanged) error {
+	recordedAt := change.At.UTC()
+	if recordedAt.IsZero() {
+		recordedAt = time.Now().UTC()
+	}
+	entry := MembershipAudit{
+		ClusterID:    change.ClusterID,
+		IssuerPeerID: change.IssuerPeerID,
+		ReceivedFrom: change.ReceivedFrom,
+		IssuedAt:     change.IssuedAt.UTC(),
+		Added:        strings.Join(normalizePeerIDs(change.Added), ","),
+		Removed:      strings.Join(normalizePeerIDs(change.Removed), ","),
+		RecordedAt:   recordedAt,
+	}
+	return DB.Create(&entry).Error
+}
+
+// ListRecent returns up to limit audit entries, newest first.
+func (r *MembershipAuditRepository) ListRecent(_ context.Context, limit int) ([]MembershipAudit, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	var entries []MembershipAudit
+	if err := DB.Order("id desc").Limit(limit).Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func splitPeerIDs(raw string) []string {
+	if strings.TrimSpace(raw) == "" {
+		return []string{}
+	}
+	return normalizePeerIDs(strings.Split(raw, ","))
+}
+
+// AddedPeerIDs returns the added members as a slice.
+func (a MembershipAudit) AddedPeerIDs() []string {
+	return splitPeerIDs(a.Added)
+}
+
+// RemovedPeerIDs returns the removed members as a slice.
+func (a MembershipAudit) RemovedPeerIDs() []string {
+	return splitPeerIDs(a.Removed)
+}
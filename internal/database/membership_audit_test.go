@@ -0,0 +1,50 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"p2pos/internal/events"
+)
+
+func TestMembershipAuditRecordsDiff(t *testing.T) {
+	initTestDB(t)
+	repo := NewMembershipAuditRepository()
+	ctx := context.Background()
+
+	issuedAt := time.Now().UTC().Add(-time.Minute)
+	for _, change := range []events.MembershipChanged{
+		{ClusterID: "test", IssuerPeerID: "admin", ReceivedFrom: "relay", IssuedAt: issuedAt, Added: []string{"c", "b"}, Removed: []string{"a"}},
+		{ClusterID: "test", IssuerPeerID: "admin", IssuedAt: issuedAt.Add(time.Second), Added: []string{"a"}},
+	} {
+		if err := repo.Append(ctx, change); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := repo.ListRecent(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d audit entries, want 2", len(entries))
+	}
+	newest, oldest := entries[0], entries[1]
+	if got := newest.AddedPeerIDs(); len(got) != 1 || got[0] != "a" || len(newest.RemovedPeerIDs()) != 0 {
+		t.Fatalf("newest entry added %v removed %v, want [a] and none", got, newest.RemovedPeerIDs())
+	}
+	if got := oldest.AddedPeerIDs(); len(got) != 2 || got[0] != "b" || got[1] != "c" {
+		t.Fatalf("oldest entry added %v, want [b c]", got)
+	}
+	if got := oldest.RemovedPeerIDs(); len(got) != 1 || got[0] != "a" {
+		t.Fatalf("oldest entry removed %v, want [a]", got)
+	}
+	if oldest.IssuerPeerID != "admin" || oldest.ReceivedFrom != "relay" || !oldest.IssuedAt.Equal(issuedAt) {
+		t.Fatalf("oldest entry %+v lost who issued or relayed it", oldest)
+	}
+
+	if limited, err := repo.ListRecent(ctx, 1); err != nil || len(limited) != 1 || limited[0].ID != newest.ID {
+		t.Fatalf("ListRecent(1) = %+v, %v", limited, err)
+	}
+}
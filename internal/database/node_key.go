@@ -0,0 +1,47 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const nodeKeyID = 1
+
+// NodeKey holds the node key when config.json is read-only, so the node
+// keeps its peer ID across restarts anyway.
+type NodeKey struct {
+	ID         uint   `gorm:"primaryKey"`
+	PrivateKey string // base64, same encoding as node_private_key
+	UpdatedAt  time.Time
+}
+
+type NodeKeyRepository struct{}
+
+func NewNodeKeyRepository() *NodeKeyRepository {
+	return &NodeKeyRepository{}
+}
+
+// LoadNodeKey returns the saved key; ok is false when nothing was saved yet.
+func (r *NodeKeyRepository) LoadNodeKey(_ context.Context) (string, bool, error) {
+	var row NodeKey
+	err := DB.First(&row, nodeKeyID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return row.PrivateKey, row.PrivateKey != "", nil
+}
+
+func (r *NodeKeyRepository) SaveNodeKey(_ context.Context, encoded string) error {
+	row := NodeKey{ID: nodeKeyID, PrivateKey: encoded, UpdatedAt: time.Now().UTC()}
+	return DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"private_key", "updated_at"}),
+	}).Create(&row).Error
+}
@@ -0,0 +1,65 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Record is one append-only business record. Payload is opaque to the store;
+// callers own its encoding. Rows are never updated, only pruned by age.
+type Record struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement"`
+	Kind      string    `gorm:"index;not null"`
+	PeerID    string    `gorm:"index"`
+	Payload   string    // caller-defined, usually JSON
+	CreatedAt time.Time `gorm:"index"`
+}
+
+type RecordRepository struct{}
+
+func NewRecordRepository() *RecordRepository {
+	return &RecordRepository{}
+}
+
+func (r *RecordRepository) Append(_ context.Context, kind, peerID, payload string) (Record, error) {
+	kind = strings.TrimSpace(kind)
+	if kind == "" {
+		return Record{}, fmt.Errorf("record kind is required")
+	}
+	rec := Record{
+		Kind:      kind,
+		PeerID:    strings.TrimSpace(peerID),
+		Payload:   payload,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := DB.Create(&rec).Error; err != nil {
+		return Record{}, err
+	}
+	return rec, nil
+}
+
+// ListSince returns up to limit records created strictly after since, oldest
+// first. An empty kind matches every kind.
+func (r *RecordRepository) ListSince(_ context.Context, kind string, since time.Time, limit int) ([]Record, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	query := DB.Where("created_at > ?", since.UTC())
+	if kind = strings.TrimSpace(kind); kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	var records []Record
+	if err := query.Order("created_at asc, id asc").Limit(limit).Find(&records).Error; err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// PruneBefore deletes records created before cutoff and reports how many
+// rows were removed.
+func (r *RecordRepository) PruneBefore(_ context.Context, cutoff time.Time) (int64, error) {
+	result := DB.Where("created_at < ?", cutoff.UTC()).Delete(&Record{})
+	return result.RowsAffected, result.Error
+}
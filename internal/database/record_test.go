@@ -0,0 +1,59 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRecordRetention(t *testing.T) {
+	initTestDB(t)
+	ctx := context.Background()
+	repo := NewRecordRepository()
+
+	if _, err := repo.Append(ctx, " ", "peer", "{}"); err == nil {
+		t.Fatal("record without a kind accepted")
+	}
+	old, err := repo.Append(ctx, "ping", "peer-a", `{"rtt_ms":12}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Append(ctx, "ping", "peer-b", `{"rtt_ms":30}`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Append(ctx, "update", "", `{}`); err != nil {
+		t.Fatal(err)
+	}
+	// Age the first record past the retention window.
+	if err := DB.Model(&Record{}).Where("id = ?", old.ID).Update("created_at", time.Now().UTC().Add(-48*time.Hour)).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	pings, err := repo.ListSince(ctx, "ping", time.Time{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pings) != 2 || pings[0].ID != old.ID {
+		t.Fatalf("pings %+v, want both, oldest first", pings)
+	}
+
+	pruned, err := repo.PruneBefore(ctx, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pruned != 1 {
+		t.Fatalf("pruned %d records, want only the aged one", pruned)
+	}
+	all, err := repo.ListSince(ctx, "", time.Time{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("kept %+v, want the two recent records", all)
+	}
+	for _, rec := range all {
+		if rec.ID == old.ID {
+			t.Fatal("aged record survived pruning")
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const runtimeResumeID = 1
+
+// RuntimeResume is the single row a node keeps so that a restart starts
+// from the last signed membership snapshot and, with resume_runtime_state
+// on, reports its last known state.
+type RuntimeResume struct {
+	ID        uint   `gorm:"primaryKey"`
+	State     string // last settled runtime state
+	Snapshot  string // last applied signed snapshot, JSON
+	UpdatedAt time.Time
+}
+
+type RuntimeResumeRepository struct{}
+
+func NewRuntimeResumeRepository() *RuntimeResumeRepository {
+	return &RuntimeResumeRepository{}
+}
+
+// Load returns the saved row; ok is false when nothing was saved yet.
+func (r *RuntimeResumeRepository) Load(_ context.Context) (RuntimeResume, bool, error) {
+	var row RuntimeResume
+	err := DB.First(&row, runtimeResumeID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return RuntimeResume{}, false, nil
+	}
+	if err != nil {
+		return RuntimeResume{}, false, err
+	}
+	return row, true, nil
+}
+
+func (r *RuntimeResumeRepository) SaveState(_ context.Context, state string) error {
+	return r.upsert(RuntimeResume{State: state}, "state")
+}
+
+func (r *RuntimeResumeRepository) SaveSnapshot(_ context.Context, snapshot string) error {
+	return r.upsert(RuntimeResume{Snapshot: snapshot}, "snapshot")
+}
+
+func (r *RuntimeResumeRepository) upsert(row RuntimeResume, column string) error {
+	row.ID = runtimeResumeID
+	row.UpdatedAt = time.Now().UTC()
+	return DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{column, "updated_at"}),
+	}).Create(&row).Error
+}
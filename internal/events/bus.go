@@ -5,6 +5,10 @@ import "sync"
 type Bus struct {
 	mu   sync.RWMutex
 	subs map[chan any]struct{}
+	// retained holds events published while startup buffering is on, so
+	// subscribers that attach during startup still see them.
+	retained  []any
+	retainMax int
 }
 
 func NewBus() *Bus {
@@ -13,6 +17,23 @@ func NewBus() *Bus {
 	}
 }
 
+// RetainStartup keeps up to limit of the most recent published events and
+// replays them to every new subscriber until EndStartup is called.
+func (b *Bus) RetainStartup(limit int) {
+	b.mu.Lock()
+	b.retainMax = limit
+	b.retained = nil
+	b.mu.Unlock()
+}
+
+// EndStartup stops retaining events and drops the retained backlog.
+func (b *Bus) EndStartup() {
+	b.mu.Lock()
+	b.retainMax = 0
+	b.retained = nil
+	b.mu.Unlock()
+}
+
 func (b *Bus) Subscribe(buffer int) (<-chan any, func()) {
 	if buffer <= 0 {
 		buffer = 1
@@ -22,6 +43,12 @@ func (b *Bus) Subscribe(buffer int) (<-chan any, func()) {
 
 	b.mu.Lock()
 	b.subs[ch] = struct{}{}
+	for _, evt := range b.retained {
+		select {
+		case ch <- evt:
+		default:
+		}
+	}
 	b.mu.Unlock()
 
 	cancel := func() {
@@ -36,14 +63,21 @@ func (b *Bus) Subscribe(buffer int) (<-chan any, func()) {
 	return ch, cancel
 }
 
+// Publish fans evt out and retains it under one lock, so a subscriber that
+// attaches concurrently gets it either live or from the replay, never neither.
 func (b *Bus) Publish(evt any) {
-	b.mu.RLock()
-	defer b.mu.RUnlock()
-
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	for ch := range b.subs {
 		select {
 		case ch <- evt:
 		default:
 		}
 	}
+	if b.retainMax > 0 {
+		b.retained = append(b.retained, evt)
+		if over := len(b.retained) - b.retainMax; over > 0 {
+			b.retained = append([]any(nil), b.retained[over:]...)
+		}
+	}
 }
@@ -0,0 +1,60 @@
+package events
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestRetainedEventReplayedToLateSubscriber(t *testing.T) {
+	b := NewBus()
+	b.RetainStartup(8)
+	b.Publish("early")
+
+	ch, cancel := b.Subscribe(8)
+	defer cancel()
+	select {
+	case evt := <-ch:
+		if evt != "early" {
+			t.Fatalf("got %v, want the retained event", evt)
+		}
+	default:
+		t.Fatal("event published before subscribing was lost")
+	}
+
+	b.EndStartup()
+	late, cancelLate := b.Subscribe(8)
+	defer cancelLate()
+	select {
+	case evt := <-late:
+		t.Fatalf("got %v after EndStartup, want no replay", evt)
+	default:
+	}
+}
+
+func TestPublishRacingSubscribeLosesNothing(t *testing.T) {
+	const events = 200
+	for round := 0; round < 50; round++ {
+		b := NewBus()
+		b.RetainStartup(events)
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < events; i++ {
+				b.Publish(i)
+			}
+		}()
+		ch, cancel := b.Subscribe(events)
+		wg.Wait()
+
+		seen := make(map[int]bool, events)
+		for len(ch) > 0 {
+			seen[(<-ch).(int)] = true
+		}
+		cancel()
+		if len(seen) != events {
+			t.Fatalf("round %d: subscriber saw %d of %d events", round, len(seen), events)
+		}
+	}
+}
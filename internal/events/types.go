@@ -24,9 +24,14 @@ type PeerStateObserved struct {
 }
 
 type PeerHeartbeat struct {
-	PeerID     string
-	RemoteAddr string
-	At         time.Time
+	PeerID      string
+	RemoteAddr  string
+	State       string
+	MemberCount int
+	AppVersion  string
+	Region      string
+	Tags        map[string]string
+	At          time.Time
 }
 
 type ConfigConnection struct {
@@ -42,7 +47,45 @@ type ConfigChanged struct {
 	At              time.Time
 }
 
+type MembershipChanged struct {
+	ClusterID    string
+	IssuerPeerID string
+	ReceivedFrom string
+	IssuedAt     time.Time
+	Added        []string
+	Removed      []string
+	At           time.Time
+}
+
 type ShutdownRequested struct {
 	Reason string
 	At     time.Time
 }
+
+// CertRenewalFailing is published when the AutoTLS certificate has stayed
+// past its renewal point for several consecutive checks.
+type CertRenewalFailing struct {
+	NotAfter  time.Time
+	Remaining time.Duration
+	Checks    int
+	At        time.Time
+}
+
+// MemberDown is published when a member of the current snapshot has stayed
+// disconnected past the alert grace. It fires once per outage.
+type MemberDown struct {
+	PeerID       string
+	OfflineSince time.Time
+	At           time.Time
+}
+
+// SelfRemoved is published when an applied snapshot no longer lists the
+// local node. Policy is the membership.self_removed_policy being enforced.
+type SelfRemoved struct {
+	ClusterID    string
+	IssuerPeerID string
+	ReceivedFrom string
+	IssuedAt     time.Time
+	Policy       string
+	At           time.Time
+}
@@ -1,19 +1,255 @@
 package logging
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
+	"os"
 	"sort"
 	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
 )
 
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+var (
+	mu       sync.RWMutex
+	minLevel = slog.LevelInfo
+	// structured is set when output is routed through slog; nil keeps the
+	// plain "[MODULE] action=..." lines.
+	structured *slog.Logger
+)
+
+// Configure sets the minimum severity that is written ("debug", "info",
+// "warn", "error") and the output format ("text" or "json"). Empty values
+// keep info and text.
+func Configure(level, format string) error {
+	parsed := slog.LevelInfo
+	if strings.TrimSpace(level) != "" {
+		if err := parsed.UnmarshalText([]byte(strings.TrimSpace(level))); err != nil {
+			return fmt.Errorf("invalid log level %q: %w", level, err)
+		}
+	}
+
+	var logger *slog.Logger
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", FormatText:
+	case FormatJSON:
+		logger = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: parsed}))
+	default:
+		return fmt.Errorf("invalid log format %q", format)
+	}
+
+	mu.Lock()
+	minLevel = parsed
+	structured = logger
+	mu.Unlock()
+	return nil
+}
+
+// levels is the severity of every action logged through Log: failures are
+// errors; rejections, refusals and anomalies are warnings; a few high-volume
+// progress actions are debug. Actions missing here are logged at info, and
+// TestSeverityCoversEveryAction keeps the table complete.
+var levels = map[string]slog.Level{
+	// debug
+	"already_latest":    slog.LevelDebug,
+	"check":             slog.LevelDebug,
+	"conn_negotiated":   slog.LevelDebug,
+	"download_progress": slog.LevelDebug,
+	"skip":              slog.LevelDebug,
+
+	// info
+	"admin_action":               slog.LevelInfo,
+	"admin_chain_disabled":       slog.LevelInfo,
+	"announce_addrs":             slog.LevelInfo,
+	"applied_shutdown":           slog.LevelInfo,
+	"apply_disconnects_members":  slog.LevelInfo,
+	"apply_snapshot":             slog.LevelInfo,
+	"apply_snapshot_push":        slog.LevelInfo,
+	"autonat_reachability":       slog.LevelInfo,
+	"autotls_cert_loaded":        slog.LevelInfo,
+	"autotls_cert_obtained":      slog.LevelInfo,
+	"autotls_cert_renewed":       slog.LevelInfo,
+	"autotls_disabled":           slog.LevelInfo,
+	"autotls_enabled":            slog.LevelInfo,
+	"autotls_restarted":          slog.LevelInfo,
+	"bootstrap_connected":        slog.LevelInfo,
+	"awaiting_resume":            slog.LevelInfo,
+	"cleanup_previous_binary":    slog.LevelInfo,
+	"deferred":                   slog.LevelInfo,
+	"download_from":              slog.LevelInfo,
+	"download_start":             slog.LevelInfo,
+	"eager_sync":                 slog.LevelInfo,
+	"held_by_ceiling":            slog.LevelInfo,
+	"import_snapshot":            slog.LevelInfo,
+	"intended_members_in_sync":   slog.LevelInfo,
+	"intended_members_published": slog.LevelInfo,
+	"intended_members_skipped":   slog.LevelInfo,
+	"local_addrs":                slog.LevelInfo,
+	"member_connected":           slog.LevelInfo,
+	"member_recovered":           slog.LevelInfo,
+	"membership_changed":         slog.LevelInfo,
+	"network_mode":               slog.LevelInfo,
+	"network_mode_auto":          slog.LevelInfo,
+	"new_version":                slog.LevelInfo,
+	"node_key_ephemeral":         slog.LevelInfo,
+	"node_key_generated":         slog.LevelInfo,
+	"node_key_loaded":            slog.LevelInfo,
+	"offline_suppressed":         slog.LevelInfo,
+	"paused":                     slog.LevelInfo,
+	"peerstore_reconcile":        slog.LevelInfo,
+	"pinned_peer":                slog.LevelInfo,
+	"pinned_peer_connected":      slog.LevelInfo,
+	"private_network_enabled":    slog.LevelInfo,
+	"private_network_opt_out":    slog.LevelInfo,
+	"publish_admin_bootstrap":    slog.LevelInfo,
+	"records_pruned":             slog.LevelInfo,
+	"relay_released":             slog.LevelInfo,
+	"relay_reservation_acquired": slog.LevelInfo,
+	"relay_reserved":             slog.LevelInfo,
+	"relay_snapshot":             slog.LevelInfo,
+	"relay_snapshot_pushed":      slog.LevelInfo,
+	"resolved":                   slog.LevelInfo,
+	"resume_runtime_state":       slog.LevelInfo,
+	"resumed":                    slog.LevelInfo,
+	"runtime_state":              slog.LevelInfo,
+	"self_dial_skipped":          slog.LevelInfo,
+	"shutdown":                   slog.LevelInfo,
+	"shutdown_phase":             slog.LevelInfo,
+	"shutdown_requested":         slog.LevelInfo,
+	"start_update_checker":       slog.LevelInfo,
+	"updated":                    slog.LevelInfo,
+	"version":                    slog.LevelInfo,
+	"watch_start":                slog.LevelInfo,
+	"watch_stop":                 slog.LevelInfo,
+
+	// warn
+	"admin_chain_ephemeral_identity": slog.LevelWarn,
+	"admin_chain_invalid":            slog.LevelWarn,
+	"apply_issuer_not_member":        slog.LevelWarn,
+	"attestation_reject":             slog.LevelWarn,
+	"business_stream_refused":        slog.LevelWarn,
+	"config_read_only":               slog.LevelWarn,
+	"deny_unconfigured":              slog.LevelWarn,
+	"dial_breaker_open":              slog.LevelWarn,
+	"dns_serve_stale":                slog.LevelWarn,
+	"duplicate_peer_id":              slog.LevelWarn,
+	"ephemeral_identity":             slog.LevelWarn,
+	"file_line_invalid":              slog.LevelWarn,
+	"heartbeat_protocol_unsupported": slog.LevelWarn,
+	"heartbeat_reject":               slog.LevelWarn,
+	"incompatible_peer_denied":       slog.LevelWarn,
+	"init_connection_duplicate":      slog.LevelWarn,
+	"init_connection_type_conflict":  slog.LevelWarn,
+	"member_down":                    slog.LevelWarn,
+	"node_key_regenerate":            slog.LevelWarn,
+	"peer_flapping":                  slog.LevelWarn,
+	"peer_id_mismatch":               slog.LevelWarn,
+	"peer_version_too_old":           slog.LevelWarn,
+	"pinned_peer_invalid":            slog.LevelWarn,
+	"pinned_peer_no_addrs":           slog.LevelWarn,
+	"publish_denied":                 slog.LevelWarn,
+	"publish_member_invalid":         slog.LevelWarn,
+	"quorum_hold":                    slog.LevelWarn,
+	"reject_peer":                    slog.LevelWarn,
+	"reject_snapshot":                slog.LevelWarn,
+	"relay_denied":                   slog.LevelWarn,
+	"relay_reservation_lost":         slog.LevelWarn,
+	"relay_reserve_none":             slog.LevelWarn,
+	"resume_snapshot_invalid":        slog.LevelWarn,
+	"resume_snapshot_rejected":       slog.LevelWarn,
+	"self_removed":                   slog.LevelWarn,
+	"shutdown_forced":                slog.LevelWarn,
+	"skip_unsupported_peer":          slog.LevelWarn,
+	"snapshot_divergent":             slog.LevelWarn,
+	"state_change_dropped":           slog.LevelWarn,
+	"stored_member_invalid":          slog.LevelWarn,
+	"unsigned_source_rejected":       slog.LevelWarn,
+	"wal_unavailable":                slog.LevelWarn,
+
+	// error
+	"admin_persist_failed":            slog.LevelError,
+	"attestation_sign_failed":         slog.LevelError,
+	"autotls_auth_failed":             slog.LevelError,
+	"autotls_renewal_failing":         slog.LevelError,
+	"autotls_retry_failed":            slog.LevelError,
+	"autotls_start_failed":            slog.LevelError,
+	"bootstrap_dial_failed":           slog.LevelError,
+	"busy_timeout_failed":             slog.LevelError,
+	"command_failed":                  slog.LevelError,
+	"eager_sync_failed":               slog.LevelError,
+	"encode_failed":                   slog.LevelError,
+	"fanout_failed":                   slog.LevelError,
+	"heartbeat_decode_failed":         slog.LevelError,
+	"heartbeat_failed":                slog.LevelError,
+	"heartbeat_send_failed":           slog.LevelError,
+	"identify_subscribe_failed":       slog.LevelError,
+	"intended_members_publish_failed": slog.LevelError,
+	"journal_mode_fallback_failed":    slog.LevelError,
+	"lookup_failed":                   slog.LevelError,
+	"member_dial_failed":              slog.LevelError,
+	"merge_failed":                    slog.LevelError,
+	"persist_failed":                  slog.LevelError,
+	"pinned_peer_dial_failed":         slog.LevelError,
+	"push_failed":                     slog.LevelError,
+	"query_failed":                    slog.LevelError,
+	"quic_udp_tune_failed":            slog.LevelError,
+	"reachability_subscribe_failed":   slog.LevelError,
+	"relay_failed":                    slog.LevelError,
+	"relay_reserve_failed":            slog.LevelError,
+	"relay_snapshot_failed":           slog.LevelError,
+	"relay_status_subscribe_failed":   slog.LevelError,
+	"runtime_resume_load_failed":      slog.LevelError,
+	"runtime_resume_save_failed":      slog.LevelError,
+	"shutdown_drain_failed":           slog.LevelError,
+	"shutdown_failed":                 slog.LevelError,
+	"sync_members_failed":             slog.LevelError,
+	"synchronous_failed":              slog.LevelError,
+	"update_failed":                   slog.LevelError,
+	"webhook_failed":                  slog.LevelError,
+}
+
+// Severity returns the level action is logged at.
+func Severity(action string) slog.Level {
+	if level, ok := levels[action]; ok {
+		return level
+	}
+	return slog.LevelInfo
+}
+
 // Log prints a structured line: [MODULE] action=... key=value ...
+// at the severity Severity assigns to action.
 func Log(module, action string, fields map[string]string) {
+	LogAt(Severity(action), module, action, fields)
+}
+
+// LogAt is Log with an explicit severity. Lines below the configured level
+// are dropped.
+func LogAt(level slog.Level, module, action string, fields map[string]string) {
+	mu.RLock()
+	enabled := level >= minLevel
+	logger := structured
+	mu.RUnlock()
+	if !enabled {
+		return
+	}
 	if module == "" {
 		module = "APP"
 	}
+	if logger != nil {
+		logStructured(logger, level, module, action, fields)
+		return
+	}
+	module = sanitize(module)
 	parts := []string{}
 	if action != "" {
-		parts = append(parts, "action="+action)
+		parts = append(parts, "action="+sanitize(action))
 	}
 	if len(fields) > 0 {
 		keys := make([]string, 0, len(fields))
@@ -22,8 +258,7 @@ func Log(module, action string, fields map[string]string) {
 		}
 		sort.Strings(keys)
 		for _, k := range keys {
-			val := strings.ReplaceAll(fields[k], " ", "_")
-			parts = append(parts, k+"="+val)
+			parts = append(parts, sanitize(k)+"="+formatValue(fields[k]))
 		}
 	}
 	if len(parts) == 0 {
@@ -32,3 +267,67 @@ func Log(module, action string, fields map[string]string) {
 	}
 	fmt.Printf("[%s] %s\n", module, strings.Join(parts, " "))
 }
+
+func logStructured(logger *slog.Logger, level slog.Level, module, action string, fields map[string]string) {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	attrs := make([]slog.Attr, 0, len(keys)+1)
+	attrs = append(attrs, slog.String("module", module))
+	for _, k := range keys {
+		attrs = append(attrs, slog.String(k, fields[k]))
+	}
+	logger.LogAttrs(context.Background(), level, action, attrs...)
+}
+
+// formatValue makes a field value safe to embed in one log line. Values often
+// come from remote peers (error strings, multiaddrs), so they must not be able
+// to end the line early and forge another one.
+func formatValue(v string) string {
+	return sanitize(strings.ReplaceAll(v, " ", "_"))
+}
+
+// sanitize escapes newlines, other control characters, Unicode line
+// separators and invalid UTF-8 as Go-style escapes.
+func sanitize(v string) string {
+	clean := true
+	for _, r := range v {
+		if r == utf8.RuneError || needsEscape(r) {
+			clean = false
+			break
+		}
+	}
+	if clean {
+		return v
+	}
+	var b strings.Builder
+	for i := 0; i < len(v); {
+		r, size := utf8.DecodeRuneInString(v[i:])
+		switch {
+		case r == utf8.RuneError && size <= 1:
+			fmt.Fprintf(&b, `\x%02x`, v[i])
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case needsEscape(r):
+			if r < 0x100 {
+				fmt.Fprintf(&b, `\x%02x`, r)
+			} else {
+				fmt.Fprintf(&b, `\u%04x`, r)
+			}
+		default:
+			b.WriteRune(r)
+		}
+		i += size
+	}
+	return b.String()
+}
+
+func needsEscape(r rune) bool {
+	return unicode.IsControl(r) || r == '\u2028' || r == '\u2029'
+}
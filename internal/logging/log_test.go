@@ -0,0 +1,147 @@
+package logging
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestSeverityCoversEveryAction fails when code logs an action through Log
+// that the levels table does not list, so a new failure cannot slip out at
+// info by accident.
+func TestSeverityCoversEveryAction(t *testing.T) {
+	root := filepath.Join("..", "..")
+	fset := token.NewFileSet()
+	seen := 0
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && (d.Name() == ".git" || d.Name() == "vendor") {
+			return filepath.SkipDir
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return err
+		}
+		ast.Inspect(file, func(node ast.Node) bool {
+			call, ok := node.(*ast.CallExpr)
+			if !ok || len(call.Args) < 2 || !isLogCall(call.Fun) {
+				return true
+			}
+			lit, ok := call.Args[1].(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				return true
+			}
+			action, err := strconv.Unquote(lit.Value)
+			if err != nil {
+				return true
+			}
+			seen++
+			if _, ok := levels[action]; !ok {
+				t.Errorf("%s: action %q is not in the severity table", fset.Position(call.Pos()), action)
+			}
+			return true
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seen == 0 {
+		t.Fatal("found no logging.Log calls; is the walk rooted at the module?")
+	}
+}
+
+// isLogCall matches logging.Log from other packages and Log inside this one.
+func isLogCall(fun ast.Expr) bool {
+	switch f := fun.(type) {
+	case *ast.SelectorExpr:
+		pkg, ok := f.X.(*ast.Ident)
+		return ok && pkg.Name == "logging" && f.Sel.Name == "Log"
+	case *ast.Ident:
+		return f.Name == "Log"
+	}
+	return false
+}
+
+func TestSeverity(t *testing.T) {
+	cases := map[string]slog.Level{
+		"download_progress":    slog.LevelDebug,
+		"runtime_state":        slog.LevelInfo,
+		"reject_peer":          slog.LevelWarn,
+		"push_failed":          slog.LevelError,
+		"not_in_the_table_yet": slog.LevelInfo,
+	}
+	for action, want := range cases {
+		if got := Severity(action); got != want {
+			t.Errorf("Severity(%q) = %s, want %s", action, got, want)
+		}
+	}
+}
+
+// captureStdout returns what fn prints to stdout.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	fn()
+	w.Close()
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestLogValuesCannotBreakLines(t *testing.T) {
+	out := captureStdout(t, func() {
+		Log("NODE", "reject_peer", map[string]string{
+			"reason": "bad\n[NODE] action=forged\r\u2028tail\xff",
+		})
+	})
+	if strings.Count(out, "\n") != 1 || !strings.HasSuffix(out, "\n") {
+		t.Fatalf("log output spans more than one line: %q", out)
+	}
+	want := `[NODE] action=reject_peer reason=bad\n[NODE]_action=forged\r\u2028tail\xff` + "\n"
+	if out != want {
+		t.Fatalf("got %q, want %q", out, want)
+	}
+}
+
+func TestConfiguredLevelFiltersLines(t *testing.T) {
+	t.Cleanup(func() { _ = Configure("", "") })
+	for _, format := range []string{FormatText, FormatJSON} {
+		out := captureStdout(t, func() {
+			// The JSON handler binds os.Stdout when configured.
+			if err := Configure("error", format); err != nil {
+				t.Fatal(err)
+			}
+			Log("NODE", "runtime_state", map[string]string{"next": "healthy"})
+			Log("NODE", "reject_peer", map[string]string{"reason": "gated"})
+			Log("MEMBERSHIP", "push_failed", map[string]string{"reason": "timeout"})
+		})
+		if strings.Contains(out, "runtime_state") || strings.Contains(out, "reject_peer") {
+			t.Fatalf("%s: lines below error written: %q", format, out)
+		}
+		if strings.Count(out, "\n") != 1 || !strings.Contains(out, "push_failed") {
+			t.Fatalf("%s: error line missing: %q", format, out)
+		}
+	}
+}
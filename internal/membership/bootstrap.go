@@ -0,0 +1,90 @@
+package membership
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+)
+
+// BootstrapEntry is an init_connections entry vouched for by the system key.
+// The signature pins the source to one expected peer ID, so a tampered config
+// cannot point the node at a different bootstrap peer.
+type BootstrapEntry struct {
+	ClusterID      string `json:"cluster_id"`
+	Type           string `json:"type"`
+	Address        string `json:"address"`
+	ExpectedPeerID string `json:"expected_peer_id"`
+	Sig            string `json:"sig"`
+}
+
+// SignBootstrapEntry signs entry with the system private key.
+func SignBootstrapEntry(priv crypto.PrivKey, entry BootstrapEntry) (BootstrapEntry, error) {
+	if priv == nil {
+		return entry, fmt.Errorf("private key is nil")
+	}
+	if strings.TrimSpace(entry.ClusterID) == "" {
+		return entry, fmt.Errorf("cluster_id is required")
+	}
+	if strings.TrimSpace(entry.Type) == "" || strings.TrimSpace(entry.Address) == "" {
+		return entry, fmt.Errorf("type and address are required")
+	}
+	if _, err := peerstore.Decode(strings.TrimSpace(entry.ExpectedPeerID)); err != nil {
+		return entry, fmt.Errorf("expected_peer_id invalid: %w", err)
+	}
+
+	sig, err := priv.Sign(canonicalBootstrapEntry(entry))
+	if err != nil {
+		return entry, err
+	}
+	entry.Sig = base64.StdEncoding.EncodeToString(sig)
+	return entry, nil
+}
+
+// VerifyBootstrapEntry checks entry against the base64 system public key.
+func VerifyBootstrapEntry(systemPubKey string, entry BootstrapEntry) error {
+	key := strings.TrimSpace(systemPubKey)
+	if key == "" {
+		return fmt.Errorf("system_pubkey is required to verify init connections")
+	}
+	if strings.TrimSpace(entry.Sig) == "" {
+		return fmt.Errorf("init connection is not signed")
+	}
+	if strings.TrimSpace(entry.ExpectedPeerID) == "" {
+		return fmt.Errorf("signed init connection has no expected_peer_id")
+	}
+	raw, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return fmt.Errorf("decode system_pubkey failed: %w", err)
+	}
+	pub, err := crypto.UnmarshalPublicKey(raw)
+	if err != nil {
+		return fmt.Errorf("unmarshal system_pubkey failed: %w", err)
+	}
+	sigBytes, err := base64.StdEncoding.DecodeString(entry.Sig)
+	if err != nil {
+		return fmt.Errorf("decode init connection sig failed: %w", err)
+	}
+	ok, err := pub.Verify(canonicalBootstrapEntry(entry), sigBytes)
+	if err != nil {
+		return fmt.Errorf("verify init connection failed: %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("init connection signature invalid")
+	}
+	return nil
+}
+
+// canonicalBootstrapEntry is prefixed so the system key's signature over an
+// entry can never be replayed as an admin proof, or the other way round.
+func canonicalBootstrapEntry(e BootstrapEntry) []byte {
+	return []byte(strings.Join([]string{
+		"p2pos-bootstrap-v1",
+		strings.TrimSpace(e.ClusterID),
+		strings.ToLower(strings.TrimSpace(e.Type)),
+		strings.TrimSpace(e.Address),
+		strings.TrimSpace(e.ExpectedPeerID),
+	}, "|"))
+}
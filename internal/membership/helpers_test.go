@@ -0,0 +1,61 @@
+package membership
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+)
+
+const testClusterID = "test"
+
+func newTestKey(t *testing.T) (crypto.PrivKey, string) {
+	t.Helper()
+	priv, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := peerstore.IDFromPrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return priv, id.String()
+}
+
+// signRaw signs snapshot as its issuer without SignSnapshot's checks, so
+// tests can build snapshots that SignSnapshot would refuse.
+func signRaw(t *testing.T, priv crypto.PrivKey, snapshot Snapshot) Snapshot {
+	t.Helper()
+	if snapshot.ClusterID == "" {
+		snapshot.ClusterID = testClusterID
+	}
+	if snapshot.IssuedAt.IsZero() {
+		snapshot.IssuedAt = time.Now().UTC()
+	}
+	snapshot.Members = normalizeMembers(snapshot.Members)
+	snapshot.Alg = KeyAlg(priv.GetPublic())
+	sig, err := priv.Sign(canonicalSnapshot(snapshot))
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot.Sig = base64.StdEncoding.EncodeToString(sig)
+	return snapshot
+}
+
+func newTestManager(t *testing.T, local string, members ...string) *Manager {
+	t.Helper()
+	m, err := NewManager(testClusterID, "", local, members)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func newTestPeerID(t *testing.T) string {
+	t.Helper()
+	_, id := newTestKey(t)
+	return id
+}
@@ -1,7 +1,11 @@
 package membership
 
 import (
+	"crypto/ed25519"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
@@ -12,13 +16,40 @@ import (
 	peerstore "github.com/libp2p/go-libp2p/core/peer"
 )
 
+// ErrInvalidSnapshot matches (errors.Is) Apply errors caused by the snapshot
+// itself failing validation (cluster, fields, proof, signature), as opposed
+// to local policy such as the max age, the member limit, the admin proof
+// validity window or the apply guard.
+var ErrInvalidSnapshot = errors.New("invalid snapshot")
+
+type invalidSnapshotError struct {
+	err error
+}
+
+func (e invalidSnapshotError) Error() string   { return e.err.Error() }
+func (e invalidSnapshotError) Unwrap() []error { return []error{e.err, ErrInvalidSnapshot} }
+
+// localPolicyError marks validation failures that depend on this node, its
+// clock or its limits, rather than on the snapshot being malformed or forged.
+// Apply does not report them as ErrInvalidSnapshot: a node with a skewed
+// clock or a lower member limit must not ban the peers that sent them.
+type localPolicyError struct {
+	err error
+}
+
+func (e localPolicyError) Error() string { return e.err.Error() }
+func (e localPolicyError) Unwrap() error { return e.err }
+
 type AdminProof struct {
 	ClusterID string    `json:"cluster_id"`
 	PeerID    string    `json:"peer_id"`
 	Role      string    `json:"role"`
 	ValidFrom time.Time `json:"valid_from"`
 	ValidTo   time.Time `json:"valid_to"`
-	Sig       string    `json:"sig"`
+	// Alg names the signature scheme (ed25519, secp256k1). Empty means
+	// whatever the system key's type is.
+	Alg string `json:"alg,omitempty"`
+	Sig string `json:"sig"`
 }
 
 type Snapshot struct {
@@ -27,17 +58,40 @@ type Snapshot struct {
 	IssuerPeerID string     `json:"issuer_peer_id"`
 	Members      []string   `json:"members"`
 	AdminProof   AdminProof `json:"admin_proof"`
-	Sig          string     `json:"sig"`
+	// Alg names the issuer key's signature scheme; empty means its type.
+	Alg string `json:"alg,omitempty"`
+	Sig string `json:"sig"`
 }
 
+// Change describes how an applied snapshot altered the member set.
+type Change struct {
+	ClusterID    string
+	IssuerPeerID string
+	IssuedAt     time.Time
+	Added        []string
+	Removed      []string
+	// IssuerNotMember is set when the issuer is missing from Members and the
+	// manager only warns about it (see SetRequireIssuerMember).
+	IssuerNotMember bool
+}
+
+// ApplyGuard inspects a pending change before Apply installs it. Returning an
+// error rejects the snapshot.
+type ApplyGuard func(change *Change) error
+
 type Manager struct {
-	mu        sync.RWMutex
-	clusterID string
-	localPeer string
-	systemPub crypto.PubKey
-	hasPubKey bool
-	snapshot  Snapshot
-	memberSet map[string]struct{}
+	mu         sync.RWMutex
+	clusterID  string
+	localPeer  string
+	systemPub  crypto.PubKey
+	hasPubKey  bool
+	snapshot   Snapshot
+	memberSet  map[string]struct{}
+	guard      ApplyGuard
+	maxMembers int
+	maxAge     time.Duration
+	// requireIssuer refuses snapshots whose issuer is not a member.
+	requireIssuer bool
 }
 
 func NewManager(clusterID, systemPubKey, localPeerID string, initialMembers []string) (*Manager, error) {
@@ -92,22 +146,105 @@ func (m *Manager) IsMember(peerID string) bool {
 	return ok
 }
 
+func (m *Manager) SetApplyGuard(guard ApplyGuard) {
+	m.mu.Lock()
+	m.guard = guard
+	m.mu.Unlock()
+}
+
+func (m *Manager) RequireIssuerMember() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.requireIssuer
+}
+
+// SetMaxMembers caps the member count accepted by Apply. Zero disables the cap.
+func (m *Manager) SetMaxMembers(max int) {
+	m.mu.Lock()
+	m.maxMembers = max
+	m.mu.Unlock()
+}
+
+func (m *Manager) MaxMembers() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.maxMembers
+}
+
+// SetMaxSnapshotAge makes Apply refuse snapshots issued more than maxAge ago,
+// even when they are newer than the current one. Zero disables the check.
+func (m *Manager) SetMaxSnapshotAge(maxAge time.Duration) {
+	m.mu.Lock()
+	m.maxAge = maxAge
+	m.mu.Unlock()
+}
+
+// SetRequireIssuerMember makes Apply refuse snapshots whose issuer is not in
+// Members. Off by default: an admin may issue snapshots without being a
+// member, and older versions did so freely.
+func (m *Manager) SetRequireIssuerMember(require bool) {
+	m.mu.Lock()
+	m.requireIssuer = require
+	m.mu.Unlock()
+}
+
 func (m *Manager) Snapshot() Snapshot {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 	return cloneSnapshot(m.snapshot)
 }
 
-func (m *Manager) Apply(snapshot Snapshot) error {
+// Apply validates and installs snapshot if it is newer than the current one.
+// The returned change is nil when the snapshot was stale and nothing changed.
+func (m *Manager) Apply(snapshot Snapshot) (*Change, error) {
+	return m.apply(snapshot, true)
+}
+
+// Restore is Apply for the snapshot this node saved itself before a restart.
+// It skips the max snapshot age: a quiet cluster's only snapshot may be old,
+// and refusing it would leave the node with no signed membership at all.
+func (m *Manager) Restore(snapshot Snapshot) (*Change, error) {
+	return m.apply(snapshot, false)
+}
+
+func (m *Manager) apply(snapshot Snapshot, checkAge bool) (*Change, error) {
 	snapshot.Members = normalizeMembers(snapshot.Members)
 	if err := m.validateSnapshot(snapshot); err != nil {
-		return err
+		var policy localPolicyError
+		if errors.As(err, &policy) {
+			return nil, err
+		}
+		return nil, invalidSnapshotError{err: err}
+	}
+	issuerIsMember := containsMember(snapshot.Members, snapshot.IssuerPeerID)
+	if !issuerIsMember && m.RequireIssuerMember() {
+		return nil, fmt.Errorf("issuer_peer_id is not in members")
 	}
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	if !snapshot.IssuedAt.UTC().After(m.snapshot.IssuedAt.UTC()) {
-		return nil
+		return nil, nil
+	}
+	if checkAge && m.maxAge > 0 {
+		if age := time.Since(snapshot.IssuedAt); age > m.maxAge {
+			return nil, fmt.Errorf("snapshot issued %s ago exceeds max age %s", age.Round(time.Second), m.maxAge)
+		}
+	}
+
+	added, removed := diffMembers(m.memberSet, snapshot.Members)
+	change := &Change{
+		ClusterID:       snapshot.ClusterID,
+		IssuerPeerID:    snapshot.IssuerPeerID,
+		IssuedAt:        snapshot.IssuedAt.UTC(),
+		Added:           added,
+		Removed:         removed,
+		IssuerNotMember: !issuerIsMember,
+	}
+	if m.guard != nil {
+		if err := m.guard(change); err != nil {
+			return nil, err
+		}
 	}
 
 	m.snapshot = cloneSnapshot(snapshot)
@@ -115,7 +252,7 @@ func (m *Manager) Apply(snapshot Snapshot) error {
 	for _, id := range snapshot.Members {
 		m.memberSet[id] = struct{}{}
 	}
-	return nil
+	return change, nil
 }
 
 func (m *Manager) validateSnapshot(snapshot Snapshot) error {
@@ -128,6 +265,12 @@ func (m *Manager) validateSnapshot(snapshot Snapshot) error {
 	if len(snapshot.Members) == 0 {
 		return fmt.Errorf("members is empty")
 	}
+	if max := m.MaxMembers(); max > 0 && len(snapshot.Members) > max {
+		return localPolicyError{fmt.Errorf("members count %d exceeds limit %d", len(snapshot.Members), max)}
+	}
+	if _, invalid := SplitPeerIDs(snapshot.Members); len(invalid) > 0 {
+		return fmt.Errorf("members contains invalid peer id %q", invalid[0])
+	}
 	if strings.TrimSpace(snapshot.IssuerPeerID) == "" {
 		return fmt.Errorf("issuer_peer_id is required")
 	}
@@ -165,7 +308,11 @@ func (m *Manager) validateAdminProof(proof AdminProof, issuer string) error {
 	}
 	now := time.Now().UTC()
 	if now.Before(proof.ValidFrom.UTC()) || now.After(proof.ValidTo.UTC()) {
-		return fmt.Errorf("admin proof expired or not yet valid")
+		return localPolicyError{fmt.Errorf("admin proof expired or not yet valid")}
+	}
+
+	if err := checkAdminProofKeyType(proof, m.systemPub); err != nil {
+		return err
 	}
 
 	sigBytes, err := base64.StdEncoding.DecodeString(proof.Sig)
@@ -182,6 +329,35 @@ func (m *Manager) validateAdminProof(proof AdminProof, issuer string) error {
 	return nil
 }
 
+// SignAdminProof signs proof with the system private key using the same
+// canonical form validateAdminProof verifies.
+func SignAdminProof(priv crypto.PrivKey, proof AdminProof) (AdminProof, error) {
+	if priv == nil {
+		return proof, fmt.Errorf("private key is nil")
+	}
+	if strings.TrimSpace(proof.ClusterID) == "" {
+		return proof, fmt.Errorf("cluster_id is required")
+	}
+	if strings.TrimSpace(proof.PeerID) == "" {
+		return proof, fmt.Errorf("peer_id is required")
+	}
+	if !proof.ValidTo.After(proof.ValidFrom) {
+		return proof, fmt.Errorf("valid_to must be after valid_from")
+	}
+
+	proof.Alg = KeyAlg(priv.GetPublic())
+	sig, err := priv.Sign(canonicalAdminProof(proof))
+	if err != nil {
+		return proof, err
+	}
+	proof.Sig = base64.StdEncoding.EncodeToString(sig)
+	return proof, nil
+}
+
+// SignSnapshot signs snapshot as its issuer. Whether the issuer has to be one
+// of the members is the applying node's issuer policy (SetRequireIssuerMember),
+// so it is left to Apply: an admin proof already authorizes an issuer that is
+// not a member, such as an admin key kept off the nodes.
 func SignSnapshot(priv crypto.PrivKey, snapshot Snapshot) (Snapshot, error) {
 	if priv == nil {
 		return snapshot, fmt.Errorf("private key is nil")
@@ -200,6 +376,7 @@ func SignSnapshot(priv crypto.PrivKey, snapshot Snapshot) (Snapshot, error) {
 		return snapshot, fmt.Errorf("members is empty")
 	}
 
+	snapshot.Alg = KeyAlg(priv.GetPublic())
 	sig, err := priv.Sign(canonicalSnapshot(snapshot))
 	if err != nil {
 		return snapshot, err
@@ -217,6 +394,9 @@ func verifySnapshotSignature(snapshot Snapshot) error {
 	if err != nil {
 		return fmt.Errorf("extract issuer public key failed: %w", err)
 	}
+	if err := checkAlg(snapshot.Alg, pub); err != nil {
+		return fmt.Errorf("snapshot %w", err)
+	}
 
 	sigBytes, err := base64.StdEncoding.DecodeString(snapshot.Sig)
 	if err != nil {
@@ -233,6 +413,64 @@ func verifySnapshotSignature(snapshot Snapshot) error {
 	return nil
 }
 
+// CheckAdminProofKeyType decodes systemPubKey (base64, as in config) and
+// reports whether proof could have been signed by a key of its type, so a
+// proof made with the wrong kind of key fails with an actionable message
+// instead of "signature invalid". It does not verify the signature.
+func CheckAdminProofKeyType(proof AdminProof, systemPubKey string) error {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(systemPubKey))
+	if err != nil {
+		return fmt.Errorf("decode system_pubkey failed: %w", err)
+	}
+	pub, err := crypto.UnmarshalPublicKey(raw)
+	if err != nil {
+		return fmt.Errorf("unmarshal system_pubkey failed: %w", err)
+	}
+	return checkAdminProofKeyType(proof, pub)
+}
+
+// checkAdminProofKeyType compares the declared alg with the system key. A
+// proof without alg predates it; for those, an Ed25519 system key still
+// rules out any signature that is not exactly ed25519.SignatureSize bytes.
+func checkAdminProofKeyType(proof AdminProof, pub crypto.PubKey) error {
+	want := KeyAlg(pub)
+	if err := checkAlg(proof.Alg, pub); err != nil {
+		return fmt.Errorf("admin proof key type mismatch with system key: proof alg %q, system key %s", strings.ToLower(strings.TrimSpace(proof.Alg)), want)
+	}
+	if strings.TrimSpace(proof.Alg) != "" {
+		return nil
+	}
+	sig, err := base64.StdEncoding.DecodeString(proof.Sig)
+	if err != nil {
+		return nil
+	}
+	if pub.Type() == crypto.Ed25519 && len(sig) != ed25519.SignatureSize {
+		return fmt.Errorf("admin proof key type mismatch with system key: %d-byte signature cannot come from a %s key", len(sig), want)
+	}
+	return nil
+}
+
+// KeyAlg is the Alg value for signatures made by pub's key type.
+func KeyAlg(pub crypto.PubKey) string {
+	return strings.ToLower(pub.Type().String())
+}
+
+// checkAlg rejects a declared algorithm that does not match the key that
+// will verify the signature. Alg is deliberately left out of the canonical
+// signed bytes so proofs and snapshots signed before it existed, or read by
+// nodes that ignore it, still verify; tampering with it can only cause a
+// rejection, never a different verifier.
+func checkAlg(alg string, pub crypto.PubKey) error {
+	alg = strings.ToLower(strings.TrimSpace(alg))
+	if alg == "" {
+		return nil
+	}
+	if want := KeyAlg(pub); alg != want {
+		return fmt.Errorf("alg %q does not match %s key", alg, want)
+	}
+	return nil
+}
+
 func normalizeMembers(in []string) []string {
 	uniq := make(map[string]struct{}, len(in))
 	for _, raw := range in {
@@ -250,6 +488,51 @@ func normalizeMembers(in []string) []string {
 	return out
 }
 
+// SplitPeerIDs partitions ids into entries that decode as libp2p peer IDs
+// and entries that do not. Blank entries are dropped from both.
+func SplitPeerIDs(ids []string) ([]string, []string) {
+	valid := make([]string, 0, len(ids))
+	invalid := make([]string, 0)
+	for _, raw := range ids {
+		id := strings.TrimSpace(raw)
+		if id == "" {
+			continue
+		}
+		if _, err := peerstore.Decode(id); err != nil {
+			invalid = append(invalid, id)
+			continue
+		}
+		valid = append(valid, id)
+	}
+	return valid, invalid
+}
+
+// containsMember reports whether id is in members, which must be normalized.
+func containsMember(members []string, id string) bool {
+	i := sort.SearchStrings(members, id)
+	return i < len(members) && members[i] == id
+}
+
+func diffMembers(prev map[string]struct{}, next []string) ([]string, []string) {
+	nextSet := make(map[string]struct{}, len(next))
+	added := make([]string, 0)
+	for _, id := range next {
+		nextSet[id] = struct{}{}
+		if _, ok := prev[id]; !ok {
+			added = append(added, id)
+		}
+	}
+	removed := make([]string, 0)
+	for id := range prev {
+		if _, ok := nextSet[id]; !ok {
+			removed = append(removed, id)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
 func cloneSnapshot(s Snapshot) Snapshot {
 	out := s
 	out.Members = append([]string(nil), s.Members...)
@@ -266,6 +549,13 @@ func canonicalAdminProof(p AdminProof) []byte {
 	}, "|"))
 }
 
+// Hash identifies a snapshot's signed content, for cheap comparison between
+// nodes.
+func Hash(s Snapshot) string {
+	sum := sha256.Sum256(canonicalSnapshot(s))
+	return hex.EncodeToString(sum[:])
+}
+
 func canonicalSnapshot(s Snapshot) []byte {
 	members := normalizeMembers(s.Members)
 	return []byte(strings.Join([]string{
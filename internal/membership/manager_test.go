@@ -0,0 +1,429 @@
+package membership
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestApplyIssuerNotInMembers(t *testing.T) {
+	issuerKey, issuer := newTestKey(t)
+	_, member := newTestKey(t)
+	snapshot := signRaw(t, issuerKey, Snapshot{IssuerPeerID: issuer, Members: []string{member}})
+
+	warn := newTestManager(t, member, member)
+	change, err := warn.Apply(snapshot)
+	if err != nil {
+		t.Fatalf("warn policy: %v", err)
+	}
+	if change == nil || !change.IssuerNotMember {
+		t.Fatalf("warn policy: change = %+v, want IssuerNotMember", change)
+	}
+
+	refuse := newTestManager(t, member, member)
+	refuse.SetRequireIssuerMember(true)
+	_, err = refuse.Apply(snapshot)
+	if err == nil {
+		t.Fatal("refuse policy accepted a snapshot whose issuer is not a member")
+	}
+	if errors.Is(err, ErrInvalidSnapshot) {
+		t.Fatal("issuer policy refusal must not count as an invalid snapshot")
+	}
+}
+
+func TestSignSnapshotLeavesIssuerPolicyToApply(t *testing.T) {
+	priv, issuer := newTestKey(t)
+	_, other := newTestKey(t)
+	signed, err := SignSnapshot(priv, Snapshot{
+		ClusterID:    testClusterID,
+		IssuedAt:     time.Now().UTC(),
+		IssuerPeerID: issuer,
+		Members:      []string{other},
+	})
+	if err != nil {
+		t.Fatalf("SignSnapshot with a non-member issuer: %v", err)
+	}
+
+	warn := newTestManager(t, other, other)
+	if change, err := warn.Apply(signed); err != nil || change == nil || !change.IssuerNotMember {
+		t.Fatalf("warn policy: change = %+v, err = %v", change, err)
+	}
+	refuse := newTestManager(t, other, other)
+	refuse.SetRequireIssuerMember(true)
+	if _, err := refuse.Apply(signed); err == nil {
+		t.Fatal("refuse policy accepted a signed snapshot whose issuer is not a member")
+	}
+}
+
+func TestRestoreSkipsMaxSnapshotAge(t *testing.T) {
+	issuerKey, issuer := newTestKey(t)
+	snapshot := signRaw(t, issuerKey, Snapshot{
+		IssuedAt:     time.Now().UTC().Add(-48 * time.Hour),
+		IssuerPeerID: issuer,
+		Members:      []string{issuer},
+	})
+
+	applied := newTestManager(t, issuer)
+	applied.SetMaxSnapshotAge(24 * time.Hour)
+	if _, err := applied.Apply(snapshot); err == nil {
+		t.Fatal("Apply accepted a snapshot older than max_snapshot_age")
+	}
+
+	restored := newTestManager(t, issuer)
+	restored.SetMaxSnapshotAge(24 * time.Hour)
+	change, err := restored.Restore(snapshot)
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if change == nil || !restored.IsMember(issuer) {
+		t.Fatalf("Restore did not install the saved snapshot: %+v", change)
+	}
+
+	tampered := snapshot
+	tampered.Members = []string{issuer, newTestPeerID(t)}
+	if _, err := newTestManager(t, issuer).Restore(tampered); !errors.Is(err, ErrInvalidSnapshot) {
+		t.Fatalf("Restore of a tampered snapshot: err = %v, want ErrInvalidSnapshot", err)
+	}
+}
+
+func TestMaxSnapshotAgeRejectsOldButNewer(t *testing.T) {
+	issuerKey, issuer := newTestKey(t)
+	other := newTestPeerID(t)
+	at := func(age time.Duration, members ...string) Snapshot {
+		return signRaw(t, issuerKey, Snapshot{
+			IssuedAt:     time.Now().UTC().Add(-age),
+			IssuerPeerID: issuer,
+			Members:      members,
+		})
+	}
+
+	m := newTestManager(t, issuer)
+	if _, err := m.Restore(at(72*time.Hour, issuer)); err != nil {
+		t.Fatal(err)
+	}
+	m.SetMaxSnapshotAge(24 * time.Hour)
+
+	if _, err := m.Apply(at(48*time.Hour, issuer, other)); err == nil {
+		t.Fatal("accepted a snapshot newer than the current one but past max age")
+	}
+	if m.IsMember(other) {
+		t.Fatal("rejected snapshot changed the member set")
+	}
+	if _, err := m.Apply(at(time.Hour, issuer, other)); err != nil {
+		t.Fatalf("fresh snapshot rejected: %v", err)
+	}
+	if !m.IsMember(other) {
+		t.Fatal("fresh snapshot not applied")
+	}
+}
+
+func TestOnlyMalformedSnapshotsAreInvalid(t *testing.T) {
+	systemKey, _ := newTestKey(t)
+	rawPub, err := crypto.MarshalPublicKey(systemKey.GetPublic())
+	if err != nil {
+		t.Fatal(err)
+	}
+	systemPub := base64.StdEncoding.EncodeToString(rawPub)
+	issuerKey, issuer := newTestKey(t)
+	proofFor := func(from, to time.Time) AdminProof {
+		proof, err := SignAdminProof(systemKey, AdminProof{
+			ClusterID: testClusterID,
+			PeerID:    issuer,
+			Role:      "admin",
+			ValidFrom: from,
+			ValidTo:   to,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return proof
+	}
+	now := time.Now().UTC()
+	valid := proofFor(now.Add(-time.Hour), now.Add(time.Hour))
+	member := newTestPeerID(t)
+
+	cases := []struct {
+		name     string
+		snapshot Snapshot
+		invalid  bool
+	}{
+		{
+			name: "expired admin proof",
+			snapshot: signRaw(t, issuerKey, Snapshot{IssuerPeerID: issuer, Members: []string{issuer},
+				AdminProof: proofFor(now.Add(-2*time.Hour), now.Add(-time.Hour))}),
+		},
+		{
+			name: "admin proof not yet valid",
+			snapshot: signRaw(t, issuerKey, Snapshot{IssuerPeerID: issuer, Members: []string{issuer},
+				AdminProof: proofFor(now.Add(time.Hour), now.Add(2*time.Hour))}),
+		},
+		{
+			name: "over the member limit",
+			snapshot: signRaw(t, issuerKey, Snapshot{IssuerPeerID: issuer, Members: []string{issuer, member, newTestPeerID(t)},
+				AdminProof: valid}),
+		},
+		{
+			name:     "bad signature",
+			snapshot: withSig(signRaw(t, issuerKey, Snapshot{IssuerPeerID: issuer, Members: []string{issuer}, AdminProof: valid}), "AAAA"),
+			invalid:  true,
+		},
+		{
+			name: "forged admin proof",
+			snapshot: signRaw(t, issuerKey, Snapshot{IssuerPeerID: issuer, Members: []string{issuer},
+				AdminProof: withProofSig(valid, "AAAA")}),
+			invalid: true,
+		},
+		{
+			name:     "wrong cluster",
+			snapshot: signRaw(t, issuerKey, Snapshot{ClusterID: "other", IssuerPeerID: issuer, Members: []string{issuer}, AdminProof: valid}),
+			invalid:  true,
+		},
+	}
+	for _, tc := range cases {
+		m, err := NewManager(testClusterID, systemPub, member, []string{member})
+		if err != nil {
+			t.Fatal(err)
+		}
+		m.SetMaxMembers(2)
+		_, err = m.Apply(tc.snapshot)
+		if err == nil {
+			t.Errorf("%s: applied", tc.name)
+			continue
+		}
+		if got := errors.Is(err, ErrInvalidSnapshot); got != tc.invalid {
+			t.Errorf("%s: errors.Is(%v, ErrInvalidSnapshot) = %v, want %v", tc.name, err, got, tc.invalid)
+		}
+	}
+}
+
+func withSig(snapshot Snapshot, sig string) Snapshot {
+	snapshot.Sig = sig
+	return snapshot
+}
+
+func withProofSig(proof AdminProof, sig string) AdminProof {
+	proof.Sig = sig
+	return proof
+}
+
+func TestApplyReportsMemberDiff(t *testing.T) {
+	issuerKey, issuer := newTestKey(t)
+	a, b, c := newTestPeerID(t), newTestPeerID(t), newTestPeerID(t)
+	m := newTestManager(t, issuer, issuer, a, b)
+
+	start := time.Now().UTC()
+	change, err := m.Apply(signRaw(t, issuerKey, Snapshot{
+		IssuedAt:     start,
+		IssuerPeerID: issuer,
+		Members:      []string{issuer, b, c},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertMembers(t, "added", change.Added, c)
+	assertMembers(t, "removed", change.Removed, a)
+
+	change, err = m.Apply(signRaw(t, issuerKey, Snapshot{
+		IssuedAt:     start.Add(time.Second),
+		IssuerPeerID: issuer,
+		Members:      []string{issuer, a, b, c},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertMembers(t, "added", change.Added, a)
+	assertMembers(t, "removed", change.Removed)
+	if change.IssuerPeerID != issuer || !change.IssuedAt.Equal(start.Add(time.Second)) {
+		t.Fatalf("change attributed to %s at %s", change.IssuerPeerID, change.IssuedAt)
+	}
+}
+
+func assertMembers(t *testing.T, what string, got []string, want ...string) {
+	t.Helper()
+	want = normalizeMembers(want)
+	if len(got) != len(want) {
+		t.Fatalf("%s %v, want %v", what, got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("%s %v, want %v", what, got, want)
+		}
+	}
+}
+
+func TestSecp256k1IssuerSnapshot(t *testing.T) {
+	priv, _, err := crypto.GenerateSecp256k1Key(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := peerstore.IDFromPrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer := id.String()
+	other := newTestPeerID(t)
+
+	signed, err := SignSnapshot(priv, Snapshot{
+		ClusterID:    testClusterID,
+		IssuedAt:     time.Now().UTC(),
+		IssuerPeerID: issuer,
+		Members:      []string{issuer, other},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signed.Alg != "secp256k1" {
+		t.Fatalf("alg %q, want secp256k1", signed.Alg)
+	}
+
+	tampered := signed
+	tampered.Members = []string{issuer}
+	if _, err := newTestManager(t, other, issuer).Apply(tampered); !errors.Is(err, ErrInvalidSnapshot) {
+		t.Fatalf("tampered secp256k1 snapshot: %v, want ErrInvalidSnapshot", err)
+	}
+	if _, err := newTestManager(t, other, issuer).Apply(signed); err != nil {
+		t.Fatalf("secp256k1 snapshot rejected: %v", err)
+	}
+}
+
+func TestSplitPeerIDs(t *testing.T) {
+	a, b := newTestPeerID(t), newTestPeerID(t)
+	valid, invalid := SplitPeerIDs([]string{a, " ", "not-a-peer-id", " " + b + " ", "12D3Koo"})
+	if len(valid) != 2 || valid[0] != a || valid[1] != b {
+		t.Fatalf("valid %v, want [%s %s] in input order", valid, a, b)
+	}
+	if len(invalid) != 2 || invalid[0] != "not-a-peer-id" || invalid[1] != "12D3Koo" {
+		t.Fatalf("invalid %v, want the two undecodable entries", invalid)
+	}
+}
+
+func TestApplyEnforcesMemberLimits(t *testing.T) {
+	issuerKey, issuer := newTestKey(t)
+	a, b := newTestPeerID(t), newTestPeerID(t)
+	start := time.Now().UTC()
+
+	m := newTestManager(t, issuer, issuer)
+	m.SetMaxMembers(2)
+	if _, err := m.Apply(signRaw(t, issuerKey, Snapshot{
+		IssuedAt:     start,
+		IssuerPeerID: issuer,
+		Members:      []string{issuer, a, b},
+	})); err == nil {
+		t.Fatal("snapshot over max_members applied")
+	}
+	if _, err := m.Apply(signRaw(t, issuerKey, Snapshot{
+		IssuedAt:     start.Add(time.Second),
+		IssuerPeerID: issuer,
+		Members:      []string{issuer, "not-a-peer-id"},
+	})); err == nil {
+		t.Fatal("snapshot with an undecodable member applied")
+	}
+	if _, err := m.Apply(signRaw(t, issuerKey, Snapshot{
+		IssuedAt:     start.Add(2 * time.Second),
+		IssuerPeerID: issuer,
+		Members:      []string{issuer, a},
+	})); err != nil {
+		t.Fatalf("snapshot within the cap rejected: %v", err)
+	}
+
+	m.SetMaxMembers(0)
+	if _, err := m.Apply(signRaw(t, issuerKey, Snapshot{
+		IssuedAt:     start.Add(3 * time.Second),
+		IssuerPeerID: issuer,
+		Members:      []string{issuer, a, b},
+	})); err != nil {
+		t.Fatalf("max_members 0 should disable the cap: %v", err)
+	}
+}
+
+func TestSignatureAlgDeclarations(t *testing.T) {
+	priv, issuer := newTestKey(t)
+	signed := signRaw(t, priv, Snapshot{IssuerPeerID: issuer, Members: []string{issuer}})
+	for _, alg := range []string{"ed25519", " Ed25519 ", ""} {
+		snapshot := signed
+		snapshot.Alg = alg
+		if err := verifySnapshotSignature(snapshot); err != nil {
+			t.Errorf("snapshot alg %q: %v", alg, err)
+		}
+	}
+	mismatched := signed
+	mismatched.Alg = "secp256k1"
+	if err := verifySnapshotSignature(mismatched); err == nil {
+		t.Error("snapshot declaring secp256k1 verified against an ed25519 issuer")
+	}
+
+	systemKey, _, err := crypto.GenerateSecp256k1Key(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := crypto.MarshalPublicKey(systemKey.GetPublic())
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded := base64.StdEncoding.EncodeToString(pub)
+	proof, err := SignAdminProof(systemKey, AdminProof{
+		ClusterID: testClusterID,
+		PeerID:    issuer,
+		ValidFrom: time.Now().Add(-time.Hour),
+		ValidTo:   time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proof.Alg != "secp256k1" {
+		t.Fatalf("proof alg %q, want secp256k1", proof.Alg)
+	}
+	if err := CheckAdminProofKeyType(proof, encoded); err != nil {
+		t.Fatalf("matching proof alg rejected: %v", err)
+	}
+	proof.Alg = "ed25519"
+	if err := CheckAdminProofKeyType(proof, encoded); err == nil {
+		t.Fatal("proof declaring ed25519 accepted for a secp256k1 system key")
+	}
+}
+
+// TestAdminProofKeyTypeWithoutAlg covers proofs signed before alg existed:
+// only the signature length can tell an Ed25519 system key they are wrong.
+func TestAdminProofKeyTypeWithoutAlg(t *testing.T) {
+	_, issuer := newTestKey(t)
+	systemKey, _ := newTestKey(t)
+	pub, err := crypto.MarshalPublicKey(systemKey.GetPublic())
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded := base64.StdEncoding.EncodeToString(pub)
+	unsigned := AdminProof{
+		ClusterID: testClusterID,
+		PeerID:    issuer,
+		ValidFrom: time.Now().Add(-time.Hour),
+		ValidTo:   time.Now().Add(time.Hour),
+	}
+
+	legacy, err := SignAdminProof(systemKey, unsigned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy.Alg = ""
+	if err := CheckAdminProofKeyType(legacy, encoded); err != nil {
+		t.Fatalf("legacy ed25519 proof rejected: %v", err)
+	}
+
+	otherKey, _, err := crypto.GenerateSecp256k1Key(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrong, err := SignAdminProof(otherKey, unsigned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrong.Alg = ""
+	err = CheckAdminProofKeyType(wrong, encoded)
+	if err == nil || !strings.Contains(err.Error(), "key type mismatch") {
+		t.Fatalf("legacy secp256k1 proof against an ed25519 system key: %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"p2pos/internal/logging"
+	"p2pos/internal/membership"
+)
+
+const adminSyncPollInterval = time.Second
+
+// StartAdminSync runs once for a node holding an admin proof. It pulls the
+// cluster snapshot as soon as the first peer connects instead of waiting for
+// the periodic membership sync and, when intended is non-empty, republishes
+// intended if the learned member set differs from it.
+//
+// The republish never overrides another admin: it only happens when the
+// snapshot in force is the last one this node issued, or there is no signed
+// snapshot yet, and at most once per run.
+func (n *Node) StartAdminSync(ctx context.Context, intended []string) {
+	n.memberMu.RLock()
+	proof := n.adminProof
+	n.memberMu.RUnlock()
+	if proof == nil {
+		return
+	}
+	go func() {
+		if !n.waitForAnyPeer(ctx) {
+			return
+		}
+		if err := n.SyncMembership(ctx); err != nil {
+			logging.Log("MEMBERSHIP", "eager_sync_failed", map[string]string{
+				"reason": err.Error(),
+			})
+			return
+		}
+		logging.Log("MEMBERSHIP", "eager_sync", map[string]string{
+			"state": string(n.RuntimeState()),
+		})
+		if len(intended) == 0 {
+			return
+		}
+		if err := n.WaitForState(ctx, RuntimeStateHealthy); err != nil {
+			return
+		}
+		n.reconcileIntendedMembers(ctx, intended)
+	}()
+}
+
+func (n *Node) waitForAnyPeer(ctx context.Context) bool {
+	ticker := time.NewTicker(adminSyncPollInterval)
+	defer ticker.Stop()
+	for len(n.Host.Network().Peers()) == 0 {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+	}
+	return true
+}
+
+func (n *Node) reconcileIntendedMembers(ctx context.Context, intended []string) {
+	want, _ := membership.SplitPeerIDs(intended)
+	snap, ok := n.membershipSnapshot()
+	if ok && sameMembers(snap.Members, want) {
+		logging.Log("MEMBERSHIP", "intended_members_in_sync", map[string]string{
+			"members": fmt.Sprintf("%d", len(want)),
+		})
+		return
+	}
+	// A snapshot from another admin, however old, is a decision this
+	// node's config does not know about.
+	if ok && snap.Sig != "" && snap.IssuerPeerID != n.Host.ID().String() {
+		logging.Log("MEMBERSHIP", "intended_members_skipped", map[string]string{
+			"issuer_peer_id": snap.IssuerPeerID,
+			"issued_at":      snap.IssuedAt.Format(time.RFC3339Nano),
+			"reason":         "current snapshot issued by another admin",
+		})
+		return
+	}
+	report, err := n.PublishMembershipSnapshot(ctx, want)
+	if err != nil {
+		logging.Log("MEMBERSHIP", "intended_members_publish_failed", map[string]string{
+			"reason": err.Error(),
+		})
+		return
+	}
+	logging.Log("MEMBERSHIP", "intended_members_published", map[string]string{
+		"members": fmt.Sprintf("%d", len(want)),
+		"acked":   fmt.Sprintf("%d", report.Acked),
+	})
+}
+
+func sameMembers(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	set := make(map[string]struct{}, len(a))
+	for _, id := range a {
+		set[id] = struct{}{}
+	}
+	for _, id := range b {
+		if _, ok := set[id]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,198 @@
+package network_test
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"p2pos/internal/membership"
+	"p2pos/internal/network"
+	"p2pos/internal/network/nettest"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+)
+
+const adminTestCluster = "test"
+
+type adminCluster struct {
+	nettest.Cluster
+	keys      []crypto.PrivKey
+	members   []string
+	systemKey crypto.PrivKey
+}
+
+// newAdminCluster starts three connected nodes that validate admin proofs
+// against a test system key, with node 0 holding an admin proof, and applies
+// initial on every node before connecting them.
+func newAdminCluster(t *testing.T, initial func(c *adminCluster) membership.Snapshot) *adminCluster {
+	t.Helper()
+	c := &adminCluster{systemKey: newKey(t)}
+	for i := 0; i < 3; i++ {
+		key := newKey(t)
+		node, bus := nettest.NewNode(t, &nettest.Config{Key: key})
+		c.Nodes = append(c.Nodes, node)
+		c.Buses = append(c.Buses, bus)
+		c.keys = append(c.keys, key)
+		c.members = append(c.members, node.Host.ID().String())
+	}
+	rawPub, err := crypto.MarshalPublicKey(c.systemKey.GetPublic())
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot := initial(c)
+	for i, node := range c.Nodes {
+		manager, err := membership.NewManager(adminTestCluster, base64.StdEncoding.EncodeToString(rawPub), node.Host.ID().String(), c.members)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := manager.Apply(snapshot); err != nil {
+			t.Fatalf("apply initial snapshot on node %d: %v", i, err)
+		}
+		node.SetMembershipManager(manager)
+		c.Managers = append(c.Managers, manager)
+	}
+	proof := c.proof(t, c.Nodes[0].Host.ID())
+	c.Nodes[0].SetAdminProof(&proof)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := c.ConnectAll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.WaitForState(ctx, network.RuntimeStateHealthy); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func (c *adminCluster) proof(t *testing.T, id peerstore.ID) membership.AdminProof {
+	t.Helper()
+	proof, err := membership.SignAdminProof(c.systemKey, membership.AdminProof{
+		ClusterID: adminTestCluster,
+		PeerID:    id.String(),
+		Role:      "admin",
+		ValidFrom: time.Now().UTC().Add(-time.Hour),
+		ValidTo:   time.Now().UTC().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return proof
+}
+
+func (c *adminCluster) sign(t *testing.T, key crypto.PrivKey, issuedAt time.Time, members []string) membership.Snapshot {
+	t.Helper()
+	id, err := peerstore.IDFromPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := membership.SignSnapshot(key, membership.Snapshot{
+		ClusterID:    adminTestCluster,
+		IssuedAt:     issuedAt,
+		IssuerPeerID: id.String(),
+		Members:      members,
+		AdminProof:   c.proof(t, id),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return snapshot
+}
+
+func newKey(t *testing.T) crypto.PrivKey {
+	t.Helper()
+	priv, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return priv
+}
+
+func TestAdminRepublishesIntendedOverItsOwnStaleSnapshot(t *testing.T) {
+	// The cluster holds the last snapshot node 0 issued, from before a
+	// restart; its config now lists one more member.
+	c := newAdminCluster(t, func(c *adminCluster) membership.Snapshot {
+		return c.sign(t, c.keys[0], time.Now().UTC().Add(-time.Hour), c.members)
+	})
+	newID, err := peerstore.IDFromPrivateKey(newKey(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	intended := append(append([]string(nil), c.members...), newID.String())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	since := time.Now().UTC()
+	network.ReconcileIntendedMembers(c.Nodes[0], ctx, intended)
+	if err := c.WaitForMembership(ctx, since); err != nil {
+		t.Fatal(err)
+	}
+	for i, manager := range c.Managers {
+		if !manager.IsMember(newID.String()) {
+			t.Fatalf("node %d did not learn the intended member", i)
+		}
+	}
+}
+
+func TestAdminDoesNotOverrideAnotherAdmin(t *testing.T) {
+	otherAdmin := newKey(t)
+	otherID, err := peerstore.IDFromPrivateKey(otherAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Another admin published before node 0 started; node 0's config does
+	// not know about that change.
+	c := newAdminCluster(t, func(c *adminCluster) membership.Snapshot {
+		return c.sign(t, otherAdmin, time.Now().UTC().Add(-time.Hour), append(append([]string(nil), c.members...), otherID.String()))
+	})
+	before := membership.Hash(c.Managers[0].Snapshot())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	network.ReconcileIntendedMembers(c.Nodes[0], ctx, c.members)
+	if got := membership.Hash(c.Managers[0].Snapshot()); got != before {
+		t.Fatal("node 0 republished over a snapshot from another admin")
+	}
+}
+
+func TestAdminBootstrapsClusterFromUnconfigured(t *testing.T) {
+	systemKey := newKey(t)
+	rawPub, err := crypto.MarshalPublicKey(systemKey.GetPublic())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &adminCluster{systemKey: systemKey}
+	start := func(bootstrap bool) *network.Node {
+		node, _ := nettest.NewNode(t, &nettest.Config{Key: newKey(t), Bootstrap: bootstrap})
+		self := node.Host.ID().String()
+		manager, err := membership.NewManager(adminTestCluster, base64.StdEncoding.EncodeToString(rawPub), self, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		node.SetMembershipManager(manager)
+		proof := c.proof(t, node.Host.ID())
+		node.SetAdminProof(&proof)
+		if state := node.RuntimeState(); state != network.RuntimeStateUnconfigured {
+			t.Fatalf("state %s before the first snapshot, want unconfigured", state)
+		}
+		return node
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	denied := start(false)
+	if _, err := denied.PublishMembershipSnapshot(ctx, []string{denied.Host.ID().String()}); err == nil {
+		t.Fatal("published from unconfigured without admin_bootstrap")
+	}
+
+	admin := start(true)
+	if _, err := admin.PublishMembershipSnapshot(ctx, []string{admin.Host.ID().String()}); err != nil {
+		t.Fatalf("admin bootstrap publish: %v", err)
+	}
+	if err := admin.WaitForState(ctx, network.RuntimeStateHealthy); err != nil {
+		t.Fatalf("still %s after bootstrapping itself: %v", admin.RuntimeState(), err)
+	}
+}
@@ -0,0 +1,59 @@
+package network
+
+import (
+	"fmt"
+	"strings"
+
+	multiaddr "github.com/multiformats/go-multiaddr"
+)
+
+type addrsFactory = func([]multiaddr.Multiaddr) []multiaddr.Multiaddr
+
+// parseAnnounceAddrs validates operator-supplied announce addresses. They must
+// be transport multiaddrs; the node appends its own /p2p component.
+func parseAnnounceAddrs(raw []string) ([]multiaddr.Multiaddr, error) {
+	out := make([]multiaddr.Multiaddr, 0, len(raw))
+	seen := make(map[string]struct{}, len(raw))
+	for _, entry := range raw {
+		value := strings.TrimSpace(entry)
+		if value == "" {
+			continue
+		}
+		addr, err := multiaddr.NewMultiaddr(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid announce address %q: %w", value, err)
+		}
+		if _, err := addr.ValueForProtocol(multiaddr.P_P2P); err == nil {
+			return nil, fmt.Errorf("announce address %q must not include /p2p", value)
+		}
+		if _, ok := seen[addr.String()]; ok {
+			continue
+		}
+		seen[addr.String()] = struct{}{}
+		out = append(out, addr)
+	}
+	return out, nil
+}
+
+// announceAddrsFactory wraps base (which may be nil) so announce addresses
+// are advertised alongside, or instead of, the discovered ones.
+func announceAddrsFactory(announce []multiaddr.Multiaddr, replace bool, base addrsFactory) addrsFactory {
+	return func(addrs []multiaddr.Multiaddr) []multiaddr.Multiaddr {
+		if base != nil {
+			addrs = base(addrs)
+		}
+		if replace {
+			return append([]multiaddr.Multiaddr(nil), announce...)
+		}
+		out := make([]multiaddr.Multiaddr, 0, len(addrs)+len(announce))
+		seen := make(map[string]struct{}, len(addrs)+len(announce))
+		for _, addr := range append(append([]multiaddr.Multiaddr(nil), addrs...), announce...) {
+			if _, ok := seen[addr.String()]; ok {
+				continue
+			}
+			seen[addr.String()] = struct{}{}
+			out = append(out, addr)
+		}
+		return out
+	}
+}
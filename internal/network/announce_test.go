@@ -0,0 +1,48 @@
+package network_test
+
+import (
+	"testing"
+
+	"p2pos/internal/events"
+	"p2pos/internal/network"
+	"p2pos/internal/network/nettest"
+
+	multiaddr "github.com/multiformats/go-multiaddr"
+)
+
+func hasAddr(addrs []multiaddr.Multiaddr, want string) bool {
+	for _, addr := range addrs {
+		if addr.String() == want {
+			return true
+		}
+	}
+	return false
+}
+
+func TestAnnounceAddrsAdvertised(t *testing.T) {
+	const announced = "/dns4/node.example.com/tcp/4001"
+
+	node, _ := nettest.NewNode(t, &nettest.Config{Key: newKey(t), Announce: []string{announced, " " + announced}})
+	addrs := node.Host.Addrs()
+	if !hasAddr(addrs, announced) {
+		t.Fatalf("announce address missing from %v", addrs)
+	}
+	if len(addrs) < 2 {
+		t.Fatalf("append mode dropped the listen addresses: %v", addrs)
+	}
+
+	node, _ = nettest.NewNode(t, &nettest.Config{Key: newKey(t), Announce: []string{announced}, AnnounceReplace: true})
+	addrs = node.Host.Addrs()
+	if len(addrs) != 1 || !hasAddr(addrs, announced) {
+		t.Fatalf("replace mode advertised %v, want only %s", addrs, announced)
+	}
+}
+
+func TestAnnounceAddrsRejectsInvalid(t *testing.T) {
+	for _, raw := range []string{"node.example.com:4001", "/ip4/203.0.113.7/tcp/4001/p2p/12D3KooWGzBV1sJjvpJQ6dQ2sjZTGhMWNdzDxgYkGXkhX3XPtLpS"} {
+		if node, err := network.NewNode(&nettest.Config{Key: newKey(t), Announce: []string{raw}}, events.NewBus()); err == nil {
+			_ = node.Close()
+			t.Errorf("announce address %q accepted", raw)
+		}
+	}
+}
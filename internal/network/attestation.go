@@ -0,0 +1,107 @@
+package network
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"p2pos/internal/config"
+	"p2pos/internal/logging"
+	"p2pos/internal/status"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+)
+
+const (
+	attestationPrefix = "p2pos-attestation-v1"
+
+	IdentityVerified   = "verified"
+	IdentityUnverified = "unverified"
+)
+
+func canonicalAttestation(a status.Attestation) []byte {
+	return []byte(strings.Join([]string{
+		attestationPrefix,
+		a.PeerID,
+		a.Region,
+		canonicalTags(a.Tags),
+		a.AppVersion,
+		a.IssuedAt.UTC().Format(time.RFC3339Nano),
+	}, "|"))
+}
+
+// signAttestation signs a with priv, which must be the key behind a.PeerID.
+func signAttestation(priv crypto.PrivKey, a status.Attestation) (status.Attestation, error) {
+	if priv == nil {
+		return a, fmt.Errorf("private key is nil")
+	}
+	sig, err := priv.Sign(canonicalAttestation(a))
+	if err != nil {
+		return a, err
+	}
+	a.Sig = base64.StdEncoding.EncodeToString(sig)
+	return a, nil
+}
+
+// signLocalAttestation attests this node's configured labels. A failure only
+// leaves the node's labels unverified for its peers.
+func (n *Node) signLocalAttestation() *status.Attestation {
+	a, err := signAttestation(n.privKey, status.Attestation{
+		PeerID:     n.Host.ID().String(),
+		Region:     n.region,
+		Tags:       copyTags(n.tags),
+		AppVersion: config.AppVersion,
+		IssuedAt:   time.Now().UTC(),
+	})
+	if err != nil {
+		logging.Log("NODE", "attestation_sign_failed", map[string]string{
+			"reason": err.Error(),
+		})
+		return nil
+	}
+	return &a
+}
+
+// VerifyAttestation checks a's signature against the public key embedded in
+// its peer ID.
+func VerifyAttestation(a status.Attestation) error {
+	if a.PeerID == "" || a.Sig == "" || a.IssuedAt.IsZero() {
+		return fmt.Errorf("attestation missing fields")
+	}
+	id, err := peerstore.Decode(a.PeerID)
+	if err != nil {
+		return fmt.Errorf("invalid peer id")
+	}
+	pub, err := id.ExtractPublicKey()
+	if err != nil {
+		return fmt.Errorf("extract public key failed")
+	}
+	sig, err := base64.StdEncoding.DecodeString(a.Sig)
+	if err != nil {
+		return fmt.Errorf("invalid signature encoding")
+	}
+	ok, err := pub.Verify(canonicalAttestation(a), sig)
+	if err != nil || !ok {
+		return fmt.Errorf("signature invalid")
+	}
+	return nil
+}
+
+// applyAttestation shows rec's labels from its attestation when that verifies
+// for rec's peer, and marks labels without one as unverified.
+func applyAttestation(rec *status.Record) {
+	if a := rec.Attestation; a != nil && a.PeerID == rec.PeerID && VerifyAttestation(*a) == nil {
+		rec.Region, rec.Tags = a.Region, copyTags(a.Tags)
+		if a.AppVersion != "" {
+			rec.AppVersion = a.AppVersion
+		}
+		rec.Identity = IdentityVerified
+		return
+	}
+	rec.Attestation = nil
+	if rec.Region != "" || len(rec.Tags) > 0 {
+		rec.Identity = IdentityUnverified
+	}
+}
@@ -0,0 +1,48 @@
+package network
+
+import (
+	"testing"
+	"time"
+
+	"p2pos/internal/status"
+)
+
+func TestVerifyAttestation(t *testing.T) {
+	priv, id := newTestPeer(t)
+	a, err := signAttestation(priv, status.Attestation{
+		PeerID:     id.String(),
+		Region:     "eu-west",
+		Tags:       map[string]string{"role": "edge"},
+		AppVersion: "1.2.3",
+		IssuedAt:   time.Now().UTC(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyAttestation(a); err != nil {
+		t.Fatalf("valid attestation rejected: %v", err)
+	}
+
+	tampered := a
+	tampered.Tags = map[string]string{"role": "core"}
+	if err := VerifyAttestation(tampered); err == nil {
+		t.Fatal("attestation with altered tags verified")
+	}
+	tampered = a
+	tampered.Region = "us-east"
+	if err := VerifyAttestation(tampered); err == nil {
+		t.Fatal("attestation with altered region verified")
+	}
+
+	_, other := newTestPeer(t)
+	rec := status.Record{PeerID: other.String(), Region: "us-east", Attestation: &a}
+	applyAttestation(&rec)
+	if rec.Identity != IdentityUnverified || rec.Attestation != nil || rec.Region != "us-east" {
+		t.Fatalf("attestation for another peer applied: %+v", rec)
+	}
+	rec = status.Record{PeerID: id.String(), Attestation: &a}
+	applyAttestation(&rec)
+	if rec.Identity != IdentityVerified || rec.Region != "eu-west" || rec.Tags["role"] != "edge" {
+		t.Fatalf("valid attestation not applied: %+v", rec)
+	}
+}
@@ -0,0 +1,126 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"p2pos/internal/audit"
+	"p2pos/internal/logging"
+
+	libp2pnet "github.com/libp2p/go-libp2p/core/network"
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+const membershipAuditProtocolID = protocol.ID("/p2pos/membership-audit/1.0.0")
+const defaultAuditLimit = 100
+
+type AuditProvider interface {
+	Recent(ctx context.Context, limit int) ([]audit.Record, error)
+}
+
+// AdminAuditor records admin actions taken through this node.
+type AdminAuditor interface {
+	RecordAdminAction(ctx context.Context, action string, detail map[string]string)
+}
+
+type auditRequest struct {
+	Limit int `json:"limit,omitempty"`
+}
+
+type auditResponse struct {
+	GeneratedAt time.Time      `json:"generated_at"`
+	Entries     []audit.Record `json:"entries"`
+	Error       string         `json:"error,omitempty"`
+}
+
+func (n *Node) SetAuditProvider(provider AuditProvider) {
+	n.statusMu.Lock()
+	n.audit = provider
+	n.statusMu.Unlock()
+}
+
+func (n *Node) SetAdminAuditor(auditor AdminAuditor) {
+	n.statusMu.Lock()
+	n.adminAuditor = auditor
+	n.statusMu.Unlock()
+}
+
+// AdminAuditor returns the auditor set by SetAdminAuditor, or nil.
+func (n *Node) AdminAuditor() AdminAuditor {
+	n.statusMu.RLock()
+	defer n.statusMu.RUnlock()
+	return n.adminAuditor
+}
+
+func (n *Node) recordAdminAction(ctx context.Context, action string, detail map[string]string) {
+	if auditor := n.AdminAuditor(); auditor != nil {
+		auditor.RecordAdminAction(ctx, action, detail)
+	}
+}
+
+func (n *Node) registerAuditHandler() {
+	n.setBusinessHandler(membershipAuditProtocolID, func(stream libp2pnet.Stream) {
+		defer stream.Close()
+
+		req := auditRequest{}
+		_ = json.NewDecoder(stream).Decode(&req)
+		if req.Limit <= 0 || req.Limit > defaultAuditLimit {
+			req.Limit = defaultAuditLimit
+		}
+
+		resp := auditResponse{
+			GeneratedAt: time.Now().UTC(),
+			Entries:     []audit.Record{},
+		}
+		if !n.canUseBusinessProtocols() {
+			resp.Error = "node is unconfigured"
+			_ = json.NewEncoder(stream).Encode(resp)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		n.statusMu.RLock()
+		provider := n.audit
+		n.statusMu.RUnlock()
+		if provider != nil {
+			entries, err := provider.Recent(ctx, req.Limit)
+			if err != nil {
+				resp.Error = err.Error()
+			} else {
+				resp.Entries = entries
+			}
+		}
+
+		if err := json.NewEncoder(stream).Encode(resp); err != nil {
+			logging.Log("AUDIT", "encode_failed", map[string]string{
+				"reason": err.Error(),
+			})
+		}
+	})
+}
+
+func (n *Node) FetchMembershipAudit(ctx context.Context, peerID peerstore.ID, limit int) ([]audit.Record, error) {
+	stream, err := n.newCountedStream(ctx, peerID, membershipAuditProtocolID)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	if err := json.NewEncoder(stream).Encode(auditRequest{Limit: limit}); err != nil {
+		return nil, err
+	}
+
+	var resp auditResponse
+	if err := json.NewDecoder(stream).Decode(&resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+	return resp.Entries, nil
+}
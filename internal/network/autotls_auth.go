@@ -0,0 +1,184 @@
+package network
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"p2pos/internal/logging"
+
+	golog "github.com/ipfs/go-log/v2"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// maxForgeAuthFailures bounds how many rejected forge registrations are
+// tolerated outside auto_tls.mode=on before the certificate flow is stopped
+// and restarted later. certmagic already retries issuance with backoff,
+// which lets a transient forge outage recover on its own within that budget.
+const maxForgeAuthFailures = 5
+
+// The stopped certificate flow is restarted after autoTLSRetryBase, doubling
+// on every further stop or failed start up to autoTLSRetryMax.
+const (
+	autoTLSRetryBase = 30 * time.Second
+	autoTLSRetryMax  = 30 * time.Minute
+)
+
+const forgeAuthRemediation = "check auto_tls.forge_auth (or FORGE_ACCESS_TOKEN) matches the token issued by the forge operator"
+
+// forgeAuthWatcher inspects p2p-forge/certmagic log entries for registration
+// errors caused by a rejected forge auth token. The forge client only
+// surfaces these through its logger.
+type forgeAuthWatcher struct {
+	mu       sync.Mutex
+	force    bool
+	failures int
+	stopped  bool
+	stop     func()
+	// restart schedules the certificate flow to start again after delay.
+	restart func(delay time.Duration)
+	delay   time.Duration
+	base    time.Duration
+	max     time.Duration
+}
+
+func newForgeAuthWatcher(force bool) *forgeAuthWatcher {
+	return &forgeAuthWatcher{force: force, base: autoTLSRetryBase, max: autoTLSRetryMax}
+}
+
+// logger returns the default p2p-forge logger teed into the watcher.
+func (w *forgeAuthWatcher) logger() *zap.SugaredLogger {
+	base := golog.Logger("p2p-forge/client").Desugar()
+	return zap.New(zapcore.NewTee(base.Core(), &forgeAuthCore{watcher: w})).Sugar()
+}
+
+// setStop installs the function used to stop the certificate flow.
+func (w *forgeAuthWatcher) setStop(stop func()) {
+	w.mu.Lock()
+	w.stop = stop
+	w.mu.Unlock()
+}
+
+func (w *forgeAuthWatcher) setRestart(restart func(delay time.Duration)) {
+	w.mu.Lock()
+	w.restart = restart
+	w.mu.Unlock()
+}
+
+// resume counts failures afresh once the certificate flow runs again.
+func (w *forgeAuthWatcher) resume() {
+	w.mu.Lock()
+	w.failures = 0
+	w.stopped = false
+	w.mu.Unlock()
+}
+
+// backoff returns the next restart delay. It keeps growing across restarts:
+// a token the forge rejected once is likely to be rejected again.
+func (w *forgeAuthWatcher) backoff() time.Duration {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.nextDelayLocked()
+}
+
+func (w *forgeAuthWatcher) nextDelayLocked() time.Duration {
+	if w.delay == 0 {
+		w.delay = w.base
+	} else {
+		w.delay *= 2
+	}
+	if w.delay > w.max {
+		w.delay = w.max
+	}
+	return w.delay
+}
+
+func (w *forgeAuthWatcher) observe(detail string) {
+	w.mu.Lock()
+	if w.stopped {
+		w.mu.Unlock()
+		return
+	}
+	w.failures++
+	failures := w.failures
+	var stop func()
+	var restart func(time.Duration)
+	var delay time.Duration
+	if !w.force && failures >= maxForgeAuthFailures && w.stop != nil {
+		w.stopped = true
+		stop = w.stop
+		restart = w.restart
+		delay = w.nextDelayLocked()
+	}
+	w.mu.Unlock()
+
+	logging.Log("NODE", "autotls_auth_failed", map[string]string{
+		"attempt":     fmt.Sprintf("%d", failures),
+		"reason":      detail,
+		"remediation": forgeAuthRemediation,
+	})
+	if stop != nil {
+		stop()
+		logging.Log("NODE", "autotls_disabled", map[string]string{
+			"reason":   "forge_auth_rejected",
+			"attempts": fmt.Sprintf("%d", failures),
+			"retry_in": delay.String(),
+		})
+		if restart != nil {
+			restart(delay)
+		}
+	}
+}
+
+// isForgeAuthError matches the status line SendChallenge embeds in its error
+// when the broker rejects the Forge-Authorization header.
+func isForgeAuthError(text string) bool {
+	if !strings.Contains(text, "p2p-forge broker registration error") {
+		return false
+	}
+	return strings.Contains(text, "401 Unauthorized") || strings.Contains(text, "403 Forbidden")
+}
+
+type forgeAuthCore struct {
+	watcher *forgeAuthWatcher
+	fields  []zapcore.Field
+}
+
+func (c *forgeAuthCore) Enabled(level zapcore.Level) bool {
+	return level >= zapcore.WarnLevel
+}
+
+func (c *forgeAuthCore) With(fields []zapcore.Field) zapcore.Core {
+	return &forgeAuthCore{
+		watcher: c.watcher,
+		fields:  append(append([]zapcore.Field(nil), c.fields...), fields...),
+	}
+}
+
+func (c *forgeAuthCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *forgeAuthCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, field := range append(append([]zapcore.Field(nil), c.fields...), fields...) {
+		field.AddTo(enc)
+	}
+	text := entry.Message
+	for _, value := range enc.Fields {
+		text += " " + fmt.Sprint(value)
+	}
+	if isForgeAuthError(text) {
+		c.watcher.observe(entry.Message)
+	}
+	return nil
+}
+
+func (c *forgeAuthCore) Sync() error {
+	return nil
+}
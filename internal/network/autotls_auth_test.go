@@ -0,0 +1,29 @@
+package network
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestForgeAuthLoggerStopsFlowOnRejectedToken(t *testing.T) {
+	w := newForgeAuthWatcher(false)
+	stops := 0
+	w.setStop(func() { stops++ })
+	w.setRestart(func(time.Duration) {})
+	log := w.logger()
+
+	rejected := errors.New("p2p-forge broker registration error: 401 Unauthorized")
+	log.Errorw("failed to obtain certificate", "error", errors.New("dial tcp: connection refused"))
+	log.Infow("retrying", "error", rejected)
+	for i := 0; i < maxForgeAuthFailures-1; i++ {
+		log.Errorw("failed to obtain certificate", "error", rejected)
+	}
+	if stops != 0 {
+		t.Fatalf("flow stopped after %d rejections, want %d", maxForgeAuthFailures-1, maxForgeAuthFailures)
+	}
+	log.Errorw("failed to obtain certificate", "error", rejected)
+	if stops != 1 {
+		t.Fatalf("flow stopped %d times, want once", stops)
+	}
+}
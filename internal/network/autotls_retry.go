@@ -0,0 +1,86 @@
+package network
+
+import (
+	"fmt"
+	"time"
+
+	"p2pos/internal/logging"
+
+	p2pforge "github.com/ipshipyard/p2p-forge/client"
+)
+
+// retryAutoTLS starts mgr again after delay, backing off through watcher
+// while Start keeps failing, and attaches it to the node once it runs.
+func (n *Node) retryAutoTLS(mgr *p2pforge.P2PForgeCertMgr, watcher *forgeAuthWatcher, delay time.Duration) {
+	retryWithBackoff(n.lifecycle.Done(), delay, watcher.backoff, func() error {
+		n.autoTLSMu.Lock()
+		defer n.autoTLSMu.Unlock()
+		// Close detaches under the same lock, so a manager started here
+		// after shutdown is never left running.
+		if err := n.lifecycle.Err(); err != nil {
+			return err
+		}
+		if err := mgr.Start(); err != nil {
+			return err
+		}
+		n.autoTLSMgr = mgr
+		return nil
+	}, func(attempt int, err error, next time.Duration) {
+		if n.lifecycle.Err() != nil {
+			return
+		}
+		if err != nil {
+			logging.Log("NODE", "autotls_retry_failed", map[string]string{
+				"attempt":  fmt.Sprintf("%d", attempt),
+				"reason":   err.Error(),
+				"retry_in": next.String(),
+			})
+			return
+		}
+		watcher.setStop(mgr.Stop)
+		watcher.resume()
+		n.startCertWatcher()
+		logging.Log("NODE", "autotls_restarted", map[string]string{
+			"attempt": fmt.Sprintf("%d", attempt),
+		})
+	})
+}
+
+// retryWithBackoff calls start after delay, and after next() each time it
+// fails, until it succeeds or done closes. report sees every attempt; err is
+// nil for the successful one.
+func retryWithBackoff(done <-chan struct{}, delay time.Duration, next func() time.Duration, start func() error, report func(attempt int, err error, next time.Duration)) {
+	go func() {
+		for attempt := 1; ; attempt++ {
+			timer := time.NewTimer(delay)
+			select {
+			case <-done:
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+			err := start()
+			if err == nil {
+				report(attempt, nil, 0)
+				return
+			}
+			delay = next()
+			report(attempt, err, delay)
+		}
+	}()
+}
+
+func (n *Node) currentAutoTLS() *p2pforge.P2PForgeCertMgr {
+	n.autoTLSMu.Lock()
+	defer n.autoTLSMu.Unlock()
+	return n.autoTLSMgr
+}
+
+// detachAutoTLS clears the running manager and returns it.
+func (n *Node) detachAutoTLS() *p2pforge.P2PForgeCertMgr {
+	n.autoTLSMu.Lock()
+	defer n.autoTLSMu.Unlock()
+	mgr := n.autoTLSMgr
+	n.autoTLSMgr = nil
+	return mgr
+}
@@ -0,0 +1,104 @@
+package network
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestForgeAuthWatcherRestartsWithCappedBackoff(t *testing.T) {
+	w := newForgeAuthWatcher(false)
+	w.base = time.Second
+	w.max = 4 * time.Second
+
+	stops := 0
+	var delays []time.Duration
+	w.setStop(func() { stops++ })
+	w.setRestart(func(delay time.Duration) { delays = append(delays, delay) })
+
+	for round := 0; round < 4; round++ {
+		for i := 0; i < maxForgeAuthFailures+2; i++ {
+			w.observe("401 Unauthorized")
+		}
+		// The restarted flow counts failures afresh.
+		w.resume()
+	}
+
+	if stops != 4 {
+		t.Fatalf("flow stopped %d times, want once per round (4)", stops)
+	}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second}
+	if len(delays) != len(want) {
+		t.Fatalf("restart delays %v, want %v", delays, want)
+	}
+	for i := range want {
+		if delays[i] != want[i] {
+			t.Fatalf("restart delays %v, want %v", delays, want)
+		}
+	}
+}
+
+func TestForgeAuthWatcherNeverStopsInForceMode(t *testing.T) {
+	w := newForgeAuthWatcher(true)
+	w.setStop(func() { t.Fatal("mode=on must not stop the certificate flow") })
+	w.setRestart(func(time.Duration) { t.Fatal("mode=on must not restart the certificate flow") })
+	for i := 0; i < 2*maxForgeAuthFailures; i++ {
+		w.observe("403 Forbidden")
+	}
+}
+
+func TestRetryWithBackoffUntilStarted(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+	var reported []error
+	done := make(chan struct{})
+
+	next := time.Millisecond
+	retryWithBackoff(make(chan struct{}), time.Millisecond, func() time.Duration {
+		next *= 2
+		return next
+	}, func() error {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls < 3 {
+			return errors.New("forge unreachable")
+		}
+		return nil
+	}, func(_ int, err error, _ time.Duration) {
+		mu.Lock()
+		reported = append(reported, err)
+		mu.Unlock()
+		if err == nil {
+			close(done)
+		}
+	})
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("start was never retried to success")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if calls != 3 || len(reported) != 3 || reported[0] == nil || reported[1] == nil {
+		t.Fatalf("calls=%d reported=%v, want two failures then success", calls, reported)
+	}
+}
+
+func TestRetryWithBackoffStopsWhenDone(t *testing.T) {
+	done := make(chan struct{})
+	close(done)
+	started := make(chan struct{}, 1)
+	retryWithBackoff(done, time.Hour, func() time.Duration { return time.Hour }, func() error {
+		started <- struct{}{}
+		return nil
+	}, func(int, error, time.Duration) {})
+
+	select {
+	case <-started:
+		t.Fatal("start ran after done")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
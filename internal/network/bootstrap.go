@@ -5,8 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
 
 	"p2pos/internal/config"
+	"p2pos/internal/logging"
+	"p2pos/internal/membership"
 
 	peerstore "github.com/libp2p/go-libp2p/core/peer"
 	multiaddr "github.com/multiformats/go-multiaddr"
@@ -16,68 +19,115 @@ type Resolver interface {
 	Resolve(ctx context.Context) ([]peerstore.AddrInfo, error)
 }
 
+// expectingResolver is implemented by resolvers whose entries can name the
+// peer ID their addresses must present (expected_peer_id). Only those
+// candidates are pinned in the gater while they are dialed.
+type expectingResolver interface {
+	ExpectsPeer(id peerstore.ID) bool
+}
+
 type InitConnectionsProvider interface {
 	Get() config.Config
 }
 
+// SourceResolver contributes bootstrap candidates for one init_connections
+// entry. Implementations are registered on ConfigResolver by Connection.Type.
+type SourceResolver interface {
+	ResolveSource(ctx context.Context, conn config.Connection) ([]peerstore.AddrInfo, error)
+}
+
+type SourceResolverFunc func(ctx context.Context, conn config.Connection) ([]peerstore.AddrInfo, error)
+
+func (f SourceResolverFunc) ResolveSource(ctx context.Context, conn config.Connection) ([]peerstore.AddrInfo, error) {
+	return f(ctx, conn)
+}
+
 type ConfigResolver struct {
 	selfID   peerstore.ID
 	provider InitConnectionsProvider
-	dns      DNSResolver
+	mu       sync.RWMutex
+	sources  map[string]SourceResolver
+	expects  map[peerstore.ID]struct{}
 }
 
 func NewConfigResolver(selfID peerstore.ID, provider InitConnectionsProvider, dns DNSResolver) *ConfigResolver {
-	return &ConfigResolver{
+	r := &ConfigResolver{
 		selfID:   selfID,
 		provider: provider,
-		dns:      dns,
+		sources:  make(map[string]SourceResolver),
 	}
+	r.Register("dns", &dnsSourceResolver{dns: dns})
+	r.Register("multiaddr", SourceResolverFunc(resolveMultiaddrSource))
+	r.Register("http", newHTTPSeedSourceResolver())
+	r.Register("file", SourceResolverFunc(resolveFileSource))
+	return r
 }
 
-func (r *ConfigResolver) Resolve(_ context.Context) ([]peerstore.AddrInfo, error) {
+// Register installs (or replaces) the resolver used for connections of kind.
+func (r *ConfigResolver) Register(kind string, source SourceResolver) {
+	key := strings.ToLower(strings.TrimSpace(kind))
+	if key == "" || source == nil {
+		return
+	}
+	r.mu.Lock()
+	r.sources[key] = source
+	r.mu.Unlock()
+}
+
+func (r *ConfigResolver) source(kind string) (SourceResolver, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	source, ok := r.sources[strings.ToLower(strings.TrimSpace(kind))]
+	return source, ok
+}
+
+func (r *ConfigResolver) Resolve(ctx context.Context) ([]peerstore.AddrInfo, error) {
 	peersByID := make(map[peerstore.ID]*peerstore.AddrInfo)
+	expects := make(map[peerstore.ID]struct{})
 	var errs []error
 
 	cfg := r.provider.Get()
 	for _, conn := range cfg.InitConnections {
-		switch conn.Type {
-		case "dns":
-			records, err := r.lookupBootstrapTXT(conn.Address)
-			if err != nil {
-				errs = append(errs, fmt.Errorf("dns %s query failed: %w", conn.Address, err))
-				continue
-			}
-			if len(records) == 0 {
+		source, ok := r.source(conn.Type)
+		if !ok {
+			continue
+		}
+		if cfg.RequireSignedBootstrap {
+			if err := verifyBootstrapSignature(cfg, conn); err != nil {
+				errs = append(errs, err)
 				continue
 			}
-			for _, record := range records {
-				for _, value := range parseTXTRecordValues(record) {
-					peerInfo, err := ParseP2PAddr(value)
-					if err != nil {
-						errs = append(errs, fmt.Errorf("dns %s parse failed for %q: %w", conn.Address, value, err))
-						continue
-					}
-					if peerInfo.ID == r.selfID {
-						continue
-					}
-					mergePeerAddrInfo(peersByID, peerInfo)
-				}
-			}
-		case "multiaddr":
-			peerInfo, err := ParseP2PAddr(conn.Address)
-			if err != nil {
-				errs = append(errs, fmt.Errorf("multiaddr %s parse failed: %w", conn.Address, err))
+		}
+		expected, err := expectedBootstrapPeer(conn)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		candidates, err := source.ResolveSource(ctx, conn)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		for i := range candidates {
+			peerInfo := &candidates[i]
+			if peerInfo.ID == r.selfID {
 				continue
 			}
-			if peerInfo.ID == r.selfID {
+			if err := checkExpectedPeer(conn, expected, peerInfo.ID); err != nil {
+				errs = append(errs, err)
 				continue
 			}
 			mergePeerAddrInfo(peersByID, peerInfo)
-		default:
-			continue
+			if expected != "" {
+				expects[peerInfo.ID] = struct{}{}
+			}
 		}
 	}
 
+	r.mu.Lock()
+	r.expects = expects
+	r.mu.Unlock()
+
 	peers := make([]peerstore.AddrInfo, 0, len(peersByID))
 	for _, info := range peersByID {
 		peers = append(peers, *info)
@@ -86,7 +136,97 @@ func (r *ConfigResolver) Resolve(_ context.Context) ([]peerstore.AddrInfo, error
 	return peers, errors.Join(errs...)
 }
 
-func (r *ConfigResolver) lookupBootstrapTXT(domain string) ([]string, error) {
+// ExpectsPeer reports whether id came from an entry with an expected_peer_id
+// in the last Resolve.
+func (r *ConfigResolver) ExpectsPeer(id peerstore.ID) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.expects[id]
+	return ok
+}
+
+func resolveMultiaddrSource(_ context.Context, conn config.Connection) ([]peerstore.AddrInfo, error) {
+	peerInfo, err := ParseP2PAddr(conn.Address)
+	if err != nil {
+		return nil, fmt.Errorf("multiaddr %s parse failed: %w", conn.Address, err)
+	}
+	return []peerstore.AddrInfo{*peerInfo}, nil
+}
+
+type dnsSourceResolver struct {
+	dns DNSResolver
+}
+
+func (r *dnsSourceResolver) ResolveSource(_ context.Context, conn config.Connection) ([]peerstore.AddrInfo, error) {
+	records, err := r.lookupBootstrapTXT(conn.Address)
+	if err != nil {
+		return nil, fmt.Errorf("dns %s query failed: %w", conn.Address, err)
+	}
+
+	var (
+		out  []peerstore.AddrInfo
+		errs []error
+	)
+	for _, record := range records {
+		for _, value := range parseTXTRecordValues(record) {
+			peerInfo, err := ParseP2PAddr(value)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("dns %s parse failed for %q: %w", conn.Address, value, err))
+				continue
+			}
+			out = append(out, *peerInfo)
+		}
+	}
+	return out, errors.Join(errs...)
+}
+
+func expectedBootstrapPeer(conn config.Connection) (peerstore.ID, error) {
+	raw := strings.TrimSpace(conn.ExpectedPeerID)
+	if raw == "" {
+		return "", nil
+	}
+	id, err := peerstore.Decode(raw)
+	if err != nil {
+		return "", fmt.Errorf("%s %s expected_peer_id invalid: %w", conn.Type, conn.Address, err)
+	}
+	return id, nil
+}
+
+// verifyBootstrapSignature rejects conn unless the system key signed it. The
+// signature covers expected_peer_id, which checkExpectedPeer then enforces on
+// every resolved candidate, so only the vouched-for peer is ever dialed.
+func verifyBootstrapSignature(cfg config.Config, conn config.Connection) error {
+	err := membership.VerifyBootstrapEntry(cfg.SystemPubKey, membership.BootstrapEntry{
+		ClusterID:      cfg.ClusterID,
+		Type:           conn.Type,
+		Address:        conn.Address,
+		ExpectedPeerID: conn.ExpectedPeerID,
+		Sig:            conn.Sig,
+	})
+	if err == nil {
+		return nil
+	}
+	logging.Log("BOOTSTRAP", "unsigned_source_rejected", map[string]string{
+		"source": conn.Type + ":" + conn.Address,
+		"reason": err.Error(),
+	})
+	return fmt.Errorf("%s %s rejected: %w", conn.Type, conn.Address, err)
+}
+
+func checkExpectedPeer(conn config.Connection, expected, got peerstore.ID) error {
+	if expected == "" || expected == got {
+		return nil
+	}
+	logging.Log("BOOTSTRAP", "peer_id_mismatch", map[string]string{
+		"source":   conn.Type + ":" + conn.Address,
+		"expected": expected.String(),
+		"peer_id":  got.String(),
+		"stage":    "resolve",
+	})
+	return fmt.Errorf("%s %s resolved peer %s, expected %s", conn.Type, conn.Address, got, expected)
+}
+
+func (r *dnsSourceResolver) lookupBootstrapTXT(domain string) ([]string, error) {
 	base := strings.TrimSpace(domain)
 	if base == "" {
 		return nil, fmt.Errorf("empty dns bootstrap domain")
@@ -162,3 +302,16 @@ func ParseP2PAddr(multiAddrStr string) (*peerstore.AddrInfo, error) {
 
 	return peer, nil
 }
+
+// DNSAddrRecord renders addr for peerID as the TXT value the dns bootstrap
+// source reads back with parseTXTRecordValues: "dnsaddr=<addr>/p2p/<id>".
+func DNSAddrRecord(addr multiaddr.Multiaddr, peerID peerstore.ID) (string, error) {
+	if _, last := multiaddr.SplitLast(addr); last != nil && last.Code() == multiaddr.P_P2P {
+		addr, _ = multiaddr.SplitLast(addr)
+	}
+	full, err := multiaddr.NewMultiaddr(addr.String() + "/p2p/" + peerID.String())
+	if err != nil {
+		return "", err
+	}
+	return "dnsaddr=" + full.String(), nil
+}
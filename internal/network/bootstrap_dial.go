@@ -0,0 +1,87 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"p2pos/internal/logging"
+
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+)
+
+// dialBootstrapCandidates dials up to n.bootstrapDials candidates at a time,
+// in resolver order, and cancels the dials still in flight once the member
+// connection floor is reached. It reports whether the floor was reached.
+// Candidates for which expects reports true are pinned in the gater to their
+// peer ID for the length of their dial; expects may be nil.
+func (n *Node) dialBootstrapCandidates(ctx context.Context, candidates []peerstore.AddrInfo, expects func(peerstore.ID) bool) bool {
+	limit := n.bootstrapDials
+	if limit <= 0 {
+		limit = 1
+	}
+	dialCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		satisfied bool
+	)
+	slots := make(chan struct{}, limit)
+	for _, candidate := range candidates {
+		if candidate.ID == n.Host.ID() {
+			continue
+		}
+		var onlySelf bool
+		if candidate, onlySelf = n.withoutSelfAddrs(candidate); onlySelf {
+			continue
+		}
+		select {
+		case <-dialCtx.Done():
+		case slots <- struct{}{}:
+		}
+		if dialCtx.Err() != nil {
+			break
+		}
+		pinned := expects != nil && expects(candidate.ID)
+		if pinned {
+			n.gater.expectBootstrap(candidate)
+		}
+		wg.Add(1)
+		go func(candidate peerstore.AddrInfo) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			if pinned {
+				defer n.gater.forgetBootstrap(candidate)
+			}
+			if err := n.dialPeer(dialCtx, candidate); err != nil {
+				if errors.Is(err, errDialThrottled) || dialCtx.Err() != nil {
+					return
+				}
+				logging.Log("BOOTSTRAP", "bootstrap_dial_failed", map[string]string{
+					"peer_id": candidate.ID.String(),
+					"reason":  err.Error(),
+				})
+				return
+			}
+			logging.Log("BOOTSTRAP", "bootstrap_connected", map[string]string{
+				"peer_id": candidate.ID.String(),
+			})
+			// Keep dialing in unconfigured mode to continue membership
+			// bootstrap attempts, and until the member connection floor is
+			// reached.
+			if n.bootstrapSatisfied() {
+				mu.Lock()
+				satisfied = true
+				mu.Unlock()
+				cancel()
+			}
+		}(candidate)
+	}
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	return satisfied
+}
@@ -0,0 +1,70 @@
+package network_test
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"p2pos/internal/membership"
+	"p2pos/internal/network"
+	"p2pos/internal/network/nettest"
+
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+	multiaddr "github.com/multiformats/go-multiaddr"
+)
+
+// hangingCandidate accepts TCP connections and never answers, so a dial to it
+// only ends when its context does.
+func hangingCandidate(t *testing.T) peerstore.AddrInfo {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { _ = conn.Close() })
+		}
+	}()
+	addr, err := multiaddr.NewMultiaddr("/ip4/127.0.0.1/tcp/" + strconv.Itoa(ln.Addr().(*net.TCPAddr).Port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := peerstore.IDFromPrivateKey(newKey(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return peerstore.AddrInfo{ID: id, Addrs: []multiaddr.Multiaddr{addr}}
+}
+
+func TestBootstrapDialsDoNotWaitOnHangingCandidates(t *testing.T) {
+	a, _ := nettest.NewNode(t, &nettest.Config{Key: newKey(t)})
+	fast, _ := nettest.NewNode(t, &nettest.Config{Key: newKey(t)})
+	members := []string{a.Host.ID().String(), fast.Host.ID().String()}
+	for _, node := range []*network.Node{a, fast} {
+		manager, err := membership.NewManager("test", "", node.Host.ID().String(), members)
+		if err != nil {
+			t.Fatal(err)
+		}
+		node.SetMembershipManager(manager)
+	}
+
+	candidates := []peerstore.AddrInfo{hangingCandidate(t), hangingCandidate(t), hangingCandidate(t),
+		{ID: fast.Host.ID(), Addrs: fast.Host.Addrs()}}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	start := time.Now()
+	if !network.DialBootstrapCandidates(a, ctx, candidates, nil) {
+		t.Fatal("bootstrap not satisfied by the fast candidate")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("bootstrap took %v, waiting on hanging candidates", elapsed)
+	}
+}
@@ -0,0 +1,49 @@
+package network_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"p2pos/internal/membership"
+	"p2pos/internal/network"
+	"p2pos/internal/network/nettest"
+)
+
+func TestBootstrapContinuesUntilPeerFloor(t *testing.T) {
+	a, _ := nettest.NewNode(t, &nettest.Config{Key: newKey(t), MinBootstrap: 2})
+	nodes := []*network.Node{a}
+	for i := 0; i < 3; i++ {
+		node, _ := nettest.NewNode(t, &nettest.Config{Key: newKey(t)})
+		nodes = append(nodes, node)
+	}
+	members := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		members = append(members, node.Host.ID().String())
+	}
+	for _, node := range nodes {
+		manager, err := membership.NewManager("test", "", node.Host.ID().String(), members)
+		if err != nil {
+			t.Fatal(err)
+		}
+		node.SetMembershipManager(manager)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if network.BootstrapSatisfied(a) {
+		t.Fatal("satisfied with no peers")
+	}
+	if err := nettest.Connect(ctx, a, nodes[1]); err != nil {
+		t.Fatal(err)
+	}
+	if network.BootstrapSatisfied(a) {
+		t.Fatal("satisfied with one member below a floor of two")
+	}
+	if err := nettest.Connect(ctx, a, nodes[2]); err != nil {
+		t.Fatal(err)
+	}
+	if !network.BootstrapSatisfied(a) {
+		t.Fatal("not satisfied once the floor was reached")
+	}
+}
@@ -0,0 +1,226 @@
+package network
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"p2pos/internal/config"
+	"p2pos/internal/membership"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	libp2pnet "github.com/libp2p/go-libp2p/core/network"
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+	multiaddr "github.com/multiformats/go-multiaddr"
+)
+
+// staticConfig serves a fixed config to ConfigResolver.
+type staticConfig config.Config
+
+func (c staticConfig) Get() config.Config { return config.Config(c) }
+
+func newTestResolver(t *testing.T, cfg config.Config) *ConfigResolver {
+	t.Helper()
+	_, self := newTestPeer(t)
+	return NewConfigResolver(self, staticConfig(cfg), nil)
+}
+
+func TestResolveRejectsUnexpectedPeerID(t *testing.T) {
+	_, real := newTestPeer(t)
+	_, impostor := newTestPeer(t)
+	addr := "/ip4/198.51.100.7/tcp/4100/p2p/"
+
+	resolver := newTestResolver(t, config.Config{InitConnections: []config.Connection{
+		{Type: "multiaddr", Address: addr + impostor.String(), ExpectedPeerID: real.String()},
+		{Type: "multiaddr", Address: addr + real.String(), ExpectedPeerID: real.String()},
+	}})
+	peers, err := resolver.Resolve(context.Background())
+	if err == nil {
+		t.Fatal("a candidate with the wrong peer ID resolved without error")
+	}
+	if len(peers) != 1 || peers[0].ID != real {
+		t.Fatalf("resolved %v, want only the expected peer", peers)
+	}
+}
+
+func TestResolverExpectsOnlyPinnedEntries(t *testing.T) {
+	_, pinned := newTestPeer(t)
+	_, open := newTestPeer(t)
+	addr := "/ip4/198.51.100.7/tcp/4100/p2p/"
+
+	resolver := newTestResolver(t, config.Config{InitConnections: []config.Connection{
+		{Type: "multiaddr", Address: addr + pinned.String(), ExpectedPeerID: pinned.String()},
+		{Type: "multiaddr", Address: addr + open.String()},
+	}})
+	if _, err := resolver.Resolve(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !resolver.ExpectsPeer(pinned) {
+		t.Fatal("an entry with expected_peer_id is not expected")
+	}
+	if resolver.ExpectsPeer(open) {
+		t.Fatal("an entry without expected_peer_id is expected")
+	}
+}
+
+func TestGaterForgetsBootstrapPinAfterDial(t *testing.T) {
+	_, expected := newTestPeer(t)
+	_, other := newTestPeer(t)
+	addr := multiaddr.StringCast("/ip4/198.51.100.7/tcp/4100")
+	info := peerstore.AddrInfo{ID: expected, Addrs: []multiaddr.Multiaddr{addr}}
+
+	g := newConnectionGater(true)
+	g.expectBootstrap(info)
+	g.forgetBootstrap(info)
+
+	if len(g.expected) != 0 {
+		t.Fatalf("pins left after the dial: %v", g.expected)
+	}
+	if !g.InterceptAddrDial(other, addr) {
+		t.Fatal("a finished bootstrap dial still pins its address")
+	}
+}
+
+func TestGaterRefusesBootstrapPeerIDMismatch(t *testing.T) {
+	_, expected := newTestPeer(t)
+	_, other := newTestPeer(t)
+	addr := multiaddr.StringCast("/ip4/198.51.100.7/tcp/4100")
+
+	g := newConnectionGater(true)
+	g.expectBootstrap(peerstore.AddrInfo{ID: expected, Addrs: []multiaddr.Multiaddr{addr}})
+
+	if g.InterceptAddrDial(other, addr) {
+		t.Fatal("dialed a bootstrap address for a peer other than the one it was resolved for")
+	}
+	if !g.InterceptAddrDial(expected, addr) {
+		t.Fatal("refused the expected bootstrap peer")
+	}
+
+	outbound := newFakeConn(addr.String(), libp2pnet.DirOutbound, time.Now())
+	if g.InterceptSecured(libp2pnet.DirOutbound, other, outbound) {
+		t.Fatal("secured a bootstrap connection that presented the wrong peer ID")
+	}
+	if !g.InterceptSecured(libp2pnet.DirOutbound, expected, outbound) {
+		t.Fatal("refused the expected bootstrap peer after the handshake")
+	}
+	// Inbound connections from that address are not bootstrap dials.
+	inbound := newFakeConn(addr.String(), libp2pnet.DirInbound, time.Now())
+	if !g.InterceptSecured(libp2pnet.DirInbound, other, inbound) {
+		t.Fatal("refused an inbound connection from a bootstrap address")
+	}
+}
+
+func TestResolveMergesRegisteredSource(t *testing.T) {
+	_, shared := newTestPeer(t)
+	_, seeded := newTestPeer(t)
+	static := "/ip4/198.51.100.7/tcp/4100/p2p/" + shared.String()
+
+	resolver := newTestResolver(t, config.Config{InitConnections: []config.Connection{
+		{Type: "multiaddr", Address: static},
+		{Type: "HTTP", Address: "https://seeds.example/list"},
+		{Type: "unknown", Address: "ignored"},
+	}})
+	var asked []string
+	resolver.Register("http", SourceResolverFunc(func(_ context.Context, conn config.Connection) ([]peerstore.AddrInfo, error) {
+		asked = append(asked, conn.Address)
+		return []peerstore.AddrInfo{
+			{ID: shared, Addrs: []multiaddr.Multiaddr{multiaddr.StringCast("/ip4/203.0.113.5/udp/4100/quic-v1")}},
+			{ID: seeded, Addrs: []multiaddr.Multiaddr{multiaddr.StringCast("/ip4/203.0.113.6/tcp/4100")}},
+		}, nil
+	}))
+
+	peers, err := resolver.Resolve(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(asked) != 1 || asked[0] != "https://seeds.example/list" {
+		t.Fatalf("custom resolver asked for %v", asked)
+	}
+	byID := make(map[peerstore.ID]peerstore.AddrInfo)
+	for _, info := range peers {
+		byID[info.ID] = info
+	}
+	if len(byID) != 2 {
+		t.Fatalf("resolved %v, want the static peer and the seeded one", peers)
+	}
+	if addrs := byID[shared].Addrs; len(addrs) != 2 {
+		t.Fatalf("addresses for the peer both sources named: %v, want them merged", addrs)
+	}
+}
+
+func TestResolveRequireSignedBootstrap(t *testing.T) {
+	systemKey, _ := newTestPeer(t)
+	pub, err := crypto.MarshalPublicKey(systemKey.GetPublic())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, signed := newTestPeer(t)
+	_, tampered := newTestPeer(t)
+	_, unsigned := newTestPeer(t)
+	addr := "/ip4/198.51.100.7/tcp/4100/p2p/"
+
+	sign := func(id peerstore.ID) config.Connection {
+		entry, err := membership.SignBootstrapEntry(systemKey, membership.BootstrapEntry{
+			ClusterID: "test", Type: "multiaddr", Address: addr + id.String(), ExpectedPeerID: id.String(),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return config.Connection{Type: entry.Type, Address: entry.Address, ExpectedPeerID: entry.ExpectedPeerID, Sig: entry.Sig}
+	}
+	// The tampered entry keeps a valid signature but points elsewhere.
+	redirected := sign(tampered)
+	redirected.Address = "/ip4/203.0.113.9/tcp/4100/p2p/" + tampered.String()
+
+	resolver := newTestResolver(t, config.Config{
+		ClusterID:              "test",
+		SystemPubKey:           base64.StdEncoding.EncodeToString(pub),
+		RequireSignedBootstrap: true,
+		InitConnections: []config.Connection{
+			sign(signed),
+			redirected,
+			{Type: "multiaddr", Address: addr + unsigned.String(), ExpectedPeerID: unsigned.String()},
+		},
+	})
+	peers, err := resolver.Resolve(context.Background())
+	if err == nil {
+		t.Fatal("tampered and unsigned entries resolved without error")
+	}
+	if len(peers) != 1 || peers[0].ID != signed {
+		t.Fatalf("resolved %v, want only the signed peer", peers)
+	}
+}
+
+func TestDNSAddrRecordParsesBack(t *testing.T) {
+	_, id := newTestPeer(t)
+	for _, raw := range []string{
+		"/ip4/203.0.113.7/tcp/4001",
+		"/dns/boot.example.com/udp/4001/quic-v1",
+		"/ip4/203.0.113.7/tcp/4001/p2p/" + id.String(),
+	} {
+		addr, err := multiaddr.NewMultiaddr(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		record, err := DNSAddrRecord(addr, id)
+		if err != nil {
+			t.Fatalf("record for %s: %v", raw, err)
+		}
+		values := parseTXTRecordValues(record)
+		if len(values) != 1 {
+			t.Fatalf("record %q parsed to %v", record, values)
+		}
+		info, err := ParseP2PAddr(values[0])
+		if err != nil {
+			t.Fatalf("record %q: %v", record, err)
+		}
+		if info.ID != id || len(info.Addrs) != 1 {
+			t.Fatalf("record %q parsed to %+v", record, info)
+		}
+		if want := strings.TrimSuffix(raw, "/p2p/"+id.String()); info.Addrs[0].String() != want {
+			t.Fatalf("record %q addr %s, want %s", record, info.Addrs[0], want)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package network
+
+import (
+	"fmt"
+	"net/netip"
+	"strconv"
+
+	"p2pos/internal/logging"
+
+	libp2pnet "github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	multiaddr "github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+)
+
+// businessListener is one business_listen socket. An unspecified IP matches
+// any local address on the same transport and port.
+type businessListener struct {
+	ip    netip.Addr
+	any   bool
+	proto int
+	port  int
+}
+
+func parseBusinessListeners(addrs []string) ([]businessListener, error) {
+	out := make([]businessListener, 0, len(addrs))
+	for _, raw := range addrs {
+		addr, err := multiaddr.NewMultiaddr(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid business listen address %q: %w", raw, err)
+		}
+		l, ok := businessListenerOf(addr)
+		if !ok {
+			return nil, fmt.Errorf("business listen address %q has no ip and tcp/udp port", raw)
+		}
+		if l.port == 0 {
+			// A random port cannot be told apart from the main listeners.
+			return nil, fmt.Errorf("business listen address %q needs a fixed port", raw)
+		}
+		l.any = l.ip.IsUnspecified()
+		out = append(out, l)
+	}
+	return out, nil
+}
+
+func businessListenerOf(addr multiaddr.Multiaddr) (businessListener, bool) {
+	ip, err := manet.ToIP(addr)
+	if err != nil {
+		return businessListener{}, false
+	}
+	parsed, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return businessListener{}, false
+	}
+	l := businessListener{ip: parsed.Unmap()}
+	for _, code := range []int{multiaddr.P_TCP, multiaddr.P_UDP} {
+		value, err := addr.ValueForProtocol(code)
+		if err != nil {
+			continue
+		}
+		port, err := strconv.Atoi(value)
+		if err != nil {
+			return businessListener{}, false
+		}
+		l.proto, l.port = code, port
+		return l, true
+	}
+	return businessListener{}, false
+}
+
+func (n *Node) onBusinessListener(local multiaddr.Multiaddr) bool {
+	got, ok := businessListenerOf(local)
+	if !ok {
+		return false
+	}
+	for _, l := range n.businessListeners {
+		if l.proto != got.proto || l.port != got.port {
+			continue
+		}
+		if l.any || l.ip == got.ip {
+			return true
+		}
+	}
+	return false
+}
+
+// allowBusinessStream refuses cluster protocols on connections that arrived
+// on a non-business listener. Connections we dialed are always allowed.
+func (n *Node) allowBusinessStream(stream libp2pnet.Stream) bool {
+	if len(n.businessListeners) == 0 {
+		return true
+	}
+	conn := stream.Conn()
+	if conn.Stat().Direction != libp2pnet.DirInbound {
+		return true
+	}
+	return n.onBusinessListener(conn.LocalMultiaddr())
+}
+
+// setBusinessHandler registers a cluster protocol handler that only serves
+// streams allowed by allowBusinessStream.
+func (n *Node) setBusinessHandler(id protocol.ID, handler libp2pnet.StreamHandler) {
+	n.setCountedHandler(id, func(stream libp2pnet.Stream) {
+		if !n.allowBusinessStream(stream) {
+			logging.Log("NODE", "business_stream_refused", map[string]string{
+				"peer_id":  stream.Conn().RemotePeer().String(),
+				"protocol": string(id),
+				"local":    stream.Conn().LocalMultiaddr().String(),
+			})
+			_ = stream.Reset()
+			return
+		}
+		handler(stream)
+	})
+}
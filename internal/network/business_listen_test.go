@@ -0,0 +1,56 @@
+package network
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	libp2pnet "github.com/libp2p/go-libp2p/core/network"
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+func TestBusinessStreamRefusedOnPublicListener(t *testing.T) {
+	const id = protocol.ID("/p2pos/test/1.0.0")
+	server := &Node{Host: newTestHost(t)}
+	server.setBusinessHandler(id, func(stream libp2pnet.Stream) {
+		defer stream.Close()
+		_, _ = stream.Write([]byte("ok"))
+	})
+	client := newTestHost(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Connect(ctx, peerstore.AddrInfo{ID: server.Host.ID(), Addrs: server.Host.Addrs()}); err != nil {
+		t.Fatal(err)
+	}
+	request := func() error {
+		stream, err := client.NewStream(ctx, server.Host.ID(), id)
+		if err != nil {
+			return err
+		}
+		defer stream.Close()
+		_, err = io.ReadAll(stream)
+		return err
+	}
+
+	// The client came in on the main listener; business traffic is only
+	// served on a separate port.
+	listeners, err := parseBusinessListeners([]string{"/ip4/127.0.0.1/tcp/1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.businessListeners = listeners
+	if err := request(); err == nil {
+		t.Fatal("business stream served on the public listener")
+	}
+
+	listeners, err = parseBusinessListeners([]string{server.Host.Addrs()[0].String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.businessListeners = listeners
+	if err := request(); err != nil {
+		t.Fatalf("business stream refused on the business listener: %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+package network
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	"p2pos/internal/events"
+	"p2pos/internal/logging"
+
+	p2pforge "github.com/ipshipyard/p2p-forge/client"
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multibase"
+)
+
+const (
+	certWatchInterval = time.Hour
+	// certRenewalAlertChecks consecutive checks with an overdue certificate
+	// raise CertRenewalFailing.
+	certRenewalAlertChecks = 3
+)
+
+// startCertWatcher periodically inspects the certificate the AutoTLS listener
+// serves. The listener resolves certificates per handshake through
+// GetCertificate, so renewals take effect without a restart; this watcher only
+// logs renewals and alerts when renewal appears stuck. It runs once, from
+// the first time AutoTLS is attached.
+func (n *Node) startCertWatcher() {
+	mgr := n.currentAutoTLS()
+	if mgr == nil {
+		return
+	}
+	n.certWatchOnce.Do(func() { n.watchCert(mgr) })
+}
+
+func (n *Node) watchCert(mgr *p2pforge.P2PForgeCertMgr) {
+	getCert := mgr.TLSConfig().GetCertificate
+	serverName := "probe." + forgePeerDomain(n.Host.ID())
+
+	go func() {
+		ticker := time.NewTicker(certWatchInterval)
+		defer ticker.Stop()
+
+		var lastNotAfter time.Time
+		overdue := 0
+		for {
+			select {
+			case <-n.lifecycle.Done():
+				return
+			case <-ticker.C:
+			}
+
+			leaf, err := servedCertificate(getCert, serverName)
+			if err != nil {
+				// No certificate yet (first issuance still pending).
+				continue
+			}
+			if !lastNotAfter.IsZero() && leaf.NotAfter.After(lastNotAfter) {
+				logging.Log("NODE", "autotls_cert_renewed", map[string]string{
+					"not_after": leaf.NotAfter.UTC().Format(time.RFC3339),
+				})
+			}
+			lastNotAfter = leaf.NotAfter
+
+			if !certRenewalOverdue(leaf, time.Now()) {
+				overdue = 0
+				continue
+			}
+			overdue++
+			if overdue < certRenewalAlertChecks {
+				continue
+			}
+			remaining := time.Until(leaf.NotAfter)
+			logging.Log("NODE", "autotls_renewal_failing", map[string]string{
+				"not_after": leaf.NotAfter.UTC().Format(time.RFC3339),
+				"remaining": remaining.Round(time.Minute).String(),
+				"checks":    fmt.Sprintf("%d", overdue),
+			})
+			if n.bus != nil {
+				n.bus.Publish(events.CertRenewalFailing{
+					NotAfter:  leaf.NotAfter.UTC(),
+					Remaining: remaining,
+					Checks:    overdue,
+					At:        time.Now().UTC(),
+				})
+			}
+		}
+	}()
+}
+
+func servedCertificate(getCert func(*tls.ClientHelloInfo) (*tls.Certificate, error), serverName string) (*x509.Certificate, error) {
+	if getCert == nil {
+		return nil, fmt.Errorf("no certificate callback")
+	}
+	cert, err := getCert(&tls.ClientHelloInfo{ServerName: serverName})
+	if err != nil {
+		return nil, err
+	}
+	if cert == nil || len(cert.Certificate) == 0 {
+		return nil, fmt.Errorf("no certificate")
+	}
+	if cert.Leaf != nil {
+		return cert.Leaf, nil
+	}
+	return x509.ParseCertificate(cert.Certificate[0])
+}
+
+// certRenewalOverdue reports whether less than a quarter of the certificate
+// lifetime remains. certmagic renews at one third, so reaching a quarter
+// means renewals have been failing for a while.
+func certRenewalOverdue(leaf *x509.Certificate, now time.Time) bool {
+	lifetime := leaf.NotAfter.Sub(leaf.NotBefore)
+	if lifetime <= 0 {
+		return true
+	}
+	return leaf.NotAfter.Sub(now) < lifetime/4
+}
+
+func forgePeerDomain(id peerstore.ID) string {
+	cid36 := peerstore.ToCid(id).Encode(multibase.MustNewEncoder(multibase.Base36))
+	return cid36 + "." + p2pforge.DefaultForgeDomain
+}
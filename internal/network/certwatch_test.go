@@ -0,0 +1,107 @@
+package network
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"math/big"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestCert(t *testing.T, notBefore, notAfter time.Time) *tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(notAfter.Unix()),
+		DNSNames:     []string{"probe.example.libp2p.direct"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// handshakeNotAfter completes a TLS handshake against cfg and returns the
+// expiry of the certificate the server presented.
+func handshakeNotAfter(t *testing.T, cfg *tls.Config) time.Time {
+	t.Helper()
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+	go func() {
+		_ = tls.Server(serverConn, cfg).Handshake()
+	}()
+	client := tls.Client(clientConn, &tls.Config{ServerName: "probe.example.libp2p.direct", InsecureSkipVerify: true})
+	if err := client.Handshake(); err != nil {
+		t.Fatal(err)
+	}
+	return client.ConnectionState().PeerCertificates[0].NotAfter
+}
+
+func TestListenerServesRenewedCertificate(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	var current atomic.Pointer[tls.Certificate]
+	current.Store(newTestCert(t, now.Add(-80*24*time.Hour), now.Add(10*24*time.Hour)))
+	// What the forge manager hands the WebSocket transport: a config that
+	// resolves the certificate per handshake.
+	base := &tls.Config{GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+		return current.Load(), nil
+	}}
+	policy, err := parseTLSPolicy("1.2", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := policy.apply(base)
+
+	if got := handshakeNotAfter(t, cfg); !got.Equal(now.Add(10 * 24 * time.Hour)) {
+		t.Fatalf("served certificate expires %s", got)
+	}
+	renewed := now.Add(90 * 24 * time.Hour)
+	current.Store(newTestCert(t, now, renewed))
+	if got := handshakeNotAfter(t, cfg); !got.Equal(renewed) {
+		t.Fatalf("after renewal the listener served a certificate expiring %s, want %s", got, renewed)
+	}
+
+	leaf, err := servedCertificate(cfg.GetCertificate, "probe.example.libp2p.direct")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !leaf.NotAfter.Equal(renewed) {
+		t.Fatalf("watcher saw a certificate expiring %s, want %s", leaf.NotAfter, renewed)
+	}
+}
+
+func TestCertRenewalOverdue(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		remaining time.Duration
+		overdue   bool
+	}{
+		{60 * 24 * time.Hour, false},
+		{30 * 24 * time.Hour, false},
+		{22 * 24 * time.Hour, true},
+		{-time.Hour, true},
+	}
+	for _, tc := range cases {
+		// A 90 day certificate, as Let's Encrypt issues.
+		notAfter := now.Add(tc.remaining)
+		leaf := &x509.Certificate{NotBefore: notAfter.Add(-90 * 24 * time.Hour), NotAfter: notAfter}
+		if got := certRenewalOverdue(leaf, now); got != tc.overdue {
+			t.Errorf("%s remaining: overdue=%v, want %v", tc.remaining, got, tc.overdue)
+		}
+	}
+	if _, err := servedCertificate(nil, "probe"); err == nil {
+		t.Error("no certificate callback reported a certificate")
+	}
+}
@@ -0,0 +1,112 @@
+package network
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemberClock is the last observed clock offset of one member, derived from
+// its signed heartbeat timestamp. A positive offset means the member is ahead.
+type MemberClock struct {
+	PeerID    string    `json:"peer_id"`
+	OffsetMs  float64   `json:"offset_ms"`
+	SampledAt time.Time `json:"sampled_at"`
+	AtRisk    bool      `json:"at_risk"`
+}
+
+// ClockHealth reports clock agreement across members. Members whose offset
+// exceeds half the heartbeat window are flagged AtRisk: their heartbeats are
+// about to fall outside the window and be rejected.
+type ClockHealth struct {
+	GeneratedAt time.Time     `json:"generated_at"`
+	WindowMs    float64       `json:"window_ms"`
+	MaxSkewMs   float64       `json:"max_skew_ms"`
+	Members     []MemberClock `json:"members"`
+}
+
+type clockSample struct {
+	offset time.Duration
+	at     time.Time
+}
+
+type clockTracker struct {
+	mu      sync.Mutex
+	samples map[string]clockSample
+}
+
+func newClockTracker() *clockTracker {
+	return &clockTracker{samples: make(map[string]clockSample)}
+}
+
+func (t *clockTracker) record(peerID string, remote, local time.Time) {
+	t.mu.Lock()
+	t.samples[peerID] = clockSample{offset: remote.Sub(local), at: local}
+	t.mu.Unlock()
+}
+
+func (t *clockTracker) forget(peerID string) {
+	t.mu.Lock()
+	delete(t.samples, peerID)
+	t.mu.Unlock()
+}
+
+func (t *clockTracker) snapshot() map[string]clockSample {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make(map[string]clockSample, len(t.samples))
+	for id, sample := range t.samples {
+		out[id] = sample
+	}
+	return out
+}
+
+func (n *Node) ClockHealth(_ context.Context) (ClockHealth, error) {
+	window := n.heartbeatWindow
+	if window <= 0 {
+		window = defaultHeartbeatWindow
+	}
+	return computeClockHealth(n.clocks.snapshot(), n.isMember, window, time.Now().UTC()), nil
+}
+
+func computeClockHealth(samples map[string]clockSample, isMember func(string) bool, window time.Duration, now time.Time) ClockHealth {
+	health := ClockHealth{
+		GeneratedAt: now,
+		WindowMs:    durationMs(window),
+		Members:     make([]MemberClock, 0, len(samples)),
+	}
+	// The local clock is the reference, so the spread always includes zero.
+	minOffset, maxOffset := time.Duration(0), time.Duration(0)
+	for id, sample := range samples {
+		if !isMember(id) {
+			continue
+		}
+		offset := sample.offset
+		if offset < minOffset {
+			minOffset = offset
+		}
+		if offset > maxOffset {
+			maxOffset = offset
+		}
+		abs := offset
+		if abs < 0 {
+			abs = -abs
+		}
+		health.Members = append(health.Members, MemberClock{
+			PeerID:    id,
+			OffsetMs:  durationMs(offset),
+			SampledAt: sample.at,
+			AtRisk:    abs > window/2,
+		})
+	}
+	sort.Slice(health.Members, func(i, j int) bool {
+		return health.Members[i].PeerID < health.Members[j].PeerID
+	})
+	health.MaxSkewMs = durationMs(maxOffset - minOffset)
+	return health
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000.0
+}
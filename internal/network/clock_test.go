@@ -0,0 +1,50 @@
+package network
+
+import (
+	"testing"
+	"time"
+)
+
+func TestComputeClockHealthFromSkewedHeartbeats(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	clocks := newClockTracker()
+	// Heartbeat timestamps as signed by each peer, received at now.
+	clocks.record("ahead", now.Add(90*time.Second), now)
+	clocks.record("behind", now.Add(-40*time.Second), now)
+	clocks.record("synced", now.Add(200*time.Millisecond), now)
+	clocks.record("stranger", now.Add(time.Hour), now)
+	clocks.record("gone", now.Add(3*time.Minute), now)
+	clocks.forget("gone")
+
+	members := map[string]bool{"ahead": true, "behind": true, "synced": true, "gone": true}
+	health := computeClockHealth(clocks.snapshot(), func(id string) bool { return members[id] }, 2*time.Minute, now)
+
+	if health.WindowMs != 120000 {
+		t.Fatalf("window %vms, want 120000", health.WindowMs)
+	}
+	if health.MaxSkewMs != 130000 {
+		t.Fatalf("max skew %vms, want the 130s between ahead and behind", health.MaxSkewMs)
+	}
+	want := []MemberClock{
+		{PeerID: "ahead", OffsetMs: 90000, SampledAt: now, AtRisk: true},
+		{PeerID: "behind", OffsetMs: -40000, SampledAt: now},
+		{PeerID: "synced", OffsetMs: 200, SampledAt: now},
+	}
+	if len(health.Members) != len(want) {
+		t.Fatalf("members %+v, want %+v", health.Members, want)
+	}
+	for i := range want {
+		if health.Members[i] != want[i] {
+			t.Fatalf("member %d: %+v, want %+v", i, health.Members[i], want[i])
+		}
+	}
+}
+
+func TestComputeClockHealthIncludesLocalClock(t *testing.T) {
+	now := time.Now().UTC()
+	samples := map[string]clockSample{"ahead": {offset: 5 * time.Second, at: now}}
+	health := computeClockHealth(samples, func(string) bool { return true }, time.Minute, now)
+	if health.MaxSkewMs != 5000 {
+		t.Fatalf("max skew %vms, want 5000 against the local clock", health.MaxSkewMs)
+	}
+}
@@ -0,0 +1,51 @@
+package network
+
+import (
+	"p2pos/internal/logging"
+	"p2pos/internal/status"
+
+	libp2pnet "github.com/libp2p/go-libp2p/core/network"
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+)
+
+// connInfo describes what a connection negotiated. It helps explain interop
+// failures, e.g. a browser peer that only speaks some muxer/security pairs.
+func connInfo(conn libp2pnet.Conn) *status.ConnInfo {
+	state := conn.ConnState()
+	return &status.ConnInfo{
+		Transport: state.Transport,
+		Security:  string(state.Security),
+		Muxer:     string(state.StreamMultiplexer),
+	}
+}
+
+// localConnInfo reports the oldest open connection to peerID, which is the
+// one libp2p prefers for new streams, or nil when not connected.
+func (n *Node) localConnInfo(peerID string) *status.ConnInfo {
+	id, err := peerstore.Decode(peerID)
+	if err != nil || id == n.Host.ID() {
+		return nil
+	}
+	conns := n.Host.Network().ConnsToPeer(id)
+	if len(conns) == 0 {
+		return nil
+	}
+	oldest := conns[0]
+	for _, conn := range conns[1:] {
+		if conn.Stat().Opened.Before(oldest.Stat().Opened) {
+			oldest = conn
+		}
+	}
+	return connInfo(oldest)
+}
+
+func logConnNegotiated(conn libp2pnet.Conn) {
+	info := connInfo(conn)
+	logging.Log("NODE", "conn_negotiated", map[string]string{
+		"peer_id":   conn.RemotePeer().String(),
+		"direction": conn.Stat().Direction.String(),
+		"transport": info.Transport,
+		"security":  info.Security,
+		"muxer":     info.Muxer,
+	})
+}
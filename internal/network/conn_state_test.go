@@ -0,0 +1,30 @@
+package network
+
+import (
+	"context"
+	"testing"
+
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestLocalConnInfoReportsNegotiatedProtocols(t *testing.T) {
+	n := &Node{Host: newTestHost(t)}
+	other := newTestHost(t)
+	if info := n.localConnInfo(other.ID().String()); info != nil {
+		t.Fatalf("conn info %+v before connecting", info)
+	}
+	if err := n.Host.Connect(context.Background(), peerstore.AddrInfo{ID: other.ID(), Addrs: other.Addrs()}); err != nil {
+		t.Fatal(err)
+	}
+
+	info := n.localConnInfo(other.ID().String())
+	if info == nil {
+		t.Fatal("no conn info for a connected peer")
+	}
+	if info.Transport != "tcp" || info.Security == "" || info.Muxer == "" {
+		t.Fatalf("conn info %+v, want tcp with a security protocol and muxer", info)
+	}
+	if self := n.localConnInfo(n.Host.ID().String()); self != nil {
+		t.Fatalf("conn info %+v for ourselves", self)
+	}
+}
@@ -0,0 +1,35 @@
+package network
+
+import (
+	"testing"
+
+	"p2pos/internal/status"
+)
+
+func TestOnlineClaimNeedsSecondObserver(t *testing.T) {
+	const local, target = "local", "target"
+	claim := []status.Record{{PeerID: target, Reachability: "online"}}
+	merged := func(reporters map[string]map[string]struct{}) string {
+		records := requireCorroboration([]status.Record{{PeerID: target, Reachability: "online"}}, reporters, local, 2)
+		return records[0].Reachability
+	}
+
+	reporters := collectOnlineReporters(nil, local, nil)
+	reporters = collectOnlineReporters(reporters, "observer-1", claim)
+	if got := merged(reporters); got != reachabilityReported {
+		t.Fatalf("one observer's claim shown as %q, want %q", got, reachabilityReported)
+	}
+
+	reporters = collectOnlineReporters(reporters, "observer-2", claim)
+	if got := merged(reporters); got != "online" {
+		t.Fatalf("corroborated claim shown as %q, want online", got)
+	}
+
+	own := collectOnlineReporters(nil, local, claim)
+	if got := merged(own); got != "online" {
+		t.Fatalf("local observation shown as %q, want online", got)
+	}
+	if records := requireCorroboration([]status.Record{{PeerID: target, Reachability: "online"}}, nil, local, 1); records[0].Reachability != "online" {
+		t.Fatalf("min observers 1 downgraded a claim: %+v", records[0])
+	}
+}
@@ -0,0 +1,67 @@
+package network
+
+import (
+	"testing"
+	"time"
+
+	"p2pos/internal/membership"
+)
+
+func TestStateDiagnosticConditions(t *testing.T) {
+	h := newTestHost(t)
+	local := h.ID().String()
+	_, a := newTestPeer(t)
+	_, b := newTestPeer(t)
+
+	manager := func(members ...string) *membership.Manager {
+		m, err := membership.NewManager("test", "", local, members)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+	cases := []struct {
+		name    string
+		node    func() *Node
+		state   RuntimeState
+		reason  string
+		online  int
+		held    int
+		members int
+	}{
+		{"no membership", func() *Node {
+			return &Node{Host: h}
+		}, RuntimeStateUnconfigured, "membership-nil", 0, 0, 0},
+		{"not a member", func() *Node {
+			return &Node{Host: h, membership: manager(a.String(), b.String())}
+		}, RuntimeStateUnconfigured, "local-not-member", 0, 0, 2},
+		{"alone", func() *Node {
+			return &Node{Host: h, membership: manager(local), quorumHold: newQuorumHold(0)}
+		}, RuntimeStateHealthy, "quorum", 1, 0, 1},
+		{"short of quorum", func() *Node {
+			return &Node{Host: h, membership: manager(local, a.String(), b.String()), quorumHold: newQuorumHold(0)}
+		}, RuntimeStateDegraded, "no-quorum", 1, 0, 3},
+		{"startup grace", func() *Node {
+			return &Node{Host: h, membership: manager(local, a.String(), b.String()), quorumHold: newQuorumHold(0), startupGraceUntil: time.Now().Add(time.Minute)}
+		}, RuntimeStateStarting, "startup-grace", 1, 0, 3},
+		{"resumed", func() *Node {
+			n := &Node{Host: h, membership: manager(local, a.String(), b.String()), quorumHold: newQuorumHold(0), startupGraceUntil: time.Now().Add(time.Minute)}
+			n.resuming.Store(true)
+			return n
+		}, RuntimeStateRecovering, "resumed", 1, 0, 3},
+		{"held member", func() *Node {
+			n := &Node{Host: h, membership: manager(local, a.String(), b.String()), quorumHold: newQuorumHold(time.Minute)}
+			n.quorumHold.start(a.String(), time.Now())
+			return n
+		}, RuntimeStateHealthy, "quorum", 1, 1, 3},
+	}
+	for _, tc := range cases {
+		diag := tc.node().StateDiagnostic()
+		if diag.State != tc.state || diag.Reason != tc.reason {
+			t.Errorf("%s: %s/%s, want %s/%s", tc.name, diag.State, diag.Reason, tc.state, tc.reason)
+		}
+		if diag.OnlineMembers != tc.online || diag.HeldMembers != tc.held || diag.MemberCount != tc.members {
+			t.Errorf("%s: online %d held %d members %d, want %d/%d/%d", tc.name, diag.OnlineMembers, diag.HeldMembers, diag.MemberCount, tc.online, tc.held, tc.members)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"p2pos/internal/logging"
+
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+)
+
+// errDialThrottled is returned by dialPeer when backoff or an open breaker
+// suppresses the dial.
+var errDialThrottled = errors.New("dial throttled")
+
+const (
+	breakerClosed   = "closed"
+	breakerOpen     = "open"
+	breakerHalfOpen = "half-open"
+)
+
+// dialBreaker throttles redials to peers that keep failing. Each failure
+// doubles the wait before the next attempt, up to maxBackoff. After
+// threshold consecutive failures the breaker opens and no dials happen for
+// cooldown; then one probe is let through (half-open) and either closes the
+// breaker or reopens it. A successful connection, by any path, resets it.
+type dialBreaker struct {
+	mu         sync.Mutex
+	base       time.Duration
+	maxBackoff time.Duration
+	threshold  int
+	cooldown   time.Duration
+	peers      map[string]*breakerEntry
+}
+
+type breakerEntry struct {
+	state       string
+	failures    int
+	nextAttempt time.Time
+	probing     bool
+}
+
+// DialBreakerState is the breaker view of one peer, exposed in status.
+type DialBreakerState struct {
+	State       string    `json:"state"`
+	Failures    int       `json:"failures"`
+	NextAttempt time.Time `json:"next_attempt"`
+}
+
+func newDialBreaker(base, maxBackoff time.Duration, threshold int, cooldown time.Duration) *dialBreaker {
+	return &dialBreaker{
+		base:       base,
+		maxBackoff: maxBackoff,
+		threshold:  threshold,
+		cooldown:   cooldown,
+		peers:      make(map[string]*breakerEntry),
+	}
+}
+
+func (b *dialBreaker) allow(peerID string, now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	entry, ok := b.peers[peerID]
+	if !ok {
+		return true
+	}
+	if now.Before(entry.nextAttempt) {
+		return false
+	}
+	switch entry.state {
+	case breakerOpen:
+		entry.state = breakerHalfOpen
+		entry.probing = true
+		return true
+	case breakerHalfOpen:
+		if entry.probing {
+			return false
+		}
+		entry.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+func (b *dialBreaker) failure(peerID string, now time.Time) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	entry, ok := b.peers[peerID]
+	if !ok {
+		entry = &breakerEntry{state: breakerClosed}
+		b.peers[peerID] = entry
+	}
+	entry.failures++
+	entry.probing = false
+	if entry.state == breakerHalfOpen || (b.threshold > 0 && entry.failures >= b.threshold) {
+		entry.state = breakerOpen
+		entry.nextAttempt = now.Add(b.cooldown)
+		return entry.state
+	}
+	backoff := b.base
+	for i := 1; i < entry.failures && backoff < b.maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > b.maxBackoff {
+		backoff = b.maxBackoff
+	}
+	entry.nextAttempt = now.Add(backoff)
+	return entry.state
+}
+
+func (b *dialBreaker) success(peerID string) {
+	b.mu.Lock()
+	delete(b.peers, peerID)
+	b.mu.Unlock()
+}
+
+func (b *dialBreaker) state(peerID string) (DialBreakerState, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	entry, ok := b.peers[peerID]
+	if !ok {
+		return DialBreakerState{}, false
+	}
+	return DialBreakerState{
+		State:       entry.state,
+		Failures:    entry.failures,
+		NextAttempt: entry.nextAttempt.UTC(),
+	}, true
+}
+
+// dialPeer connects to info unless its breaker says to wait, and records the
+// outcome.
+func (n *Node) dialPeer(ctx context.Context, info peerstore.AddrInfo) error {
+	id := info.ID.String()
+	if !n.dialBreaker.allow(id, time.Now()) {
+		return errDialThrottled
+	}
+	if err := n.Connect(ctx, info); err != nil {
+		state := n.dialBreaker.failure(id, time.Now())
+		if state == breakerOpen {
+			if s, ok := n.dialBreaker.state(id); ok {
+				logging.Log("NODE", "dial_breaker_open", map[string]string{
+					"peer_id":  id,
+					"failures": fmt.Sprintf("%d", s.Failures),
+					"until":    s.NextAttempt.Format(time.RFC3339),
+				})
+			}
+		}
+		return err
+	}
+	n.dialBreaker.success(id)
+	return nil
+}
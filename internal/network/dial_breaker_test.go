@@ -0,0 +1,78 @@
+package network
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+	multiaddr "github.com/multiformats/go-multiaddr"
+)
+
+func TestDialBreakerOpensAfterThreshold(t *testing.T) {
+	b := newDialBreaker(time.Second, 4*time.Second, 3, time.Minute)
+	now := time.Unix(1000, 0)
+
+	if got := b.failure("p", now); got != breakerClosed {
+		t.Fatalf("state after 1 failure = %s, want closed", got)
+	}
+	if b.allow("p", now.Add(500*time.Millisecond)) {
+		t.Fatal("dial allowed inside first backoff")
+	}
+	if !b.allow("p", now.Add(time.Second)) {
+		t.Fatal("dial refused after first backoff")
+	}
+	b.failure("p", now.Add(time.Second))
+	if got := b.failure("p", now.Add(3*time.Second)); got != breakerOpen {
+		t.Fatalf("state after 3 failures = %s, want open", got)
+	}
+	if b.allow("p", now.Add(30*time.Second)) {
+		t.Fatal("dial allowed while breaker open")
+	}
+
+	probeAt := now.Add(3*time.Second + time.Minute)
+	if !b.allow("p", probeAt) {
+		t.Fatal("half-open probe refused after cooldown")
+	}
+	if b.allow("p", probeAt) {
+		t.Fatal("second half-open probe allowed while first is in flight")
+	}
+	b.success("p")
+	if _, ok := b.state("p"); ok {
+		t.Fatal("success did not reset the breaker")
+	}
+}
+
+func TestDialPeerThrottlesDeadPeer(t *testing.T) {
+	n := &Node{
+		Host:        newTestHost(t),
+		dialBreaker: newDialBreaker(time.Minute, time.Minute, 2, time.Hour),
+	}
+	priv, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := peerstore.IDFromPrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dead := peerstore.AddrInfo{ID: id, Addrs: []multiaddr.Multiaddr{multiaddr.StringCast("/ip4/127.0.0.1/tcp/1")}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := n.dialPeer(ctx, dead); err == nil || errors.Is(err, errDialThrottled) {
+		t.Fatalf("first dial: got %v, want a connection error", err)
+	}
+	for i := 0; i < 5; i++ {
+		if err := n.dialPeer(ctx, dead); !errors.Is(err, errDialThrottled) {
+			t.Fatalf("redial %d: got %v, want errDialThrottled", i, err)
+		}
+	}
+	s, ok := n.dialBreaker.state(id.String())
+	if !ok || s.Failures != 1 {
+		t.Fatalf("breaker state = %+v, want exactly one recorded failure", s)
+	}
+}
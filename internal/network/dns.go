@@ -7,17 +7,54 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
+
+	"p2pos/internal/logging"
 )
 
 type DNSResolver interface {
 	LookupTXT(domain string) ([]string, error)
 }
 
-type NetDNSResolver struct{}
+const (
+	defaultDoHTimeout = 4 * time.Second
+	defaultDNSMinTTL  = time.Minute
+)
+
+// cloudflareDoHEndpoint is a variable so tests can point it at a fake server.
+var cloudflareDoHEndpoint = "https://cloudflare-dns.com/dns-query"
+
+// DNSOptions tunes NetDNSResolver. Zero values use the defaults.
+type DNSOptions struct {
+	DoHTimeout time.Duration
+	// MinTTL is the shortest time a TXT answer is cached, regardless of the
+	// record TTL. System resolver answers carry no TTL and use MinTTL.
+	MinTTL time.Duration
+}
+
+type NetDNSResolver struct {
+	opts  DNSOptions
+	mu    sync.Mutex
+	cache map[string]dnsCacheEntry
+}
+
+type dnsCacheEntry struct {
+	records []string
+	expires time.Time
+}
 
-func NewNetDNSResolver() *NetDNSResolver {
-	return &NetDNSResolver{}
+func NewNetDNSResolver(opts DNSOptions) *NetDNSResolver {
+	if opts.DoHTimeout <= 0 {
+		opts.DoHTimeout = defaultDoHTimeout
+	}
+	if opts.MinTTL <= 0 {
+		opts.MinTTL = defaultDNSMinTTL
+	}
+	return &NetDNSResolver{
+		opts:  opts,
+		cache: make(map[string]dnsCacheEntry),
+	}
 }
 
 func (r *NetDNSResolver) LookupTXT(domain string) ([]string, error) {
@@ -26,58 +63,98 @@ func (r *NetDNSResolver) LookupTXT(domain string) ([]string, error) {
 		return nil, fmt.Errorf("empty domain")
 	}
 
+	now := time.Now()
+	r.mu.Lock()
+	cached, hasCached := r.cache[name]
+	r.mu.Unlock()
+	if hasCached && now.Before(cached.expires) {
+		return append([]string(nil), cached.records...), nil
+	}
+
+	records, ttl, err := r.lookupTXT(name)
+	if err != nil {
+		if hasCached {
+			logging.Log("BOOTSTRAP", "dns_serve_stale", map[string]string{
+				"domain": name,
+				"reason": err.Error(),
+			})
+			return append([]string(nil), cached.records...), nil
+		}
+		return nil, err
+	}
+
+	if ttl < r.opts.MinTTL {
+		ttl = r.opts.MinTTL
+	}
+	r.mu.Lock()
+	r.cache[name] = dnsCacheEntry{
+		records: append([]string(nil), records...),
+		expires: now.Add(ttl),
+	}
+	r.mu.Unlock()
+	return records, nil
+}
+
+func (r *NetDNSResolver) lookupTXT(name string) ([]string, time.Duration, error) {
 	// Prefer Cloudflare DoH to reduce stale TXT results from other recursive resolvers.
-	if records, err := lookupTXTFromCloudflare(name); err == nil && len(records) > 0 {
-		return records, nil
+	if records, ttl, err := lookupTXTFromCloudflare(name, r.opts.DoHTimeout); err == nil && len(records) > 0 {
+		return records, ttl, nil
 	}
 
 	// Fallback to system resolver.
-	return net.LookupTXT(name)
+	records, err := net.LookupTXT(name)
+	return records, 0, err
 }
 
 type dohResponse struct {
 	Status int `json:"Status"`
 	Answer []struct {
 		Type int    `json:"type"`
+		TTL  int    `json:"TTL"`
 		Data string `json:"data"`
 	} `json:"Answer"`
 }
 
-func lookupTXTFromCloudflare(name string) ([]string, error) {
-	endpoint := "https://cloudflare-dns.com/dns-query?name=" + url.QueryEscape(name) + "&type=TXT"
+// lookupTXTFromCloudflare returns the TXT values and the smallest answer TTL.
+func lookupTXTFromCloudflare(name string, timeout time.Duration) ([]string, time.Duration, error) {
+	endpoint := cloudflareDoHEndpoint + "?name=" + url.QueryEscape(name) + "&type=TXT"
 
 	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	req.Header.Set("accept", "application/dns-json")
 
 	client := &http.Client{
-		Timeout: 4 * time.Second,
+		Timeout: timeout,
 	}
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("cloudflare doh status %d", resp.StatusCode)
+		return nil, 0, fmt.Errorf("cloudflare doh status %d", resp.StatusCode)
 	}
 
 	var body dohResponse
 	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	if body.Status != 0 {
-		return nil, fmt.Errorf("cloudflare doh dns status %d", body.Status)
+		return nil, 0, fmt.Errorf("cloudflare doh dns status %d", body.Status)
 	}
 
 	out := make([]string, 0, len(body.Answer))
+	ttl := time.Duration(0)
 	for _, ans := range body.Answer {
 		if ans.Type != 16 {
 			continue
 		}
+		if answerTTL := time.Duration(ans.TTL) * time.Second; ttl == 0 || answerTTL < ttl {
+			ttl = answerTTL
+		}
 		value := strings.TrimSpace(ans.Data)
 		value = strings.TrimPrefix(value, "\"")
 		value = strings.TrimSuffix(value, "\"")
@@ -87,5 +164,5 @@ func lookupTXTFromCloudflare(name string) ([]string, error) {
 		}
 		out = append(out, value)
 	}
-	return out, nil
+	return out, ttl, nil
 }
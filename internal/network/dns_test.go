@@ -0,0 +1,78 @@
+package network
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeDoH answers every TXT query with one record and the given TTL.
+func fakeDoH(t *testing.T, ttlSeconds int) *atomic.Int32 {
+	t.Helper()
+	var queries atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries.Add(1)
+		body := dohResponse{}
+		body.Answer = append(body.Answer, struct {
+			Type int    `json:"type"`
+			TTL  int    `json:"TTL"`
+			Data string `json:"data"`
+		}{16, ttlSeconds, `"dnsaddr=/ip4/198.51.100.1/tcp/4100"`})
+		_ = json.NewEncoder(w).Encode(body)
+	}))
+	prev := cloudflareDoHEndpoint
+	cloudflareDoHEndpoint = server.URL
+	t.Cleanup(func() {
+		cloudflareDoHEndpoint = prev
+		server.Close()
+	})
+	return &queries
+}
+
+func TestLookupTXTServedFromCacheWithinTTL(t *testing.T) {
+	queries := fakeDoH(t, 300)
+	resolver := NewNetDNSResolver(DNSOptions{MinTTL: time.Millisecond})
+
+	for i := 0; i < 3; i++ {
+		records, err := resolver.LookupTXT("_p2pos.example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(records) != 1 || records[0] != "dnsaddr=/ip4/198.51.100.1/tcp/4100" {
+			t.Fatalf("records %q", records)
+		}
+	}
+	if got := queries.Load(); got != 1 {
+		t.Fatalf("%d DoH queries within the TTL, want 1", got)
+	}
+	if _, err := resolver.LookupTXT("_p2pos.example.org"); err != nil {
+		t.Fatal(err)
+	}
+	if got := queries.Load(); got != 2 {
+		t.Fatalf("another domain was served from the cache")
+	}
+}
+
+func TestLookupTXTMinTTLFloorAndExpiry(t *testing.T) {
+	queries := fakeDoH(t, 0)
+	resolver := NewNetDNSResolver(DNSOptions{MinTTL: 50 * time.Millisecond})
+
+	for i := 0; i < 2; i++ {
+		if _, err := resolver.LookupTXT("_p2pos.example.com"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := queries.Load(); got != 1 {
+		t.Fatalf("%d DoH queries, want a zero TTL raised to the floor", got)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if _, err := resolver.LookupTXT("_p2pos.example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if got := queries.Load(); got != 2 {
+		t.Fatalf("%d DoH queries after the floor expired, want 2", got)
+	}
+}
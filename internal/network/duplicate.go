@@ -0,0 +1,110 @@
+package network
+
+import (
+	"net"
+	"strings"
+	"time"
+
+	"p2pos/internal/logging"
+
+	libp2pnet "github.com/libp2p/go-libp2p/core/network"
+	multiaddr "github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+)
+
+// duplicateBackoff is how long the gater refuses a peer ID from the IP that
+// duplicated an established connection.
+const duplicateBackoff = 5 * time.Minute
+
+// refuseDuplicateConn looks for signs of a second machine using the same node
+// key: another direct inbound connection for conn's peer ID, over the same
+// transport and address family, from a different IP. That is the usual
+// symptom of a cloned VM that kept node_private_key. The established
+// connection is kept; the newer one is refused, and its IP is denied for that
+// peer ID for duplicateBackoff so the clone does not keep redialing. It
+// reports whether conn was refused.
+//
+// Relayed and limited connections are never compared, so a direct
+// connection opened by hole punching alongside a relayed one is kept. Peers
+// with several addresses of different families or transports, and NAT
+// rebinding (same IP, new port), do not match either.
+func (n *Node) refuseDuplicateConn(conn libp2pnet.Conn) bool {
+	other := duplicateConnOf(conn, n.Host.Network().ConnsToPeer(conn.RemotePeer()))
+	if other == nil {
+		return false
+	}
+	until := time.Now().Add(duplicateBackoff)
+	n.gater.denyAddr(conn.RemotePeer(), conn.RemoteMultiaddr(), until)
+	logging.Log("NODE", "duplicate_peer_id", map[string]string{
+		"peer_id":    conn.RemotePeer().String(),
+		"remote":     conn.RemoteMultiaddr().String(),
+		"existing":   other.RemoteMultiaddr().String(),
+		"resolution": "refuse_newer",
+		"until":      until.Format(time.RFC3339),
+	})
+	_ = conn.Close()
+	return true
+}
+
+// duplicateConnOf returns the first conn in others that conn duplicates, as
+// described on staleDuplicateConn.
+func duplicateConnOf(conn libp2pnet.Conn, others []libp2pnet.Conn) libp2pnet.Conn {
+	if !isDirectInbound(conn) {
+		return nil
+	}
+	ip := connRemoteIP(conn)
+	if ip == nil {
+		return nil
+	}
+	transport := connTransport(conn.RemoteMultiaddr())
+
+	for _, other := range others {
+		if other == conn || !isDirectInbound(other) {
+			continue
+		}
+		otherIP := connRemoteIP(other)
+		if otherIP == nil || (otherIP.To4() == nil) != (ip.To4() == nil) {
+			continue
+		}
+		if otherIP.Equal(ip) || connTransport(other.RemoteMultiaddr()) != transport {
+			continue
+		}
+		if other.Stat().Opened.After(conn.Stat().Opened) {
+			continue
+		}
+		return other
+	}
+	return nil
+}
+
+func isDirectInbound(conn libp2pnet.Conn) bool {
+	stat := conn.Stat()
+	if stat.Direction != libp2pnet.DirInbound || stat.Limited {
+		return false
+	}
+	_, err := conn.RemoteMultiaddr().ValueForProtocol(multiaddr.P_CIRCUIT)
+	return err != nil
+}
+
+// connTransport names the protocols of addr after the IP, without ports or
+// peer IDs: "tcp", "udp/quic-v1", "tcp/tls/ws".
+func connTransport(addr multiaddr.Multiaddr) string {
+	parts := make([]string, 0, len(addr))
+	for _, c := range addr {
+		switch c.Code() {
+		case multiaddr.P_IP4, multiaddr.P_IP6, multiaddr.P_IP6ZONE, multiaddr.P_P2P,
+			multiaddr.P_CERTHASH, multiaddr.P_SNI:
+			continue
+		}
+		parts = append(parts, c.Protocol().Name)
+	}
+	return strings.Join(parts, "/")
+}
+
+func connRemoteIP(conn libp2pnet.Conn) net.IP {
+	ip, err := manet.ToIP(conn.RemoteMultiaddr())
+	if err != nil {
+		return nil
+	}
+	return ip
+}
@@ -0,0 +1,133 @@
+package network
+
+import (
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	libp2pnet "github.com/libp2p/go-libp2p/core/network"
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+	multiaddr "github.com/multiformats/go-multiaddr"
+)
+
+type fakeConn struct {
+	libp2pnet.Conn
+	remote multiaddr.Multiaddr
+	stat   libp2pnet.ConnStats
+}
+
+func (c *fakeConn) RemoteMultiaddr() multiaddr.Multiaddr { return c.remote }
+func (c *fakeConn) Stat() libp2pnet.ConnStats            { return c.stat }
+
+func newFakeConn(addr string, dir libp2pnet.Direction, opened time.Time) *fakeConn {
+	c := &fakeConn{remote: multiaddr.StringCast(addr)}
+	c.stat.Direction = dir
+	c.stat.Opened = opened
+	return c
+}
+
+func TestDuplicateConnOf(t *testing.T) {
+	t0 := time.Unix(1000, 0)
+	t1 := t0.Add(time.Minute)
+	limited := newFakeConn("/ip4/203.0.113.9/tcp/4100", libp2pnet.DirInbound, t0)
+	limited.stat.Limited = true
+
+	cases := []struct {
+		name     string
+		existing *fakeConn
+		incoming *fakeConn
+		want     bool
+	}{
+		{
+			name:     "cloned key from another host",
+			existing: newFakeConn("/ip4/198.51.100.1/tcp/4100", libp2pnet.DirInbound, t0),
+			incoming: newFakeConn("/ip4/203.0.113.9/tcp/4100", libp2pnet.DirInbound, t1),
+			want:     true,
+		},
+		{
+			name:     "nat rebinding keeps the ip",
+			existing: newFakeConn("/ip4/198.51.100.1/tcp/4100", libp2pnet.DirInbound, t0),
+			incoming: newFakeConn("/ip4/198.51.100.1/tcp/5555", libp2pnet.DirInbound, t1),
+		},
+		{
+			name:     "dual stack",
+			existing: newFakeConn("/ip4/198.51.100.1/tcp/4100", libp2pnet.DirInbound, t0),
+			incoming: newFakeConn("/ip6/2001:db8::1/tcp/4100", libp2pnet.DirInbound, t1),
+		},
+		{
+			name:     "different transport",
+			existing: newFakeConn("/ip4/198.51.100.1/tcp/4100", libp2pnet.DirInbound, t0),
+			incoming: newFakeConn("/ip4/203.0.113.9/udp/4100/quic-v1", libp2pnet.DirInbound, t1),
+		},
+		{
+			name:     "hole punch next to relayed conn",
+			existing: newFakeConn("/ip4/192.0.2.7/tcp/4100/p2p/12D3KooWDpJ7As7BWAwRMfu1VU2WCqNjvq387JEYKDBj4kx6nXTN/p2p-circuit", libp2pnet.DirInbound, t0),
+			incoming: newFakeConn("/ip4/203.0.113.9/tcp/4100", libp2pnet.DirInbound, t1),
+		},
+		{
+			name:     "limited existing conn",
+			existing: limited,
+			incoming: newFakeConn("/ip4/198.51.100.1/tcp/4100", libp2pnet.DirInbound, t1),
+		},
+		{
+			name:     "outbound conn is ours",
+			existing: newFakeConn("/ip4/198.51.100.1/tcp/4100", libp2pnet.DirOutbound, t0),
+			incoming: newFakeConn("/ip4/203.0.113.9/tcp/4100", libp2pnet.DirInbound, t1),
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := duplicateConnOf(tc.incoming, []libp2pnet.Conn{tc.existing, tc.incoming})
+			if tc.want && got != libp2pnet.Conn(tc.existing) {
+				t.Fatalf("got %v, want the established conn it duplicates", got)
+			}
+			if !tc.want && got != nil {
+				t.Fatalf("got %v, want no duplicate", got.RemoteMultiaddr())
+			}
+		})
+	}
+}
+
+func TestGaterRefusesSelfID(t *testing.T) {
+	priv, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	self, err := peerstore.IDFromPrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := newConnectionGater(true)
+	g.setLocalPeer(self)
+
+	for _, dir := range []libp2pnet.Direction{libp2pnet.DirInbound, libp2pnet.DirOutbound} {
+		if g.InterceptSecured(dir, self, nil) {
+			t.Fatalf("%s connection claiming our own peer ID was allowed", dir)
+		}
+	}
+}
+
+func TestGaterRefusesDuplicateFromDeniedIP(t *testing.T) {
+	_, clone := newTestPeer(t)
+	_, other := newTestPeer(t)
+	g := newConnectionGater(true)
+	g.denyAddr(clone, multiaddr.StringCast("/ip4/203.0.113.9/tcp/4100"), time.Now().Add(time.Minute))
+
+	redial := newFakeConn("/ip4/203.0.113.9/tcp/5555", libp2pnet.DirInbound, time.Now())
+	if g.InterceptSecured(libp2pnet.DirInbound, clone, redial) {
+		t.Fatal("clone redialed from its denied IP was allowed")
+	}
+	if !g.InterceptSecured(libp2pnet.DirInbound, other, redial) {
+		t.Fatal("another peer from the same IP was refused")
+	}
+	established := newFakeConn("/ip4/198.51.100.1/tcp/4100", libp2pnet.DirInbound, time.Now())
+	if !g.InterceptSecured(libp2pnet.DirInbound, clone, established) {
+		t.Fatal("the peer ID from its established IP was refused")
+	}
+
+	g.denyAddr(other, multiaddr.StringCast("/ip4/203.0.113.9/tcp/4100"), time.Now().Add(-time.Second))
+	if !g.InterceptSecured(libp2pnet.DirInbound, other, redial) {
+		t.Fatal("an expired denial still refused the peer")
+	}
+}
@@ -0,0 +1,125 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"p2pos/internal/logging"
+
+	libp2pnet "github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+// eventsProtocolID streams internal bus events (connections, membership,
+// state) to a watcher as newline-delimited JSON until it closes the stream.
+// There is no HTTP admin API; this is the live feed for dashboards.
+const eventsProtocolID = protocol.ID("/p2pos/events/1.0.0")
+
+const (
+	eventsStreamBuffer       = 64
+	eventsStreamWriteTimeout = 10 * time.Second
+)
+
+type eventsRequest struct {
+	// Types limits the feed to these event names (e.g. "PeerConnected",
+	// "MembershipChanged"); empty streams everything.
+	Types []string `json:"types,omitempty"`
+}
+
+// EventMessage is one line of the events feed.
+type EventMessage struct {
+	Type  string    `json:"type"`
+	At    time.Time `json:"at"`
+	Data  any       `json:"data,omitempty"`
+	Error string    `json:"error,omitempty"`
+}
+
+func (n *Node) registerEventsHandler() {
+	n.setBusinessHandler(eventsProtocolID, func(stream libp2pnet.Stream) {
+		defer stream.Close()
+
+		req := eventsRequest{}
+		decoder := json.NewDecoder(stream)
+		_ = decoder.Decode(&req)
+		encoder := json.NewEncoder(stream)
+
+		if !n.canUseBusinessProtocols() || n.bus == nil {
+			_ = encoder.Encode(EventMessage{Type: "error", At: time.Now().UTC(), Error: "node is unconfigured"})
+			return
+		}
+
+		filter := eventTypeFilter(req.Types)
+		eventCh, unsubscribe := n.bus.Subscribe(eventsStreamBuffer)
+		defer unsubscribe()
+
+		// The watcher sends nothing after the request; a read returning means
+		// it closed or reset the stream.
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			_, _ = io.Copy(io.Discard, decoder.Buffered())
+			_, _ = io.Copy(io.Discard, stream)
+			cancel()
+		}()
+
+		watcher := stream.Conn().RemotePeer().String()
+		logging.Log("EVENTS", "watch_start", map[string]string{
+			"peer_id": watcher,
+			"types":   strings.Join(req.Types, ","),
+		})
+		defer logging.Log("EVENTS", "watch_stop", map[string]string{
+			"peer_id": watcher,
+		})
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case evt, ok := <-eventCh:
+				if !ok {
+					return
+				}
+				name := eventTypeName(evt)
+				if filter != nil {
+					if _, ok := filter[strings.ToLower(name)]; !ok {
+						continue
+					}
+				}
+				_ = stream.SetWriteDeadline(time.Now().Add(eventsStreamWriteTimeout))
+				if err := encoder.Encode(EventMessage{Type: name, At: time.Now().UTC(), Data: evt}); err != nil {
+					return
+				}
+			}
+		}
+	})
+}
+
+func eventTypeFilter(types []string) map[string]struct{} {
+	var filter map[string]struct{}
+	for _, raw := range types {
+		name := strings.ToLower(strings.TrimSpace(raw))
+		if name == "" {
+			continue
+		}
+		if filter == nil {
+			filter = make(map[string]struct{})
+		}
+		filter[name] = struct{}{}
+	}
+	return filter
+}
+
+func eventTypeName(evt any) string {
+	t := reflect.TypeOf(evt)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Name() == "" {
+		return "unknown"
+	}
+	return t.Name()
+}
@@ -0,0 +1,68 @@
+package network_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"p2pos/internal/events"
+	"p2pos/internal/membership"
+	"p2pos/internal/network"
+	"p2pos/internal/network/nettest"
+)
+
+func TestEventsStreamDeliversPublishedEvent(t *testing.T) {
+	a, bus := nettest.NewNode(t, &nettest.Config{Key: newKey(t)})
+	b, _ := nettest.NewNode(t, &nettest.Config{Key: newKey(t)})
+	members := []string{a.Host.ID().String(), b.Host.ID().String()}
+	for _, node := range []*network.Node{a, b} {
+		manager, err := membership.NewManager("test", "", node.Host.ID().String(), members)
+		if err != nil {
+			t.Fatal(err)
+		}
+		node.SetMembershipManager(manager)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := nettest.Connect(ctx, a, b); err != nil {
+		t.Fatal(err)
+	}
+
+	stream, err := b.Host.NewStream(ctx, a.Host.ID(), network.EventsProtocolID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	if err := json.NewEncoder(stream).Encode(map[string][]string{"types": {"MemberDown"}}); err != nil {
+		t.Fatal(err)
+	}
+	_ = stream.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	// The handler subscribes after reading the request; keep publishing
+	// until the watcher sees one.
+	go func() {
+		ticker := time.NewTicker(50 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				bus.Publish(events.MemberDown{PeerID: "watched", At: time.Now().UTC()})
+			}
+		}
+	}()
+
+	var msg struct {
+		Type string            `json:"type"`
+		Data events.MemberDown `json:"data"`
+	}
+	if err := json.NewDecoder(bufio.NewReader(stream)).Decode(&msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Type != "MemberDown" || msg.Data.PeerID != "watched" {
+		t.Fatalf("event %+v, want the published MemberDown", msg)
+	}
+}
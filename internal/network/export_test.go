@@ -0,0 +1,13 @@
+package network
+
+// Hooks for the network_test package, which can use nettest.
+
+var ReconcileIntendedMembers = (*Node).reconcileIntendedMembers
+
+var BootstrapSatisfied = (*Node).bootstrapSatisfied
+
+var DialBootstrapCandidates = (*Node).dialBootstrapCandidates
+
+const EventsProtocolID = eventsProtocolID
+
+const MembershipPushProtocolID = membershipPushProtocolID
@@ -0,0 +1,61 @@
+package network
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"p2pos/internal/config"
+	"p2pos/internal/logging"
+
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+)
+
+// resolveFileSource reads Connection.Address as a local peers file with one
+// p2p multiaddr per line. Blank lines and text after '#' are ignored. The
+// file is re-read on every resolve so operators can edit it live.
+func resolveFileSource(_ context.Context, conn config.Connection) ([]peerstore.AddrInfo, error) {
+	path := strings.TrimSpace(conn.Address)
+	if path == "" {
+		return nil, fmt.Errorf("file bootstrap path is empty")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("file %s open failed: %w", path, err)
+	}
+	defer f.Close()
+
+	var out []peerstore.AddrInfo
+	scanner := bufio.NewScanner(f)
+	line := 0
+	for scanner.Scan() {
+		line++
+		value := scanner.Text()
+		if idx := strings.Index(value, "#"); idx >= 0 {
+			value = value[:idx]
+		}
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		peerInfo, err := ParseP2PAddr(value)
+		if err != nil {
+			logging.Log("BOOTSTRAP", "file_line_invalid", map[string]string{
+				"path":   path,
+				"line":   strconv.Itoa(line),
+				"value":  value,
+				"reason": err.Error(),
+			})
+			continue
+		}
+		out = append(out, *peerInfo)
+	}
+	if err := scanner.Err(); err != nil {
+		return out, fmt.Errorf("file %s read failed: %w", path, err)
+	}
+	return out, nil
+}
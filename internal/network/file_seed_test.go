@@ -0,0 +1,42 @@
+package network
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"p2pos/internal/config"
+)
+
+func TestFileSeedList(t *testing.T) {
+	_, a := newTestPeer(t)
+	_, b := newTestPeer(t)
+	lines := []string{
+		"# cluster seeds",
+		"/ip4/198.51.100.1/tcp/4100/p2p/" + a.String(),
+		"",
+		"   /ip4/198.51.100.2/tcp/4100/p2p/" + b.String() + "  # rack 2",
+		"not a multiaddr",
+		"/ip4/198.51.100.3/tcp/4100",
+	}
+	path := filepath.Join(t.TempDir(), "peers.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	peers, err := resolveFileSource(context.Background(), config.Connection{Type: "file", Address: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(peers) != 2 || peers[0].ID != a || peers[1].ID != b {
+		t.Fatalf("resolved %v, want the two valid lines", peers)
+	}
+
+	for _, address := range []string{"", filepath.Join(t.TempDir(), "missing.txt")} {
+		if _, err := resolveFileSource(context.Background(), config.Connection{Type: "file", Address: address}); err == nil {
+			t.Errorf("file source %q resolved", address)
+		}
+	}
+}
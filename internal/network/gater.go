@@ -0,0 +1,303 @@
+package network
+
+import (
+	"net/netip"
+	"sync"
+	"time"
+
+	"p2pos/internal/config"
+	"p2pos/internal/logging"
+
+	"github.com/libp2p/go-libp2p/core/control"
+	libp2pnet "github.com/libp2p/go-libp2p/core/network"
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+	multiaddr "github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+)
+
+// connectionGater is installed on the libp2p host. It refuses outbound
+// bootstrap connections whose secured peer ID differs from the peer the
+// bootstrap address was resolved for, any remote claiming our own ID, and,
+// unless allowed, dials to private addresses of peers that are not trusted.
+// Peers on the deny list are refused until their ban expires, as is a peer ID
+// from an IP denied for it. While paused, or held after the local node was
+// removed from membership, it refuses every connection.
+type connectionGater struct {
+	mu          sync.RWMutex
+	local       peerstore.ID
+	expected    map[string]peerstore.ID
+	denied      map[peerstore.ID]time.Time
+	deniedAddrs map[string]time.Time
+	dialPrivate bool
+	paused      bool
+	// held is set by the "hold" self-removed policy. Unlike paused, Resume
+	// does not clear it: only a restart does.
+	held bool
+	// trusted reports members and pinned peers, whose private addresses
+	// are dialed whatever dial_private_addrs says: a public node still
+	// has to reach cluster peers on its own LAN.
+	trusted func(peerstore.ID) bool
+}
+
+func newConnectionGater(dialPrivate bool) *connectionGater {
+	return &connectionGater{
+		expected:    make(map[string]peerstore.ID),
+		denied:      make(map[peerstore.ID]time.Time),
+		deniedAddrs: make(map[string]time.Time),
+		dialPrivate: dialPrivate,
+	}
+}
+
+// deny refuses connections to and from p until until.
+func (g *connectionGater) deny(p peerstore.ID, until time.Time) {
+	g.mu.Lock()
+	g.denied[p] = until
+	g.mu.Unlock()
+}
+
+func (g *connectionGater) isDenied(p peerstore.ID) bool {
+	g.mu.RLock()
+	until, ok := g.denied[p]
+	g.mu.RUnlock()
+	if !ok {
+		return false
+	}
+	if time.Now().Before(until) {
+		return true
+	}
+	g.mu.Lock()
+	if until, ok := g.denied[p]; ok && !time.Now().Before(until) {
+		delete(g.denied, p)
+	}
+	g.mu.Unlock()
+	return false
+}
+
+// denyAddr refuses p from addr's IP until until, whatever the port.
+func (g *connectionGater) denyAddr(p peerstore.ID, addr multiaddr.Multiaddr, until time.Time) {
+	key, ok := peerIPKey(p, addr)
+	if !ok {
+		return
+	}
+	g.mu.Lock()
+	g.deniedAddrs[key] = until
+	g.mu.Unlock()
+}
+
+func (g *connectionGater) isAddrDenied(p peerstore.ID, addr multiaddr.Multiaddr) bool {
+	key, ok := peerIPKey(p, addr)
+	if !ok {
+		return false
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	until, ok := g.deniedAddrs[key]
+	if !ok {
+		return false
+	}
+	if time.Now().Before(until) {
+		return true
+	}
+	delete(g.deniedAddrs, key)
+	return false
+}
+
+func peerIPKey(p peerstore.ID, addr multiaddr.Multiaddr) (string, bool) {
+	if addr == nil {
+		return "", false
+	}
+	ip, err := manet.ToIP(addr)
+	if err != nil {
+		return "", false
+	}
+	return p.String() + "|" + ip.String(), true
+}
+
+func (g *connectionGater) setLocalPeer(id peerstore.ID) {
+	g.mu.Lock()
+	g.local = id
+	g.mu.Unlock()
+}
+
+func (g *connectionGater) setTrusted(trusted func(peerstore.ID) bool) {
+	g.mu.Lock()
+	g.trusted = trusted
+	g.mu.Unlock()
+}
+
+func (g *connectionGater) isTrusted(p peerstore.ID) bool {
+	g.mu.RLock()
+	trusted := g.trusted
+	g.mu.RUnlock()
+	return trusted != nil && trusted(p)
+}
+
+func (g *connectionGater) setPaused(paused bool) {
+	g.mu.Lock()
+	g.paused = paused
+	g.mu.Unlock()
+}
+
+func (g *connectionGater) hold() {
+	g.mu.Lock()
+	g.held = true
+	g.mu.Unlock()
+}
+
+// refusesAll reports whether the gater is paused or held.
+func (g *connectionGater) refusesAll() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.paused || g.held
+}
+
+func (g *connectionGater) localPeer() peerstore.ID {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.local
+}
+
+// expectBootstrap pins every transport address of info to info.ID.
+func (g *connectionGater) expectBootstrap(info peerstore.AddrInfo) {
+	if info.ID == "" {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, addr := range info.Addrs {
+		g.expected[transportAddrKey(addr)] = info.ID
+	}
+}
+
+// forgetBootstrap drops the pins expectBootstrap recorded for info once its
+// dial has finished.
+func (g *connectionGater) forgetBootstrap(info peerstore.AddrInfo) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, addr := range info.Addrs {
+		key := transportAddrKey(addr)
+		if g.expected[key] == info.ID {
+			delete(g.expected, key)
+		}
+	}
+}
+
+func (g *connectionGater) expectedPeer(addr multiaddr.Multiaddr) (peerstore.ID, bool) {
+	if addr == nil {
+		return "", false
+	}
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	id, ok := g.expected[transportAddrKey(addr)]
+	return id, ok
+}
+
+func (g *connectionGater) InterceptPeerDial(p peerstore.ID) bool {
+	return !g.refusesAll() && !g.isDenied(p)
+}
+
+func (g *connectionGater) InterceptAddrDial(p peerstore.ID, addr multiaddr.Multiaddr) bool {
+	if !g.dialPrivate && isPrivateDialAddr(addr) && !g.isTrusted(p) {
+		return false
+	}
+	if expected, ok := g.expectedPeer(addr); ok && expected != p {
+		logging.Log("BOOTSTRAP", "peer_id_mismatch", map[string]string{
+			"addr":     addr.String(),
+			"expected": expected.String(),
+			"peer_id":  p.String(),
+			"stage":    "dial",
+		})
+		return false
+	}
+	return true
+}
+
+func (g *connectionGater) InterceptAccept(_ libp2pnet.ConnMultiaddrs) bool {
+	return true
+}
+
+func (g *connectionGater) InterceptSecured(dir libp2pnet.Direction, p peerstore.ID, addrs libp2pnet.ConnMultiaddrs) bool {
+	if local := g.localPeer(); local != "" && p == local {
+		remote := ""
+		if addrs != nil {
+			remote = addrs.RemoteMultiaddr().String()
+		}
+		logging.Log("NODE", "duplicate_peer_id", map[string]string{
+			"peer_id":    p.String(),
+			"remote":     remote,
+			"direction":  dir.String(),
+			"resolution": "refuse_self_id",
+		})
+		return false
+	}
+	if g.refusesAll() || g.isDenied(p) {
+		return false
+	}
+	if addrs != nil && g.isAddrDenied(p, addrs.RemoteMultiaddr()) {
+		return false
+	}
+	if dir != libp2pnet.DirOutbound || addrs == nil {
+		return true
+	}
+	if expected, ok := g.expectedPeer(addrs.RemoteMultiaddr()); ok && expected != p {
+		logging.Log("BOOTSTRAP", "peer_id_mismatch", map[string]string{
+			"addr":     addrs.RemoteMultiaddr().String(),
+			"expected": expected.String(),
+			"peer_id":  p.String(),
+			"stage":    "secured",
+		})
+		return false
+	}
+	return true
+}
+
+func (g *connectionGater) InterceptUpgraded(_ libp2pnet.Conn) (bool, control.DisconnectReason) {
+	return true, 0
+}
+
+func transportAddrKey(addr multiaddr.Multiaddr) string {
+	transport, _ := peerstore.SplitAddr(addr)
+	if transport == nil {
+		return addr.String()
+	}
+	return transport.String()
+}
+
+// allowPrivateDials resolves dial_private_addrs against the network mode. It
+// covers untrusted peers only; see connectionGater.trusted.
+func allowPrivateDials(policy string, publicService bool) bool {
+	switch policy {
+	case config.DialPrivateAllow:
+		return true
+	case config.DialPrivateDeny:
+		return false
+	default:
+		return !publicService
+	}
+}
+
+// isPrivateDialAddr reports whether addr targets a private (RFC 1918 or
+// ULA), loopback, link-local or unspecified IP. A peer can advertise such
+// addresses to make public nodes probe internal networks. DNS, unix and
+// relay addresses are left to their own checks.
+func isPrivateDialAddr(addr multiaddr.Multiaddr) bool {
+	if addr == nil {
+		return false
+	}
+	if _, err := addr.ValueForProtocol(multiaddr.P_CIRCUIT); err == nil {
+		return false
+	}
+	if _, err := addr.ValueForProtocol(multiaddr.P_IP6ZONE); err == nil {
+		return true
+	}
+	ip, err := manet.ToIP(addr)
+	if err != nil {
+		return false
+	}
+	parsed, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return false
+	}
+	parsed = parsed.Unmap()
+	return parsed.IsPrivate() || parsed.IsLoopback() || parsed.IsLinkLocalUnicast() || parsed.IsUnspecified()
+}
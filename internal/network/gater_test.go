@@ -0,0 +1,64 @@
+package network
+
+import (
+	"testing"
+
+	"p2pos/internal/config"
+
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+	multiaddr "github.com/multiformats/go-multiaddr"
+)
+
+func TestPrivateDialPolicyMatrix(t *testing.T) {
+	member := peerstore.ID("member")
+	stranger := peerstore.ID("stranger")
+	lan := multiaddr.StringCast("/ip4/192.168.1.20/tcp/4100")
+	public := multiaddr.StringCast("/ip4/203.0.113.7/tcp/4100")
+
+	cases := []struct {
+		policy        string
+		publicService bool
+		strangerLAN   bool
+	}{
+		{config.DialPrivateAuto, false, true},
+		{config.DialPrivateAuto, true, false},
+		{config.DialPrivateAllow, false, true},
+		{config.DialPrivateAllow, true, true},
+		{config.DialPrivateDeny, false, false},
+		{config.DialPrivateDeny, true, false},
+	}
+	for _, tc := range cases {
+		g := newConnectionGater(allowPrivateDials(tc.policy, tc.publicService))
+		g.setTrusted(func(p peerstore.ID) bool { return p == member })
+
+		if !g.InterceptAddrDial(member, lan) {
+			t.Errorf("%s public=%v: member refused on a LAN address", tc.policy, tc.publicService)
+		}
+		if got := g.InterceptAddrDial(stranger, lan); got != tc.strangerLAN {
+			t.Errorf("%s public=%v: stranger on a LAN address allowed=%v, want %v", tc.policy, tc.publicService, got, tc.strangerLAN)
+		}
+		if !g.InterceptAddrDial(stranger, public) {
+			t.Errorf("%s public=%v: stranger refused on a public address", tc.policy, tc.publicService)
+		}
+	}
+}
+
+func TestIsPrivateDialAddr(t *testing.T) {
+	cases := map[string]bool{
+		"/ip4/10.0.0.1/tcp/1":     true,
+		"/ip4/127.0.0.1/tcp/1":    true,
+		"/ip4/169.254.1.1/tcp/1":  true,
+		"/ip4/0.0.0.0/tcp/1":      true,
+		"/ip6/fd00::1/tcp/1":      true,
+		"/ip6/fe80::1/tcp/1":      true,
+		"/ip4/8.8.8.8/tcp/1":      false,
+		"/ip6/2001:db8::1/tcp/1":  false,
+		"/dns4/example.com/tcp/1": false,
+		"/ip4/10.0.0.1/tcp/1/p2p/QmYyQSo1c1Ym7orWxLYvCrM2EmxFTANf8wXmmE7DWjhx5N/p2p-circuit": false,
+	}
+	for addr, want := range cases {
+		if got := isPrivateDialAddr(multiaddr.StringCast(addr)); got != want {
+			t.Errorf("isPrivateDialAddr(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
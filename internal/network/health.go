@@ -0,0 +1,137 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"p2pos/internal/logging"
+
+	libp2pnet "github.com/libp2p/go-libp2p/core/network"
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+// healthProtocolID serves orchestration probes. Liveness says the process is
+// working and should not be restarted; readiness says it can do cluster work.
+// An unconfigured node is live but not ready, so it is left running while it
+// waits for membership instead of being killed.
+const healthProtocolID = protocol.ID("/p2pos/health/1.0.0")
+const livenessTimeout = 2 * time.Second
+
+const (
+	ProbeLiveness  = "live"
+	ProbeReadiness = "ready"
+)
+
+// LivenessCheck reports whether a local dependency the process cannot work
+// without, such as the database, is reachable.
+type LivenessCheck func(ctx context.Context) error
+
+type healthRequest struct {
+	Probe string `json:"probe"`
+	// RequireQuorum makes readiness wait for the healthy state rather than
+	// just a configured membership.
+	RequireQuorum bool `json:"require_quorum,omitempty"`
+}
+
+type healthResponse struct {
+	Probe  string       `json:"probe"`
+	OK     bool         `json:"ok"`
+	State  RuntimeState `json:"state"`
+	Reason string       `json:"reason,omitempty"`
+	// Relays is reported with every probe so operators can tell whether a
+	// private node is reachable at all.
+	Relays []RelayReservation `json:"relays,omitempty"`
+}
+
+func (n *Node) SetLivenessCheck(check LivenessCheck) {
+	n.statusMu.Lock()
+	n.liveness = check
+	n.statusMu.Unlock()
+}
+
+// Liveness runs the liveness check, bounded by livenessTimeout.
+func (n *Node) Liveness(ctx context.Context) error {
+	n.statusMu.RLock()
+	check := n.liveness
+	n.statusMu.RUnlock()
+	if check == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, livenessTimeout)
+	defer cancel()
+	return check(ctx)
+}
+
+// Readiness reports whether the node can serve cluster work, and why not.
+// It never blocks.
+func (n *Node) Readiness(requireQuorum bool) (bool, string) {
+	if !n.canUseBusinessProtocols() {
+		return false, "membership not configured"
+	}
+	if requireQuorum && n.RuntimeState() != RuntimeStateHealthy {
+		return false, n.StateDiagnostic().Reason
+	}
+	return true, ""
+}
+
+func (n *Node) registerHealthHandler() {
+	n.setCountedHandler(healthProtocolID, func(stream libp2pnet.Stream) {
+		defer stream.Close()
+
+		req := healthRequest{}
+		_ = json.NewDecoder(stream).Decode(&req)
+		if req.Probe == "" {
+			req.Probe = ProbeLiveness
+		}
+
+		resp := healthResponse{Probe: req.Probe, State: n.RuntimeState(), Relays: n.RelayStatus()}
+		switch req.Probe {
+		case ProbeLiveness:
+			if err := n.Liveness(n.lifecycle); err != nil {
+				resp.Reason = err.Error()
+			} else {
+				resp.OK = true
+			}
+		case ProbeReadiness:
+			resp.OK, resp.Reason = n.Readiness(req.RequireQuorum)
+		default:
+			resp.Reason = fmt.Sprintf("unknown probe %q", req.Probe)
+		}
+
+		if err := json.NewEncoder(stream).Encode(resp); err != nil {
+			logging.Log("STATUS", "encode_failed", map[string]string{
+				"reason": err.Error(),
+			})
+		}
+	})
+}
+
+// FetchHealth runs probe on peerID. A failed probe is reported as ok=false
+// with the remote reason as the error.
+func (n *Node) FetchHealth(ctx context.Context, peerID peerstore.ID, probe string, requireQuorum bool) (bool, RuntimeState, error) {
+	stream, err := n.newCountedStream(ctx, peerID, healthProtocolID)
+	if err != nil {
+		return false, "", err
+	}
+	defer stream.Close()
+
+	if err := json.NewEncoder(stream).Encode(healthRequest{Probe: probe, RequireQuorum: requireQuorum}); err != nil {
+		return false, "", err
+	}
+
+	var resp healthResponse
+	if err := json.NewDecoder(stream).Decode(&resp); err != nil {
+		return false, "", err
+	}
+	if !resp.OK {
+		if resp.Reason == "" {
+			resp.Reason = "probe failed"
+		}
+		return false, resp.State, errors.New(resp.Reason)
+	}
+	return true, resp.State, nil
+}
@@ -0,0 +1,57 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"p2pos/internal/membership"
+)
+
+func TestProbesByRuntimeState(t *testing.T) {
+	h := newTestHost(t)
+	_, other := newTestPeer(t)
+	manager, err := membership.NewManager("test", "", h.ID().String(), []string{h.ID().String(), other.String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		state       RuntimeState
+		ready       bool
+		readyQuorum bool
+	}{
+		{RuntimeStateUnconfigured, false, false},
+		{RuntimeStateStarting, true, false},
+		{RuntimeStateDegraded, true, false},
+		{RuntimeStateRecovering, true, false},
+		{RuntimeStateHealthy, true, true},
+	}
+	for _, tc := range cases {
+		n := &Node{Host: h, membership: manager, quorumHold: newQuorumHold(0)}
+		n.setRuntimeState(tc.state, "test")
+		if err := n.Liveness(context.Background()); err != nil {
+			t.Errorf("%s: not live: %v", tc.state, err)
+		}
+		if ok, reason := n.Readiness(false); ok != tc.ready {
+			t.Errorf("%s: ready=%v (%s), want %v", tc.state, ok, reason, tc.ready)
+		}
+		if ok, reason := n.Readiness(true); ok != tc.readyQuorum || (!ok && reason == "") {
+			t.Errorf("%s: ready with quorum=%v (%q), want %v with a reason", tc.state, ok, reason, tc.readyQuorum)
+		}
+	}
+}
+
+func TestLivenessReportsFailedCheck(t *testing.T) {
+	n := &Node{}
+	n.setRuntimeState(RuntimeStateHealthy, "test")
+	dbDown := errors.New("database is locked")
+	n.SetLivenessCheck(func(ctx context.Context) error {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("liveness check ran without a deadline")
+		}
+		return dbDown
+	})
+	if err := n.Liveness(context.Background()); !errors.Is(err, dbDown) {
+		t.Fatalf("Liveness = %v, want the check's error", err)
+	}
+}
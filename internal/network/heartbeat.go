@@ -4,12 +4,16 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
+	"p2pos/internal/config"
 	"p2pos/internal/events"
 	"p2pos/internal/logging"
+	"p2pos/internal/status"
 
 	libp2pnet "github.com/libp2p/go-libp2p/core/network"
 	peerstore "github.com/libp2p/go-libp2p/core/peer"
@@ -17,17 +21,109 @@ import (
 )
 
 const heartbeatProtocolID = protocol.ID("/p2pos/heartbeat/1.0.0")
-const heartbeatWindow = 5 * time.Minute
+const defaultHeartbeatWindow = 5 * time.Minute
 
 type heartbeatMessage struct {
 	ClusterID string `json:"cluster_id"`
 	PeerID    string `json:"peer_id"`
 	Timestamp string `json:"ts"`
 	Sig       string `json:"sig"`
+	heartbeatDigest
+	// Attestation is self-signed, so it travels outside the heartbeat
+	// signature.
+	Attestation *status.Attestation `json:"attestation,omitempty"`
+}
+
+// heartbeatDigest is optional liveness metadata. When present it is part of
+// the signed payload; heartbeats from older peers simply omit it.
+type heartbeatDigest struct {
+	State       string            `json:"state,omitempty"`
+	MemberCount int               `json:"member_count,omitempty"`
+	AppVersion  string            `json:"app_version,omitempty"`
+	Region      string            `json:"region,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+}
+
+func (d heartbeatDigest) empty() bool {
+	return d.State == "" && d.MemberCount == 0 && d.AppVersion == "" && !d.hasTags()
+}
+
+func (d heartbeatDigest) hasTags() bool {
+	return d.Region != "" || len(d.Tags) > 0
+}
+
+// heartbeatLimiter bounds how often a member's heartbeats reach the event bus
+// (and therefore the DB). Excess heartbeats only refresh in-memory last-seen;
+// the latest one is published later by due, so a burst that ends between two
+// publishes still lands with the time it was last seen.
+type heartbeatLimiter struct {
+	mu          sync.Mutex
+	minInterval time.Duration
+	peers       map[string]heartbeatSeen
+}
+
+type heartbeatSeen struct {
+	lastSeen      time.Time
+	lastPublished time.Time
+	// pending is the newest heartbeat held back since lastPublished.
+	pending *events.PeerHeartbeat
+}
+
+func newHeartbeatLimiter(minInterval time.Duration) *heartbeatLimiter {
+	return &heartbeatLimiter{
+		minInterval: minInterval,
+		peers:       make(map[string]heartbeatSeen),
+	}
+}
+
+// allow records heartbeat from peerID and reports whether it should be
+// published now. A heartbeat that is not is kept until due hands it out.
+func (l *heartbeatLimiter) allow(peerID string, now time.Time, heartbeat events.PeerHeartbeat) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	seen := l.peers[peerID]
+	seen.lastSeen = now
+	publish := l.minInterval <= 0 || seen.lastPublished.IsZero() || now.Sub(seen.lastPublished) >= l.minInterval
+	if publish {
+		seen.lastPublished = now
+		seen.pending = nil
+	} else {
+		seen.pending = &heartbeat
+	}
+	l.peers[peerID] = seen
+	return publish
+}
+
+// due returns the held-back heartbeats whose interval has passed, stamped
+// with when their peer was last seen, and counts them as published.
+func (l *heartbeatLimiter) due(now time.Time) []events.PeerHeartbeat {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var out []events.PeerHeartbeat
+	for peerID, seen := range l.peers {
+		if seen.pending == nil || now.Sub(seen.lastPublished) < l.minInterval {
+			continue
+		}
+		heartbeat := *seen.pending
+		heartbeat.At = seen.lastSeen
+		out = append(out, heartbeat)
+		seen.lastPublished = now
+		seen.pending = nil
+		l.peers[peerID] = seen
+	}
+	return out
+}
+
+func (l *heartbeatLimiter) forget(peerID string) {
+	l.mu.Lock()
+	delete(l.peers, peerID)
+	l.mu.Unlock()
 }
 
 func (n *Node) registerHeartbeatHandler() {
-	n.Host.SetStreamHandler(heartbeatProtocolID, func(stream libp2pnet.Stream) {
+	n.setBusinessHandler(heartbeatProtocolID, func(stream libp2pnet.Stream) {
 		defer stream.Close()
 		if !n.canUseBusinessProtocols() {
 			return
@@ -45,57 +141,101 @@ func (n *Node) registerHeartbeatHandler() {
 				"peer_id": msg.PeerID,
 				"reason":  err.Error(),
 			})
+			if errors.Is(err, errIncompatibleHeartbeat) {
+				n.recordValidationFailure(stream.Conn().RemotePeer(), "heartbeat", err)
+			}
 			return
 		}
 
+		now := time.Now().UTC()
+		if ts, err := time.Parse(time.RFC3339Nano, msg.Timestamp); err == nil {
+			n.clocks.record(msg.PeerID, ts, now)
+		}
+		n.peerTags.record(msg.PeerID, msg.Region, msg.Tags)
+		if a := msg.Attestation; a != nil {
+			if a.PeerID == msg.PeerID && VerifyAttestation(*a) == nil {
+				n.peerTags.recordAttestation(msg.PeerID, *a)
+			} else {
+				logging.Log("STATUS", "attestation_reject", map[string]string{
+					"peer_id": msg.PeerID,
+				})
+			}
+		}
+
 		remoteAddr := ""
 		if stream.Conn() != nil {
 			remoteAddr = stream.Conn().RemoteMultiaddr().String()
 		}
+		heartbeat := events.PeerHeartbeat{
+			PeerID:      msg.PeerID,
+			RemoteAddr:  remoteAddr,
+			State:       msg.State,
+			MemberCount: msg.MemberCount,
+			AppVersion:  msg.AppVersion,
+			Region:      msg.Region,
+			Tags:        msg.Tags,
+			At:          now,
+		}
+		if !n.heartbeats.allow(msg.PeerID, now, heartbeat) {
+			return
+		}
 		if n.bus != nil {
-			n.bus.Publish(events.PeerHeartbeat{
-				PeerID:     msg.PeerID,
-				RemoteAddr: remoteAddr,
-				At:         time.Now().UTC(),
-			})
+			n.bus.Publish(heartbeat)
 		}
 	})
 }
 
+// flushHeartbeats publishes heartbeats the limiter held back once their
+// interval has passed.
+func (n *Node) flushHeartbeats(now time.Time) {
+	for _, heartbeat := range n.heartbeats.due(now) {
+		if n.bus != nil {
+			n.bus.Publish(heartbeat)
+		}
+	}
+}
+
 func (n *Node) BroadcastHeartbeat(ctx context.Context) error {
 	if !n.canUseBusinessProtocols() {
 		return nil
 	}
+	n.flushHeartbeats(time.Now().UTC())
 	if n.privKey == nil {
 		return fmt.Errorf("private key not initialized")
 	}
 
 	clusterID := n.clusterID()
 	ts := time.Now().UTC()
-	payload := canonicalHeartbeat(clusterID, n.Host.ID().String(), ts)
+	digest := heartbeatDigest{}
+	if n.heartbeatDigest {
+		digest = n.localHeartbeatDigest()
+	}
+	payload := canonicalHeartbeat(clusterID, n.Host.ID().String(), ts, digest)
 	sig, err := n.privKey.Sign(payload)
 	if err != nil {
 		return err
 	}
 
 	msg := heartbeatMessage{
-		ClusterID: clusterID,
-		PeerID:    n.Host.ID().String(),
-		Timestamp: ts.Format(time.RFC3339Nano),
-		Sig:       base64.StdEncoding.EncodeToString(sig),
+		ClusterID:       clusterID,
+		PeerID:          n.Host.ID().String(),
+		Timestamp:       ts.Format(time.RFC3339Nano),
+		Sig:             base64.StdEncoding.EncodeToString(sig),
+		heartbeatDigest: digest,
+		Attestation:     n.attestation,
 	}
 
 	for _, peerID := range n.Host.Network().Peers() {
 		if !n.isMember(peerID.String()) {
 			continue
 		}
-		if _, skip := n.heartbeatUnsupported.Load(peerID); skip {
+		if n.heartbeatUnsupported.skip(peerID, time.Now()) {
 			continue
 		}
 		reqCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 		if err := n.sendHeartbeat(reqCtx, peerID, msg); err != nil {
 			if strings.Contains(err.Error(), "protocols not supported") {
-				n.heartbeatUnsupported.Store(peerID, struct{}{})
+				n.heartbeatUnsupported.mark(peerID, time.Now())
 				logging.Log("STATUS", "heartbeat_protocol_unsupported", map[string]string{
 					"peer_id": peerID.String(),
 				})
@@ -113,7 +253,7 @@ func (n *Node) BroadcastHeartbeat(ctx context.Context) error {
 }
 
 func (n *Node) sendHeartbeat(ctx context.Context, peerID peerstore.ID, msg heartbeatMessage) error {
-	stream, err := n.Host.NewStream(ctx, peerID, heartbeatProtocolID)
+	stream, err := n.newCountedStream(ctx, peerID, heartbeatProtocolID)
 	if err != nil {
 		return err
 	}
@@ -131,21 +271,28 @@ func (n *Node) validateHeartbeat(msg heartbeatMessage) error {
 	}
 	clusterID := n.clusterID()
 	if clusterID != "" && msg.ClusterID != "" && msg.ClusterID != clusterID {
-		return fmt.Errorf("cluster_id mismatch")
+		return incompatibleHeartbeat("cluster_id mismatch")
+	}
+	if err := validateHeartbeatDigest(msg.heartbeatDigest); err != nil {
+		return err
 	}
 
 	ts, err := time.Parse(time.RFC3339Nano, msg.Timestamp)
 	if err != nil {
 		return fmt.Errorf("invalid timestamp")
 	}
+	window := n.heartbeatWindow
+	if window <= 0 {
+		window = defaultHeartbeatWindow
+	}
 	now := time.Now().UTC()
-	if ts.After(now.Add(heartbeatWindow)) || ts.Before(now.Add(-heartbeatWindow)) {
+	if ts.After(now.Add(window)) || ts.Before(now.Add(-window)) {
 		return fmt.Errorf("timestamp out of window")
 	}
 
 	sigBytes, err := base64.StdEncoding.DecodeString(msg.Sig)
 	if err != nil {
-		return fmt.Errorf("invalid signature encoding")
+		return incompatibleHeartbeat("invalid signature encoding")
 	}
 
 	id, err := peerstore.Decode(msg.PeerID)
@@ -157,10 +304,10 @@ func (n *Node) validateHeartbeat(msg heartbeatMessage) error {
 		return fmt.Errorf("extract public key failed")
 	}
 
-	payload := canonicalHeartbeat(clusterID, msg.PeerID, ts)
+	payload := canonicalHeartbeat(clusterID, msg.PeerID, ts, msg.heartbeatDigest)
 	ok, err := pub.Verify(payload, sigBytes)
 	if err != nil || !ok {
-		return fmt.Errorf("signature invalid")
+		return incompatibleHeartbeat("signature invalid")
 	}
 
 	return nil
@@ -176,6 +323,61 @@ func (n *Node) clusterID() string {
 	return manager.Snapshot().ClusterID
 }
 
-func canonicalHeartbeat(clusterID, peerID string, ts time.Time) []byte {
-	return []byte(fmt.Sprintf("%s|%s|%s", clusterID, peerID, ts.UTC().Format(time.RFC3339Nano)))
+func (n *Node) localHeartbeatDigest() heartbeatDigest {
+	state := n.RuntimeState()
+	if state == RuntimeStateStarting || state == RuntimeStateRecovering {
+		// Older peers reject digests with states they do not know, and a
+		// starting node is degraded as far as the cluster is concerned.
+		state = RuntimeStateDegraded
+	}
+	digest := heartbeatDigest{
+		State:      string(state),
+		AppVersion: config.AppVersion,
+		Region:     n.region,
+		Tags:       n.tags,
+	}
+	if snap, ok := n.membershipSnapshot(); ok {
+		digest.MemberCount = len(snap.Members)
+	}
+	return digest
+}
+
+func validateHeartbeatDigest(d heartbeatDigest) error {
+	switch RuntimeState(d.State) {
+	case "", RuntimeStateUnconfigured, RuntimeStateStarting, RuntimeStateDegraded, RuntimeStateHealthy:
+	default:
+		return fmt.Errorf("invalid state %q", d.State)
+	}
+	if d.MemberCount < 0 {
+		return fmt.Errorf("invalid member_count")
+	}
+	if len(d.AppVersion) > 64 || strings.ContainsAny(d.AppVersion, "|\n") {
+		return fmt.Errorf("invalid app_version")
+	}
+	if !config.ValidNodeTag(d.Region) {
+		return fmt.Errorf("invalid region")
+	}
+	if len(d.Tags) > config.MaxNodeTags {
+		return fmt.Errorf("too many tags")
+	}
+	for k, v := range d.Tags {
+		if k == "" || !config.ValidNodeTag(k) || !config.ValidNodeTag(v) {
+			return fmt.Errorf("invalid tag %q", k)
+		}
+	}
+	return nil
+}
+
+// canonicalHeartbeat keeps the legacy three-field payload when no digest is
+// sent so heartbeats from older peers still verify.
+func canonicalHeartbeat(clusterID, peerID string, ts time.Time, digest heartbeatDigest) []byte {
+	base := fmt.Sprintf("%s|%s|%s", clusterID, peerID, ts.UTC().Format(time.RFC3339Nano))
+	if digest.empty() {
+		return []byte(base)
+	}
+	payload := fmt.Sprintf("%s|%s|%d|%s", base, digest.State, digest.MemberCount, digest.AppVersion)
+	if !digest.hasTags() {
+		return []byte(payload)
+	}
+	return []byte(payload + "|" + digest.Region + "|" + canonicalTags(digest.Tags))
 }
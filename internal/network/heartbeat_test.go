@@ -0,0 +1,174 @@
+package network
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"p2pos/internal/events"
+	"p2pos/internal/membership"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestHeartbeatLimiterBoundsPublishes(t *testing.T) {
+	l := newHeartbeatLimiter(10 * time.Second)
+	start := time.Now()
+
+	published := 0
+	var last time.Time
+	for i := 0; i < 100; i++ {
+		last = start.Add(time.Duration(i) * 50 * time.Millisecond)
+		if l.allow("peer", last, events.PeerHeartbeat{PeerID: "peer", At: last}) {
+			published++
+		}
+	}
+	if published != 1 {
+		t.Fatalf("a burst of 100 heartbeats published %d, want 1", published)
+	}
+
+	if got := l.due(start.Add(5 * time.Second)); len(got) != 0 {
+		t.Fatalf("held-back heartbeat published before the interval: %v", got)
+	}
+	got := l.due(start.Add(10 * time.Second))
+	if len(got) != 1 {
+		t.Fatalf("held-back heartbeats after the interval: %v, want one", got)
+	}
+	if !got[0].At.Equal(last) {
+		t.Fatalf("deferred heartbeat stamped %s, want last seen %s", got[0].At, last)
+	}
+	if again := l.due(start.Add(time.Hour)); len(again) != 0 {
+		t.Fatalf("deferred heartbeat published twice: %v", again)
+	}
+
+	// The deferred publish counts toward the interval.
+	if l.allow("peer", start.Add(15*time.Second), events.PeerHeartbeat{PeerID: "peer"}) {
+		t.Fatal("heartbeat right after a deferred publish was not limited")
+	}
+}
+
+func TestFlushHeartbeatsPublishesToBus(t *testing.T) {
+	bus := events.NewBus()
+	ch, cancel := bus.Subscribe(8)
+	defer cancel()
+	n := &Node{bus: bus, heartbeats: newHeartbeatLimiter(time.Second)}
+
+	now := time.Now()
+	n.heartbeats.allow("peer", now, events.PeerHeartbeat{PeerID: "peer"})
+	n.heartbeats.allow("peer", now.Add(100*time.Millisecond), events.PeerHeartbeat{PeerID: "peer", State: "healthy"})
+	n.flushHeartbeats(now.Add(2 * time.Second))
+
+	select {
+	case evt := <-ch:
+		heartbeat, ok := evt.(events.PeerHeartbeat)
+		if !ok || heartbeat.State != "healthy" {
+			t.Fatalf("published %#v, want the held-back heartbeat", evt)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("held-back heartbeat never reached the bus")
+	}
+}
+
+func TestValidateHeartbeatKeyTypes(t *testing.T) {
+	ed, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secp, _, err := crypto.GenerateSecp256k1Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := map[string]crypto.PrivKey{"ed25519": ed, "secp256k1": secp}
+
+	members := make([]string, 0, len(keys))
+	for _, key := range keys {
+		id, err := peerstore.IDFromPrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		members = append(members, id.String())
+	}
+	manager, err := membership.NewManager("test", "", members[0], members)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := &Node{membership: manager}
+
+	for name, key := range keys {
+		t.Run(name, func(t *testing.T) {
+			id, err := peerstore.IDFromPrivateKey(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ts := time.Now().UTC()
+			digest := heartbeatDigest{State: "healthy", MemberCount: 2}
+			sig, err := key.Sign(canonicalHeartbeat("test", id.String(), ts, digest))
+			if err != nil {
+				t.Fatal(err)
+			}
+			msg := heartbeatMessage{
+				ClusterID:       "test",
+				PeerID:          id.String(),
+				Timestamp:       ts.Format(time.RFC3339Nano),
+				Sig:             base64.StdEncoding.EncodeToString(sig),
+				heartbeatDigest: digest,
+			}
+			if err := n.validateHeartbeat(msg); err != nil {
+				t.Fatalf("valid heartbeat rejected: %v", err)
+			}
+			msg.MemberCount = 3
+			if err := n.validateHeartbeat(msg); err == nil {
+				t.Fatal("tampered heartbeat accepted")
+			}
+		})
+	}
+}
+
+func TestCanonicalHeartbeatDigest(t *testing.T) {
+	ts := time.Date(2026, 10, 16, 8, 30, 0, 0, time.UTC)
+	legacy := "test|peer|2026-10-16T08:30:00Z"
+	if got := string(canonicalHeartbeat("test", "peer", ts, heartbeatDigest{})); got != legacy {
+		t.Fatalf("heartbeat without digest signs %q, want the legacy %q", got, legacy)
+	}
+
+	digest := heartbeatDigest{State: "healthy", MemberCount: 3, AppVersion: "20261016-0830"}
+	want := legacy + "|healthy|3|20261016-0830"
+	if got := string(canonicalHeartbeat("test", "peer", ts, digest)); got != want {
+		t.Fatalf("signed payload %q, want %q", got, want)
+	}
+	for _, changed := range []heartbeatDigest{
+		{State: "degraded", MemberCount: 3, AppVersion: "20261016-0830"},
+		{State: "healthy", MemberCount: 4, AppVersion: "20261016-0830"},
+		{State: "healthy", MemberCount: 3, AppVersion: "20261016-0831"},
+	} {
+		if string(canonicalHeartbeat("test", "peer", ts, changed)) == want {
+			t.Errorf("digest %+v signs the same payload as %+v", changed, digest)
+		}
+	}
+}
+
+func TestValidateHeartbeatDigest(t *testing.T) {
+	valid := []heartbeatDigest{
+		{},
+		{State: "healthy", MemberCount: 3, AppVersion: "20261016-0830"},
+		{State: "unconfigured"},
+	}
+	for _, d := range valid {
+		if err := validateHeartbeatDigest(d); err != nil {
+			t.Errorf("digest %+v rejected: %v", d, err)
+		}
+	}
+	invalid := []heartbeatDigest{
+		{State: "exploding"},
+		{MemberCount: -1},
+		{AppVersion: "1.0|healthy"},
+		{AppVersion: "1.0\nforged"},
+	}
+	for _, d := range invalid {
+		if err := validateHeartbeatDigest(d); err == nil {
+			t.Errorf("digest %+v accepted", d)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"p2pos/internal/config"
+
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+)
+
+const httpSeedTimeout = 10 * time.Second
+const httpSeedMaxBytes = 1 << 20
+
+// httpSeedSourceResolver fetches a JSON array of p2p multiaddrs from
+// Connection.Address. The default transport honours HTTP(S)_PROXY.
+type httpSeedSourceResolver struct {
+	client *http.Client
+}
+
+func newHTTPSeedSourceResolver() *httpSeedSourceResolver {
+	return &httpSeedSourceResolver{
+		client: &http.Client{Timeout: httpSeedTimeout},
+	}
+}
+
+func (r *httpSeedSourceResolver) ResolveSource(ctx context.Context, conn config.Connection) ([]peerstore.AddrInfo, error) {
+	endpoint := strings.TrimSpace(conn.Address)
+	if endpoint == "" {
+		return nil, fmt.Errorf("http seed list url is empty")
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, httpSeedTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("http %s request invalid: %w", endpoint, err)
+	}
+	req.Header.Set("accept", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("http %s fetch failed: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("http %s returned status %d", endpoint, resp.StatusCode)
+	}
+
+	var values []string
+	if err := json.NewDecoder(io.LimitReader(resp.Body, httpSeedMaxBytes)).Decode(&values); err != nil {
+		return nil, fmt.Errorf("http %s seed list malformed: %w", endpoint, err)
+	}
+
+	var (
+		out  []peerstore.AddrInfo
+		errs []error
+	)
+	for _, raw := range values {
+		value := strings.TrimSpace(raw)
+		if value == "" {
+			continue
+		}
+		peerInfo, err := ParseP2PAddr(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("http %s parse failed for %q: %w", endpoint, value, err))
+			continue
+		}
+		out = append(out, *peerInfo)
+	}
+	return out, errors.Join(errs...)
+}
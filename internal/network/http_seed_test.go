@@ -0,0 +1,74 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"p2pos/internal/config"
+)
+
+func TestHTTPSeedList(t *testing.T) {
+	_, a := newTestPeer(t)
+	_, b := newTestPeer(t)
+	seeds := []string{
+		"/ip4/198.51.100.1/tcp/4100/p2p/" + a.String(),
+		" ",
+		"/ip4/198.51.100.2/tcp/4100/p2p/" + b.String(),
+		"not a multiaddr",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/seeds":
+			_ = json.NewEncoder(w).Encode(seeds)
+		case "/broken":
+			_, _ = w.Write([]byte(`{"not": "a list"`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	resolver := newHTTPSeedSourceResolver()
+	ctx := context.Background()
+
+	peers, err := resolver.ResolveSource(ctx, config.Connection{Type: "http", Address: server.URL + "/seeds"})
+	if err == nil {
+		t.Fatal("the unparsable seed was not reported")
+	}
+	if len(peers) != 2 || peers[0].ID != a || peers[1].ID != b {
+		t.Fatalf("resolved %v, want both valid seeds", peers)
+	}
+
+	for _, path := range []string{"/broken", "/missing"} {
+		peers, err := resolver.ResolveSource(ctx, config.Connection{Type: "http", Address: server.URL + path})
+		if err == nil || len(peers) != 0 {
+			t.Fatalf("%s: got %v, %v, want an error and no peers", path, peers, err)
+		}
+	}
+}
+
+func TestHTTPSeedListIsRegistered(t *testing.T) {
+	_, self := newTestPeer(t)
+	_, other := newTestPeer(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode([]string{
+			"/ip4/198.51.100.1/tcp/4100/p2p/" + other.String(),
+			"/ip4/198.51.100.9/tcp/4100/p2p/" + self.String(),
+		})
+	}))
+	defer server.Close()
+
+	resolver := NewConfigResolver(self, staticConfig(config.Config{InitConnections: []config.Connection{
+		{Type: "http", Address: server.URL},
+	}}), nil)
+	peers, err := resolver.Resolve(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(peers) != 1 || peers[0].ID != other {
+		t.Fatalf("resolved %v, want the seeded peer without ourselves", peers)
+	}
+}
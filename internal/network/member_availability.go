@@ -0,0 +1,164 @@
+package network
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"p2pos/internal/events"
+	"p2pos/internal/logging"
+
+	libp2pnet "github.com/libp2p/go-libp2p/core/network"
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+)
+
+const memberAvailabilityInterval = 10 * time.Second
+
+// MemberAvailability reports whether a member of the current snapshot is
+// connected. Down is set once it has been offline longer than the alert
+// grace.
+type MemberAvailability struct {
+	PeerID       string     `json:"peer_id"`
+	Online       bool       `json:"online"`
+	OfflineSince *time.Time `json:"offline_since,omitempty"`
+	Down         bool       `json:"down"`
+}
+
+// availabilityTracker remembers when each member was first seen offline and
+// whether MemberDown was already raised for that outage.
+type availabilityTracker struct {
+	mu      sync.Mutex
+	grace   time.Duration
+	offline map[string]time.Time
+	alerted map[string]bool
+}
+
+func newAvailabilityTracker(grace time.Duration) *availabilityTracker {
+	return &availabilityTracker{
+		grace:   grace,
+		offline: make(map[string]time.Time),
+		alerted: make(map[string]bool),
+	}
+}
+
+// observe records one sweep. It returns the members that crossed the grace in
+// this sweep and those that came back after an alert.
+func (t *availabilityTracker) observe(online map[string]bool, now time.Time) (down, recovered []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for id := range t.offline {
+		if _, member := online[id]; !member {
+			delete(t.offline, id)
+			delete(t.alerted, id)
+		}
+	}
+	for id, up := range online {
+		if up {
+			if t.alerted[id] {
+				recovered = append(recovered, id)
+			}
+			delete(t.offline, id)
+			delete(t.alerted, id)
+			continue
+		}
+		since, ok := t.offline[id]
+		if !ok {
+			t.offline[id] = now
+			continue
+		}
+		if !t.alerted[id] && now.Sub(since) >= t.grace {
+			t.alerted[id] = true
+			down = append(down, id)
+		}
+	}
+	sort.Strings(down)
+	sort.Strings(recovered)
+	return down, recovered
+}
+
+func (t *availabilityTracker) offlineSince(id string) time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.offline[id]
+}
+
+func (t *availabilityTracker) report(online map[string]bool) []MemberAvailability {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]MemberAvailability, 0, len(online))
+	for id, up := range online {
+		entry := MemberAvailability{PeerID: id, Online: up}
+		if since, ok := t.offline[id]; ok && !up {
+			since := since.UTC()
+			entry.OfflineSince = &since
+			entry.Down = t.alerted[id]
+		}
+		out = append(out, entry)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].PeerID < out[j].PeerID })
+	return out
+}
+
+// memberOnline maps every remote member of the current snapshot to whether
+// it is connected.
+func (n *Node) memberOnline() map[string]bool {
+	snap, ok := n.membershipSnapshot()
+	if !ok {
+		return map[string]bool{}
+	}
+	local := n.Host.ID().String()
+	out := make(map[string]bool, len(snap.Members))
+	for _, id := range snap.Members {
+		if id == local {
+			continue
+		}
+		pid, err := peerstore.Decode(id)
+		out[id] = err == nil && n.Host.Network().Connectedness(pid) == libp2pnet.Connected
+	}
+	return out
+}
+
+// MemberAvailability cross-references the member set with live connections.
+func (n *Node) MemberAvailability() []MemberAvailability {
+	return n.availability.report(n.memberOnline())
+}
+
+// startAvailabilityWatcher raises events.MemberDown for members that stay
+// disconnected past the alert grace, once per outage.
+func (n *Node) startAvailabilityWatcher() {
+	go func() {
+		ticker := time.NewTicker(memberAvailabilityInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-n.lifecycle.Done():
+				return
+			case <-ticker.C:
+			}
+			if !n.canUseBusinessProtocols() {
+				continue
+			}
+			now := time.Now()
+			down, recovered := n.availability.observe(n.memberOnline(), now)
+			for _, id := range down {
+				since := n.availability.offlineSince(id)
+				logging.Log("NODE", "member_down", map[string]string{
+					"peer_id":       id,
+					"offline_since": since.UTC().Format(time.RFC3339),
+				})
+				if n.bus != nil {
+					n.bus.Publish(events.MemberDown{
+						PeerID:       id,
+						OfflineSince: since.UTC(),
+						At:           now.UTC(),
+					})
+				}
+			}
+			for _, id := range recovered {
+				logging.Log("NODE", "member_recovered", map[string]string{
+					"peer_id": id,
+				})
+			}
+		}
+	}()
+}
@@ -0,0 +1,45 @@
+package network
+
+import (
+	"testing"
+	"time"
+
+	"p2pos/internal/membership"
+)
+
+func TestAbsentMemberGoesDownAfterGrace(t *testing.T) {
+	h := newTestHost(t)
+	_, absent := newTestPeer(t)
+	manager, err := membership.NewManager("test", "", h.ID().String(), []string{h.ID().String(), absent.String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := &Node{Host: h, membership: manager, availability: newAvailabilityTracker(time.Minute)}
+
+	online := n.memberOnline()
+	if up, ok := online[absent.String()]; !ok || up || len(online) != 1 {
+		t.Fatalf("member online map %v, want only the absent member, offline", online)
+	}
+	start := time.Now()
+	if down, _ := n.availability.observe(online, start); len(down) != 0 {
+		t.Fatalf("down %v on first sight", down)
+	}
+	if down, _ := n.availability.observe(online, start.Add(30*time.Second)); len(down) != 0 {
+		t.Fatalf("down %v within the grace", down)
+	}
+	down, _ := n.availability.observe(online, start.Add(time.Minute))
+	if len(down) != 1 || down[0] != absent.String() {
+		t.Fatalf("down %v after the grace, want the absent member", down)
+	}
+	if down, _ := n.availability.observe(online, start.Add(2*time.Minute)); len(down) != 0 {
+		t.Fatalf("MemberDown raised twice for one outage: %v", down)
+	}
+	if report := n.MemberAvailability(); len(report) != 1 || !report[0].Down || report[0].OfflineSince == nil {
+		t.Fatalf("availability %+v, want the member reported down", report)
+	}
+
+	_, recovered := n.availability.observe(map[string]bool{absent.String(): true}, start.Add(3*time.Minute))
+	if len(recovered) != 1 {
+		t.Fatalf("recovered %v, want the member back", recovered)
+	}
+}
@@ -3,6 +3,7 @@ package network
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -22,7 +23,7 @@ type membershipResponse struct {
 }
 
 func (n *Node) registerMembershipHandler() {
-	n.Host.SetStreamHandler(membershipProtocolID, func(stream libp2pnet.Stream) {
+	n.setBusinessHandler(membershipProtocolID, func(stream libp2pnet.Stream) {
 		defer stream.Close()
 
 		resp := membershipResponse{}
@@ -50,7 +51,7 @@ func (n *Node) membershipSnapshot() (membership.Snapshot, bool) {
 }
 
 func (n *Node) fetchMembershipSnapshot(ctx context.Context, peerID peerstore.ID) (membership.Snapshot, error) {
-	stream, err := n.Host.NewStream(ctx, peerID, membershipProtocolID)
+	stream, err := n.newCountedStream(ctx, peerID, membershipProtocolID)
 	if err != nil {
 		return membership.Snapshot{}, err
 	}
@@ -61,7 +62,7 @@ func (n *Node) fetchMembershipSnapshot(ctx context.Context, peerID peerstore.ID)
 		return membership.Snapshot{}, err
 	}
 	if resp.Error != "" {
-		return membership.Snapshot{}, fmt.Errorf(resp.Error)
+		return membership.Snapshot{}, errors.New(resp.Error)
 	}
 	return resp.Snapshot, nil
 }
@@ -88,21 +89,29 @@ func (n *Node) SyncMembership(ctx context.Context) error {
 			continue
 		}
 
-		before := manager.Snapshot().IssuedAt
-		if err := manager.Apply(snapshot); err != nil {
+		change, err := manager.Apply(snapshot)
+		if n.isMember(peerID.String()) {
+			n.checkMembershipDivergence(peerID.String(), membership.Hash(manager.Snapshot()), membership.Hash(snapshot))
+		}
+		if err != nil {
 			logging.Log("MEMBERSHIP", "reject_snapshot", map[string]string{
 				"peer_id": peerID.String(),
 				"reason":  err.Error(),
 			})
+			if errors.Is(err, membership.ErrInvalidSnapshot) {
+				n.recordValidationFailure(peerID, "snapshot", err)
+			}
 			continue
 		}
-		after := manager.Snapshot().IssuedAt
-		if after.After(before) {
+		if change != nil {
 			n.notifyMembershipApplied(manager.Snapshot())
+			n.publishMembershipChange(change, peerID.String())
 			logging.Log("MEMBERSHIP", "apply_snapshot", map[string]string{
 				"peer_id":   peerID.String(),
-				"issued_at": after.UTC().Format(time.RFC3339Nano),
+				"issued_at": change.IssuedAt.Format(time.RFC3339Nano),
 				"members":   fmt.Sprintf("%d", len(manager.Snapshot().Members)),
+				"added":     fmt.Sprintf("%d", len(change.Added)),
+				"removed":   fmt.Sprintf("%d", len(change.Removed)),
 			})
 		}
 	}
@@ -1,6 +1,18 @@
 package network
 
-import "p2pos/internal/membership"
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"p2pos/internal/config"
+	"p2pos/internal/events"
+	"p2pos/internal/logging"
+	"p2pos/internal/membership"
+
+	libp2pnet "github.com/libp2p/go-libp2p/core/network"
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+)
 
 func (n *Node) notifyMembershipApplied(snapshot membership.Snapshot) {
 	n.memberMu.RLock()
@@ -11,3 +23,117 @@ func (n *Node) notifyMembershipApplied(snapshot membership.Snapshot) {
 	}
 	fn(snapshot)
 }
+
+func (n *Node) publishMembershipChange(change *membership.Change, receivedFrom string) {
+	if change == nil {
+		return
+	}
+	if n.Host != nil && containsPeer(change.Removed, n.Host.ID().String()) {
+		n.handleSelfRemoved(change, receivedFrom)
+	}
+	if n.bus == nil {
+		return
+	}
+	n.bus.Publish(events.MembershipChanged{
+		ClusterID:    change.ClusterID,
+		IssuerPeerID: change.IssuerPeerID,
+		ReceivedFrom: receivedFrom,
+		IssuedAt:     change.IssuedAt,
+		Added:        append([]string(nil), change.Added...),
+		Removed:      append([]string(nil), change.Removed...),
+		At:           time.Now().UTC(),
+	})
+}
+
+// guardMembershipChange runs before a snapshot is installed. Removing members
+// that are currently connected is logged, and refused for remote snapshots
+// when membership.disconnect_policy is "refuse". A snapshot whose issuer is
+// not a member only reaches here under membership.issuer_policy "warn".
+func (n *Node) guardMembershipChange(change *membership.Change) error {
+	if change == nil || n.Host == nil {
+		return nil
+	}
+	if change.IssuerNotMember {
+		logging.Log("MEMBERSHIP", "apply_issuer_not_member", map[string]string{
+			"issuer_peer_id": change.IssuerPeerID,
+			"issued_at":      change.IssuedAt.Format(time.RFC3339Nano),
+		})
+	}
+	local := n.Host.ID().String()
+	connected := make([]string, 0)
+	for _, id := range change.Removed {
+		if id == local {
+			continue
+		}
+		pid, err := peerstore.Decode(id)
+		if err != nil {
+			continue
+		}
+		if n.Host.Network().Connectedness(pid) == libp2pnet.Connected {
+			connected = append(connected, id)
+		}
+	}
+	if len(connected) == 0 {
+		return nil
+	}
+
+	refuse := n.disconnectPolicy == config.MembershipDisconnectRefuse && change.IssuerPeerID != local
+	logging.Log("MEMBERSHIP", "apply_disconnects_members", map[string]string{
+		"issuer_peer_id": change.IssuerPeerID,
+		"issued_at":      change.IssuedAt.Format(time.RFC3339Nano),
+		"peers":          strings.Join(connected, ","),
+		"policy":         n.disconnectPolicy,
+		"refused":        fmt.Sprintf("%t", refuse),
+	})
+	if refuse {
+		return fmt.Errorf("snapshot would disconnect %d connected members", len(connected))
+	}
+	return nil
+}
+
+// handleSelfRemoved reacts to a snapshot that dropped the local node, per
+// membership.self_removed_policy. The runtime state falls to unconfigured
+// either way; "hold" also refuses every connection until restart and
+// "shutdown" stops the process.
+func (n *Node) handleSelfRemoved(change *membership.Change, receivedFrom string) {
+	logging.Log("MEMBERSHIP", "self_removed", map[string]string{
+		"issuer_peer_id": change.IssuerPeerID,
+		"received_from":  receivedFrom,
+		"issued_at":      change.IssuedAt.Format(time.RFC3339Nano),
+		"policy":         n.selfRemovedPolicy,
+	})
+	if n.bus != nil {
+		n.bus.Publish(events.SelfRemoved{
+			ClusterID:    change.ClusterID,
+			IssuerPeerID: change.IssuerPeerID,
+			ReceivedFrom: receivedFrom,
+			IssuedAt:     change.IssuedAt,
+			Policy:       n.selfRemovedPolicy,
+			At:           time.Now().UTC(),
+		})
+	}
+	switch n.selfRemovedPolicy {
+	case config.SelfRemovedHold:
+		n.gater.hold()
+		n.Pause()
+		for _, pid := range n.Host.Network().Peers() {
+			_ = n.Host.Network().ClosePeer(pid)
+		}
+	case config.SelfRemovedShutdown:
+		if n.bus != nil {
+			n.bus.Publish(events.ShutdownRequested{
+				Reason: "self-removed",
+				At:     time.Now().UTC(),
+			})
+		}
+	}
+}
+
+func containsPeer(ids []string, id string) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
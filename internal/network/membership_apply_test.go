@@ -0,0 +1,66 @@
+package network
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"p2pos/internal/config"
+	"p2pos/internal/membership"
+
+	libp2pnet "github.com/libp2p/go-libp2p/core/network"
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestGuardMembershipChangeDisconnects(t *testing.T) {
+	local := newTestHost(t)
+	remote := newTestHost(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := local.Connect(ctx, peerstore.AddrInfo{ID: remote.ID(), Addrs: remote.Addrs()}); err != nil {
+		t.Fatal(err)
+	}
+
+	change := &membership.Change{
+		IssuerPeerID: remote.ID().String(),
+		IssuedAt:     time.Now().UTC(),
+		Removed:      []string{remote.ID().String()},
+	}
+
+	warn := &Node{Host: local, disconnectPolicy: config.MembershipDisconnectWarn}
+	if err := warn.guardMembershipChange(change); err != nil {
+		t.Fatalf("warn policy refused: %v", err)
+	}
+
+	refuse := &Node{Host: local, disconnectPolicy: config.MembershipDisconnectRefuse}
+	if err := refuse.guardMembershipChange(change); err == nil {
+		t.Fatal("refuse policy allowed a snapshot disconnecting a connected member")
+	}
+
+	change.IssuerPeerID = local.ID().String()
+	if err := refuse.guardMembershipChange(change); err != nil {
+		t.Fatalf("refuse policy blocked the local admin's own snapshot: %v", err)
+	}
+}
+
+func TestSelfRemovedHoldSurvivesResume(t *testing.T) {
+	_, remote := newTestPeer(t)
+	n := &Node{
+		Host:              newTestHost(t),
+		gater:             newConnectionGater(true),
+		selfRemovedPolicy: config.SelfRemovedHold,
+	}
+	n.handleSelfRemoved(&membership.Change{IssuedAt: time.Now().UTC()}, "")
+	if !n.Paused() {
+		t.Fatal("the hold policy did not pause the node")
+	}
+
+	// A manual-start resume (SIGUSR1) must not lift the hold.
+	n.Resume()
+	if n.gater.InterceptPeerDial(remote) {
+		t.Fatal("dialed out after Resume on a node held for self-removal")
+	}
+	if n.gater.InterceptSecured(libp2pnet.DirInbound, remote, nil) {
+		t.Fatal("accepted a connection after Resume on a node held for self-removal")
+	}
+}
@@ -0,0 +1,90 @@
+package network
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"p2pos/internal/logging"
+)
+
+// membershipDivergenceWindow is how long a member may hold a different
+// snapshot before it counts as divergent. Ordinary propagation settles within
+// a sync round or two; a split brain, e.g. two admins publishing snapshots
+// with the same issued_at, never does.
+const membershipDivergenceWindow = 2 * time.Minute
+
+// divergenceTracker remembers since when each peer's snapshot has differed
+// from ours.
+type divergenceTracker struct {
+	mu      sync.Mutex
+	since   map[string]time.Time
+	alerted map[string]bool
+}
+
+func newDivergenceTracker() *divergenceTracker {
+	return &divergenceTracker{
+		since:   make(map[string]time.Time),
+		alerted: make(map[string]bool),
+	}
+}
+
+// record notes whether peerID's snapshot matched ours at now. It reports how
+// long the peer has diverged and whether that just crossed the window.
+func (t *divergenceTracker) record(peerID string, divergent bool, now time.Time) (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !divergent {
+		delete(t.since, peerID)
+		delete(t.alerted, peerID)
+		return 0, false
+	}
+	since, ok := t.since[peerID]
+	if !ok {
+		t.since[peerID] = now
+		return 0, false
+	}
+	age := now.Sub(since)
+	if age < membershipDivergenceWindow || t.alerted[peerID] {
+		return age, false
+	}
+	t.alerted[peerID] = true
+	return age, true
+}
+
+func (t *divergenceTracker) forget(peerID string) {
+	t.mu.Lock()
+	delete(t.since, peerID)
+	delete(t.alerted, peerID)
+	t.mu.Unlock()
+}
+
+// divergent lists peers whose snapshot has differed for longer than the
+// window.
+func (t *divergenceTracker) divergent(now time.Time) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]string, 0)
+	for id, since := range t.since {
+		if now.Sub(since) >= membershipDivergenceWindow {
+			out = append(out, id)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// checkMembershipDivergence compares a member's snapshot hash with ours and
+// logs once when a difference outlasts the window.
+func (n *Node) checkMembershipDivergence(peerID, localHash, remoteHash string) {
+	age, alert := n.divergence.record(peerID, localHash != remoteHash, time.Now())
+	if !alert {
+		return
+	}
+	logging.Log("MEMBERSHIP", "snapshot_divergent", map[string]string{
+		"peer_id":     peerID,
+		"local_hash":  localHash,
+		"remote_hash": remoteHash,
+		"for":         age.Round(time.Second).String(),
+	})
+}
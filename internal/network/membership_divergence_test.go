@@ -0,0 +1,46 @@
+package network
+
+import (
+	"testing"
+	"time"
+
+	"p2pos/internal/membership"
+)
+
+func TestDivergentSnapshotsDetected(t *testing.T) {
+	issued := time.Now().UTC()
+	// Two admins publishing different member sets with the same issued_at.
+	ours := membership.Snapshot{ClusterID: "test", IssuedAt: issued, IssuerPeerID: "admin-a", Members: []string{"admin-a", "x"}}
+	theirs := membership.Snapshot{ClusterID: "test", IssuedAt: issued, IssuerPeerID: "admin-b", Members: []string{"admin-b", "y"}}
+	local, remote := membership.Hash(ours), membership.Hash(theirs)
+	if local == remote {
+		t.Fatal("divergent snapshots hash the same")
+	}
+	if membership.Hash(ours) != local {
+		t.Fatal("snapshot hash is not stable")
+	}
+
+	tracker := newDivergenceTracker()
+	start := time.Now()
+	if _, alert := tracker.record("peer", local != remote, start); alert {
+		t.Fatal("alerted on first sight")
+	}
+	if got := tracker.divergent(start.Add(time.Second)); len(got) != 0 {
+		t.Fatalf("divergent %v within the window", got)
+	}
+	later := start.Add(membershipDivergenceWindow)
+	if _, alert := tracker.record("peer", true, later); !alert {
+		t.Fatal("no alert once the divergence outlasted the window")
+	}
+	if _, alert := tracker.record("peer", true, later.Add(time.Minute)); alert {
+		t.Fatal("alerted twice for one divergence")
+	}
+	if got := tracker.divergent(later); len(got) != 1 || got[0] != "peer" {
+		t.Fatalf("divergent %v, want [peer]", got)
+	}
+
+	tracker.record("peer", false, later.Add(2*time.Minute))
+	if got := tracker.divergent(later.Add(time.Hour)); len(got) != 0 {
+		t.Fatalf("divergent %v after the snapshots converged", got)
+	}
+}
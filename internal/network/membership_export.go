@@ -0,0 +1,64 @@
+package network
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"p2pos/internal/logging"
+	"p2pos/internal/membership"
+)
+
+// ExportMembership writes the current signed membership snapshot as JSON.
+// Unlike config members, which are only a hint, the snapshot carries the
+// issuer signature and admin proof, so any node of the cluster can verify it.
+func (n *Node) ExportMembership(w io.Writer) error {
+	snapshot, ok := n.membershipSnapshot()
+	if !ok {
+		return fmt.Errorf("membership not initialized")
+	}
+	if snapshot.Sig == "" {
+		return fmt.Errorf("no signed membership snapshot to export yet")
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(snapshot)
+}
+
+// ImportMembership reads a snapshot written by ExportMembership and applies
+// it with the usual signature, admin proof and freshness checks. It needs no
+// live admin and works on an unconfigured node, which is how a new bootstrap
+// node learns the cluster. The snapshot is not pushed to peers; they already
+// have it or will sync it.
+func (n *Node) ImportMembership(r io.Reader) error {
+	var snapshot membership.Snapshot
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return fmt.Errorf("decode membership snapshot failed: %w", err)
+	}
+
+	n.memberMu.RLock()
+	manager := n.membership
+	n.memberMu.RUnlock()
+	if manager == nil {
+		return fmt.Errorf("membership not initialized")
+	}
+
+	change, err := manager.Apply(snapshot)
+	if err != nil {
+		return err
+	}
+	if change == nil {
+		return errors.New("snapshot is not newer than the current one")
+	}
+	n.notifyMembershipApplied(manager.Snapshot())
+	n.publishMembershipChange(change, "")
+	logging.Log("MEMBERSHIP", "import_snapshot", map[string]string{
+		"peer_id":   change.IssuerPeerID,
+		"issued_at": change.IssuedAt.Format(time.RFC3339Nano),
+		"members":   fmt.Sprintf("%d", len(snapshot.Members)),
+	})
+	n.evaluateRuntimeState("membership-import")
+	return nil
+}
@@ -3,9 +3,12 @@ package network
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	"p2pos/internal/audit"
 	"p2pos/internal/logging"
 	"p2pos/internal/membership"
 
@@ -15,6 +18,7 @@ import (
 )
 
 const membershipPushProtocolID = protocol.ID("/p2pos/membership-push/1.0.0")
+const membershipFanoutTimeout = 30 * time.Second
 
 type membershipPushResponse struct {
 	Applied bool   `json:"applied"`
@@ -22,7 +26,7 @@ type membershipPushResponse struct {
 }
 
 func (n *Node) registerMembershipPushHandler() {
-	n.Host.SetStreamHandler(membershipPushProtocolID, func(stream libp2pnet.Stream) {
+	n.setBusinessHandler(membershipPushProtocolID, func(stream libp2pnet.Stream) {
 		defer stream.Close()
 
 		var snapshot membership.Snapshot
@@ -39,24 +43,31 @@ func (n *Node) registerMembershipPushHandler() {
 			return
 		}
 
-		before := manager.Snapshot().IssuedAt
-		if err := manager.Apply(snapshot); err != nil {
+		change, err := manager.Apply(snapshot)
+		if err != nil {
 			logging.Log("MEMBERSHIP", "reject_snapshot", map[string]string{
 				"peer_id": snapshot.IssuerPeerID,
 				"reason":  err.Error(),
 			})
+			if errors.Is(err, membership.ErrInvalidSnapshot) {
+				n.recordValidationFailure(stream.Conn().RemotePeer(), "snapshot", err)
+			}
 			_ = json.NewEncoder(stream).Encode(membershipPushResponse{Applied: false, Error: err.Error()})
 			return
 		}
-		after := manager.Snapshot().IssuedAt
 
 		logging.Log("MEMBERSHIP", "apply_snapshot_push", map[string]string{
 			"peer_id":   snapshot.IssuerPeerID,
 			"issued_at": snapshot.IssuedAt.UTC().Format(time.RFC3339Nano),
 			"members":   fmt.Sprintf("%d", len(snapshot.Members)),
 		})
-		if after.After(before) {
+		if change != nil {
 			n.notifyMembershipApplied(manager.Snapshot())
+			receivedFrom := ""
+			if stream.Conn() != nil {
+				receivedFrom = stream.Conn().RemotePeer().String()
+			}
+			n.publishMembershipChange(change, receivedFrom)
 		}
 		n.evaluateRuntimeState("membership-push")
 		_ = json.NewEncoder(stream).Encode(membershipPushResponse{Applied: true})
@@ -64,17 +75,22 @@ func (n *Node) registerMembershipPushHandler() {
 		// Propagate only when the pusher is the snapshot issuer to avoid endless relay loops.
 		// This covers admin->node direct push from Web Admin and gives one-hop fanout.
 		if stream.Conn() != nil && stream.Conn().RemotePeer().String() == snapshot.IssuerPeerID {
-			n.fanoutSnapshot(stream.Conn().RemotePeer(), snapshot)
+			n.fanoutSnapshot(n.lifecycle, stream.Conn().RemotePeer(), snapshot)
 		}
 	})
 }
 
-func (n *Node) PublishMembershipSnapshot(ctx context.Context, members []string) error {
-	if !n.canWriteAdmin() {
+// PublishMembershipSnapshot signs and applies members locally, then pushes
+// the snapshot to connected peers. It returns once the configured ack quorum
+// is reached (or every push finished); remaining pushes continue in the
+// background and are reported as pending.
+func (n *Node) PublishMembershipSnapshot(ctx context.Context, members []string) (PushReport, error) {
+	bootstrap := n.canBootstrapAdmin()
+	if !n.canWriteAdmin() && !bootstrap {
 		logging.Log("MEMBERSHIP", "publish_denied", map[string]string{
 			"state": string(n.RuntimeState()),
 		})
-		return fmt.Errorf("node not healthy")
+		return PushReport{}, fmt.Errorf("node not healthy")
 	}
 
 	n.memberMu.RLock()
@@ -82,13 +98,28 @@ func (n *Node) PublishMembershipSnapshot(ctx context.Context, members []string)
 	proof := n.adminProof
 	n.memberMu.RUnlock()
 	if manager == nil {
-		return fmt.Errorf("membership not initialized")
+		return PushReport{}, fmt.Errorf("membership not initialized")
 	}
 	if proof == nil {
-		return fmt.Errorf("admin_proof not configured")
+		return PushReport{}, fmt.Errorf("admin_proof not configured")
 	}
 	if err := manager.ValidateAdminProof(*proof, proof.PeerID); err != nil {
-		return err
+		return PushReport{}, err
+	}
+	if bootstrap {
+		logging.Log("MEMBERSHIP", "publish_admin_bootstrap", map[string]string{
+			"peer_id": proof.PeerID,
+		})
+	}
+
+	members, invalid := membership.SplitPeerIDs(members)
+	for _, id := range invalid {
+		logging.Log("MEMBERSHIP", "publish_member_invalid", map[string]string{
+			"peer_id": id,
+		})
+	}
+	if max := manager.MaxMembers(); max > 0 && len(members) > max {
+		return PushReport{}, fmt.Errorf("members count %d exceeds limit %d", len(members), max)
 	}
 
 	clusterID := manager.Snapshot().ClusterID
@@ -101,45 +132,103 @@ func (n *Node) PublishMembershipSnapshot(ctx context.Context, members []string)
 	}
 	signed, err := membership.SignSnapshot(n.privKey, snapshot)
 	if err != nil {
-		return err
+		return PushReport{}, err
 	}
 
-	if err := manager.Apply(signed); err != nil {
-		return err
+	change, err := manager.Apply(signed)
+	if err != nil {
+		return PushReport{}, err
 	}
 	n.notifyMembershipApplied(manager.Snapshot())
+	n.publishMembershipChange(change, n.Host.ID().String())
+	if bootstrap {
+		n.evaluateRuntimeState("membership-admin-bootstrap")
+	}
+	n.recordAdminAction(ctx, audit.ActionMembershipPublish, map[string]string{
+		"cluster_id": signed.ClusterID,
+		"issued_at":  signed.IssuedAt.Format(time.RFC3339Nano),
+		"members":    strings.Join(signed.Members, ","),
+	})
 
-	for _, peerID := range n.Host.Network().Peers() {
-		if err := n.pushSnapshot(ctx, peerID, signed); err != nil {
-			logging.Log("MEMBERSHIP", "push_failed", map[string]string{
-				"peer_id": peerID.String(),
-				"reason":  err.Error(),
-			})
-		}
+	peers := n.Host.Network().Peers()
+	quorum := n.pushAckQuorum
+	if quorum <= 0 {
+		quorum = len(peers)/2 + 1
 	}
+	return n.pushToPeers(ctx, peers, signed, quorum, "push_failed"), nil
+}
 
-	return nil
+// RelayMembershipSnapshot applies a snapshot that was signed elsewhere, by an
+// admin key that need not live on this node, and pushes it to connected
+// peers like PublishMembershipSnapshot. The node only relays: the snapshot
+// must carry its issuer's signature and an admin proof for that issuer that
+// the system key vouches for.
+func (n *Node) RelayMembershipSnapshot(ctx context.Context, snapshot membership.Snapshot) (PushReport, error) {
+	if !n.canWriteAdmin() {
+		logging.Log("MEMBERSHIP", "relay_denied", map[string]string{
+			"state": string(n.RuntimeState()),
+		})
+		return PushReport{}, fmt.Errorf("node not healthy")
+	}
+
+	n.memberMu.RLock()
+	manager := n.membership
+	n.memberMu.RUnlock()
+	if manager == nil {
+		return PushReport{}, fmt.Errorf("membership not initialized")
+	}
+	// Apply skips the proof when no system key is configured; a relayed
+	// snapshot has no local admin to fall back on, so require it here.
+	if err := manager.ValidateAdminProof(snapshot.AdminProof, snapshot.IssuerPeerID); err != nil {
+		return PushReport{}, err
+	}
+
+	change, err := manager.Apply(snapshot)
+	if err != nil {
+		return PushReport{}, err
+	}
+	if change == nil {
+		return PushReport{}, fmt.Errorf("snapshot is not newer than the current one")
+	}
+	n.notifyMembershipApplied(manager.Snapshot())
+	n.publishMembershipChange(change, n.Host.ID().String())
+	n.recordAdminAction(ctx, audit.ActionMembershipRelay, map[string]string{
+		"cluster_id": change.ClusterID,
+		"issuer":     change.IssuerPeerID,
+		"issued_at":  change.IssuedAt.Format(time.RFC3339Nano),
+		"members":    strings.Join(snapshot.Members, ","),
+	})
+	logging.Log("MEMBERSHIP", "relay_snapshot", map[string]string{
+		"issuer":    change.IssuerPeerID,
+		"issued_at": change.IssuedAt.Format(time.RFC3339Nano),
+		"members":   fmt.Sprintf("%d", len(snapshot.Members)),
+	})
+
+	peers := n.Host.Network().Peers()
+	quorum := n.pushAckQuorum
+	if quorum <= 0 {
+		quorum = len(peers)/2 + 1
+	}
+	return n.pushToPeers(ctx, peers, snapshot, quorum, "relay_failed"), nil
 }
 
-func (n *Node) fanoutSnapshot(source peerstore.ID, snapshot membership.Snapshot) {
+// fanoutSnapshot pushes snapshot to every connected peer except source,
+// bounded by membershipFanoutTimeout.
+func (n *Node) fanoutSnapshot(ctx context.Context, source peerstore.ID, snapshot membership.Snapshot) {
+	peers := make([]peerstore.ID, 0)
 	for _, peerID := range n.Host.Network().Peers() {
-		if peerID == source {
-			continue
-		}
-		if err := n.pushSnapshot(context.Background(), peerID, snapshot); err != nil {
-			logging.Log("MEMBERSHIP", "fanout_failed", map[string]string{
-				"peer_id": peerID.String(),
-				"reason":  err.Error(),
-			})
+		if peerID != source {
+			peers = append(peers, peerID)
 		}
 	}
+	n.pushToPeers(ctx, peers, snapshot, len(peers), "fanout_failed")
 }
 
 func (n *Node) pushSnapshot(ctx context.Context, peerID peerstore.ID, snapshot membership.Snapshot) error {
 	reqCtx, cancel := context.WithTimeout(ctx, 8*time.Second)
 	defer cancel()
 
-	stream, err := n.Host.NewStream(reqCtx, peerID, membershipPushProtocolID)
+	stream, err := n.newCountedStream(reqCtx, peerID, membershipPushProtocolID)
 	if err != nil {
 		return err
 	}
@@ -157,7 +246,7 @@ func (n *Node) pushSnapshot(ctx context.Context, peerID peerstore.ID, snapshot m
 		if resp.Error == "" {
 			resp.Error = "push rejected"
 		}
-		return fmt.Errorf(resp.Error)
+		return errors.New(resp.Error)
 	}
 	return nil
 }
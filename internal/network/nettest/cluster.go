@@ -0,0 +1,131 @@
+// Package nettest spins up connected in-process nodes for cluster-level
+// tests of quorum, fan-out and membership sync.
+package nettest
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"testing"
+	"time"
+
+	"p2pos/internal/events"
+	"p2pos/internal/membership"
+	"p2pos/internal/network"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+)
+
+const defaultClusterID = "nettest"
+
+// Cluster is a set of in-process nodes, each with its own event bus, whose
+// membership lists every node.
+type Cluster struct {
+	Nodes    []*network.Node
+	Managers []*membership.Manager
+	Buses    []*events.Bus
+}
+
+// NewCluster starts n nodes on loopback, gives each a membership manager
+// holding all n peer IDs and registers cleanup with tb. The nodes are not
+// connected; call ConnectAll.
+func NewCluster(tb testing.TB, n int) *Cluster {
+	tb.Helper()
+	c := &Cluster{}
+	members := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		priv, _, err := crypto.GenerateEd25519Key(rand.Reader)
+		if err != nil {
+			tb.Fatalf("generate key: %v", err)
+		}
+		node, bus := NewNode(tb, &Config{Key: priv})
+		c.Nodes = append(c.Nodes, node)
+		c.Buses = append(c.Buses, bus)
+		members = append(members, node.Host.ID().String())
+	}
+	for _, node := range c.Nodes {
+		manager, err := membership.NewManager(defaultClusterID, "", node.Host.ID().String(), members)
+		if err != nil {
+			tb.Fatalf("membership manager: %v", err)
+		}
+		node.SetMembershipManager(manager)
+		c.Managers = append(c.Managers, manager)
+	}
+	return c
+}
+
+// NewNode starts a single node from cfg with a fresh event bus and closes it
+// when tb finishes.
+func NewNode(tb testing.TB, cfg *Config) (*network.Node, *events.Bus) {
+	tb.Helper()
+	bus := events.NewBus()
+	node, err := network.NewNode(cfg, bus)
+	if err != nil {
+		tb.Fatalf("new node: %v", err)
+	}
+	tb.Cleanup(func() { _ = node.Close() })
+	return node, bus
+}
+
+// Connect dials b from a.
+func Connect(ctx context.Context, a, b *network.Node) error {
+	info := peerstore.AddrInfo{ID: b.Host.ID(), Addrs: b.Host.Addrs()}
+	return a.Host.Connect(ctx, info)
+}
+
+// ConnectAll connects every pair of nodes in the cluster.
+func (c *Cluster) ConnectAll(ctx context.Context) error {
+	for i := range c.Nodes {
+		for j := i + 1; j < len(c.Nodes); j++ {
+			if err := Connect(ctx, c.Nodes[i], c.Nodes[j]); err != nil {
+				return fmt.Errorf("connect node %d to %d: %w", i, j, err)
+			}
+		}
+	}
+	return nil
+}
+
+// WaitForState blocks until every node reports state or ctx ends.
+func (c *Cluster) WaitForState(ctx context.Context, state network.RuntimeState) error {
+	for i, node := range c.Nodes {
+		if err := node.WaitForState(ctx, state); err != nil {
+			return fmt.Errorf("node %d stuck in %s: %w", i, node.RuntimeState(), err)
+		}
+	}
+	return nil
+}
+
+// WaitForMembership polls until every manager holds the same snapshot as the
+// first one, issued at or after since.
+func (c *Cluster) WaitForMembership(ctx context.Context, since time.Time) error {
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		if c.membershipConverged(since) {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("membership did not converge: %w", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Cluster) membershipConverged(since time.Time) bool {
+	if len(c.Managers) == 0 {
+		return true
+	}
+	first := c.Managers[0].Snapshot()
+	if first.IssuedAt.Before(since) {
+		return false
+	}
+	want := membership.Hash(first)
+	for _, manager := range c.Managers[1:] {
+		if membership.Hash(manager.Snapshot()) != want {
+			return false
+		}
+	}
+	return true
+}
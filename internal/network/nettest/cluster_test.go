@@ -0,0 +1,22 @@
+package nettest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"p2pos/internal/network"
+)
+
+func TestClusterReachesHealthy(t *testing.T) {
+	c := NewCluster(t, 3)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	if err := c.ConnectAll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.WaitForState(ctx, network.RuntimeStateHealthy); err != nil {
+		t.Fatal(err)
+	}
+}
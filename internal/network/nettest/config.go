@@ -0,0 +1,99 @@
+package nettest
+
+import (
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+)
+
+// Config is a network.ListenProvider for in-process nodes: loopback TCP on a
+// random port, private network mode, no AutoTLS and, unless Grace is set, no
+// startup grace, so nodes settle into a runtime state as soon as membership
+// is set.
+type Config struct {
+	Key        crypto.PrivKey
+	Listen     []string
+	QuorumHold time.Duration
+	AckQuorum  int
+	Bootstrap  bool
+	Pinned     []string
+	// Announce is advertised as announce_addrs, in place of the listen
+	// addresses when AnnounceReplace is set.
+	Announce        []string
+	AnnounceReplace bool
+	// PSK, when set, gates the node's transports like private_network.secret.
+	PSK []byte
+	// NodeRegion and NodeTags are announced in heartbeats; setting either
+	// turns on the heartbeat digest that carries them.
+	NodeRegion string
+	NodeTags   map[string]string
+	// Unix enables the unix socket transport for /unix entries in Listen.
+	Unix bool
+	// MinBootstrap is min_bootstrap_peers; zero keeps the default of one.
+	MinBootstrap int
+	// Grace is the startup grace; zero settles the state immediately.
+	Grace time.Duration
+}
+
+func (c *Config) ListenAddresses() []string {
+	if len(c.Listen) == 0 {
+		return []string{"127.0.0.1:0"}
+	}
+	return c.Listen
+}
+
+func (c *Config) BusinessListenAddresses() []string { return nil }
+
+func (c *Config) NodePrivateKey() crypto.PrivKey { return c.Key }
+func (c *Config) NetworkMode() string            { return "private" }
+func (c *Config) DialPrivateAddrs() string       { return "allow" }
+func (c *Config) AutoTLSMode() string            { return "off" }
+func (c *Config) AutoTLSUserEmail() string       { return "" }
+func (c *Config) AutoTLSCacheDir() string        { return "" }
+func (c *Config) AutoTLSPort() int               { return 0 }
+func (c *Config) AutoTLSForgeAuth() string       { return "" }
+func (c *Config) AutoTLSMinVersion() string      { return "" }
+func (c *Config) AutoTLSCipherSuites() []string  { return nil }
+
+func (c *Config) HeartbeatWindow() time.Duration      { return 30 * time.Second }
+func (c *Config) HeartbeatMinInterval() time.Duration { return 0 }
+func (c *Config) HeartbeatDigest() bool               { return c.NodeRegion != "" || len(c.NodeTags) > 0 }
+func (c *Config) Region() string                      { return c.NodeRegion }
+func (c *Config) Tags() map[string]string             { return c.NodeTags }
+
+func (c *Config) MembershipSelfRemovedPolicy() string     { return "continue" }
+func (c *Config) MembershipDisconnectPolicy() string      { return "" }
+func (c *Config) MembershipDownAlert() time.Duration      { return time.Minute }
+func (c *Config) MembershipQuorumHold() time.Duration     { return c.QuorumHold }
+func (c *Config) MembershipPushConcurrency() int          { return 4 }
+func (c *Config) MembershipPushAckQuorum() int            { return c.AckQuorum }
+func (c *Config) PresenceMinObservers() int               { return 1 }
+func (c *Config) PresenceEqualObservers() bool            { return false }
+func (c *Config) PinnedPeers() []string                   { return c.Pinned }
+func (c *Config) ShutdownTimeout() time.Duration          { return time.Second }
+func (c *Config) AdminBootstrap() bool                    { return c.Bootstrap }
+func (c *Config) StartupGrace() time.Duration             { return c.Grace }
+func (c *Config) SelfDialCheck() bool                     { return false }
+func (c *Config) UnixTransport() bool                     { return c.Unix }
+func (c *Config) BootstrapConcurrency() int               { return 4 }
+func (c *Config) MinBootstrapPeers() int                  { return c.MinBootstrap }
+func (c *Config) ReconnectBackoffBase() time.Duration     { return 100 * time.Millisecond }
+func (c *Config) ReconnectBackoffMax() time.Duration      { return time.Second }
+func (c *Config) ReconnectBreakerThreshold() int          { return 5 }
+func (c *Config) ReconnectBreakerCooldown() time.Duration { return time.Second }
+func (c *Config) ValidationBanThreshold() int             { return 5 }
+func (c *Config) ValidationBanWindow() time.Duration      { return time.Minute }
+func (c *Config) ValidationBanDuration() time.Duration    { return time.Minute }
+func (c *Config) ProtocolSupportTTL() time.Duration       { return time.Minute }
+func (c *Config) MinPeerVersion() string                  { return "" }
+func (c *Config) PeerVersionPolicy() string               { return "" }
+func (c *Config) AnnounceAddrs() []string                 { return c.Announce }
+func (c *Config) PrivateNetworkPSK() []byte               { return c.PSK }
+func (c *Config) PrivateNetworkPublicOptOut() bool        { return false }
+
+func (c *Config) AnnounceMode() string {
+	if c.AnnounceReplace {
+		return "replace"
+	}
+	return "append"
+}
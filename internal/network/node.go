@@ -9,6 +9,7 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"p2pos/internal/events"
@@ -36,31 +37,121 @@ type Node struct {
 	Host                 host.Host
 	PingService          *ping.PingService
 	Tracker              *Tracker
+	gater                *connectionGater
+	businessListeners    []businessListener
+	availability         *availabilityTracker
+	attestation          *status.Attestation
+	pauseMu              sync.Mutex
+	resumed              chan struct{}
+	pinned               map[peerstore.ID]peerstore.AddrInfo
 	bus                  *events.Bus
 	memberMu             sync.RWMutex
 	membership           *membership.Manager
 	onMembershipApplied  func(snapshot membership.Snapshot)
-	heartbeatUnsupported sync.Map
-	statusUnsupported    sync.Map
+	heartbeatUnsupported unsupportedCache
+	heartbeatWindow      time.Duration
+	heartbeats           *heartbeatLimiter
+	clocks               *clockTracker
+	relays               *relayReservations
+	heartbeatDigest      bool
+	region               string
+	tags                 map[string]string
+	peerTags             *peerTagTracker
+	reconciler           *peerstoreReconciler
+	selfDialCheck        bool
+	minBootstrapPeers    int
+	bootstrapDials       int
+	dialBreaker          *dialBreaker
+	validationBans       *validationBans
+	relaySource          *relayPeerSource
+	divergence           *divergenceTracker
+	minPeerVersion       string
+	peerVersionPolicy    string
+	peerVersions         sync.Map
+	disconnectPolicy     string
+	selfRemovedPolicy    string
+	quorumHold           *quorumHold
+	pushConcurrency      int
+	pushAckQuorum        int
+	minObservers         int
+	equalObservers       bool
+	statusUnsupported    unsupportedCache
 	state                stateHolder
+	reachabilityMu       sync.RWMutex
+	reachability         libp2pnet.Reachability
 	statusMu             sync.RWMutex
 	status               StatusProvider
+	liveness             LivenessCheck
+	clusterCache         clusterStatusCache
+	rttObservations      rttObservationStore
+	protocolStats        protocolStatsStore
+	audit                AuditProvider
+	adminAuditor         AdminAuditor
+	tasks                TaskStatsProvider
 	privKey              crypto.PrivKey
 	adminProof           *membership.AdminProof
+	autoTLSMu            sync.Mutex
 	autoTLSMgr           *p2pforge.P2PForgeCertMgr
+	certWatchOnce        sync.Once
+	shutdownTimeout      time.Duration
+	adminBootstrap       bool
+	startupGraceUntil    time.Time
+	resuming             atomic.Bool
+	lifecycle            context.Context
+	stopLifecycle        context.CancelFunc
+	shutdownOnce         sync.Once
 	closeOnce            sync.Once
 	closeErr             error
 }
 
 type ListenProvider interface {
 	ListenAddresses() []string
+	BusinessListenAddresses() []string
 	NodePrivateKey() crypto.PrivKey
 	NetworkMode() string
+	DialPrivateAddrs() string
 	AutoTLSMode() string
 	AutoTLSUserEmail() string
 	AutoTLSCacheDir() string
 	AutoTLSPort() int
 	AutoTLSForgeAuth() string
+	AutoTLSMinVersion() string
+	AutoTLSCipherSuites() []string
+	HeartbeatWindow() time.Duration
+	HeartbeatMinInterval() time.Duration
+	HeartbeatDigest() bool
+	Region() string
+	Tags() map[string]string
+	MembershipDisconnectPolicy() string
+	MembershipSelfRemovedPolicy() string
+	MembershipQuorumHold() time.Duration
+	MembershipDownAlert() time.Duration
+	MembershipPushConcurrency() int
+	MembershipPushAckQuorum() int
+	PresenceMinObservers() int
+	PresenceEqualObservers() bool
+	PinnedPeers() []string
+	ShutdownTimeout() time.Duration
+	AdminBootstrap() bool
+	StartupGrace() time.Duration
+	SelfDialCheck() bool
+	UnixTransport() bool
+	MinBootstrapPeers() int
+	BootstrapConcurrency() int
+	ReconnectBackoffBase() time.Duration
+	ReconnectBackoffMax() time.Duration
+	ReconnectBreakerThreshold() int
+	ReconnectBreakerCooldown() time.Duration
+	ValidationBanThreshold() int
+	ValidationBanWindow() time.Duration
+	ValidationBanDuration() time.Duration
+	ProtocolSupportTTL() time.Duration
+	MinPeerVersion() string
+	PeerVersionPolicy() string
+	AnnounceAddrs() []string
+	AnnounceMode() string
+	PrivateNetworkPSK() []byte
+	PrivateNetworkPublicOptOut() bool
 }
 
 type StatusProvider interface {
@@ -70,25 +161,54 @@ type StatusProvider interface {
 func NewNode(cfg ListenProvider, bus *events.Bus) (*Node, error) {
 	tuneQUICUDPBuffer()
 
-	listenAddrs, err := buildListenMultiaddrs(cfg.ListenAddresses())
+	listenAddrs, err := buildListenMultiaddrs(cfg.ListenAddresses(), cfg.UnixTransport())
+	if err != nil {
+		return nil, err
+	}
+	var businessListeners []businessListener
+	if raw := cfg.BusinessListenAddresses(); len(raw) > 0 {
+		businessAddrs, err := buildListenMultiaddrs(raw, false)
+		if err != nil {
+			return nil, err
+		}
+		businessListeners, err = parseBusinessListeners(businessAddrs)
+		if err != nil {
+			return nil, err
+		}
+		listenAddrs = append(listenAddrs, businessAddrs...)
+	}
+	announceAddrs, err := parseAnnounceAddrs(cfg.AnnounceAddrs())
 	if err != nil {
 		return nil, err
 	}
 	enablePublicService := shouldEnablePublicService(cfg.NetworkMode())
+	psk := privateNetworkKey(cfg, enablePublicService)
+	autoTLSMode := strings.ToLower(strings.TrimSpace(cfg.AutoTLSMode()))
+	if psk != nil {
+		// Browsers and the forge cannot join a PSK network, and QUIC does
+		// not support one.
+		listenAddrs = withoutQUICAddrs(listenAddrs)
+		autoTLSMode = "off"
+		logging.Log("NODE", "private_network_enabled", map[string]string{
+			"transports": "tcp,ws",
+		})
+	}
 	wsOptions := []interface{}{}
 	var autoTLSMgr *p2pforge.P2PForgeCertMgr
-	switch strings.ToLower(strings.TrimSpace(cfg.AutoTLSMode())) {
+	forceAutoTLS := autoTLSMode == "on"
+	authWatcher := newForgeAuthWatcher(forceAutoTLS)
+	switch autoTLSMode {
 	case "on":
-		autoTLSMgr, err = createAutoTLSManager(cfg, &listenAddrs, &wsOptions, true)
+		autoTLSMgr, err = createAutoTLSManager(cfg, &listenAddrs, &wsOptions, true, authWatcher)
 	case "auto":
 		if enablePublicService {
-			autoTLSMgr, err = createAutoTLSManager(cfg, &listenAddrs, &wsOptions, false)
+			autoTLSMgr, err = createAutoTLSManager(cfg, &listenAddrs, &wsOptions, false, authWatcher)
 		}
 	case "off":
 		// disabled explicitly
 	default:
 		if enablePublicService {
-			autoTLSMgr, err = createAutoTLSManager(cfg, &listenAddrs, &wsOptions, false)
+			autoTLSMgr, err = createAutoTLSManager(cfg, &listenAddrs, &wsOptions, false, authWatcher)
 		}
 	}
 	if err != nil {
@@ -115,53 +235,43 @@ func NewNode(cfg ListenProvider, bus *events.Bus) (*Node, error) {
 		return hostRef.h
 	}
 
-	relayPeerSource := func(ctx context.Context, num int) <-chan peerstore.AddrInfo {
-		ch := make(chan peerstore.AddrInfo, num)
-		go func() {
-			defer close(ch)
-
-			h := getHost()
-			if h == nil {
-				return
-			}
-
-			sent := 0
-			for _, peerID := range h.Network().Peers() {
-				if sent >= num {
-					return
-				}
-				if h.Network().Connectedness(peerID) != libp2pnet.Connected {
-					continue
-				}
-				info := h.Peerstore().PeerInfo(peerID)
-				if info.ID == "" || len(info.Addrs) == 0 {
-					continue
-				}
-
-				select {
-				case <-ctx.Done():
-					return
-				case ch <- info:
-					sent++
-				}
-			}
-		}()
-		return ch
-	}
+	relaySource := newRelayPeerSource(getHost, relayPeerSourceRefresh)
 
+	gater := newConnectionGater(allowPrivateDials(cfg.DialPrivateAddrs(), enablePublicService))
 	opts := []libp2p.Option{
 		libp2p.ListenAddrStrings(listenAddrs...),
+		libp2p.ConnectionGater(gater),
 		libp2p.Identity(privKey),
+		libp2p.UserAgent(userAgent()),
 		libp2p.Ping(true),
 		libp2p.NATPortMap(),
-		libp2p.EnableAutoRelayWithPeerSource(autorelay.PeerSource(relayPeerSource)),
+		libp2p.EnableAutoRelayWithPeerSource(autorelay.PeerSource(relaySource.peerSource)),
 		libp2p.EnableHolePunching(),
 		libp2p.Transport(libp2ptcp.NewTCPTransport),
-		libp2p.Transport(libp2pquic.NewTransport),
 		libp2p.Transport(websocket.New, wsOptions...),
 	}
+	if psk != nil {
+		opts = append(opts, libp2p.PrivateNetwork(psk))
+	} else {
+		opts = append(opts, libp2p.Transport(libp2pquic.NewTransport))
+	}
+	if cfg.UnixTransport() {
+		opts = append(opts, libp2p.Transport(newUnixTransport))
+	}
+	var factory addrsFactory
 	if autoTLSMgr != nil {
-		opts = append(opts, libp2p.AddrsFactory(autoTLSMgr.AddressFactory()))
+		factory = addrsFactory(autoTLSMgr.AddressFactory())
+	}
+	if len(announceAddrs) > 0 {
+		replace := strings.EqualFold(strings.TrimSpace(cfg.AnnounceMode()), "replace")
+		factory = announceAddrsFactory(announceAddrs, replace, factory)
+		logging.Log("NODE", "announce_addrs", map[string]string{
+			"addrs":   joinMultiaddrs(announceAddrs),
+			"replace": fmt.Sprintf("%t", replace),
+		})
+	}
+	if factory != nil {
+		opts = append(opts, libp2p.AddrsFactory(factory))
 	}
 	if enablePublicService {
 		opts = append(opts, libp2p.EnableNATService(), libp2p.EnableRelayService())
@@ -174,14 +284,54 @@ func NewNode(cfg ListenProvider, bus *events.Bus) (*Node, error) {
 	hostRef.mu.Lock()
 	hostRef.h = hostNode
 	hostRef.mu.Unlock()
+	gater.setLocalPeer(hostNode.ID())
 
+	lifecycle, stopLifecycle := context.WithCancel(context.Background())
 	n := &Node{
-		Host:        hostNode,
-		PingService: &ping.PingService{Host: hostNode},
-		Tracker:     NewTracker(),
-		bus:         bus,
-		privKey:     privKey,
-		autoTLSMgr:  autoTLSMgr,
+		Host:              hostNode,
+		PingService:       &ping.PingService{Host: hostNode},
+		Tracker:           NewTracker(),
+		gater:             gater,
+		businessListeners: businessListeners,
+		pinned:            parsePinnedPeers(cfg.PinnedPeers()),
+		bus:               bus,
+		privKey:           privKey,
+		autoTLSMgr:        autoTLSMgr,
+		heartbeatWindow:   cfg.HeartbeatWindow(),
+		heartbeats:        newHeartbeatLimiter(cfg.HeartbeatMinInterval()),
+		clocks:            newClockTracker(),
+		relays:            newRelayReservations(),
+		heartbeatDigest:   cfg.HeartbeatDigest(),
+		region:            cfg.Region(),
+		tags:              cfg.Tags(),
+		peerTags:          newPeerTagTracker(),
+		reconciler:        newPeerstoreReconciler(),
+		selfDialCheck:     cfg.SelfDialCheck(),
+		minBootstrapPeers: cfg.MinBootstrapPeers(),
+		bootstrapDials:    cfg.BootstrapConcurrency(),
+		dialBreaker: newDialBreaker(cfg.ReconnectBackoffBase(), cfg.ReconnectBackoffMax(),
+			cfg.ReconnectBreakerThreshold(), cfg.ReconnectBreakerCooldown()),
+		heartbeatUnsupported: unsupportedCache{ttl: cfg.ProtocolSupportTTL()},
+		statusUnsupported:    unsupportedCache{ttl: cfg.ProtocolSupportTTL()},
+		relaySource:          relaySource,
+		validationBans: newValidationBans(cfg.ValidationBanThreshold(), cfg.ValidationBanWindow(),
+			cfg.ValidationBanDuration()),
+		divergence:        newDivergenceTracker(),
+		minPeerVersion:    cfg.MinPeerVersion(),
+		peerVersionPolicy: cfg.PeerVersionPolicy(),
+		disconnectPolicy:  cfg.MembershipDisconnectPolicy(),
+		selfRemovedPolicy: cfg.MembershipSelfRemovedPolicy(),
+		quorumHold:        newQuorumHold(cfg.MembershipQuorumHold()),
+		availability:      newAvailabilityTracker(cfg.MembershipDownAlert()),
+		pushConcurrency:   cfg.MembershipPushConcurrency(),
+		pushAckQuorum:     cfg.MembershipPushAckQuorum(),
+		minObservers:      cfg.PresenceMinObservers(),
+		equalObservers:    cfg.PresenceEqualObservers(),
+		shutdownTimeout:   cfg.ShutdownTimeout(),
+		adminBootstrap:    cfg.AdminBootstrap(),
+		startupGraceUntil: time.Now().Add(cfg.StartupGrace()),
+		lifecycle:         lifecycle,
+		stopLifecycle:     stopLifecycle,
 		state: stateHolder{
 			state: RuntimeStateUnconfigured,
 		},
@@ -189,9 +339,29 @@ func NewNode(cfg ListenProvider, bus *events.Bus) (*Node, error) {
 	if autoTLSMgr != nil {
 		autoTLSMgr.ProvideHost(hostNode)
 		if err := autoTLSMgr.Start(); err != nil {
-			hostNode.Close()
-			return nil, err
+			if forceAutoTLS {
+				stopLifecycle()
+				hostNode.Close()
+				return nil, err
+			}
+			// Outside mode=on AutoTLS is best effort: keep the node up
+			// without TLS and keep trying to start it in the background.
+			delay := authWatcher.backoff()
+			logging.Log("NODE", "autotls_start_failed", map[string]string{
+				"mode":     cfg.AutoTLSMode(),
+				"reason":   err.Error(),
+				"retry_in": delay.String(),
+			})
+			n.autoTLSMgr = nil
+			n.retryAutoTLS(autoTLSMgr, authWatcher, delay)
+		} else {
+			authWatcher.setStop(autoTLSMgr.Stop)
 		}
+		mgr := autoTLSMgr
+		authWatcher.setRestart(func(delay time.Duration) {
+			n.detachAutoTLS()
+			n.retryAutoTLS(mgr, authWatcher, delay)
+		})
 	}
 	if enablePublicService {
 		logging.Log("NODE", "network_mode", map[string]string{
@@ -202,27 +372,55 @@ func NewNode(cfg ListenProvider, bus *events.Bus) (*Node, error) {
 			"mode": "private",
 		})
 	}
+	gater.setTrusted(func(p peerstore.ID) bool {
+		return n.isMember(p.String()) || n.isPinned(p.String())
+	})
+	n.attestation = n.signLocalAttestation()
+	n.protectPinnedPeers()
 	n.registerConnectionNotifications()
 	n.registerMembershipHandler()
 	n.registerMembershipPushHandler()
 	n.registerHeartbeatHandler()
 	n.registerStatusHandler()
+	n.registerAuditHandler()
+	n.registerReadyHandler()
+	n.registerHealthHandler()
+	n.registerPeerLookupHandler()
+	n.registerEventsHandler()
 	n.startReachabilityWatcher()
+	n.startRelayStatusWatcher()
+	n.startPeerVersionWatcher()
+	n.startCertWatcher()
+	n.startAvailabilityWatcher()
+	n.startStartupGraceTimer()
 	return n, nil
 }
 
-func createAutoTLSManager(cfg ListenProvider, listenAddrs *[]string, wsOptions *[]interface{}, force bool) (*p2pforge.P2PForgeCertMgr, error) {
+func createAutoTLSManager(cfg ListenProvider, listenAddrs *[]string, wsOptions *[]interface{}, force bool, authWatcher *forgeAuthWatcher) (*p2pforge.P2PForgeCertMgr, error) {
+	policy, err := parseTLSPolicy(cfg.AutoTLSMinVersion(), cfg.AutoTLSCipherSuites())
+	if err != nil {
+		return nil, err
+	}
 	autoTLSOpts := []p2pforge.P2PForgeCertMgrOptions{
 		p2pforge.WithUserEmail(cfg.AutoTLSUserEmail()),
 		p2pforge.WithCertificateStorage(&certmagic.FileStorage{
 			Path: cfg.AutoTLSCacheDir(),
 		}),
+		p2pforge.WithLogger(authWatcher.logger()),
 	}
 	if force {
 		// In force mode (auto_tls.mode=on), attempt certificate flow immediately
 		// without waiting for reachability events. Useful for first bootstrap node.
 		autoTLSOpts = append(autoTLSOpts, p2pforge.WithAllowPrivateForgeAddrs())
 	}
+	autoTLSOpts = append(autoTLSOpts,
+		p2pforge.WithOnCertLoaded(func() {
+			logging.Log("NODE", "autotls_cert_loaded", nil)
+		}),
+		p2pforge.WithOnCertRenewed(func() {
+			logging.Log("NODE", "autotls_cert_obtained", nil)
+		}),
+	)
 	forgeAuth := strings.TrimSpace(cfg.AutoTLSForgeAuth())
 	if forgeAuth != "" {
 		autoTLSOpts = append(autoTLSOpts, p2pforge.WithForgeAuth(forgeAuth))
@@ -239,11 +437,13 @@ func createAutoTLSManager(cfg ListenProvider, listenAddrs *[]string, wsOptions *
 		fmt.Sprintf("/ip4/0.0.0.0/tcp/%d/tls/sni/*.%s/ws", port, p2pforge.DefaultForgeDomain),
 		fmt.Sprintf("/ip6/::/tcp/%d/tls/sni/*.%s/ws", port, p2pforge.DefaultForgeDomain),
 	)
-	*wsOptions = append(*wsOptions, websocket.WithTLSConfig(autoTLSMgr.TLSConfig()))
+	*wsOptions = append(*wsOptions, websocket.WithTLSConfig(policy.apply(autoTLSMgr.TLSConfig())))
 	logging.Log("NODE", "autotls_enabled", map[string]string{
-		"forge_domain": p2pforge.DefaultForgeDomain,
-		"mode":         cfg.AutoTLSMode(),
-		"port":         fmt.Sprintf("%d", port),
+		"forge_domain":  p2pforge.DefaultForgeDomain,
+		"mode":          cfg.AutoTLSMode(),
+		"port":          fmt.Sprintf("%d", port),
+		"min_version":   tlsVersionName(policy.minVersion),
+		"cipher_suites": fmt.Sprintf("%d", len(policy.cipherSuites)),
 	})
 	return autoTLSMgr, nil
 }
@@ -321,13 +521,25 @@ func (n *Node) startReachabilityWatcher() {
 			if !ok {
 				continue
 			}
+			n.reachabilityMu.Lock()
+			prev := n.reachability
+			n.reachability = ev.Reachability
+			n.reachabilityMu.Unlock()
 			logging.Log("NODE", "autonat_reachability", map[string]string{
 				"reachability": ev.Reachability.String(),
 			})
+			n.onReachabilityChanged(prev, ev.Reachability)
 		}
 	}()
 }
 
+// LocalReachability returns the last AutoNAT reachability reported by the host.
+func (n *Node) LocalReachability() libp2pnet.Reachability {
+	n.reachabilityMu.RLock()
+	defer n.reachabilityMu.RUnlock()
+	return n.reachability
+}
+
 func tuneQUICUDPBuffer() {
 	if runtime.GOOS != "linux" {
 		return
@@ -353,8 +565,9 @@ func tuneQUICUDPBuffer() {
 
 func (n *Node) Close() error {
 	n.closeOnce.Do(func() {
-		if n.autoTLSMgr != nil {
-			n.autoTLSMgr.Stop()
+		n.stopLifecycle()
+		if mgr := n.detachAutoTLS(); mgr != nil {
+			mgr.Stop()
 		}
 		n.closeErr = n.Host.Close()
 	})
@@ -368,6 +581,9 @@ func (n *Node) SetStatusProvider(provider StatusProvider) {
 }
 
 func (n *Node) SetMembershipManager(manager *membership.Manager) {
+	if manager != nil {
+		manager.SetApplyGuard(n.guardMembershipChange)
+	}
 	n.memberMu.Lock()
 	n.membership = manager
 	n.memberMu.Unlock()
@@ -417,14 +633,42 @@ func (n *Node) Connect(ctx context.Context, peerInfo peerstore.AddrInfo) error {
 	return n.Host.Connect(ctx, peerInfo)
 }
 
+// bootstrapSatisfied reports whether bootstrap can stop: business protocols
+// are usable and at least minBootstrapPeers members are connected. The floor
+// is capped at the number of other members so small clusters can finish.
+func (n *Node) bootstrapSatisfied() bool {
+	if !n.canUseBusinessProtocols() {
+		return false
+	}
+	floor := n.minBootstrapPeers
+	if floor <= 0 {
+		floor = 1
+	}
+	if snap, ok := n.membershipSnapshot(); ok && len(snap.Members)-1 < floor {
+		floor = len(snap.Members) - 1
+	}
+	connected := 0
+	for _, pid := range n.Host.Network().Peers() {
+		if n.isMember(pid.String()) {
+			connected++
+		}
+	}
+	return connected >= floor && (floor > 0 || len(n.Host.Network().Peers()) > 0)
+}
+
 func (n *Node) StartBootstrap(ctx context.Context, resolver Resolver, interval time.Duration) {
 	if interval <= 0 {
 		interval = time.Minute
 	}
 
+	var expects func(peerstore.ID) bool
+	if r, ok := resolver.(expectingResolver); ok {
+		expects = r.ExpectsPeer
+	}
+
 	run := func() bool {
-		if n.canUseBusinessProtocols() && len(n.Host.Network().Peers()) > 0 {
-			fmt.Println("[BOOTSTRAP] Existing peer connection detected, stopping bootstrap discovery")
+		if n.bootstrapSatisfied() {
+			fmt.Println("[BOOTSTRAP] Enough member connections, stopping bootstrap discovery")
 			return false
 		}
 
@@ -437,17 +681,8 @@ func (n *Node) StartBootstrap(ctx context.Context, resolver Resolver, interval t
 			return true
 		}
 
-		for _, candidate := range candidates {
-			if candidate.ID == n.Host.ID() {
-				continue
-			}
-			if err := n.Connect(ctx, candidate); err != nil {
-				fmt.Printf("[BOOTSTRAP] Failed to connect to %s: %v\n", candidate.ID.String(), err)
-				continue
-			}
-			fmt.Printf("[BOOTSTRAP] Connected to bootstrap peer: %s\n", candidate.ID.String())
-			// Keep retry loop in unconfigured mode to continue membership bootstrap attempts.
-			return !n.canUseBusinessProtocols()
+		if n.dialBootstrapCandidates(ctx, candidates, expects) || ctx.Err() != nil {
+			return false
 		}
 
 		return true
@@ -497,11 +732,7 @@ func (n *Node) StartShutdownHandler(ctx context.Context) {
 				logging.Log("NODE", "shutdown", map[string]string{
 					"reason": shutdown.Reason,
 				})
-				if err := n.Close(); err != nil {
-					logging.Log("NODE", "shutdown_failed", map[string]string{
-						"reason": err.Error(),
-					})
-				}
+				n.Shutdown(shutdown.Reason)
 				return
 			}
 		}
@@ -511,8 +742,11 @@ func (n *Node) StartShutdownHandler(ctx context.Context) {
 func (n *Node) registerConnectionNotifications() {
 	n.Host.Network().Notify(&libp2pnet.NotifyBundle{
 		ConnectedF: func(_ libp2pnet.Network, conn libp2pnet.Conn) {
-			n.heartbeatUnsupported.Delete(conn.RemotePeer())
-			n.statusUnsupported.Delete(conn.RemotePeer())
+			n.heartbeatUnsupported.forget(conn.RemotePeer())
+			n.statusUnsupported.forget(conn.RemotePeer())
+			if n.refuseDuplicateConn(conn) {
+				return
+			}
 			if !n.allowPeer(conn.RemotePeer().String()) {
 				logging.Log("NODE", "reject_peer", map[string]string{
 					"peer_id": conn.RemotePeer().String(),
@@ -521,7 +755,11 @@ func (n *Node) registerConnectionNotifications() {
 				_ = conn.Close()
 				return
 			}
+			logConnNegotiated(conn)
 			n.Tracker.Upsert(remoteAddrInfo(conn.RemotePeer(), conn.RemoteMultiaddr()))
+			n.quorumHold.release(conn.RemotePeer().String())
+			n.dialBreaker.success(conn.RemotePeer().String())
+			n.clusterCache.invalidate()
 			if n.bus != nil {
 				n.bus.Publish(events.PeerConnected{
 					PeerID:     conn.RemotePeer().String(),
@@ -534,6 +772,14 @@ func (n *Node) registerConnectionNotifications() {
 		DisconnectedF: func(network libp2pnet.Network, conn libp2pnet.Conn) {
 			if len(network.ConnsToPeer(conn.RemotePeer())) == 0 {
 				n.Tracker.Remove(conn.RemotePeer())
+				n.heartbeats.forget(conn.RemotePeer().String())
+				n.clocks.forget(conn.RemotePeer().String())
+				n.divergence.forget(conn.RemotePeer().String())
+				n.peerVersions.Delete(conn.RemotePeer())
+				n.clusterCache.invalidate()
+				if n.isMember(conn.RemotePeer().String()) {
+					n.holdMemberForQuorum(conn.RemotePeer().String())
+				}
 			}
 			if !n.allowPeer(conn.RemotePeer().String()) {
 				n.evaluateRuntimeState("peer-disconnected-non-member")
@@ -558,7 +804,7 @@ func remoteAddrInfo(peerID peerstore.ID, addr multiaddr.Multiaddr) peerstore.Add
 	}
 }
 
-func buildListenMultiaddrs(listens []string) ([]string, error) {
+func buildListenMultiaddrs(listens []string, allowUnix bool) ([]string, error) {
 	addrs := make([]string, 0, len(listens))
 	seen := make(map[string]struct{}, len(listens))
 
@@ -568,6 +814,20 @@ func buildListenMultiaddrs(listens []string) ([]string, error) {
 			continue
 		}
 
+		if strings.HasPrefix(listen, "/unix/") {
+			if !allowUnix {
+				return nil, fmt.Errorf("unix listen address %q requires enable_unix_transport", listen)
+			}
+			if _, err := multiaddr.NewMultiaddr(listen); err != nil {
+				return nil, fmt.Errorf("invalid unix listen address %q: %w", listen, err)
+			}
+			if _, ok := seen[listen]; !ok {
+				seen[listen] = struct{}{}
+				addrs = append(addrs, listen)
+			}
+			continue
+		}
+
 		hosts := []string{}
 		port := ""
 
@@ -588,20 +848,27 @@ func buildListenMultiaddrs(listens []string) ([]string, error) {
 		}
 
 		for _, host := range hosts {
-			ip := net.ParseIP(host)
-			if ip == nil {
+			// netip keeps the zone of link-local addresses like
+			// fe80::1%eth0, which net.ParseIP would reject.
+			ip, err := netip.ParseAddr(host)
+			if err != nil {
 				return nil, fmt.Errorf("invalid listen host %q", host)
 			}
 
-			var tcpAddr string
-			var quicAddr string
-			if ip.To4() != nil {
-				tcpAddr = fmt.Sprintf("/ip4/%s/tcp/%s", host, port)
-				quicAddr = fmt.Sprintf("/ip4/%s/udp/%s/quic-v1", host, port)
-			} else {
-				tcpAddr = fmt.Sprintf("/ip6/%s/tcp/%s", host, port)
-				quicAddr = fmt.Sprintf("/ip6/%s/udp/%s/quic-v1", host, port)
+			var ipPart string
+			switch {
+			case ip.Is4() || ip.Is4In6():
+				ipPart = "/ip4/" + ip.Unmap().String()
+			case ip.Zone() != "":
+				ipPart = fmt.Sprintf("/ip6zone/%s/ip6/%s", ip.Zone(), ip.WithZone("").String())
+				if _, err := multiaddr.NewMultiaddr(ipPart); err != nil {
+					return nil, fmt.Errorf("listen host %q has a zone multiaddr cannot represent: %w", host, err)
+				}
+			default:
+				ipPart = "/ip6/" + ip.String()
 			}
+			tcpAddr := fmt.Sprintf("%s/tcp/%s", ipPart, port)
+			quicAddr := fmt.Sprintf("%s/udp/%s/quic-v1", ipPart, port)
 
 			for _, addr := range []string{tcpAddr, quicAddr} {
 				if _, ok := seen[addr]; ok {
@@ -0,0 +1,40 @@
+package network
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildListenMultiaddrsZonedIPv6(t *testing.T) {
+	cases := []struct {
+		listen string
+		want   []string
+	}{
+		{"[fe80::1%eth0]:4100", []string{
+			"/ip6zone/eth0/ip6/fe80::1/tcp/4100",
+			"/ip6zone/eth0/ip6/fe80::1/udp/4100/quic-v1",
+		}},
+		{"[::ffff:192.0.2.1]:4100", []string{
+			"/ip4/192.0.2.1/tcp/4100",
+			"/ip4/192.0.2.1/udp/4100/quic-v1",
+		}},
+		{"[2001:db8::1]:4100", []string{
+			"/ip6/2001:db8::1/tcp/4100",
+			"/ip6/2001:db8::1/udp/4100/quic-v1",
+		}},
+	}
+	for _, tc := range cases {
+		got, err := buildListenMultiaddrs([]string{tc.listen}, false)
+		if err != nil {
+			t.Errorf("%s: %v", tc.listen, err)
+			continue
+		}
+		if strings.Join(got, " ") != strings.Join(tc.want, " ") {
+			t.Errorf("%s: %v, want %v", tc.listen, got, tc.want)
+		}
+	}
+
+	if _, err := buildListenMultiaddrs([]string{"[fe80::1%eth/0]:4100"}, false); err == nil {
+		t.Error("zone containing a slash accepted")
+	}
+}
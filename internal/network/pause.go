@@ -0,0 +1,60 @@
+package network
+
+import (
+	"context"
+
+	"p2pos/internal/logging"
+)
+
+// Pause holds the node before it joins the cluster: listeners and identity
+// stay up, but the gater refuses every dial and inbound connection until
+// Resume. Callers hold bootstrap and scheduled tasks with WaitResumed.
+func (n *Node) Pause() {
+	n.pauseMu.Lock()
+	defer n.pauseMu.Unlock()
+	if n.resumed != nil {
+		return
+	}
+	n.resumed = make(chan struct{})
+	n.gater.setPaused(true)
+	logging.Log("NODE", "paused", map[string]string{
+		"peer_id": n.Host.ID().String(),
+	})
+}
+
+// Resume lets a paused node connect. It is a no-op on a running node.
+func (n *Node) Resume() {
+	n.pauseMu.Lock()
+	defer n.pauseMu.Unlock()
+	if n.resumed == nil {
+		return
+	}
+	n.gater.setPaused(false)
+	close(n.resumed)
+	n.resumed = nil
+	logging.Log("NODE", "resumed", map[string]string{
+		"peer_id": n.Host.ID().String(),
+	})
+}
+
+func (n *Node) Paused() bool {
+	n.pauseMu.Lock()
+	defer n.pauseMu.Unlock()
+	return n.resumed != nil
+}
+
+// WaitResumed blocks until the node is not paused or ctx ends.
+func (n *Node) WaitResumed(ctx context.Context) error {
+	n.pauseMu.Lock()
+	resumed := n.resumed
+	n.pauseMu.Unlock()
+	if resumed == nil {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-resumed:
+		return nil
+	}
+}
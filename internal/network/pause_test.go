@@ -0,0 +1,42 @@
+package network_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"p2pos/internal/network/nettest"
+
+	libp2pnet "github.com/libp2p/go-libp2p/core/network"
+)
+
+func TestPausedNodeMakesNoConnectionsUntilResumed(t *testing.T) {
+	a, _ := nettest.NewNode(t, &nettest.Config{Key: newKey(t)})
+	b, _ := nettest.NewNode(t, &nettest.Config{Key: newKey(t)})
+	a.Pause()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := nettest.Connect(ctx, a, b); err == nil {
+		t.Fatal("paused node dialed out")
+	}
+	// The dialer may finish its side of the handshake before a refuses
+	// the secured connection, so check a's view.
+	_ = nettest.Connect(ctx, b, a)
+	if a.Host.Network().Connectedness(b.Host.ID()) == libp2pnet.Connected {
+		t.Fatal("paused node accepted an inbound connection")
+	}
+	waitCtx, waitCancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer waitCancel()
+	if err := a.WaitResumed(waitCtx); err == nil {
+		t.Fatal("WaitResumed returned while paused")
+	}
+
+	a.Resume()
+	if err := a.WaitResumed(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := nettest.Connect(ctx, a, b); err != nil {
+		t.Fatalf("resumed node could not dial: %v", err)
+	}
+}
@@ -0,0 +1,251 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"p2pos/internal/logging"
+
+	libp2pnet "github.com/libp2p/go-libp2p/core/network"
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+	corepeerstore "github.com/libp2p/go-libp2p/core/peerstore"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	multiaddr "github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+)
+
+// peerLookupProtocolID lets a member ask another member for the addresses it
+// knows for one peer ID, instead of waiting for a full peer exchange.
+const peerLookupProtocolID = protocol.ID("/p2pos/peer-lookup/1.0.0")
+const maxLookupAddrs = 32
+
+type peerLookupRequest struct {
+	PeerID string `json:"peer_id"`
+}
+
+type peerLookupResponse struct {
+	PeerID    string   `json:"peer_id"`
+	Addrs     []string `json:"addrs"`
+	Connected bool     `json:"connected"`
+	Error     string   `json:"error,omitempty"`
+}
+
+func (n *Node) registerPeerLookupHandler() {
+	n.setBusinessHandler(peerLookupProtocolID, func(stream libp2pnet.Stream) {
+		defer stream.Close()
+
+		req := peerLookupRequest{}
+		_ = json.NewDecoder(stream).Decode(&req)
+
+		resp := peerLookupResponse{PeerID: req.PeerID, Addrs: []string{}}
+		requester := stream.Conn().RemotePeer().String()
+		switch {
+		case !n.canUseBusinessProtocols():
+			resp.Error = "node is unconfigured"
+		case !n.isMember(requester):
+			resp.Error = "requester is not a member"
+		case !n.isMember(req.PeerID):
+			resp.Error = "target is not a member"
+		default:
+			target, err := peerstore.Decode(req.PeerID)
+			if err != nil {
+				resp.Error = err.Error()
+				break
+			}
+			resp.Connected = n.Host.Network().Connectedness(target) == libp2pnet.Connected
+			resp.Addrs = lookupAddrs(n, target)
+		}
+
+		if err := json.NewEncoder(stream).Encode(resp); err != nil {
+			logging.Log("LOOKUP", "encode_failed", map[string]string{
+				"reason": err.Error(),
+			})
+		}
+	})
+}
+
+// lookupAddrs prefers the remote addresses of live connections, then falls
+// back to the peerstore. Lookups for this node itself answer with
+// selfLookupAddrs.
+func lookupAddrs(n *Node, target peerstore.ID) []string {
+	if target == n.Host.ID() {
+		return selfLookupAddrs(n)
+	}
+	seen := make(map[string]struct{})
+	out := make([]string, 0)
+	add := func(addr multiaddr.Multiaddr) {
+		if len(out) >= maxLookupAddrs {
+			return
+		}
+		value := addr.String()
+		if _, ok := seen[value]; ok {
+			return
+		}
+		seen[value] = struct{}{}
+		out = append(out, value)
+	}
+	for _, conn := range n.Host.Network().ConnsToPeer(target) {
+		add(conn.RemoteMultiaddr())
+	}
+	for _, addr := range n.Host.Peerstore().Addrs(target) {
+		add(addr)
+	}
+	return out
+}
+
+// selfLookupAddrs lists the addresses this node shares for itself: relay and
+// public addresses first, then local ones. Behind NAT, Host.Addrs() carries
+// only relay and private addresses until AutoNAT confirms reachability, so
+// identify's observed addresses (reported by AllAddrs) are folded in to let
+// peers try the real external address sooner.
+func selfLookupAddrs(n *Node) []string {
+	addrs := append([]multiaddr.Multiaddr(nil), n.Host.Addrs()...)
+	if h, ok := n.Host.(interface{ AllAddrs() []multiaddr.Multiaddr }); ok {
+		addrs = append(addrs, h.AllAddrs()...)
+	}
+
+	var preferred, local []multiaddr.Multiaddr
+	for _, addr := range addrs {
+		_, relayErr := addr.ValueForProtocol(multiaddr.P_CIRCUIT)
+		if relayErr == nil || manet.IsPublicAddr(addr) {
+			preferred = append(preferred, addr)
+			continue
+		}
+		local = append(local, addr)
+	}
+
+	seen := make(map[string]struct{})
+	out := make([]string, 0)
+	for _, addr := range append(preferred, local...) {
+		if len(out) >= maxLookupAddrs {
+			break
+		}
+		value := addr.String()
+		if _, ok := seen[value]; ok {
+			continue
+		}
+		seen[value] = struct{}{}
+		out = append(out, value)
+	}
+	return out
+}
+
+// LookupPeer asks via for the addresses it knows for target.
+func (n *Node) LookupPeer(ctx context.Context, via, target peerstore.ID) ([]multiaddr.Multiaddr, error) {
+	stream, err := n.newCountedStream(ctx, via, peerLookupProtocolID)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	if err := json.NewEncoder(stream).Encode(peerLookupRequest{PeerID: target.String()}); err != nil {
+		return nil, err
+	}
+	var resp peerLookupResponse
+	if err := json.NewDecoder(stream).Decode(&resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+
+	addrs := make([]multiaddr.Multiaddr, 0, len(resp.Addrs))
+	for _, raw := range resp.Addrs {
+		addr, err := multiaddr.NewMultiaddr(raw)
+		if err != nil {
+			continue
+		}
+		addrs = append(addrs, addr)
+	}
+	return addrs, nil
+}
+
+// resolveViaMembers asks connected members for target's addresses and stores
+// whatever they return in the peerstore.
+func (n *Node) resolveViaMembers(ctx context.Context, target peerstore.ID) []multiaddr.Multiaddr {
+	for _, via := range n.Host.Network().Peers() {
+		if ctx.Err() != nil {
+			return nil
+		}
+		if via == target || !n.isMember(via.String()) {
+			continue
+		}
+		reqCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		addrs, err := n.LookupPeer(reqCtx, via, target)
+		cancel()
+		if err != nil {
+			logging.Log("LOOKUP", "lookup_failed", map[string]string{
+				"peer_id": target.String(),
+				"via":     via.String(),
+				"reason":  err.Error(),
+			})
+			continue
+		}
+		info, onlySelf := n.withoutSelfAddrs(peerstore.AddrInfo{ID: target, Addrs: addrs})
+		addrs = info.Addrs
+		if onlySelf || len(addrs) == 0 {
+			continue
+		}
+		n.Host.Peerstore().AddAddrs(target, addrs, corepeerstore.RecentlyConnectedAddrTTL)
+		logging.Log("LOOKUP", "resolved", map[string]string{
+			"peer_id": target.String(),
+			"via":     via.String(),
+			"addrs":   joinMultiaddrs(addrs),
+		})
+		return addrs
+	}
+	return nil
+}
+
+// ReconnectMembers dials every member that is not currently connected,
+// looking up addresses through other members when none are known.
+func (n *Node) ReconnectMembers(ctx context.Context) error {
+	n.memberMu.RLock()
+	manager := n.membership
+	n.memberMu.RUnlock()
+	if manager == nil || !n.canUseBusinessProtocols() {
+		return nil
+	}
+
+	for _, member := range manager.Snapshot().Members {
+		// Stop between peers on shutdown or task timeout instead of
+		// working through the rest of the list with dead contexts.
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		id, err := peerstore.Decode(member)
+		if err != nil || id == n.Host.ID() {
+			continue
+		}
+		if n.Host.Network().Connectedness(id) == libp2pnet.Connected {
+			continue
+		}
+		info := n.Host.Peerstore().PeerInfo(id)
+		if len(info.Addrs) == 0 {
+			info.Addrs = n.resolveViaMembers(ctx, id)
+		}
+		if len(info.Addrs) == 0 {
+			continue
+		}
+
+		reqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		err = n.dialPeer(reqCtx, info)
+		cancel()
+		if errors.Is(err, errDialThrottled) {
+			continue
+		}
+		if err != nil {
+			logging.Log("NODE", "member_dial_failed", map[string]string{
+				"peer_id": id.String(),
+				"reason":  err.Error(),
+			})
+			continue
+		}
+		logging.Log("NODE", "member_connected", map[string]string{
+			"peer_id": id.String(),
+		})
+	}
+	return nil
+}
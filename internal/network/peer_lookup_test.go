@@ -0,0 +1,44 @@
+package network_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"p2pos/internal/network/nettest"
+
+	libp2pnet "github.com/libp2p/go-libp2p/core/network"
+)
+
+func TestLookupLearnsAddressThroughMember(t *testing.T) {
+	cluster := nettest.NewCluster(t, 3)
+	a, b, c := cluster.Nodes[0], cluster.Nodes[1], cluster.Nodes[2]
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	// A and C only know B.
+	if err := nettest.Connect(ctx, a, b); err != nil {
+		t.Fatal(err)
+	}
+	if err := nettest.Connect(ctx, b, c); err != nil {
+		t.Fatal(err)
+	}
+	if got := a.Host.Peerstore().Addrs(c.Host.ID()); len(got) != 0 {
+		t.Fatalf("A already knows C at %v", got)
+	}
+
+	addrs, err := a.LookupPeer(ctx, b.Host.ID(), c.Host.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addrs) == 0 {
+		t.Fatal("B returned no addresses for C")
+	}
+
+	if err := a.ReconnectMembers(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if a.Host.Network().Connectedness(c.Host.ID()) != libp2pnet.Connected {
+		t.Fatal("A did not reach C through the address B shared")
+	}
+}
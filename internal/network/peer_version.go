@@ -0,0 +1,113 @@
+package network
+
+import (
+	"strings"
+
+	"p2pos/internal/config"
+	"p2pos/internal/logging"
+	"p2pos/internal/update"
+
+	libp2pevent "github.com/libp2p/go-libp2p/core/event"
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+)
+
+const userAgentPrefix = "p2pos/"
+
+func userAgent() string {
+	return userAgentPrefix + config.AppVersion
+}
+
+// versionFromUserAgent extracts the p2pos version from an identify agent
+// string such as "p2pos/20250101-1200". Other agents yield "".
+func versionFromUserAgent(agent string) string {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(agent), userAgentPrefix)
+	if !ok {
+		return ""
+	}
+	// Ignore any trailing comment, e.g. "p2pos/20250101-1200 (linux)".
+	if i := strings.IndexByte(rest, ' '); i >= 0 {
+		rest = rest[:i]
+	}
+	version := strings.TrimPrefix(rest, "v")
+	if !update.ValidVersion(version) {
+		return ""
+	}
+	return version
+}
+
+// peerVersionTooOld reports whether version is below min. Unknown versions
+// are never considered too old: peers predating the user agent cannot be
+// told apart from foreign libp2p software.
+func peerVersionTooOld(version, min string) bool {
+	if min == "" || version == "" {
+		return false
+	}
+	return update.CompareVersion(version, min) < 0
+}
+
+// startPeerVersionWatcher records each peer's advertised version once
+// identify completes and applies the min_peer_version gate.
+func (n *Node) startPeerVersionWatcher() {
+	sub, err := n.Host.EventBus().Subscribe(new(libp2pevent.EvtPeerIdentificationCompleted))
+	if err != nil {
+		logging.Log("NODE", "identify_subscribe_failed", map[string]string{
+			"reason": err.Error(),
+		})
+		return
+	}
+
+	go func() {
+		defer sub.Close()
+		for {
+			select {
+			case <-n.lifecycle.Done():
+				return
+			case evt, ok := <-sub.Out():
+				if !ok {
+					return
+				}
+				ev, ok := evt.(libp2pevent.EvtPeerIdentificationCompleted)
+				if !ok {
+					continue
+				}
+				n.checkPeerVersion(ev.Peer, ev.AgentVersion)
+			}
+		}
+	}()
+}
+
+func (n *Node) checkPeerVersion(id peerstore.ID, agent string) {
+	version := versionFromUserAgent(agent)
+	if version != "" {
+		n.peerVersions.Store(id, version)
+	}
+	if !peerVersionTooOld(version, n.minPeerVersion) {
+		return
+	}
+
+	logging.Log("NODE", "peer_version_too_old", map[string]string{
+		"peer_id":     id.String(),
+		"version":     version,
+		"min_version": n.minPeerVersion,
+		"policy":      n.peerVersionPolicy,
+	})
+	if n.peerVersionPolicy == config.PeerVersionDisconnect {
+		_ = n.Host.Network().ClosePeer(id)
+	}
+}
+
+// peerVersion returns the p2pos version peerID advertised via identify, or
+// our own version for the local peer.
+func (n *Node) peerVersion(peerID string) string {
+	if peerID == n.Host.ID().String() {
+		return config.AppVersion
+	}
+	id, err := peerstore.Decode(peerID)
+	if err != nil {
+		return ""
+	}
+	if v, ok := n.peerVersions.Load(id); ok {
+		return v.(string)
+	}
+	return ""
+}
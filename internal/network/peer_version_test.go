@@ -0,0 +1,55 @@
+package network
+
+import (
+	"context"
+	"testing"
+
+	"p2pos/internal/config"
+
+	libp2pnet "github.com/libp2p/go-libp2p/core/network"
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestVersionFromUserAgent(t *testing.T) {
+	cases := map[string]string{
+		"p2pos/20250101-1200":         "20250101-1200",
+		"p2pos/v20250101-1200":        "20250101-1200",
+		"p2pos/20250101-1200 (linux)": "20250101-1200",
+		"p2pos/20250101-1200-dev":     "20250101-1200-dev",
+		"p2pos/garbage":               "",
+		"kubo/0.30.0":                 "",
+		"":                            "",
+	}
+	for agent, want := range cases {
+		if got := versionFromUserAgent(agent); got != want {
+			t.Errorf("versionFromUserAgent(%q) = %q, want %q", agent, got, want)
+		}
+	}
+}
+
+func TestPeerVersionGate(t *testing.T) {
+	a, b := newTestHost(t), newTestHost(t)
+	if err := a.Connect(context.Background(), peerstore.AddrInfo{ID: b.ID(), Addrs: b.Addrs()}); err != nil {
+		t.Fatal(err)
+	}
+	n := &Node{Host: a, minPeerVersion: "20250601-0000", peerVersionPolicy: config.PeerVersionWarn}
+
+	n.checkPeerVersion(b.ID(), "kubo/0.30.0")
+	n.checkPeerVersion(b.ID(), "p2pos/20250101-1200")
+	if got := n.peerVersion(b.ID().String()); got != "20250101-1200" {
+		t.Fatalf("recorded version %q", got)
+	}
+	if a.Network().Connectedness(b.ID()) != libp2pnet.Connected {
+		t.Fatal("warn policy disconnected an old peer")
+	}
+
+	n.peerVersionPolicy = config.PeerVersionDisconnect
+	n.checkPeerVersion(b.ID(), "p2pos/20250701-0000")
+	if a.Network().Connectedness(b.ID()) != libp2pnet.Connected {
+		t.Fatal("disconnect policy dropped a new enough peer")
+	}
+	n.checkPeerVersion(b.ID(), "p2pos/20250101-1200")
+	if a.Network().Connectedness(b.ID()) == libp2pnet.Connected {
+		t.Fatal("disconnect policy kept an old peer")
+	}
+}
@@ -0,0 +1,131 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"p2pos/internal/logging"
+
+	libp2pnet "github.com/libp2p/go-libp2p/core/network"
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+	corepeerstore "github.com/libp2p/go-libp2p/core/peerstore"
+	multiaddr "github.com/multiformats/go-multiaddr"
+)
+
+const pinnedProtectTag = "p2pos-pinned"
+
+// parsePinnedPeers accepts full /p2p multiaddrs or bare peer IDs. Entries
+// sharing a peer ID are merged; invalid entries are logged and skipped.
+func parsePinnedPeers(raw []string) map[peerstore.ID]peerstore.AddrInfo {
+	out := make(map[peerstore.ID]peerstore.AddrInfo)
+	for _, entry := range raw {
+		value := strings.TrimSpace(entry)
+		if value == "" {
+			continue
+		}
+
+		var info peerstore.AddrInfo
+		if strings.HasPrefix(value, "/") {
+			parsed, err := ParseP2PAddr(value)
+			if err != nil {
+				logging.Log("NODE", "pinned_peer_invalid", map[string]string{
+					"value":  value,
+					"reason": err.Error(),
+				})
+				continue
+			}
+			info = *parsed
+		} else {
+			id, err := peerstore.Decode(value)
+			if err != nil {
+				logging.Log("NODE", "pinned_peer_invalid", map[string]string{
+					"value":  value,
+					"reason": err.Error(),
+				})
+				continue
+			}
+			info = peerstore.AddrInfo{ID: id}
+		}
+
+		existing := out[info.ID]
+		existing.ID = info.ID
+		existing.Addrs = append(existing.Addrs, info.Addrs...)
+		out[info.ID] = existing
+	}
+	return out
+}
+
+func (n *Node) protectPinnedPeers() {
+	for id, info := range n.pinned {
+		n.Host.ConnManager().Protect(id, pinnedProtectTag)
+		if len(info.Addrs) > 0 {
+			n.Host.Peerstore().AddAddrs(id, info.Addrs, corepeerstore.PermanentAddrTTL)
+		}
+		logging.Log("NODE", "pinned_peer", map[string]string{
+			"peer_id": id.String(),
+			"addrs":   joinMultiaddrs(info.Addrs),
+		})
+	}
+}
+
+func (n *Node) isPinned(peerID string) bool {
+	id, err := peerstore.Decode(peerID)
+	if err != nil {
+		return false
+	}
+	_, ok := n.pinned[id]
+	return ok
+}
+
+// ReconnectPinnedPeers dials every pinned peer that is not currently connected.
+func (n *Node) ReconnectPinnedPeers(ctx context.Context) error {
+	for id := range n.pinned {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if id == n.Host.ID() {
+			continue
+		}
+		if n.Host.Network().Connectedness(id) == libp2pnet.Connected {
+			continue
+		}
+		info := n.Host.Peerstore().PeerInfo(id)
+		if len(info.Addrs) == 0 && n.canUseBusinessProtocols() && n.isMember(id.String()) {
+			info.Addrs = n.resolveViaMembers(ctx, id)
+		}
+		if len(info.Addrs) == 0 {
+			logging.Log("NODE", "pinned_peer_no_addrs", map[string]string{
+				"peer_id": id.String(),
+			})
+			continue
+		}
+
+		reqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		err := n.dialPeer(reqCtx, info)
+		cancel()
+		if errors.Is(err, errDialThrottled) {
+			continue
+		}
+		if err != nil {
+			logging.Log("NODE", "pinned_peer_dial_failed", map[string]string{
+				"peer_id": id.String(),
+				"reason":  err.Error(),
+			})
+			continue
+		}
+		logging.Log("NODE", "pinned_peer_connected", map[string]string{
+			"peer_id": id.String(),
+		})
+	}
+	return nil
+}
+
+func joinMultiaddrs(addrs []multiaddr.Multiaddr) string {
+	parts := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		parts = append(parts, addr.String())
+	}
+	return strings.Join(parts, ",")
+}
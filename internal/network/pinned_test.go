@@ -0,0 +1,121 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p"
+	libp2pnet "github.com/libp2p/go-libp2p/core/network"
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+	corepeerstore "github.com/libp2p/go-libp2p/core/peerstore"
+	"github.com/libp2p/go-libp2p/p2p/net/connmgr"
+)
+
+func TestParsePinnedPeers(t *testing.T) {
+	_, a := newTestPeer(t)
+	_, b := newTestPeer(t)
+	pinned := parsePinnedPeers([]string{
+		"/ip4/198.51.100.1/tcp/4100/p2p/" + a.String(),
+		"/ip4/198.51.100.1/udp/4100/quic-v1/p2p/" + a.String(),
+		" " + b.String() + " ",
+		"",
+		"not-a-peer",
+		"/ip4/198.51.100.1/tcp/4100",
+	})
+	if len(pinned) != 2 {
+		t.Fatalf("parsed %v, want a and b", pinned)
+	}
+	if got := pinned[a].Addrs; len(got) != 2 {
+		t.Fatalf("addresses for a: %v, want both entries merged", got)
+	}
+	if got := pinned[b]; got.ID != b || len(got.Addrs) != 0 {
+		t.Fatalf("bare peer ID parsed as %v", got)
+	}
+}
+
+func TestPinnedPeerSurvivesTrim(t *testing.T) {
+	mgr, err := connmgr.NewConnManager(1, 2, connmgr.WithGracePeriod(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"), libp2p.ConnectionManager(mgr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = h.Close() })
+
+	var others []peerstore.ID
+	var pinnedID peerstore.ID
+	for i := 0; i < 4; i++ {
+		other := newTestHost(t)
+		if err := h.Connect(context.Background(), peerstore.AddrInfo{ID: other.ID(), Addrs: other.Addrs()}); err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			pinnedID = other.ID()
+			continue
+		}
+		others = append(others, other.ID())
+	}
+
+	n := &Node{Host: h, pinned: parsePinnedPeers([]string{pinnedID.String()})}
+	n.protectPinnedPeers()
+	mgr.TrimOpenConns(context.Background())
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		dropped := 0
+		for _, id := range others {
+			if h.Network().Connectedness(id) != libp2pnet.Connected {
+				dropped++
+			}
+		}
+		if dropped > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the trim dropped no unpinned peer")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if h.Network().Connectedness(pinnedID) != libp2pnet.Connected {
+		t.Fatal("the trim dropped the pinned peer")
+	}
+}
+
+func TestReconnectPinnedPeersStopsOnCancel(t *testing.T) {
+	n := &Node{
+		Host:        newTestHost(t),
+		dialBreaker: newDialBreaker(time.Minute, time.Minute, 2, time.Hour),
+		pinned:      make(map[peerstore.ID]peerstore.AddrInfo),
+	}
+	var targets []peerstore.ID
+	for i := 0; i < 3; i++ {
+		other := newTestHost(t)
+		n.pinned[other.ID()] = peerstore.AddrInfo{ID: other.ID()}
+		n.Host.Peerstore().AddAddrs(other.ID(), other.Addrs(), corepeerstore.PermanentAddrTTL)
+		targets = append(targets, other.ID())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// Cancel as soon as the first pinned peer is connected.
+	n.Host.Network().Notify(&libp2pnet.NotifyBundle{
+		ConnectedF: func(libp2pnet.Network, libp2pnet.Conn) { cancel() },
+	})
+
+	if err := n.ReconnectPinnedPeers(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("ReconnectPinnedPeers = %v, want context.Canceled", err)
+	}
+	connected := 0
+	for _, id := range targets {
+		if n.Host.Network().Connectedness(id) == libp2pnet.Connected {
+			connected++
+		}
+	}
+	if connected != 1 {
+		t.Fatalf("%d pinned peers dialed, want the loop to stop after the first", connected)
+	}
+}
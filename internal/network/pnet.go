@@ -0,0 +1,38 @@
+package network
+
+import (
+	"strings"
+
+	"p2pos/internal/logging"
+
+	"github.com/libp2p/go-libp2p/core/pnet"
+)
+
+// privateNetworkKey returns the PSK to install on the host, or nil when the
+// private network is not configured or public-service mode opted out.
+func privateNetworkKey(cfg ListenProvider, publicService bool) pnet.PSK {
+	psk := cfg.PrivateNetworkPSK()
+	if len(psk) == 0 {
+		return nil
+	}
+	if publicService && cfg.PrivateNetworkPublicOptOut() {
+		logging.Log("NODE", "private_network_opt_out", map[string]string{
+			"network_mode": cfg.NetworkMode(),
+		})
+		return nil
+	}
+	return pnet.PSK(psk)
+}
+
+// withoutQUICAddrs drops QUIC listen addresses; libp2p cannot run QUIC
+// inside a private network.
+func withoutQUICAddrs(addrs []string) []string {
+	out := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		if strings.Contains(addr, "/quic") {
+			continue
+		}
+		out = append(out, addr)
+	}
+	return out
+}
@@ -0,0 +1,34 @@
+package network_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"p2pos/internal/network/nettest"
+)
+
+func TestPrivateNetworkRefusesWrongPSK(t *testing.T) {
+	cluster := bytes.Repeat([]byte{0x42}, 32)
+	other := bytes.Repeat([]byte{0x17}, 32)
+
+	a, _ := nettest.NewNode(t, &nettest.Config{Key: newKey(t), PSK: cluster})
+	b, _ := nettest.NewNode(t, &nettest.Config{Key: newKey(t), PSK: cluster})
+	wrong, _ := nettest.NewNode(t, &nettest.Config{Key: newKey(t), PSK: other})
+	open, _ := nettest.NewNode(t, &nettest.Config{Key: newKey(t)})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := nettest.Connect(ctx, a, b); err != nil {
+		t.Fatalf("nodes sharing the PSK failed to connect: %v", err)
+	}
+	dialCtx, dialCancel := context.WithTimeout(ctx, 3*time.Second)
+	defer dialCancel()
+	if err := nettest.Connect(dialCtx, wrong, a); err == nil {
+		t.Fatal("node with the wrong PSK connected")
+	}
+	if err := nettest.Connect(dialCtx, open, a); err == nil {
+		t.Fatal("node without a PSK connected")
+	}
+}
@@ -0,0 +1,119 @@
+package network
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	libp2pnet "github.com/libp2p/go-libp2p/core/network"
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+// ProtocolStats is the traffic seen on one p2pos protocol since start.
+// Every protocol carries a single request/response per stream, so the stream
+// counts double as message counts.
+type ProtocolStats struct {
+	Protocol   string `json:"protocol"`
+	StreamsIn  uint64 `json:"streams_in"`
+	StreamsOut uint64 `json:"streams_out"`
+	BytesIn    uint64 `json:"bytes_in"`
+	BytesOut   uint64 `json:"bytes_out"`
+}
+
+type protocolCounters struct {
+	streamsIn  atomic.Uint64
+	streamsOut atomic.Uint64
+	bytesIn    atomic.Uint64
+	bytesOut   atomic.Uint64
+}
+
+// protocolStatsStore aggregates counters per protocol ID. The zero value is
+// ready to use.
+type protocolStatsStore struct {
+	mu       sync.RWMutex
+	counters map[protocol.ID]*protocolCounters
+}
+
+func (s *protocolStatsStore) forProtocol(id protocol.ID) *protocolCounters {
+	s.mu.RLock()
+	c := s.counters[id]
+	s.mu.RUnlock()
+	if c != nil {
+		return c
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.counters == nil {
+		s.counters = make(map[protocol.ID]*protocolCounters)
+	}
+	if c = s.counters[id]; c == nil {
+		c = &protocolCounters{}
+		s.counters[id] = c
+	}
+	return c
+}
+
+func (s *protocolStatsStore) snapshot() []ProtocolStats {
+	s.mu.RLock()
+	out := make([]ProtocolStats, 0, len(s.counters))
+	for id, c := range s.counters {
+		out = append(out, ProtocolStats{
+			Protocol:   string(id),
+			StreamsIn:  c.streamsIn.Load(),
+			StreamsOut: c.streamsOut.Load(),
+			BytesIn:    c.bytesIn.Load(),
+			BytesOut:   c.bytesOut.Load(),
+		})
+	}
+	s.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Protocol < out[j].Protocol })
+	return out
+}
+
+// countedStream adds every byte read and written to its protocol counters.
+type countedStream struct {
+	libp2pnet.Stream
+	counters *protocolCounters
+}
+
+func (s *countedStream) Read(p []byte) (int, error) {
+	n, err := s.Stream.Read(p)
+	s.counters.bytesIn.Add(uint64(n))
+	return n, err
+}
+
+func (s *countedStream) Write(p []byte) (int, error) {
+	n, err := s.Stream.Write(p)
+	s.counters.bytesOut.Add(uint64(n))
+	return n, err
+}
+
+// setCountedHandler registers handler for id with the stream wrapped in a
+// byte counter.
+func (n *Node) setCountedHandler(id protocol.ID, handler libp2pnet.StreamHandler) {
+	n.Host.SetStreamHandler(id, func(stream libp2pnet.Stream) {
+		counters := n.protocolStats.forProtocol(id)
+		counters.streamsIn.Add(1)
+		handler(&countedStream{Stream: stream, counters: counters})
+	})
+}
+
+// newCountedStream opens an outbound stream whose traffic is counted
+// against id.
+func (n *Node) newCountedStream(ctx context.Context, peerID peerstore.ID, id protocol.ID) (libp2pnet.Stream, error) {
+	stream, err := n.Host.NewStream(ctx, peerID, id)
+	if err != nil {
+		return nil, err
+	}
+	counters := n.protocolStats.forProtocol(id)
+	counters.streamsOut.Add(1)
+	return &countedStream{Stream: stream, counters: counters}, nil
+}
+
+// ProtocolStats returns per-protocol stream and byte counts, sorted by
+// protocol ID.
+func (n *Node) ProtocolStats() []ProtocolStats {
+	return n.protocolStats.snapshot()
+}
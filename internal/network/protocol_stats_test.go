@@ -0,0 +1,56 @@
+package network
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	libp2pnet "github.com/libp2p/go-libp2p/core/network"
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+func TestProtocolStatsCountKnownExchange(t *testing.T) {
+	const id = protocol.ID("/p2pos/test/1.0.0")
+	server := &Node{Host: newTestHost(t)}
+	client := &Node{Host: newTestHost(t)}
+	served := make(chan struct{})
+	server.setCountedHandler(id, func(stream libp2pnet.Stream) {
+		defer close(served)
+		defer stream.Close()
+		buf := make([]byte, 5)
+		if _, err := io.ReadFull(stream, buf); err != nil {
+			t.Error(err)
+			return
+		}
+		_, _ = stream.Write([]byte("world!"))
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Host.Connect(ctx, peerstore.AddrInfo{ID: server.Host.ID(), Addrs: server.Host.Addrs()}); err != nil {
+		t.Fatal(err)
+	}
+	stream, err := client.newCountedStream(ctx, server.Host.ID(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadAll(stream); err != nil {
+		t.Fatal(err)
+	}
+	stream.Close()
+	<-served
+
+	want := ProtocolStats{Protocol: string(id), StreamsOut: 1, BytesOut: 5, BytesIn: 6}
+	if got := client.ProtocolStats(); len(got) != 1 || got[0] != want {
+		t.Fatalf("client stats %+v, want %+v", got, want)
+	}
+	want = ProtocolStats{Protocol: string(id), StreamsIn: 1, BytesIn: 5, BytesOut: 6}
+	if got := server.ProtocolStats(); len(got) != 1 || got[0] != want {
+		t.Fatalf("server stats %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,49 @@
+package network
+
+import (
+	"sync"
+	"time"
+
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+)
+
+// unsupportedCache remembers peers that answered "protocols not supported"
+// so periodic tasks stop opening streams to them. Marks expire after ttl,
+// so a peer that upgrades mid-connection is probed again without having to
+// reconnect. The zero value never expires marks.
+type unsupportedCache struct {
+	mu    sync.Mutex
+	ttl   time.Duration
+	peers map[peerstore.ID]time.Time
+}
+
+func (c *unsupportedCache) mark(id peerstore.ID, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.peers == nil {
+		c.peers = make(map[peerstore.ID]time.Time)
+	}
+	c.peers[id] = now
+}
+
+// skip reports whether id is still marked unsupported. An expired mark is
+// dropped, so the caller probes the peer again.
+func (c *unsupportedCache) skip(id peerstore.ID, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	markedAt, ok := c.peers[id]
+	if !ok {
+		return false
+	}
+	if c.ttl > 0 && now.Sub(markedAt) >= c.ttl {
+		delete(c.peers, id)
+		return false
+	}
+	return true
+}
+
+func (c *unsupportedCache) forget(id peerstore.ID) {
+	c.mu.Lock()
+	delete(c.peers, id)
+	c.mu.Unlock()
+}
@@ -0,0 +1,28 @@
+package network
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUnsupportedMarkExpires(t *testing.T) {
+	_, id := newTestPeer(t)
+	cache := &unsupportedCache{ttl: time.Minute}
+	start := time.Now()
+	cache.mark(id, start)
+	if !cache.skip(id, start.Add(30*time.Second)) {
+		t.Fatal("marked peer probed within the ttl")
+	}
+	if cache.skip(id, start.Add(time.Minute)) {
+		t.Fatal("marked peer still skipped after the ttl")
+	}
+	if cache.skip(id, start.Add(time.Minute+time.Second)) {
+		t.Fatal("expired mark came back")
+	}
+
+	forever := &unsupportedCache{}
+	forever.mark(id, start)
+	if !forever.skip(id, start.Add(24*time.Hour)) {
+		t.Fatal("mark without a ttl expired")
+	}
+}
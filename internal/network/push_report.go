@@ -0,0 +1,98 @@
+package network
+
+import (
+	"context"
+	"sync"
+
+	"p2pos/internal/logging"
+	"p2pos/internal/membership"
+
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+)
+
+const defaultPushConcurrency = 8
+
+// PeerAck is the outcome of pushing a snapshot to one peer. Pending pushes
+// were still in flight when the caller got its answer.
+type PeerAck struct {
+	PeerID  string `json:"peer_id"`
+	Acked   bool   `json:"acked"`
+	Pending bool   `json:"pending,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// PushReport summarises a membership push at the moment it returned.
+type PushReport struct {
+	Quorum int       `json:"quorum"`
+	Acked  int       `json:"acked"`
+	Peers  []PeerAck `json:"peers"`
+}
+
+// pushToPeers pushes snapshot to peers with bounded concurrency and returns
+// once quorum peers acked, every push finished, or ctx is done. Pushes still
+// in flight keep running under the node lifecycle, bounded by
+// membershipFanoutTimeout, and log their failures under failEvent.
+func (n *Node) pushToPeers(ctx context.Context, peers []peerstore.ID, snapshot membership.Snapshot, quorum int, failEvent string) PushReport {
+	if quorum > len(peers) {
+		quorum = len(peers)
+	}
+	concurrency := n.pushConcurrency
+	if concurrency <= 0 {
+		concurrency = defaultPushConcurrency
+	}
+
+	pushCtx, cancel := context.WithTimeout(n.lifecycle, membershipFanoutTimeout)
+	results := make(chan PeerAck, len(peers))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, peerID := range peers {
+		wg.Add(1)
+		go func(peerID peerstore.ID) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-pushCtx.Done():
+				results <- PeerAck{PeerID: peerID.String(), Error: pushCtx.Err().Error()}
+				return
+			}
+			defer func() { <-sem }()
+
+			ack := PeerAck{PeerID: peerID.String(), Acked: true}
+			if err := n.pushSnapshot(pushCtx, peerID, snapshot); err != nil {
+				ack.Acked = false
+				ack.Error = err.Error()
+				logging.Log("MEMBERSHIP", failEvent, map[string]string{
+					"peer_id": peerID.String(),
+					"reason":  err.Error(),
+				})
+			}
+			results <- ack
+		}(peerID)
+	}
+	go func() {
+		wg.Wait()
+		cancel()
+	}()
+
+	report := PushReport{Quorum: quorum, Peers: make([]PeerAck, 0, len(peers))}
+	done := make(map[string]struct{}, len(peers))
+wait:
+	for len(done) < len(peers) && report.Acked < quorum {
+		select {
+		case ack := <-results:
+			done[ack.PeerID] = struct{}{}
+			report.Peers = append(report.Peers, ack)
+			if ack.Acked {
+				report.Acked++
+			}
+		case <-ctx.Done():
+			break wait
+		}
+	}
+	for _, peerID := range peers {
+		if _, ok := done[peerID.String()]; !ok {
+			report.Peers = append(report.Peers, PeerAck{PeerID: peerID.String(), Pending: true})
+		}
+	}
+	return report
+}
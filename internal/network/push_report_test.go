@@ -0,0 +1,117 @@
+package network
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"p2pos/internal/membership"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	libp2pnet "github.com/libp2p/go-libp2p/core/network"
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+)
+
+// pushTarget is a peer that counts membership pushes and answers them
+// after delay, or never when delay is negative.
+type pushTarget struct {
+	host   host.Host
+	pushes atomic.Int32
+}
+
+func newPushTarget(t *testing.T, from host.Host, delay time.Duration) *pushTarget {
+	t.Helper()
+	target := &pushTarget{host: newTestHost(t)}
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+	target.host.SetStreamHandler(membershipPushProtocolID, func(stream libp2pnet.Stream) {
+		defer stream.Close()
+		target.pushes.Add(1)
+		if delay < 0 {
+			<-release
+			return
+		}
+		select {
+		case <-time.After(delay):
+		case <-release:
+			return
+		}
+		_, _ = stream.Write([]byte(`{"applied":true}` + "\n"))
+	})
+	if err := from.Connect(context.Background(), peerstore.AddrInfo{ID: target.host.ID(), Addrs: target.host.Addrs()}); err != nil {
+		t.Fatal(err)
+	}
+	return target
+}
+
+func TestFanoutStopsWhenNodeCloses(t *testing.T) {
+	lifecycle, stop := context.WithCancel(context.Background())
+	defer stop()
+	n := &Node{Host: newTestHost(t), lifecycle: lifecycle, pushConcurrency: 1}
+
+	var targets []*pushTarget
+	for i := 0; i < 4; i++ {
+		targets = append(targets, newPushTarget(t, n.Host, -1))
+	}
+	pushed := func() int32 {
+		var total int32
+		for _, target := range targets {
+			total += target.pushes.Load()
+		}
+		return total
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		n.fanoutSnapshot(lifecycle, "", membership.Snapshot{ClusterID: "test"})
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for pushed() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("fanout never pushed to the first peer")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	stop()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("fanout did not return after the node closed")
+	}
+	time.Sleep(200 * time.Millisecond)
+	if got := pushed(); got != 1 {
+		t.Fatalf("%d pushes reached peers, want only the one in flight when the node closed", got)
+	}
+}
+
+func TestPushReturnsAfterAckQuorum(t *testing.T) {
+	lifecycle, stop := context.WithCancel(context.Background())
+	defer stop()
+	n := &Node{Host: newTestHost(t), lifecycle: lifecycle}
+
+	slow := newPushTarget(t, n.Host, -1)
+	peers := []peerstore.ID{
+		newPushTarget(t, n.Host, 0).host.ID(),
+		newPushTarget(t, n.Host, 0).host.ID(),
+		slow.host.ID(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	report := n.pushToPeers(ctx, peers, membership.Snapshot{ClusterID: "test"}, 2, "push_failed")
+	if ctx.Err() != nil {
+		t.Fatal("push waited on the slow peer")
+	}
+	if report.Quorum != 2 || report.Acked != 2 {
+		t.Fatalf("quorum %d acked %d, want 2/2", report.Quorum, report.Acked)
+	}
+	for _, ack := range report.Peers {
+		if ack.PeerID == slow.host.ID().String() && !ack.Pending {
+			t.Fatalf("slow peer reported %+v, want pending", ack)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package network
+
+import (
+	"sync"
+	"time"
+
+	"p2pos/internal/logging"
+)
+
+// quorumHold keeps recently disconnected members counted towards quorum for
+// a short hold-down, so transient drops do not flip the node to degraded.
+type quorumHold struct {
+	mu    sync.Mutex
+	hold  time.Duration
+	until map[string]time.Time
+}
+
+func newQuorumHold(hold time.Duration) *quorumHold {
+	return &quorumHold{hold: hold, until: make(map[string]time.Time)}
+}
+
+func (h *quorumHold) start(peerID string, now time.Time) time.Duration {
+	if h.hold <= 0 {
+		return 0
+	}
+	h.mu.Lock()
+	h.until[peerID] = now.Add(h.hold)
+	h.mu.Unlock()
+	return h.hold
+}
+
+func (h *quorumHold) release(peerID string) {
+	h.mu.Lock()
+	delete(h.until, peerID)
+	h.mu.Unlock()
+}
+
+// active returns members still within their hold-down and drops expired ones.
+func (h *quorumHold) active(now time.Time) []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out := make([]string, 0, len(h.until))
+	for id, until := range h.until {
+		if !now.Before(until) {
+			delete(h.until, id)
+			continue
+		}
+		out = append(out, id)
+	}
+	return out
+}
+
+// holdMemberForQuorum starts the hold-down for a disconnected member and
+// re-evaluates the runtime state once it expires. A reconnect in between
+// releases the hold, so the expiry evaluation is then a no-op.
+func (n *Node) holdMemberForQuorum(peerID string) {
+	hold := n.quorumHold.start(peerID, time.Now())
+	if hold <= 0 {
+		return
+	}
+	logging.Log("NODE", "quorum_hold", map[string]string{
+		"peer_id": peerID,
+		"hold":    hold.String(),
+	})
+	time.AfterFunc(hold, func() {
+		select {
+		case <-n.lifecycle.Done():
+			return
+		default:
+		}
+		n.evaluateRuntimeState("peer-quorum-hold-expired")
+	})
+}
@@ -0,0 +1,86 @@
+package network_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"p2pos/internal/membership"
+	"p2pos/internal/network"
+	"p2pos/internal/network/nettest"
+)
+
+// newHealthyPair starts two connected member nodes with the given quorum
+// hold and waits until both are healthy.
+func newHealthyPair(t *testing.T, hold time.Duration) (a, b *network.Node) {
+	t.Helper()
+	a, _ = nettest.NewNode(t, &nettest.Config{Key: newKey(t), QuorumHold: hold})
+	b, _ = nettest.NewNode(t, &nettest.Config{Key: newKey(t), QuorumHold: hold})
+	members := []string{a.Host.ID().String(), b.Host.ID().String()}
+	for _, node := range []*network.Node{a, b} {
+		manager, err := membership.NewManager("test", "", node.Host.ID().String(), members)
+		if err != nil {
+			t.Fatal(err)
+		}
+		node.SetMembershipManager(manager)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := nettest.Connect(ctx, a, b); err != nil {
+		t.Fatal(err)
+	}
+	for _, node := range []*network.Node{a, b} {
+		if err := node.WaitForState(ctx, network.RuntimeStateHealthy); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return a, b
+}
+
+// blip disconnects a from b and reconnects after gap, returning every state
+// a passed through meanwhile.
+func blip(t *testing.T, a, b *network.Node, gap time.Duration) []network.RuntimeState {
+	t.Helper()
+	var mu sync.Mutex
+	var states []network.RuntimeState
+	unregister := a.OnStateChange(func(_, next network.RuntimeState, _ string) {
+		mu.Lock()
+		states = append(states, next)
+		mu.Unlock()
+	})
+	defer unregister()
+
+	if err := a.Host.Network().ClosePeer(b.Host.ID()); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(gap)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := nettest.Connect(ctx, a, b); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.WaitForState(ctx, network.RuntimeStateHealthy); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	return append([]network.RuntimeState(nil), states...)
+}
+
+func TestBriefDisconnectWithinQuorumHold(t *testing.T) {
+	a, b := newHealthyPair(t, 2*time.Second)
+	for _, state := range blip(t, a, b, 200*time.Millisecond) {
+		if state != network.RuntimeStateHealthy {
+			t.Fatalf("a brief disconnect moved the node to %s", state)
+		}
+	}
+
+	// Without a hold the same blip drops the pair below quorum.
+	a, b = newHealthyPair(t, 0)
+	states := blip(t, a, b, 200*time.Millisecond)
+	if len(states) == 0 || states[0] != network.RuntimeStateDegraded {
+		t.Fatalf("states %v, want degraded without a quorum hold", states)
+	}
+}
@@ -0,0 +1,122 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"p2pos/internal/logging"
+
+	libp2pnet "github.com/libp2p/go-libp2p/core/network"
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+)
+
+// readyProtocolID answers readiness probes. Unlike status it is served in
+// every runtime state, since "not ready yet" is the interesting answer.
+const readyProtocolID = protocol.ID("/p2pos/ready/1.0.0")
+const maxReadyWait = 30 * time.Second
+
+type readyRequest struct {
+	State  RuntimeState `json:"state,omitempty"`
+	WaitMs int64        `json:"wait_ms,omitempty"`
+}
+
+type readyResponse struct {
+	State      RuntimeState     `json:"state"`
+	Ready      bool             `json:"ready"`
+	Diagnostic *StateDiagnostic `json:"diagnostic,omitempty"`
+	Error      string           `json:"error,omitempty"`
+}
+
+// WaitForState blocks until the node is in target or ctx is done. It returns
+// immediately when the node is already in target.
+func (n *Node) WaitForState(ctx context.Context, target RuntimeState) error {
+	reached := make(chan struct{}, 1)
+	unregister := n.OnStateChange(func(_, next RuntimeState, _ string) {
+		if next != target {
+			return
+		}
+		select {
+		case reached <- struct{}{}:
+		default:
+		}
+	})
+	defer unregister()
+
+	if n.RuntimeState() == target {
+		return nil
+	}
+	select {
+	case <-reached:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (n *Node) registerReadyHandler() {
+	n.setCountedHandler(readyProtocolID, func(stream libp2pnet.Stream) {
+		defer stream.Close()
+
+		req := readyRequest{}
+		_ = json.NewDecoder(stream).Decode(&req)
+		if req.State == "" {
+			req.State = RuntimeStateHealthy
+		}
+
+		resp := readyResponse{}
+		switch req.State {
+		case RuntimeStateUnconfigured, RuntimeStateStarting, RuntimeStateRecovering, RuntimeStateDegraded, RuntimeStateHealthy:
+			wait := time.Duration(req.WaitMs) * time.Millisecond
+			if wait > maxReadyWait {
+				wait = maxReadyWait
+			}
+			if wait > 0 {
+				ctx, cancel := context.WithTimeout(n.lifecycle, wait)
+				_ = n.WaitForState(ctx, req.State)
+				cancel()
+			}
+			resp.State = n.RuntimeState()
+			resp.Ready = resp.State == req.State
+			if !resp.Ready {
+				diag := n.StateDiagnostic()
+				resp.Diagnostic = &diag
+			}
+		default:
+			resp.State = n.RuntimeState()
+			resp.Error = fmt.Sprintf("unknown state %q", req.State)
+		}
+
+		if err := json.NewEncoder(stream).Encode(resp); err != nil {
+			logging.Log("STATUS", "encode_failed", map[string]string{
+				"reason": err.Error(),
+			})
+		}
+	})
+}
+
+// FetchReadiness asks peerID whether it is in state, waiting up to wait for
+// the transition on the remote side.
+func (n *Node) FetchReadiness(ctx context.Context, peerID peerstore.ID, state RuntimeState, wait time.Duration) (bool, RuntimeState, error) {
+	stream, err := n.newCountedStream(ctx, peerID, readyProtocolID)
+	if err != nil {
+		return false, "", err
+	}
+	defer stream.Close()
+
+	if err := json.NewEncoder(stream).Encode(readyRequest{State: state, WaitMs: wait.Milliseconds()}); err != nil {
+		return false, "", err
+	}
+
+	var resp readyResponse
+	if err := json.NewDecoder(stream).Decode(&resp); err != nil {
+		return false, "", err
+	}
+	if resp.Error != "" {
+		return false, resp.State, errors.New(resp.Error)
+	}
+	return resp.Ready, resp.State, nil
+}
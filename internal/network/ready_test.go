@@ -0,0 +1,54 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWaitForStateReturnsOnTransition(t *testing.T) {
+	n := &Node{}
+	n.setRuntimeState(RuntimeStateDegraded, "test")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- n.WaitForState(ctx, RuntimeStateHealthy) }()
+
+	select {
+	case err := <-done:
+		t.Fatalf("returned before quorum formed: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	n.setRuntimeState(RuntimeStateHealthy, "quorum")
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("WaitForState did not return after the quorum transition")
+	}
+
+	// Already in the target state.
+	if err := n.WaitForState(ctx, RuntimeStateHealthy); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWaitForStateHonoursContext(t *testing.T) {
+	n := &Node{}
+	n.setRuntimeState(RuntimeStateDegraded, "test")
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := n.WaitForState(ctx, RuntimeStateHealthy); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want the context deadline", err)
+	}
+	n.state.mu.RLock()
+	listeners := len(n.state.listeners)
+	n.state.mu.RUnlock()
+	if listeners != 0 {
+		t.Fatalf("%d state listeners left registered", listeners)
+	}
+}
@@ -0,0 +1,99 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"p2pos/internal/logging"
+
+	libp2pnet "github.com/libp2p/go-libp2p/core/network"
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+)
+
+// stalePeerAddrTTL is how long a disconnected peer keeps its peerstore
+// addresses before reconciliation clears them.
+const stalePeerAddrTTL = 30 * time.Minute
+
+// peerstoreReconciler remembers when each peer was first seen disconnected
+// with addresses still in the peerstore.
+type peerstoreReconciler struct {
+	mu             sync.Mutex
+	disconnectedAt map[peerstore.ID]time.Time
+}
+
+func newPeerstoreReconciler() *peerstoreReconciler {
+	return &peerstoreReconciler{disconnectedAt: make(map[peerstore.ID]time.Time)}
+}
+
+// ReconcilePeerstore brings the peerstore and tracker back in line with the
+// network's connected set: addresses of peers disconnected for longer than
+// stalePeerAddrTTL are cleared, and the tracker is made to hold exactly the
+// connected peers. Pinned peers keep their addresses.
+func (n *Node) ReconcilePeerstore(_ context.Context) error {
+	now := time.Now()
+	network := n.Host.Network()
+
+	n.reconciler.mu.Lock()
+	seen := make(map[peerstore.ID]struct{})
+	pruned := 0
+	for _, id := range n.Host.Peerstore().PeersWithAddrs() {
+		if id == n.Host.ID() || network.Connectedness(id) == libp2pnet.Connected {
+			continue
+		}
+		if _, ok := n.pinned[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		since, ok := n.reconciler.disconnectedAt[id]
+		if !ok {
+			n.reconciler.disconnectedAt[id] = now
+			continue
+		}
+		if now.Sub(since) < stalePeerAddrTTL {
+			continue
+		}
+		n.Host.Peerstore().ClearAddrs(id)
+		delete(n.reconciler.disconnectedAt, id)
+		delete(seen, id)
+		pruned++
+	}
+	for id := range n.reconciler.disconnectedAt {
+		if _, ok := seen[id]; !ok {
+			delete(n.reconciler.disconnectedAt, id)
+		}
+	}
+	n.reconciler.mu.Unlock()
+
+	added, removed := 0, 0
+	for _, info := range n.Tracker.GetAll() {
+		if network.Connectedness(info.ID) != libp2pnet.Connected {
+			n.Tracker.Remove(info.ID)
+			removed++
+		}
+	}
+	for _, id := range network.Peers() {
+		if n.Tracker.Has(id) {
+			continue
+		}
+		conns := network.ConnsToPeer(id)
+		if len(conns) == 0 {
+			continue
+		}
+		n.Tracker.Upsert(remoteAddrInfo(id, conns[0].RemoteMultiaddr()))
+		added++
+	}
+
+	if pruned > 0 || added > 0 || removed > 0 {
+		if added > 0 || removed > 0 {
+			n.clusterCache.invalidate()
+		}
+		logging.Log("NODE", "peerstore_reconcile", map[string]string{
+			"pruned_addrs":    fmt.Sprintf("%d", pruned),
+			"tracker_added":   fmt.Sprintf("%d", added),
+			"tracker_removed": fmt.Sprintf("%d", removed),
+		})
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package network
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+	corepeerstore "github.com/libp2p/go-libp2p/core/peerstore"
+	"github.com/multiformats/go-multiaddr"
+)
+
+func TestReconcilePeerstorePrunesStaleEntries(t *testing.T) {
+	_, stale := newTestPeer(t)
+	_, pinned := newTestPeer(t)
+	n := &Node{
+		Host:       newTestHost(t),
+		Tracker:    NewTracker(),
+		reconciler: newPeerstoreReconciler(),
+		pinned:     map[peerstore.ID]peerstore.AddrInfo{pinned: {ID: pinned}},
+	}
+	addr := multiaddr.StringCast("/ip4/192.0.2.1/tcp/4001")
+	for _, id := range []peerstore.ID{stale, pinned} {
+		n.Host.Peerstore().AddAddr(id, addr, corepeerstore.PermanentAddrTTL)
+	}
+	n.Tracker.Upsert(peerstore.AddrInfo{ID: stale, Addrs: []multiaddr.Multiaddr{addr}})
+
+	if err := n.ReconcilePeerstore(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n.Tracker.Has(stale) {
+		t.Fatal("disconnected peer still tracked")
+	}
+	if len(n.Host.Peerstore().Addrs(stale)) == 0 {
+		t.Fatal("addresses cleared on first sighting")
+	}
+
+	n.reconciler.disconnectedAt[stale] = time.Now().Add(-stalePeerAddrTTL - time.Second)
+	if err := n.ReconcilePeerstore(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if addrs := n.Host.Peerstore().Addrs(stale); len(addrs) != 0 {
+		t.Fatalf("stale peer kept %v", addrs)
+	}
+	if len(n.Host.Peerstore().Addrs(pinned)) == 0 {
+		t.Fatal("pinned peer lost its addresses")
+	}
+}
@@ -0,0 +1,258 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"p2pos/internal/logging"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	libp2pnet "github.com/libp2p/go-libp2p/core/network"
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+	relayclient "github.com/libp2p/go-libp2p/p2p/protocol/circuitv2/client"
+	relayproto "github.com/libp2p/go-libp2p/p2p/protocol/circuitv2/proto"
+)
+
+const (
+	relayProtectTag      = "p2pos-relay"
+	maxRelayReservations = 2
+	relayReserveTimeout  = 15 * time.Second
+)
+
+// relayReservations tracks circuit reservations made in reaction to AutoNAT
+// reporting the node as private. AutoRelay keeps running independently; this
+// only shortens recovery after a NAT change.
+//
+// mu is never held across a Reserve round trip: candidates are claimed in
+// pending, reserved unlocked, then recorded. A release in between bumps gen so
+// late results are dropped instead of resurrecting released slots.
+type relayReservations struct {
+	mu      sync.Mutex
+	active  map[peerstore.ID]*relayclient.Reservation
+	pending map[peerstore.ID]struct{}
+	gen     uint64
+	reserve func(ctx context.Context, h host.Host, ai peerstore.AddrInfo) (*relayclient.Reservation, error)
+}
+
+func newRelayReservations() *relayReservations {
+	return &relayReservations{
+		active:  make(map[peerstore.ID]*relayclient.Reservation),
+		pending: make(map[peerstore.ID]struct{}),
+		reserve: relayclient.Reserve,
+	}
+}
+
+func (r *relayReservations) snapshot() map[peerstore.ID]*relayclient.Reservation {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make(map[peerstore.ID]*relayclient.Reservation, len(r.active))
+	for pid, rsvp := range r.active {
+		out[pid] = rsvp
+	}
+	return out
+}
+
+// onReachabilityChanged reserves relay slots when the node turns private and
+// releases them once it is publicly reachable again.
+func (n *Node) onReachabilityChanged(prev, next libp2pnet.Reachability) {
+	if prev == next {
+		return
+	}
+	switch next {
+	case libp2pnet.ReachabilityPrivate:
+		go n.reserveRelays(n.lifecycle)
+	case libp2pnet.ReachabilityPublic:
+		n.releaseRelays()
+	}
+}
+
+// claimRelayCandidates picks connected hop-capable peers for the free slots
+// and marks them pending.
+func (n *Node) claimRelayCandidates() ([]peerstore.ID, uint64) {
+	n.relays.mu.Lock()
+	defer n.relays.mu.Unlock()
+
+	free := maxRelayReservations - len(n.relays.active) - len(n.relays.pending)
+	var out []peerstore.ID
+	for _, pid := range n.Host.Network().Peers() {
+		if len(out) >= free {
+			break
+		}
+		if _, ok := n.relays.active[pid]; ok {
+			continue
+		}
+		if _, ok := n.relays.pending[pid]; ok {
+			continue
+		}
+		if n.Host.Network().Connectedness(pid) != libp2pnet.Connected {
+			continue
+		}
+		protos, err := n.Host.Peerstore().SupportsProtocols(pid, relayproto.ProtoIDv2Hop)
+		if err != nil || len(protos) == 0 {
+			continue
+		}
+		n.relays.pending[pid] = struct{}{}
+		out = append(out, pid)
+	}
+	return out, n.relays.gen
+}
+
+func (n *Node) reserveRelays(ctx context.Context) {
+	candidates, gen := n.claimRelayCandidates()
+	for _, pid := range candidates {
+		reqCtx, cancel := context.WithTimeout(ctx, relayReserveTimeout)
+		rsvp, err := n.relays.reserve(reqCtx, n.Host, peerstore.AddrInfo{ID: pid})
+		cancel()
+
+		n.relays.mu.Lock()
+		delete(n.relays.pending, pid)
+		kept := err == nil && n.relays.gen == gen && len(n.relays.active) < maxRelayReservations
+		if kept {
+			n.relays.active[pid] = rsvp
+		}
+		n.relays.mu.Unlock()
+
+		if err != nil {
+			logging.Log("NODE", "relay_reserve_failed", map[string]string{
+				"peer_id": pid.String(),
+				"reason":  err.Error(),
+			})
+			continue
+		}
+		if !kept {
+			continue
+		}
+		n.Host.ConnManager().Protect(pid, relayProtectTag)
+		logging.Log("NODE", "relay_reserved", map[string]string{
+			"peer_id":    pid.String(),
+			"expiration": rsvp.Expiration.UTC().Format(time.RFC3339),
+			"addrs":      joinMultiaddrs(rsvp.Addrs),
+		})
+	}
+
+	n.relays.mu.Lock()
+	none := len(n.relays.active) == 0 && len(n.relays.pending) == 0
+	n.relays.mu.Unlock()
+	if none {
+		logging.Log("NODE", "relay_reserve_none", map[string]string{
+			"peers": fmt.Sprintf("%d", len(n.Host.Network().Peers())),
+		})
+	}
+}
+
+// releaseRelays drops our reservations. Circuit v2 has no explicit cancel, so
+// releasing means unprotecting the relay connections and letting the
+// reservations lapse.
+func (n *Node) releaseRelays() {
+	n.relays.mu.Lock()
+	n.relays.gen++
+	released := make([]peerstore.ID, 0, len(n.relays.active))
+	for pid := range n.relays.active {
+		released = append(released, pid)
+		delete(n.relays.active, pid)
+	}
+	n.relays.mu.Unlock()
+
+	if len(released) == 0 {
+		return
+	}
+	for _, pid := range released {
+		n.Host.ConnManager().Unprotect(pid, relayProtectTag)
+	}
+	logging.Log("NODE", "relay_released", nil)
+}
+
+// relayPeerSourceRefresh bounds how often AutoRelay's peer source walks the
+// connected peers; requests in between are served from the last walk.
+const relayPeerSourceRefresh = 10 * time.Second
+
+// relayPeerSource feeds AutoRelay with connected peers as relay candidates.
+// AutoRelay may ask often, so the candidate list is cached for refresh and
+// every request is answered from a pre-filled channel, without a goroutine.
+type relayPeerSource struct {
+	getHost func() host.Host
+	refresh time.Duration
+
+	mu        sync.Mutex
+	cached    []peerstore.AddrInfo
+	fetchedAt time.Time
+	requests  uint64
+	supplied  uint64
+	refreshes uint64
+}
+
+// RelaySourceStats counts AutoRelay's candidate requests and how many
+// connected peers were handed to it.
+type RelaySourceStats struct {
+	Requests   uint64 `json:"requests"`
+	Supplied   uint64 `json:"supplied"`
+	Refreshes  uint64 `json:"refreshes"`
+	Candidates int    `json:"candidates"`
+}
+
+func newRelayPeerSource(getHost func() host.Host, refresh time.Duration) *relayPeerSource {
+	return &relayPeerSource{getHost: getHost, refresh: refresh}
+}
+
+// peerSource implements autorelay.PeerSource. It yields at most num peers
+// and closes the channel once they are queued.
+func (s *relayPeerSource) peerSource(_ context.Context, num int) <-chan peerstore.AddrInfo {
+	if num < 0 {
+		num = 0
+	}
+	s.mu.Lock()
+	candidates := s.candidates(time.Now())
+	if len(candidates) > num {
+		candidates = candidates[:num]
+	}
+	s.requests++
+	s.supplied += uint64(len(candidates))
+	s.mu.Unlock()
+
+	ch := make(chan peerstore.AddrInfo, len(candidates))
+	for _, info := range candidates {
+		ch <- info
+	}
+	close(ch)
+	return ch
+}
+
+// candidates returns the cached list, walking the connected peers again
+// once it is older than refresh. Callers hold s.mu.
+func (s *relayPeerSource) candidates(now time.Time) []peerstore.AddrInfo {
+	if !s.fetchedAt.IsZero() && now.Sub(s.fetchedAt) < s.refresh {
+		return s.cached
+	}
+	h := s.getHost()
+	if h == nil {
+		return nil
+	}
+	out := make([]peerstore.AddrInfo, 0)
+	for _, peerID := range h.Network().Peers() {
+		if h.Network().Connectedness(peerID) != libp2pnet.Connected {
+			continue
+		}
+		info := h.Peerstore().PeerInfo(peerID)
+		if info.ID == "" || len(info.Addrs) == 0 {
+			continue
+		}
+		out = append(out, info)
+	}
+	s.cached = out
+	s.fetchedAt = now
+	s.refreshes++
+	return out
+}
+
+func (s *relayPeerSource) stats() RelaySourceStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return RelaySourceStats{
+		Requests:   s.requests,
+		Supplied:   s.supplied,
+		Refreshes:  s.refreshes,
+		Candidates: len(s.cached),
+	}
+}
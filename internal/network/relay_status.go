@@ -0,0 +1,147 @@
+package network
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"p2pos/internal/logging"
+
+	libp2pevent "github.com/libp2p/go-libp2p/core/event"
+	libp2pnet "github.com/libp2p/go-libp2p/core/network"
+	multiaddr "github.com/multiformats/go-multiaddr"
+)
+
+const (
+	// RelaySourceAutoRelay marks a relay found through the circuit
+	// addresses the host advertises, which AutoRelay adds per reservation.
+	RelaySourceAutoRelay = "autorelay"
+	// RelaySourceReserved marks a relay reserved by reserveRelays after
+	// AutoNAT reported the node private.
+	RelaySourceReserved = "reserved"
+)
+
+// RelayReservation is a relay this node holds a circuit reservation on.
+type RelayReservation struct {
+	PeerID     string     `json:"peer_id"`
+	Source     string     `json:"source"`
+	Expiration *time.Time `json:"expiration,omitempty"`
+	Addrs      []string   `json:"addrs,omitempty"`
+}
+
+// RelayStatus lists the relays this node can currently be reached through.
+// An empty list on a private node means it is unreachable from outside.
+func (n *Node) RelayStatus() []RelayReservation {
+	byPeer := make(map[string]*RelayReservation)
+	for _, addr := range n.Host.Addrs() {
+		relayID := circuitRelayID(addr)
+		if relayID == "" {
+			continue
+		}
+		r, ok := byPeer[relayID]
+		if !ok {
+			r = &RelayReservation{PeerID: relayID, Source: RelaySourceAutoRelay}
+			byPeer[relayID] = r
+		}
+		r.Addrs = append(r.Addrs, addr.String())
+	}
+
+	now := time.Now()
+	for pid, rsvp := range n.relays.snapshot() {
+		if rsvp == nil || (!rsvp.Expiration.IsZero() && now.After(rsvp.Expiration)) {
+			continue
+		}
+		if n.Host.Network().Connectedness(pid) != libp2pnet.Connected {
+			continue
+		}
+		r, ok := byPeer[pid.String()]
+		if !ok {
+			r = &RelayReservation{PeerID: pid.String()}
+			byPeer[pid.String()] = r
+			for _, addr := range rsvp.Addrs {
+				r.Addrs = append(r.Addrs, addr.String())
+			}
+		}
+		r.Source = RelaySourceReserved
+		if !rsvp.Expiration.IsZero() {
+			expiration := rsvp.Expiration.UTC()
+			r.Expiration = &expiration
+		}
+	}
+
+	out := make([]RelayReservation, 0, len(byPeer))
+	for _, r := range byPeer {
+		out = append(out, *r)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].PeerID < out[j].PeerID
+	})
+	return out
+}
+
+// circuitRelayID returns the relay peer ID of a /p2p/<relay>/p2p-circuit
+// address, or "" if addr is not relayed.
+func circuitRelayID(addr multiaddr.Multiaddr) string {
+	relayID := ""
+	for _, c := range addr {
+		switch c.Code() {
+		case multiaddr.P_P2P:
+			relayID = c.Value()
+		case multiaddr.P_CIRCUIT:
+			return relayID
+		}
+	}
+	return ""
+}
+
+// startRelayStatusWatcher logs relay reservations as they appear in and
+// drop out of the host's advertised addresses.
+func (n *Node) startRelayStatusWatcher() {
+	sub, err := n.Host.EventBus().Subscribe(new(libp2pevent.EvtLocalAddressesUpdated))
+	if err != nil {
+		logging.Log("NODE", "relay_status_subscribe_failed", map[string]string{
+			"reason": err.Error(),
+		})
+		return
+	}
+
+	go func() {
+		defer sub.Close()
+		known := make(map[string]struct{})
+		for {
+			select {
+			case <-n.lifecycle.Done():
+				return
+			case _, ok := <-sub.Out():
+				if !ok {
+					return
+				}
+				known = n.logRelayChanges(known)
+			}
+		}
+	}()
+}
+
+func (n *Node) logRelayChanges(known map[string]struct{}) map[string]struct{} {
+	current := make(map[string]struct{})
+	for _, r := range n.RelayStatus() {
+		current[r.PeerID] = struct{}{}
+		if _, ok := known[r.PeerID]; ok {
+			continue
+		}
+		logging.Log("NODE", "relay_reservation_acquired", map[string]string{
+			"peer_id": r.PeerID,
+			"source":  r.Source,
+		})
+	}
+	for peerID := range known {
+		if _, ok := current[peerID]; ok {
+			continue
+		}
+		logging.Log("NODE", "relay_reservation_lost", map[string]string{
+			"peer_id":   peerID,
+			"remaining": fmt.Sprintf("%d", len(current)),
+		})
+	}
+	return current
+}
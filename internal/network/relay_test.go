@@ -0,0 +1,217 @@
+package network
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	libp2pnet "github.com/libp2p/go-libp2p/core/network"
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+	relayclient "github.com/libp2p/go-libp2p/p2p/protocol/circuitv2/client"
+	relayproto "github.com/libp2p/go-libp2p/p2p/protocol/circuitv2/proto"
+	multiaddr "github.com/multiformats/go-multiaddr"
+)
+
+// newRelayTestNode returns a node connected to one peer that advertises the
+// circuit v2 hop protocol, with Reserve replaced by reserve.
+func newRelayTestNode(t *testing.T, reserve func(context.Context, host.Host, peerstore.AddrInfo) (*relayclient.Reservation, error)) (*Node, peerstore.ID) {
+	t.Helper()
+	local := newTestHost(t)
+	relay := newTestHost(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := local.Connect(ctx, peerstore.AddrInfo{ID: relay.ID(), Addrs: relay.Addrs()}); err != nil {
+		t.Fatal(err)
+	}
+	if err := local.Peerstore().AddProtocols(relay.ID(), relayproto.ProtoIDv2Hop); err != nil {
+		t.Fatal(err)
+	}
+	relays := newRelayReservations()
+	relays.reserve = reserve
+	return &Node{Host: local, relays: relays, lifecycle: context.Background()}, relay.ID()
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestReachabilityDrivesRelayReservations(t *testing.T) {
+	calls := make(chan peerstore.ID, 4)
+	n, relayID := newRelayTestNode(t, func(_ context.Context, _ host.Host, ai peerstore.AddrInfo) (*relayclient.Reservation, error) {
+		calls <- ai.ID
+		return &relayclient.Reservation{Expiration: time.Now().Add(time.Hour)}, nil
+	})
+
+	n.onReachabilityChanged(libp2pnet.ReachabilityUnknown, libp2pnet.ReachabilityPrivate)
+	select {
+	case got := <-calls:
+		if got != relayID {
+			t.Fatalf("reserved on %s, want %s", got, relayID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("turning private did not attempt a reservation")
+	}
+	waitFor(t, "reservation recorded", func() bool { return len(n.relays.snapshot()) == 1 })
+
+	n.onReachabilityChanged(libp2pnet.ReachabilityPrivate, libp2pnet.ReachabilityPublic)
+	if got := len(n.relays.snapshot()); got != 0 {
+		t.Fatalf("%d reservations left after turning public", got)
+	}
+}
+
+func TestReleaseDoesNotWaitForReserve(t *testing.T) {
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	n, _ := newRelayTestNode(t, func(ctx context.Context, _ host.Host, _ peerstore.AddrInfo) (*relayclient.Reservation, error) {
+		close(started)
+		<-unblock
+		return &relayclient.Reservation{Expiration: time.Now().Add(time.Hour)}, nil
+	})
+
+	done := make(chan struct{})
+	go func() {
+		n.reserveRelays(context.Background())
+		close(done)
+	}()
+	<-started
+
+	released := make(chan struct{})
+	go func() {
+		n.releaseRelays()
+		close(released)
+	}()
+	select {
+	case <-released:
+	case <-time.After(time.Second):
+		t.Fatal("releaseRelays blocked on an in-flight Reserve")
+	}
+
+	close(unblock)
+	<-done
+	if got := len(n.relays.snapshot()); got != 0 {
+		t.Fatalf("reservation finished after release was kept (%d active)", got)
+	}
+}
+
+func drain(ch <-chan peerstore.AddrInfo) []peerstore.AddrInfo {
+	var out []peerstore.AddrInfo
+	for info := range ch {
+		out = append(out, info)
+	}
+	return out
+}
+
+func connectTestHosts(t *testing.T, h host.Host, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		other := newTestHost(t)
+		if err := h.Connect(context.Background(), peerstore.AddrInfo{ID: other.ID(), Addrs: other.Addrs()}); err != nil {
+			t.Fatalf("connect: %v", err)
+		}
+	}
+}
+
+func TestRelayPeerSourceBoundsAndCaches(t *testing.T) {
+	h := newTestHost(t)
+	connectTestHosts(t, h, 3)
+
+	walks := 0
+	source := newRelayPeerSource(func() host.Host {
+		walks++
+		return h
+	}, time.Hour)
+	ctx := context.Background()
+
+	if got := drain(source.peerSource(ctx, 2)); len(got) != 2 {
+		t.Fatalf("asked for 2 peers, got %d", len(got))
+	}
+	if got := drain(source.peerSource(ctx, 10)); len(got) != 3 {
+		t.Fatalf("asked for 10 with 3 connected, got %d", len(got))
+	}
+	if got := drain(source.peerSource(ctx, 0)); len(got) != 0 {
+		t.Fatalf("asked for none, got %d", len(got))
+	}
+
+	// A peer connected within the interval is not seen until the cache expires.
+	connectTestHosts(t, h, 1)
+	if got := drain(source.peerSource(ctx, 10)); len(got) != 3 {
+		t.Fatalf("cache not reused within the interval: got %d peers", len(got))
+	}
+	if walks != 1 {
+		t.Fatalf("walked the peers %d times within the interval, want 1", walks)
+	}
+
+	source.mu.Lock()
+	source.fetchedAt = source.fetchedAt.Add(-2 * time.Hour)
+	source.mu.Unlock()
+	if got := drain(source.peerSource(ctx, 10)); len(got) != 4 {
+		t.Fatalf("expired cache not refreshed: got %d peers, want 4", len(got))
+	}
+
+	stats := source.stats()
+	if stats.Requests != 5 || stats.Supplied != 12 || stats.Refreshes != 2 || stats.Candidates != 4 {
+		t.Fatalf("stats %+v", stats)
+	}
+}
+
+func TestRelayPeerSourceWithoutHost(t *testing.T) {
+	source := newRelayPeerSource(func() host.Host { return nil }, time.Hour)
+	if got := drain(source.peerSource(context.Background(), 4)); len(got) != 0 {
+		t.Fatalf("no host yet, got %d peers", len(got))
+	}
+}
+
+// circuitHost advertises one relayed address, as AutoRelay does per
+// reservation.
+type circuitHost struct {
+	host.Host
+	circuit multiaddr.Multiaddr
+}
+
+func (h circuitHost) Addrs() []multiaddr.Multiaddr {
+	return append(h.Host.Addrs(), h.circuit)
+}
+
+func TestRelayStatusReportsReservations(t *testing.T) {
+	expiration := time.Now().Add(time.Hour)
+	n, relayID := newRelayTestNode(t, func(context.Context, host.Host, peerstore.AddrInfo) (*relayclient.Reservation, error) {
+		return &relayclient.Reservation{Expiration: expiration}, nil
+	})
+	if got := n.RelayStatus(); len(got) != 0 {
+		t.Fatalf("relay status %+v before any reservation", got)
+	}
+
+	n.onReachabilityChanged(libp2pnet.ReachabilityUnknown, libp2pnet.ReachabilityPrivate)
+	waitFor(t, "reservation recorded", func() bool { return len(n.relays.snapshot()) == 1 })
+	got := n.RelayStatus()
+	if len(got) != 1 || got[0].PeerID != relayID.String() || got[0].Source != RelaySourceReserved {
+		t.Fatalf("relay status %+v, want the reservation on %s", got, relayID)
+	}
+	if got[0].Expiration == nil || !got[0].Expiration.Equal(expiration.UTC()) {
+		t.Fatalf("reservation expiration %v, want %v", got[0].Expiration, expiration)
+	}
+
+	_, autoRelayID := newTestPeer(t)
+	circuit, err := multiaddr.NewMultiaddr("/ip4/198.51.100.4/tcp/4001/p2p/" + autoRelayID.String() + "/p2p-circuit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.Host = circuitHost{Host: n.Host, circuit: circuit}
+	got = n.RelayStatus()
+	if len(got) != 2 {
+		t.Fatalf("relay status %+v, want the reservation and the advertised circuit", got)
+	}
+	for _, r := range got {
+		if r.PeerID == autoRelayID.String() && (r.Source != RelaySourceAutoRelay || len(r.Addrs) != 1) {
+			t.Fatalf("advertised circuit reported as %+v", r)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package network
+
+import "p2pos/internal/logging"
+
+// ResumeFrom tells the node which state it was in before the restart. If it
+// was configured then, it reports recovering instead of starting during the
+// startup grace until the first member reconnects, so monitors see a node
+// coming back rather than a new one. Call it before SetMembershipManager.
+func (n *Node) ResumeFrom(last RuntimeState) {
+	switch last {
+	case RuntimeStateHealthy, RuntimeStateDegraded:
+	default:
+		return
+	}
+	n.resuming.Store(true)
+	logging.Log("NODE", "resume_runtime_state", map[string]string{
+		"last": string(last),
+	})
+}
@@ -0,0 +1,101 @@
+package network
+
+import (
+	"sort"
+	"sync"
+
+	"p2pos/internal/status"
+
+	libp2pnet "github.com/libp2p/go-libp2p/core/network"
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+)
+
+// RTTObservation is one observer's latency to one peer, as reported in that
+// observer's local status.
+type RTTObservation struct {
+	Observer string  `json:"observer"`
+	PeerID   string  `json:"peer_id"`
+	RTTMs    float64 `json:"rtt_ms"`
+}
+
+// rttObservationStore keeps the observations of the last cluster status
+// fan-out for the topology scope and the summary.
+type rttObservationStore struct {
+	mu           sync.RWMutex
+	observations []RTTObservation
+}
+
+func (s *rttObservationStore) set(observations []RTTObservation) {
+	s.mu.Lock()
+	s.observations = observations
+	s.mu.Unlock()
+}
+
+func (s *rttObservationStore) get() []RTTObservation {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]RTTObservation(nil), s.observations...)
+}
+
+// localRTTMs returns our latency to a connected peer, or nil if unknown.
+func (n *Node) localRTTMs(peerID string) *float64 {
+	id, err := peerstore.Decode(peerID)
+	if err != nil || id == n.Host.ID() {
+		return nil
+	}
+	if n.Host.Network().Connectedness(id) != libp2pnet.Connected {
+		return nil
+	}
+	rtt := n.Host.Peerstore().LatencyEWMA(id)
+	if rtt <= 0 {
+		return nil
+	}
+	ms := float64(rtt.Microseconds()) / 1000.0
+	return &ms
+}
+
+func collectRTTObservations(out []RTTObservation, observer string, records []status.Record) []RTTObservation {
+	for _, rec := range records {
+		if rec.RTTMs == nil || rec.PeerID == "" || rec.PeerID == observer {
+			continue
+		}
+		out = append(out, RTTObservation{Observer: observer, PeerID: rec.PeerID, RTTMs: *rec.RTTMs})
+	}
+	return out
+}
+
+// attachRTTStats sets the cross-observer RTT aggregate on each record.
+func attachRTTStats(records []status.Record, observations []RTTObservation) []status.Record {
+	byPeer := make(map[string][]float64)
+	for _, obs := range observations {
+		byPeer[obs.PeerID] = append(byPeer[obs.PeerID], obs.RTTMs)
+	}
+	for i := range records {
+		samples := byPeer[records[i].PeerID]
+		if len(samples) == 0 {
+			continue
+		}
+		stats := rttStats(samples)
+		records[i].RTT = &stats
+	}
+	return records
+}
+
+func rttStats(samples []float64) status.RTTStats {
+	if len(samples) == 0 {
+		return status.RTTStats{}
+	}
+	sorted := append([]float64(nil), samples...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	median := sorted[mid]
+	if len(sorted)%2 == 0 {
+		median = (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return status.RTTStats{
+		MinMs:     sorted[0],
+		MedianMs:  median,
+		MaxMs:     sorted[len(sorted)-1],
+		Observers: len(sorted),
+	}
+}
@@ -0,0 +1,45 @@
+package network
+
+import (
+	"testing"
+
+	"p2pos/internal/status"
+)
+
+func TestAttachRTTStatsAcrossObservers(t *testing.T) {
+	rtt := func(ms float64) *float64 { return &ms }
+	var observations []RTTObservation
+	observations = collectRTTObservations(observations, "a", []status.Record{
+		{PeerID: "a", RTTMs: rtt(0)},
+		{PeerID: "c", RTTMs: rtt(40)},
+	})
+	observations = collectRTTObservations(observations, "b", []status.Record{
+		{PeerID: "c", RTTMs: rtt(10)},
+		{PeerID: "d"},
+	})
+	observations = collectRTTObservations(observations, "d", []status.Record{
+		{PeerID: "c", RTTMs: rtt(25)},
+		{PeerID: "e", RTTMs: rtt(5)},
+	})
+	if len(observations) != 4 {
+		t.Fatalf("observations %+v, want 4 (self and unknown RTTs skipped)", observations)
+	}
+
+	records := attachRTTStats([]status.Record{{PeerID: "c"}, {PeerID: "d"}, {PeerID: "e"}}, observations)
+	want := status.RTTStats{MinMs: 10, MedianMs: 25, MaxMs: 40, Observers: 3}
+	if records[0].RTT == nil || *records[0].RTT != want {
+		t.Fatalf("c: %+v, want %+v", records[0].RTT, want)
+	}
+	if records[1].RTT != nil {
+		t.Fatalf("d: %+v, want no stats without observations", records[1].RTT)
+	}
+	if records[2].RTT == nil || records[2].RTT.Observers != 1 || records[2].RTT.MedianMs != 5 {
+		t.Fatalf("e: %+v, want a single 5ms observation", records[2].RTT)
+	}
+}
+
+func TestRTTStatsEvenMedian(t *testing.T) {
+	if got := rttStats([]float64{30, 10, 20, 40}); got.MedianMs != 25 || got.MinMs != 10 || got.MaxMs != 40 {
+		t.Fatalf("rttStats = %+v, want median 25 over 10..40", got)
+	}
+}
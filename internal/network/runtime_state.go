@@ -2,23 +2,62 @@ package network
 
 import (
 	"sync"
+	"time"
 
 	"p2pos/internal/logging"
 
 	libp2pnet "github.com/libp2p/go-libp2p/core/network"
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
 )
 
 type RuntimeState string
 
 const (
 	RuntimeStateUnconfigured RuntimeState = "unconfigured"
-	RuntimeStateDegraded     RuntimeState = "degraded"
-	RuntimeStateHealthy      RuntimeState = "healthy"
+	// RuntimeStateStarting is degraded during the startup grace: a member
+	// that has not reached quorum yet, most likely because it just started.
+	RuntimeStateStarting RuntimeState = "starting"
+	// RuntimeStateRecovering replaces starting for a node that was
+	// configured before a restart (resume_runtime_state), until the first
+	// member reconnects.
+	RuntimeStateRecovering RuntimeState = "recovering"
+	RuntimeStateDegraded   RuntimeState = "degraded"
+	RuntimeStateHealthy    RuntimeState = "healthy"
 )
 
+// StateChangeFunc observes a runtime state transition and the reason logged
+// for it.
+type StateChangeFunc func(prev, next RuntimeState, reason string)
+
 type stateHolder struct {
-	mu    sync.RWMutex
-	state RuntimeState
+	mu        sync.RWMutex
+	state     RuntimeState
+	listeners map[uint64]StateChangeFunc
+	nextID    uint64
+}
+
+// OnStateChange registers fn to be called after every runtime state
+// transition. Callbacks run synchronously on the transitioning goroutine,
+// outside the state lock, so they may call RuntimeState but should not block.
+// The returned func unregisters fn.
+func (n *Node) OnStateChange(fn StateChangeFunc) func() {
+	if fn == nil {
+		return func() {}
+	}
+	n.state.mu.Lock()
+	if n.state.listeners == nil {
+		n.state.listeners = make(map[uint64]StateChangeFunc)
+	}
+	id := n.state.nextID
+	n.state.nextID++
+	n.state.listeners[id] = fn
+	n.state.mu.Unlock()
+
+	return func() {
+		n.state.mu.Lock()
+		delete(n.state.listeners, id)
+		n.state.mu.Unlock()
+	}
 }
 
 func (n *Node) RuntimeState() RuntimeState {
@@ -35,6 +74,10 @@ func (n *Node) setRuntimeState(next RuntimeState, reason string) {
 		return
 	}
 	n.state.state = next
+	listeners := make([]StateChangeFunc, 0, len(n.state.listeners))
+	for _, fn := range n.state.listeners {
+		listeners = append(listeners, fn)
+	}
 	n.state.mu.Unlock()
 	fields := map[string]string{
 		"prev":   string(prev),
@@ -51,6 +94,9 @@ func (n *Node) setRuntimeState(next RuntimeState, reason string) {
 		fields["peer_id"] = n.Host.ID().String()
 	}
 	logging.Log("NODE", "runtime_state", fields)
+	for _, fn := range listeners {
+		fn(prev, next, reason)
+	}
 }
 
 func (n *Node) canUseBusinessProtocols() bool {
@@ -61,48 +107,119 @@ func (n *Node) canWriteAdmin() bool {
 	return n.RuntimeState() == RuntimeStateHealthy
 }
 
+// canBootstrapAdmin lets an unconfigured node publish the first membership
+// when admin_bootstrap is set. Callers must still verify the admin proof
+// against the system key; this only lifts the runtime state requirement.
+func (n *Node) canBootstrapAdmin() bool {
+	return n.adminBootstrap && n.RuntimeState() == RuntimeStateUnconfigured
+}
+
 func (n *Node) allowPeer(peerID string) bool {
 	if n.RuntimeState() == RuntimeStateUnconfigured {
 		return true
 	}
-	return n.isMember(peerID)
+	return n.isMember(peerID) || n.isPinned(peerID)
 }
 
-func (n *Node) evaluateRuntimeState(reason string) {
+// StateDiagnostic explains the runtime state: which inputs were seen and,
+// unless healthy, which condition failed.
+type StateDiagnostic struct {
+	State           RuntimeState `json:"state"`
+	Reason          string       `json:"reason"`
+	MembershipSet   bool         `json:"membership_set"`
+	LocalMember     bool         `json:"local_member"`
+	MemberCount     int          `json:"member_count"`
+	OnlineMembers   int          `json:"online_members"`
+	HeldMembers     int          `json:"held_members"`
+	QuorumThreshold int          `json:"quorum_threshold"`
+}
+
+// StateDiagnostic evaluates the current membership and connectivity without
+// changing the runtime state.
+func (n *Node) StateDiagnostic() StateDiagnostic {
 	n.memberMu.RLock()
 	manager := n.membership
 	n.memberMu.RUnlock()
 	if manager == nil {
-		n.setRuntimeState(RuntimeStateUnconfigured, reason+":membership-nil")
-		return
+		return StateDiagnostic{State: RuntimeStateUnconfigured, Reason: "membership-nil"}
 	}
 
+	diag := StateDiagnostic{MembershipSet: true}
 	localID := n.Host.ID().String()
-	if !manager.IsMember(localID) {
-		n.setRuntimeState(RuntimeStateUnconfigured, reason+":local-not-member")
-		return
+	diag.LocalMember = manager.IsMember(localID)
+	diag.MemberCount = len(manager.Snapshot().Members)
+	diag.QuorumThreshold = diag.MemberCount/2 + 1
+	if !diag.LocalMember {
+		diag.State, diag.Reason = RuntimeStateUnconfigured, "local-not-member"
+		return diag
 	}
-
-	snap := manager.Snapshot()
-	memberCount := len(snap.Members)
-	if memberCount == 0 {
-		n.setRuntimeState(RuntimeStateUnconfigured, reason+":member-set-empty")
-		return
+	if diag.MemberCount == 0 {
+		diag.State, diag.Reason = RuntimeStateUnconfigured, "member-set-empty"
+		return diag
 	}
 
-	online := 1 // local self
+	diag.OnlineMembers = 1 // local self
 	for _, pid := range n.Host.Network().Peers() {
 		if n.Host.Network().Connectedness(pid) != libp2pnet.Connected {
 			continue
 		}
 		if manager.IsMember(pid.String()) {
-			online++
+			diag.OnlineMembers++
+		}
+	}
+	// Members that just dropped still count towards quorum until their
+	// hold-down expires, so a brief blip does not degrade the node.
+	for _, id := range n.quorumHold.active(time.Now()) {
+		pid, err := peerstore.Decode(id)
+		if err != nil || !manager.IsMember(id) {
+			continue
+		}
+		if n.Host.Network().Connectedness(pid) != libp2pnet.Connected {
+			diag.HeldMembers++
+		}
+	}
+
+	if diag.OnlineMembers+diag.HeldMembers >= diag.QuorumThreshold {
+		diag.State, diag.Reason = RuntimeStateHealthy, "quorum"
+		return diag
+	}
+	if time.Now().Before(n.startupGraceUntil) {
+		if n.resuming.Load() && diag.OnlineMembers == 1 {
+			diag.State, diag.Reason = RuntimeStateRecovering, "resumed"
+			return diag
 		}
+		diag.State, diag.Reason = RuntimeStateStarting, "startup-grace"
+		return diag
 	}
+	diag.State, diag.Reason = RuntimeStateDegraded, "no-quorum"
+	return diag
+}
 
-	if online*2 > memberCount {
-		n.setRuntimeState(RuntimeStateHealthy, reason+":quorum")
+// startStartupGraceTimer re-evaluates the runtime state once the startup
+// grace ends, so a node still short of quorum moves from starting to
+// degraded without waiting for the next connectivity event.
+func (n *Node) startStartupGraceTimer() {
+	wait := time.Until(n.startupGraceUntil)
+	if wait <= 0 {
 		return
 	}
-	n.setRuntimeState(RuntimeStateDegraded, reason+":no-quorum")
+	timer := time.AfterFunc(wait, func() {
+		if n.lifecycle.Err() != nil {
+			return
+		}
+		n.evaluateRuntimeState("startup-grace-expired")
+	})
+	go func() {
+		<-n.lifecycle.Done()
+		timer.Stop()
+	}()
+}
+
+func (n *Node) evaluateRuntimeState(reason string) {
+	diag := n.StateDiagnostic()
+	if diag.OnlineMembers > 1 {
+		// Recovering only covers the gap until the first member is back.
+		n.resuming.Store(false)
+	}
+	n.setRuntimeState(diag.State, reason+":"+diag.Reason)
 }
@@ -0,0 +1,116 @@
+package network_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"p2pos/internal/membership"
+	"p2pos/internal/network"
+	"p2pos/internal/network/nettest"
+
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+)
+
+type stateTransition struct {
+	prev, next network.RuntimeState
+}
+
+func TestOnStateChangeFollowsQuorum(t *testing.T) {
+	cluster := nettest.NewCluster(t, 3)
+	node := cluster.Nodes[0]
+
+	var mu sync.Mutex
+	var seen []stateTransition
+	unregister := node.OnStateChange(func(prev, next network.RuntimeState, _ string) {
+		mu.Lock()
+		seen = append(seen, stateTransition{prev, next})
+		mu.Unlock()
+	})
+	defer unregister()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	start := node.RuntimeState()
+	if err := cluster.ConnectAll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := node.WaitForState(ctx, network.RuntimeStateHealthy); err != nil {
+		t.Fatal(err)
+	}
+
+	// Losing both peers drops the node below quorum.
+	for _, peer := range cluster.Nodes[1:] {
+		if err := peer.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := node.WaitForState(ctx, network.RuntimeStateDegraded); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []stateTransition{{start, network.RuntimeStateHealthy}, {network.RuntimeStateHealthy, network.RuntimeStateDegraded}}
+	if len(seen) != len(want) {
+		t.Fatalf("transitions %v, want %v", seen, want)
+	}
+	for i := range want {
+		if seen[i] != want[i] {
+			t.Fatalf("transitions %v, want %v", seen, want)
+		}
+	}
+}
+
+func TestStartupGraceReportsStartingThenDegraded(t *testing.T) {
+	node, _ := nettest.NewNode(t, &nettest.Config{Key: newKey(t), Grace: 300 * time.Millisecond})
+	absent, err := peerstore.IDFromPrivateKey(newKey(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	manager, err := membership.NewManager("test", "", node.Host.ID().String(), []string{node.Host.ID().String(), absent.String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	node.SetMembershipManager(manager)
+	if state := node.RuntimeState(); state != network.RuntimeStateStarting {
+		t.Fatalf("state %s during the startup grace, want starting", state)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := node.WaitForState(ctx, network.RuntimeStateDegraded); err != nil {
+		t.Fatalf("still %s after the grace: %v", node.RuntimeState(), err)
+	}
+}
+
+func TestResumedNodeReportsRecoveringUntilQuorum(t *testing.T) {
+	node, _ := nettest.NewNode(t, &nettest.Config{Key: newKey(t), Grace: time.Minute})
+	peer, _ := nettest.NewNode(t, &nettest.Config{Key: newKey(t)})
+	absent, err := peerstore.IDFromPrivateKey(newKey(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	members := []string{node.Host.ID().String(), peer.Host.ID().String(), absent.String()}
+	node.ResumeFrom(network.RuntimeStateHealthy)
+	for _, n := range []*network.Node{node, peer} {
+		manager, err := membership.NewManager("test", "", n.Host.ID().String(), members)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n.SetMembershipManager(manager)
+	}
+	if state := node.RuntimeState(); state != network.RuntimeStateRecovering {
+		t.Fatalf("state %s after a resumed restart, want recovering", state)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := nettest.Connect(ctx, node, peer); err != nil {
+		t.Fatal(err)
+	}
+	if err := node.WaitForState(ctx, network.RuntimeStateHealthy); err != nil {
+		t.Fatalf("still %s once quorum re-formed: %v", node.RuntimeState(), err)
+	}
+}
@@ -0,0 +1,76 @@
+package network
+
+import (
+	"net"
+
+	"p2pos/internal/logging"
+
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+	multiaddr "github.com/multiformats/go-multiaddr"
+)
+
+// withoutSelfAddrs drops candidate addresses that point back at this node,
+// e.g. our own public IP reflected by NAT or a seed entry carrying the wrong
+// peer ID. onlySelf reports that every address was ours, so the candidate
+// should not be dialed at all.
+func (n *Node) withoutSelfAddrs(info peerstore.AddrInfo) (out peerstore.AddrInfo, onlySelf bool) {
+	if !n.selfDialCheck || len(info.Addrs) == 0 {
+		return info, false
+	}
+	self := n.selfAddrKeys()
+	out = peerstore.AddrInfo{ID: info.ID}
+	for _, addr := range info.Addrs {
+		key, ok := addrHostPort(addr)
+		if !ok {
+			out.Addrs = append(out.Addrs, addr)
+			continue
+		}
+		if _, mine := self[key]; !mine {
+			out.Addrs = append(out.Addrs, addr)
+			continue
+		}
+		logging.Log("NODE", "self_dial_skipped", map[string]string{
+			"peer_id": info.ID.String(),
+			"addr":    addr.String(),
+		})
+	}
+	return out, len(out.Addrs) == 0
+}
+
+// selfAddrKeys collects host:port keys for every specific address we listen
+// on or advertise, including NAT-observed and announced ones.
+func (n *Node) selfAddrKeys() map[string]struct{} {
+	keys := make(map[string]struct{})
+	addrs := append([]multiaddr.Multiaddr{}, n.Host.Addrs()...)
+	addrs = append(addrs, n.Host.Network().ListenAddresses()...)
+	for _, addr := range addrs {
+		if key, ok := addrHostPort(addr); ok {
+			keys[key] = struct{}{}
+		}
+	}
+	return keys
+}
+
+// addrHostPort returns "transport/ip:port" for direct IP addresses. Relayed
+// and DNS addresses, and unspecified IPs, are not comparable and report false.
+func addrHostPort(addr multiaddr.Multiaddr) (string, bool) {
+	if _, err := addr.ValueForProtocol(multiaddr.P_CIRCUIT); err == nil {
+		return "", false
+	}
+	host, err := addr.ValueForProtocol(multiaddr.P_IP4)
+	if err != nil {
+		if host, err = addr.ValueForProtocol(multiaddr.P_IP6); err != nil {
+			return "", false
+		}
+	}
+	if ip := net.ParseIP(host); ip == nil || ip.IsUnspecified() {
+		return "", false
+	}
+	if port, err := addr.ValueForProtocol(multiaddr.P_TCP); err == nil {
+		return "tcp/" + net.JoinHostPort(host, port), true
+	}
+	if port, err := addr.ValueForProtocol(multiaddr.P_UDP); err == nil {
+		return "udp/" + net.JoinHostPort(host, port), true
+	}
+	return "", false
+}
@@ -0,0 +1,49 @@
+package network
+
+import (
+	"testing"
+
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+	multiaddr "github.com/multiformats/go-multiaddr"
+)
+
+func TestWithoutSelfAddrs(t *testing.T) {
+	n := &Node{Host: newTestHost(t), selfDialCheck: true}
+	_, other := newTestPeer(t)
+	own := n.Host.Addrs()[0]
+	remote := multiaddr.StringCast("/ip4/192.0.2.1/tcp/4001")
+	relayed := multiaddr.StringCast(own.String() + "/p2p/" + other.String() + "/p2p-circuit")
+
+	out, onlySelf := n.withoutSelfAddrs(peerstore.AddrInfo{ID: other, Addrs: []multiaddr.Multiaddr{own, remote, relayed}})
+	if onlySelf || len(out.Addrs) != 2 || !out.Addrs[0].Equal(remote) || !out.Addrs[1].Equal(relayed) {
+		t.Fatalf("mixed candidate = %v onlySelf=%v, want remote and relayed kept", out.Addrs, onlySelf)
+	}
+
+	if _, onlySelf := n.withoutSelfAddrs(peerstore.AddrInfo{ID: other, Addrs: []multiaddr.Multiaddr{own}}); !onlySelf {
+		t.Fatal("candidate with only our own address not reported as self")
+	}
+
+	n.selfDialCheck = false
+	if out, onlySelf := n.withoutSelfAddrs(peerstore.AddrInfo{ID: other, Addrs: []multiaddr.Multiaddr{own}}); onlySelf || len(out.Addrs) != 1 {
+		t.Fatalf("disabled check filtered %v", out.Addrs)
+	}
+}
+
+func TestAddrHostPort(t *testing.T) {
+	cases := []struct {
+		addr string
+		key  string
+		ok   bool
+	}{
+		{"/ip4/192.0.2.1/tcp/4001", "tcp/192.0.2.1:4001", true},
+		{"/ip6/2001:db8::1/udp/4001/quic-v1", "udp/[2001:db8::1]:4001", true},
+		{"/ip4/0.0.0.0/tcp/4001", "", false},
+		{"/dns4/example.com/tcp/4001", "", false},
+	}
+	for _, tc := range cases {
+		key, ok := addrHostPort(multiaddr.StringCast(tc.addr))
+		if key != tc.key || ok != tc.ok {
+			t.Errorf("%s: %q %v, want %q %v", tc.addr, key, ok, tc.key, tc.ok)
+		}
+	}
+}
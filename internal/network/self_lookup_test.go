@@ -0,0 +1,35 @@
+package network
+
+import (
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	multiaddr "github.com/multiformats/go-multiaddr"
+)
+
+// observedHost reports an extra identify-observed address through AllAddrs,
+// as a NAT'd basic host does before AutoNAT confirms it.
+type observedHost struct {
+	host.Host
+	observed multiaddr.Multiaddr
+}
+
+func (h observedHost) AllAddrs() []multiaddr.Multiaddr {
+	return append(h.Host.Addrs(), h.observed)
+}
+
+func TestSelfLookupIncludesObservedAddr(t *testing.T) {
+	observed, err := multiaddr.NewMultiaddr("/ip4/93.184.216.34/tcp/4001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := &Node{Host: observedHost{Host: newTestHost(t), observed: observed}}
+
+	addrs := lookupAddrs(n, n.Host.ID())
+	if len(addrs) < 2 {
+		t.Fatalf("self addrs %v, want the observed and local addresses", addrs)
+	}
+	if addrs[0] != observed.String() {
+		t.Fatalf("self addrs %v, want the observed public address first", addrs)
+	}
+}
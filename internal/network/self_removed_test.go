@@ -0,0 +1,45 @@
+package network_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"p2pos/internal/events"
+	"p2pos/internal/membership"
+)
+
+func TestSelfRemovedWhenDroppedFromSnapshot(t *testing.T) {
+	c := newAdminCluster(t, func(c *adminCluster) membership.Snapshot {
+		return c.sign(t, c.keys[0], time.Now().UTC().Add(-time.Hour), c.members)
+	})
+	sub, unsubscribe := c.Buses[2].Subscribe(16)
+	defer unsubscribe()
+
+	dropped := c.sign(t, c.keys[0], time.Now().UTC(), c.members[:2])
+	raw, err := json.Marshal(dropped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Nodes[2].ImportMembership(bytes.NewReader(raw)); err != nil {
+		t.Fatal(err)
+	}
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case evt := <-sub:
+			removed, ok := evt.(events.SelfRemoved)
+			if !ok {
+				continue
+			}
+			if removed.IssuerPeerID != c.members[0] || removed.Policy != "continue" {
+				t.Fatalf("SelfRemoved %+v, want issuer node 0 and the continue policy", removed)
+			}
+			return
+		case <-timeout:
+			t.Fatal("no SelfRemoved after the local node was dropped")
+		}
+	}
+}
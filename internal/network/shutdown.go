@@ -0,0 +1,67 @@
+package network
+
+import (
+	"context"
+	"time"
+
+	"p2pos/internal/logging"
+)
+
+const defaultShutdownTimeout = 10 * time.Second
+
+// Shutdown drains the node within shutdownTimeout: half the budget goes to a
+// final heartbeat so members refresh last-seen, the rest to Close. If Close
+// has not returned by the deadline the node is abandoned so the process can
+// still exit. Only the first call does any work.
+func (n *Node) Shutdown(reason string) {
+	n.shutdownOnce.Do(func() {
+		n.shutdown(reason)
+	})
+}
+
+func (n *Node) shutdown(reason string) {
+	timeout := n.shutdownTimeout
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	deadline := time.Now().Add(timeout)
+	logging.Log("NODE", "shutdown_phase", map[string]string{
+		"phase":   "drain",
+		"reason":  reason,
+		"timeout": timeout.String(),
+	})
+
+	drainCtx, cancel := context.WithTimeout(context.Background(), timeout/2)
+	if err := n.BroadcastHeartbeat(drainCtx); err != nil {
+		logging.Log("NODE", "shutdown_drain_failed", map[string]string{
+			"reason": err.Error(),
+		})
+	}
+	cancel()
+
+	logging.Log("NODE", "shutdown_phase", map[string]string{
+		"phase": "close",
+	})
+	done := make(chan error, 1)
+	go func() {
+		done <- n.Close()
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			logging.Log("NODE", "shutdown_failed", map[string]string{
+				"reason": err.Error(),
+			})
+			return
+		}
+		logging.Log("NODE", "shutdown_phase", map[string]string{
+			"phase": "done",
+		})
+	case <-time.After(time.Until(deadline)):
+		logging.Log("NODE", "shutdown_forced", map[string]string{
+			"reason":  "deadline_exceeded",
+			"timeout": timeout.String(),
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package network
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/host"
+)
+
+// hangingHost never finishes closing, like a host stuck on a peer that does
+// not let go of its connection.
+type hangingHost struct {
+	host.Host
+	release chan struct{}
+}
+
+func (h *hangingHost) Close() error {
+	<-h.release
+	return h.Host.Close()
+}
+
+func TestShutdownForcedAtDeadline(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	lifecycle, stop := context.WithCancel(context.Background())
+	n := &Node{
+		Host:            &hangingHost{Host: newTestHost(t), release: release},
+		heartbeats:      newHeartbeatLimiter(0),
+		lifecycle:       lifecycle,
+		stopLifecycle:   stop,
+		shutdownTimeout: 300 * time.Millisecond,
+	}
+
+	start := time.Now()
+	n.Shutdown("test")
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("shutdown took %s with a %s deadline", elapsed, n.shutdownTimeout)
+	}
+	if lifecycle.Err() == nil {
+		t.Fatal("shutdown did not cancel the node lifecycle")
+	}
+
+	// Later calls are no-ops rather than a second wait.
+	start = time.Now()
+	n.Shutdown("again")
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Fatalf("second shutdown waited %s", elapsed)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"p2pos/internal/logging"
+	"p2pos/internal/scheduler"
 	"p2pos/internal/status"
 
 	libp2pnet "github.com/libp2p/go-libp2p/core/network"
@@ -21,22 +22,35 @@ const statusProtocolID = protocol.ID("/p2pos/status/1.0.0")
 type statusScope string
 
 const (
-	statusScopeLocal   statusScope = "local"
-	statusScopeCluster statusScope = "cluster"
+	statusScopeLocal    statusScope = "local"
+	statusScopeCluster  statusScope = "cluster"
+	statusScopeSummary  statusScope = "summary"
+	statusScopeTasks    statusScope = "tasks"
+	statusScopeTopology statusScope = "topology"
+	statusScopeTraffic  statusScope = "traffic"
 )
 
+type TaskStatsProvider interface {
+	Stats() []scheduler.TaskStats
+}
+
 type statusRequest struct {
 	Scope statusScope `json:"scope,omitempty"`
 }
 
 type statusResponse struct {
-	GeneratedAt time.Time       `json:"generated_at"`
-	Peers       []status.Record `json:"peers"`
-	Error       string          `json:"error,omitempty"`
+	GeneratedAt time.Time             `json:"generated_at"`
+	Peers       []status.Record       `json:"peers"`
+	Summary     *ClusterSummary       `json:"summary,omitempty"`
+	CacheAgeMs  int64                 `json:"cache_age_ms,omitempty"`
+	Tasks       []scheduler.TaskStats `json:"tasks,omitempty"`
+	Topology    []RTTObservation      `json:"topology,omitempty"`
+	Traffic     []ProtocolStats       `json:"traffic,omitempty"`
+	Error       string                `json:"error,omitempty"`
 }
 
 func (n *Node) registerStatusHandler() {
-	n.Host.SetStreamHandler(statusProtocolID, func(stream libp2pnet.Stream) {
+	n.setBusinessHandler(statusProtocolID, func(stream libp2pnet.Stream) {
 		defer stream.Close()
 
 		req := statusRequest{Scope: statusScopeLocal}
@@ -65,14 +79,32 @@ func (n *Node) registerStatusHandler() {
 			peers []status.Record
 			err   error
 		)
-		if req.Scope == statusScopeCluster {
-			peers, err = n.ClusterStatus(ctx)
-		} else {
+		switch req.Scope {
+		case statusScopeCluster:
+			var age time.Duration
+			peers, age, err = n.clusterStatusWithAge(ctx)
+			resp.CacheAgeMs = age.Milliseconds()
+		case statusScopeSummary:
+			var summary ClusterSummary
+			summary, err = n.ClusterSummary(ctx)
+			if err == nil {
+				resp.Summary = &summary
+			}
+		case statusScopeTasks:
+			resp.Tasks = n.taskStats()
+		case statusScopeTopology:
+			var age time.Duration
+			peers, age, err = n.clusterStatusWithAge(ctx)
+			resp.CacheAgeMs = age.Milliseconds()
+			resp.Topology = n.rttObservations.get()
+		case statusScopeTraffic:
+			resp.Traffic = n.ProtocolStats()
+		default:
 			peers, err = n.localStatus(ctx)
 		}
 		if err != nil {
 			resp.Error = err.Error()
-		} else {
+		} else if peers != nil {
 			resp.Peers = peers
 		}
 
@@ -84,6 +116,22 @@ func (n *Node) registerStatusHandler() {
 	})
 }
 
+func (n *Node) SetTaskStatsProvider(provider TaskStatsProvider) {
+	n.statusMu.Lock()
+	n.tasks = provider
+	n.statusMu.Unlock()
+}
+
+func (n *Node) taskStats() []scheduler.TaskStats {
+	n.statusMu.RLock()
+	provider := n.tasks
+	n.statusMu.RUnlock()
+	if provider == nil {
+		return []scheduler.TaskStats{}
+	}
+	return provider.Stats()
+}
+
 func (n *Node) localStatus(ctx context.Context) ([]status.Record, error) {
 	n.statusMu.RLock()
 	provider := n.status
@@ -91,11 +139,30 @@ func (n *Node) localStatus(ctx context.Context) ([]status.Record, error) {
 	if provider == nil {
 		return []status.Record{}, nil
 	}
-	return provider.Snapshot(ctx)
+	records, err := provider.Snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range records {
+		records[i].Pinned = n.isPinned(records[i].PeerID)
+		n.annotateTags(&records[i])
+		applyAttestation(&records[i])
+		records[i].AppVersion = n.peerVersion(records[i].PeerID)
+		records[i].RTTMs = n.localRTTMs(records[i].PeerID)
+		records[i].Conn = n.localConnInfo(records[i].PeerID)
+		if s, ok := n.dialBreaker.state(records[i].PeerID); ok {
+			records[i].DialBreaker = &status.DialBreaker{
+				State:       s.State,
+				Failures:    s.Failures,
+				NextAttempt: s.NextAttempt,
+			}
+		}
+	}
+	return records, nil
 }
 
 func (n *Node) FetchStatus(ctx context.Context, peerID peerstore.ID, scope string) ([]status.Record, error) {
-	stream, err := n.Host.NewStream(ctx, peerID, statusProtocolID)
+	stream, err := n.newCountedStream(ctx, peerID, statusProtocolID)
 	if err != nil {
 		return nil, err
 	}
@@ -123,10 +190,21 @@ func (n *Node) FetchStatus(ctx context.Context, peerID peerstore.ID, scope strin
 	return resp.Peers, nil
 }
 
+// ClusterStatus returns the merged cluster view, shared across concurrent
+// callers and cached briefly to avoid N×N fan-out storms.
 func (n *Node) ClusterStatus(ctx context.Context) ([]status.Record, error) {
+	records, _, err := n.clusterStatusWithAge(ctx)
+	return records, err
+}
+
+func (n *Node) clusterStatusWithAge(ctx context.Context) ([]status.Record, time.Duration, error) {
 	if !n.canUseBusinessProtocols() {
-		return nil, errors.New("node is unconfigured")
+		return nil, 0, errors.New("node is unconfigured")
 	}
+	return n.clusterCache.get(ctx, n.fetchClusterStatus)
+}
+
+func (n *Node) fetchClusterStatus(ctx context.Context) ([]status.Record, error) {
 	all := make([]status.Record, 0)
 
 	local, err := n.localStatus(ctx)
@@ -134,9 +212,11 @@ func (n *Node) ClusterStatus(ctx context.Context) ([]status.Record, error) {
 		return nil, err
 	}
 	all = append(all, local...)
+	observations := collectRTTObservations(nil, n.Host.ID().String(), local)
+	reporters := collectOnlineReporters(nil, n.Host.ID().String(), local)
 
 	for _, peerID := range n.Host.Network().Peers() {
-		if _, skip := n.statusUnsupported.Load(peerID); skip {
+		if n.statusUnsupported.skip(peerID, time.Now()) {
 			continue
 		}
 		reqCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
@@ -144,7 +224,7 @@ func (n *Node) ClusterStatus(ctx context.Context) ([]status.Record, error) {
 		cancel()
 		if err != nil {
 			if strings.Contains(err.Error(), "protocols not supported") {
-				n.statusUnsupported.Store(peerID, struct{}{})
+				n.statusUnsupported.mark(peerID, time.Now())
 				logging.Log("STATUS", "skip_unsupported_peer", map[string]string{
 					"peer_id": peerID.String(),
 				})
@@ -157,25 +237,55 @@ func (n *Node) ClusterStatus(ctx context.Context) ([]status.Record, error) {
 			continue
 		}
 		all = append(all, remote...)
+		observations = collectRTTObservations(observations, peerID.String(), remote)
+		reporters = collectOnlineReporters(reporters, peerID.String(), remote)
 	}
 
-	return mergeStatusRecords(all), nil
+	n.rttObservations.set(observations)
+	merged := requireCorroboration(mergeStatusRecords(all, n.recordAuthority()), reporters, n.Host.ID().String(), n.minObservers)
+	return attachRTTStats(merged, observations), nil
 }
 
-func mergeStatusRecords(in []status.Record) []status.Record {
+// mergeStatusRecords keeps one record per peer, chosen by recordWins.
+// authority may be nil to decide by timestamp alone.
+func mergeStatusRecords(in []status.Record, authority func(status.Record) int) []status.Record {
 	merged := make(map[string]status.Record)
 	for _, rec := range in {
 		if rec.PeerID == "" {
 			continue
 		}
 		prev, ok := merged[rec.PeerID]
-		if !ok || recordIsNewer(rec, prev) {
+		if !ok || recordWins(rec, prev, authority) {
+			// Not every observer has heard the member's tags yet; keep
+			// the ones we already have rather than dropping them.
+			if ok && rec.Region == "" && len(rec.Tags) == 0 {
+				rec.Region, rec.Tags = prev.Region, prev.Tags
+			}
+			if ok && rec.AppVersion == "" {
+				rec.AppVersion = prev.AppVersion
+			}
+			if ok && rec.Attestation == nil {
+				rec.Attestation = prev.Attestation
+			}
 			merged[rec.PeerID] = rec
+		} else {
+			if prev.Region == "" && len(prev.Tags) == 0 {
+				prev.Region, prev.Tags = rec.Region, rec.Tags
+			}
+			if prev.AppVersion == "" {
+				prev.AppVersion = rec.AppVersion
+			}
+			if prev.Attestation == nil {
+				prev.Attestation = rec.Attestation
+			}
+			merged[rec.PeerID] = prev
 		}
 	}
 
 	out := make([]status.Record, 0, len(merged))
 	for _, rec := range merged {
+		// Observers relay labels; only the peer's own signature is trusted.
+		applyAttestation(&rec)
 		out = append(out, rec)
 	}
 	sort.Slice(out, func(i, j int) bool {
@@ -184,6 +294,111 @@ func mergeStatusRecords(in []status.Record) []status.Record {
 	return out
 }
 
+// reachabilityReported marks a peer that only too few remote members claim
+// is online; it is shown but not counted as reachable.
+const reachabilityReported = "reported"
+
+// collectOnlineReporters records observer as a witness for every peer it
+// reports online. A "self" row only counts when it comes from the peer
+// itself; anyone else relaying it is just another remote claim.
+func collectOnlineReporters(dst map[string]map[string]struct{}, observer string, records []status.Record) map[string]map[string]struct{} {
+	if dst == nil {
+		dst = make(map[string]map[string]struct{})
+	}
+	for _, rec := range records {
+		if rec.PeerID == "" || !reportsOnline(rec.Reachability) {
+			continue
+		}
+		if dst[rec.PeerID] == nil {
+			dst[rec.PeerID] = make(map[string]struct{})
+		}
+		dst[rec.PeerID][observer] = struct{}{}
+	}
+	return dst
+}
+
+// requireCorroboration downgrades merged online records to "reported" unless
+// this node or the peer itself vouches for them, or at least minObservers
+// distinct remote members do. One member cannot make a peer look reachable
+// on its own word.
+func requireCorroboration(records []status.Record, reporters map[string]map[string]struct{}, localID string, minObservers int) []status.Record {
+	if minObservers <= 1 {
+		return records
+	}
+	for i := range records {
+		rec := &records[i]
+		if rec.PeerID == localID || !reportsOnline(rec.Reachability) {
+			continue
+		}
+		witnesses := reporters[rec.PeerID]
+		if _, ok := witnesses[localID]; ok {
+			continue
+		}
+		if _, ok := witnesses[rec.PeerID]; ok {
+			continue
+		}
+		if len(witnesses) < minObservers {
+			rec.Reachability = reachabilityReported
+		}
+	}
+	return records
+}
+
+func reportsOnline(reachability string) bool {
+	switch reachability {
+	case "online", "connected", "self":
+		return true
+	default:
+		return false
+	}
+}
+
+// Observer authority, lowest to highest, as in the database merge: anyone, a
+// member, the peer itself.
+const (
+	authorityOther = iota
+	authorityMember
+	authoritySelf
+)
+
+// recordAuthority ranks how much a record's observer is trusted about its
+// peer, or returns nil when presence.equal_observers turns weighting off.
+func (n *Node) recordAuthority() func(status.Record) int {
+	if n.equalObservers {
+		return nil
+	}
+	return func(rec status.Record) int {
+		switch {
+		case rec.ObservedBy == "":
+			return authorityOther
+		case rec.ObservedBy == rec.PeerID:
+			return authoritySelf
+		case n.isMember(rec.ObservedBy):
+			return authorityMember
+		}
+		return authorityOther
+	}
+}
+
+// recordWins reports whether rec replaces prev in the merged view. The newer
+// record wins; with authority weighting a tie goes to the more trusted
+// observer, and a weaker observer losing sight of the peer does not override
+// what a stronger one saw.
+func recordWins(rec, prev status.Record, authority func(status.Record) int) bool {
+	newer := recordIsNewer(rec, prev)
+	if authority == nil {
+		return newer
+	}
+	recAuth, prevAuth := authority(rec), authority(prev)
+	switch {
+	case recordTimestamp(rec).Equal(recordTimestamp(prev)):
+		return recAuth > prevAuth
+	case newer && recAuth < prevAuth && !reportsOnline(rec.Reachability):
+		return false
+	}
+	return newer
+}
+
 func recordIsNewer(a, b status.Record) bool {
 	return recordTimestamp(a).After(recordTimestamp(b))
 }
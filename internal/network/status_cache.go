@@ -0,0 +1,83 @@
+package network
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"p2pos/internal/status"
+)
+
+const clusterStatusCacheTTL = 5 * time.Second
+
+// clusterStatusCache single-flights cluster status fan-out: concurrent
+// callers share one in-flight round and later callers reuse the result
+// until it is older than clusterStatusCacheTTL or invalidated.
+type clusterStatusCache struct {
+	mu         sync.Mutex
+	records    []status.Record
+	computedAt time.Time
+	generation uint64
+	inflight   *clusterStatusCall
+}
+
+type clusterStatusCall struct {
+	done    chan struct{}
+	records []status.Record
+	err     error
+	at      time.Time
+}
+
+func (c *clusterStatusCache) get(ctx context.Context, compute func(context.Context) ([]status.Record, error)) ([]status.Record, time.Duration, error) {
+	c.mu.Lock()
+	now := time.Now()
+	if !c.computedAt.IsZero() && now.Sub(c.computedAt) < clusterStatusCacheTTL {
+		records := append([]status.Record(nil), c.records...)
+		age := now.Sub(c.computedAt)
+		c.mu.Unlock()
+		return records, age, nil
+	}
+	call := c.inflight
+	if call == nil {
+		call = &clusterStatusCall{done: make(chan struct{})}
+		c.inflight = call
+		generation := c.generation
+		c.mu.Unlock()
+
+		// Detached from the first caller so its cancellation does not fail the
+		// round for everyone else waiting on it.
+		roundCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		call.records, call.err = compute(roundCtx)
+		cancel()
+		call.at = time.Now()
+
+		c.mu.Lock()
+		c.inflight = nil
+		if call.err == nil && generation == c.generation {
+			c.records = call.records
+			c.computedAt = call.at
+		}
+		c.mu.Unlock()
+		close(call.done)
+	} else {
+		c.mu.Unlock()
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, 0, ctx.Err()
+	case <-call.done:
+	}
+	if call.err != nil {
+		return nil, 0, call.err
+	}
+	return append([]status.Record(nil), call.records...), time.Since(call.at), nil
+}
+
+func (c *clusterStatusCache) invalidate() {
+	c.mu.Lock()
+	c.generation++
+	c.computedAt = time.Time{}
+	c.records = nil
+	c.mu.Unlock()
+}
@@ -0,0 +1,90 @@
+package network
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"p2pos/internal/status"
+)
+
+func TestClusterStatusCacheSingleFlight(t *testing.T) {
+	var cache clusterStatusCache
+	var rounds atomic.Int32
+	release := make(chan struct{})
+	compute := func(context.Context) ([]status.Record, error) {
+		rounds.Add(1)
+		<-release
+		return []status.Record{{PeerID: "a"}}, nil
+	}
+
+	const callers = 16
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			records, _, err := cache.get(context.Background(), compute)
+			if err == nil && len(records) != 1 {
+				t.Errorf("caller got %d records", len(records))
+			}
+			errs <- err
+		}()
+	}
+	// Let every caller join the round before it finishes.
+	for {
+		cache.mu.Lock()
+		started := cache.inflight != nil
+		cache.mu.Unlock()
+		if started {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, _, err := cache.get(context.Background(), compute); err != nil {
+		t.Fatal(err)
+	}
+	if got := rounds.Load(); got != 1 {
+		t.Fatalf("%d fan-out rounds for %d concurrent callers and a cached read, want 1", got, callers)
+	}
+
+	cache.invalidate()
+	if _, _, err := cache.get(context.Background(), compute); err != nil {
+		t.Fatal(err)
+	}
+	if got := rounds.Load(); got != 2 {
+		t.Fatalf("%d fan-out rounds after invalidate, want 2", got)
+	}
+}
+
+func TestClusterStatusCacheDropsRoundInvalidatedMidFlight(t *testing.T) {
+	var cache clusterStatusCache
+	var rounds atomic.Int32
+	compute := func(context.Context) ([]status.Record, error) {
+		if rounds.Add(1) == 1 {
+			// A peer connects while the first round is still running.
+			cache.invalidate()
+		}
+		return nil, nil
+	}
+	for i := 0; i < 2; i++ {
+		if _, _, err := cache.get(context.Background(), compute); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := rounds.Load(); got != 2 {
+		t.Fatalf("stale round was cached: %d rounds, want 2", got)
+	}
+}
@@ -0,0 +1,44 @@
+package network
+
+import (
+	"testing"
+	"time"
+
+	"p2pos/internal/status"
+)
+
+func TestMergeStatusRecordsObserverAuthority(t *testing.T) {
+	const peer, member, stranger = "peer", "member", "stranger"
+	authority := func(rec status.Record) int {
+		switch rec.ObservedBy {
+		case rec.PeerID:
+			return authoritySelf
+		case member:
+			return authorityMember
+		}
+		return authorityOther
+	}
+	at := time.Now().UTC()
+	record := func(observer, reachability string, seen time.Time) status.Record {
+		return status.Record{PeerID: peer, ObservedBy: observer, Reachability: reachability, LastSeenAt: seen}
+	}
+	cases := []struct {
+		name      string
+		in        []status.Record
+		authority func(status.Record) int
+		want      string
+	}{
+		{"tie goes to the peer itself", []status.Record{record(stranger, "offline", at), record(peer, "online", at)}, authority, peer},
+		{"tie goes to a member", []status.Record{record(stranger, "offline", at), record(member, "online", at)}, authority, member},
+		{"weaker offline does not override", []status.Record{record(member, "online", at), record(stranger, "offline", at.Add(time.Second))}, authority, member},
+		{"weaker online still newer", []status.Record{record(member, "offline", at), record(stranger, "online", at.Add(time.Second))}, authority, stranger},
+		{"equal observers keep the first on a tie", []status.Record{record(stranger, "offline", at), record(peer, "online", at)}, nil, stranger},
+		{"equal observers take the newest", []status.Record{record(member, "online", at), record(stranger, "offline", at.Add(time.Second))}, nil, stranger},
+	}
+	for _, tc := range cases {
+		merged := mergeStatusRecords(tc.in, tc.authority)
+		if len(merged) != 1 || merged[0].ObservedBy != tc.want {
+			t.Errorf("%s: merged %+v, want the record from %s", tc.name, merged, tc.want)
+		}
+	}
+}
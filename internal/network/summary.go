@@ -0,0 +1,159 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"p2pos/internal/status"
+
+	libp2pnet "github.com/libp2p/go-libp2p/core/network"
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+)
+
+// ClusterSummary is an aggregated, dashboard-friendly view of ClusterStatus.
+type ClusterSummary struct {
+	GeneratedAt          time.Time       `json:"generated_at"`
+	ClusterID            string          `json:"cluster_id"`
+	RuntimeState         RuntimeState    `json:"runtime_state"`
+	Reachability         string          `json:"reachability"`
+	TotalMembers         int             `json:"total_members"`
+	OnlineMembers        int             `json:"online_members"`
+	Quorum               bool            `json:"quorum"`
+	ByReachability       map[string]int  `json:"by_reachability"`
+	MedianRTTMs          float64         `json:"median_rtt_ms"`
+	MaxRTTMs             float64         `json:"max_rtt_ms"`
+	ObservedRTT          status.RTTStats `json:"observed_rtt"`
+	MembershipIssuedAt   time.Time       `json:"membership_issued_at"`
+	MembershipIssuerPeer string          `json:"membership_issuer_peer_id"`
+	ClockMaxSkewMs       float64         `json:"clock_max_skew_ms"`
+	ClockAtRisk          []string        `json:"clock_at_risk"`
+	MembershipConsistent bool            `json:"membership_consistent"`
+	DivergentPeers       []string        `json:"divergent_peers"`
+	// MemberAvailability lists every remote member; Down marks members
+	// offline past membership.down_alert_seconds.
+	MemberAvailability []MemberAvailability `json:"member_availability"`
+	// RelaySource counts AutoRelay's requests for relay candidates.
+	RelaySource RelaySourceStats `json:"relay_source"`
+	// Relays lists the relays this node holds circuit reservations on.
+	Relays []RelayReservation `json:"relays"`
+}
+
+func (n *Node) ClusterSummary(ctx context.Context) (ClusterSummary, error) {
+	records, err := n.ClusterStatus(ctx)
+	if err != nil {
+		return ClusterSummary{}, err
+	}
+
+	summary := summarizeRecords(records, n.isMember)
+	summary.GeneratedAt = time.Now().UTC()
+	summary.RuntimeState = n.RuntimeState()
+	summary.Reachability = n.LocalReachability().String()
+	summary.MedianRTTMs, summary.MaxRTTMs = n.memberRTTStats()
+	// Every observer→peer sample from the last cluster fan-out, members only.
+	samples := make([]float64, 0)
+	for _, obs := range n.rttObservations.get() {
+		if n.isMember(obs.PeerID) && n.isMember(obs.Observer) {
+			samples = append(samples, obs.RTTMs)
+		}
+	}
+	summary.ObservedRTT = rttStats(samples)
+	if snap, ok := n.membershipSnapshot(); ok {
+		summary.ClusterID = snap.ClusterID
+		summary.TotalMembers = len(snap.Members)
+		summary.MembershipIssuedAt = snap.IssuedAt.UTC()
+		summary.MembershipIssuerPeer = snap.IssuerPeerID
+	}
+	if clock, err := n.ClockHealth(ctx); err == nil {
+		summary.ClockMaxSkewMs = clock.MaxSkewMs
+		summary.ClockAtRisk = make([]string, 0)
+		for _, member := range clock.Members {
+			if member.AtRisk {
+				summary.ClockAtRisk = append(summary.ClockAtRisk, member.PeerID)
+			}
+		}
+	}
+	summary.DivergentPeers = n.divergence.divergent(time.Now())
+	summary.MembershipConsistent = len(summary.DivergentPeers) == 0
+	summary.MemberAvailability = n.MemberAvailability()
+	if n.relaySource != nil {
+		summary.RelaySource = n.relaySource.stats()
+	}
+	summary.Relays = n.RelayStatus()
+	summary.Quorum = summary.TotalMembers > 0 && summary.OnlineMembers*2 > summary.TotalMembers
+	return summary, nil
+}
+
+func summarizeRecords(records []status.Record, isMember func(string) bool) ClusterSummary {
+	summary := ClusterSummary{
+		ByReachability: make(map[string]int),
+	}
+	for _, rec := range records {
+		reachability := rec.Reachability
+		if reachability == "" {
+			reachability = "unknown"
+		}
+		summary.ByReachability[reachability]++
+		if !isMember(rec.PeerID) {
+			continue
+		}
+		if reachability == "online" || reachability == "self" {
+			summary.OnlineMembers++
+		}
+	}
+	return summary
+}
+
+// memberRTTStats reports median and max latency to connected members, in ms.
+func (n *Node) memberRTTStats() (float64, float64) {
+	samples := make([]float64, 0)
+	for _, pid := range n.Host.Network().Peers() {
+		if n.Host.Network().Connectedness(pid) != libp2pnet.Connected {
+			continue
+		}
+		if !n.isMember(pid.String()) {
+			continue
+		}
+		rtt := n.Host.Peerstore().LatencyEWMA(pid)
+		if rtt <= 0 {
+			continue
+		}
+		samples = append(samples, float64(rtt.Microseconds())/1000.0)
+	}
+	if len(samples) == 0 {
+		return 0, 0
+	}
+	sort.Float64s(samples)
+	mid := len(samples) / 2
+	median := samples[mid]
+	if len(samples)%2 == 0 {
+		median = (samples[mid-1] + samples[mid]) / 2
+	}
+	return median, samples[len(samples)-1]
+}
+
+func (n *Node) FetchClusterSummary(ctx context.Context, peerID peerstore.ID) (ClusterSummary, error) {
+	stream, err := n.newCountedStream(ctx, peerID, statusProtocolID)
+	if err != nil {
+		return ClusterSummary{}, err
+	}
+	defer stream.Close()
+
+	if err := json.NewEncoder(stream).Encode(statusRequest{Scope: statusScopeSummary}); err != nil {
+		return ClusterSummary{}, err
+	}
+
+	var resp statusResponse
+	if err := json.NewDecoder(stream).Decode(&resp); err != nil {
+		return ClusterSummary{}, err
+	}
+	if resp.Error != "" {
+		return ClusterSummary{}, errors.New(resp.Error)
+	}
+	if resp.Summary == nil {
+		return ClusterSummary{}, errors.New("summary missing from response")
+	}
+	return *resp.Summary, nil
+}
@@ -0,0 +1,32 @@
+package network
+
+import (
+	"testing"
+
+	"p2pos/internal/status"
+)
+
+func TestSummarizeRecords(t *testing.T) {
+	members := map[string]bool{"self": true, "a": true, "b": true, "c": true}
+	records := []status.Record{
+		{PeerID: "self", Reachability: "self"},
+		{PeerID: "a", Reachability: "online"},
+		{PeerID: "b", Reachability: "offline"},
+		{PeerID: "c"},
+		{PeerID: "outsider", Reachability: "online"},
+	}
+
+	summary := summarizeRecords(records, func(id string) bool { return members[id] })
+	if summary.OnlineMembers != 2 {
+		t.Fatalf("online members %d, want 2 (self and a; the outsider is not a member)", summary.OnlineMembers)
+	}
+	want := map[string]int{"self": 1, "online": 2, "offline": 1, "unknown": 1}
+	if len(summary.ByReachability) != len(want) {
+		t.Fatalf("by reachability %v, want %v", summary.ByReachability, want)
+	}
+	for reachability, count := range want {
+		if summary.ByReachability[reachability] != count {
+			t.Fatalf("by reachability %v, want %v", summary.ByReachability, want)
+		}
+	}
+}
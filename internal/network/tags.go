@@ -0,0 +1,90 @@
+package network
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"p2pos/internal/status"
+)
+
+// peerTagTracker keeps the region and tags each member last announced in a
+// verified heartbeat. Tags are advisory and never used for authorization.
+type peerTagTracker struct {
+	mu    sync.RWMutex
+	peers map[string]peerTags
+}
+
+type peerTags struct {
+	region      string
+	tags        map[string]string
+	attestation *status.Attestation
+}
+
+func newPeerTagTracker() *peerTagTracker {
+	return &peerTagTracker{peers: make(map[string]peerTags)}
+}
+
+func (t *peerTagTracker) record(peerID, region string, tags map[string]string) {
+	if region == "" && len(tags) == 0 {
+		return
+	}
+	t.mu.Lock()
+	t.peers[peerID] = peerTags{region: region, tags: tags, attestation: t.peers[peerID].attestation}
+	t.mu.Unlock()
+}
+
+// recordAttestation keeps a verified attestation for peerID.
+func (t *peerTagTracker) recordAttestation(peerID string, a status.Attestation) {
+	t.mu.Lock()
+	entry := t.peers[peerID]
+	entry.attestation = &a
+	t.peers[peerID] = entry
+	t.mu.Unlock()
+}
+
+func (t *peerTagTracker) get(peerID string) (peerTags, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	entry, ok := t.peers[peerID]
+	return entry, ok
+}
+
+// annotateTags fills in the region, tags and attestation for rec: our own
+// from config, other members' from their heartbeats.
+func (n *Node) annotateTags(rec *status.Record) {
+	if rec.PeerID == n.Host.ID().String() {
+		rec.Region, rec.Tags = n.region, copyTags(n.tags)
+		rec.Attestation = n.attestation
+		return
+	}
+	if entry, ok := n.peerTags.get(rec.PeerID); ok {
+		rec.Region, rec.Tags = entry.region, copyTags(entry.tags)
+		rec.Attestation = entry.attestation
+	}
+}
+
+func copyTags(in map[string]string) map[string]string {
+	if len(in) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(in))
+	for k, v := range in {
+		out[k] = v
+	}
+	return out
+}
+
+// canonicalTags renders tags as sorted k=v pairs for the signed payload.
+func canonicalTags(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+tags[k])
+	}
+	return strings.Join(parts, ",")
+}
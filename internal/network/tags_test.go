@@ -0,0 +1,69 @@
+package network_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"p2pos/internal/membership"
+	"p2pos/internal/network"
+	"p2pos/internal/network/nettest"
+	"p2pos/internal/status"
+)
+
+// fixedStatus reports one record per peer ID.
+type fixedStatus []string
+
+func (f fixedStatus) Snapshot(context.Context) ([]status.Record, error) {
+	records := make([]status.Record, 0, len(f))
+	for _, id := range f {
+		records = append(records, status.Record{PeerID: id})
+	}
+	return records, nil
+}
+
+func TestTagsReachRemoteClusterStatus(t *testing.T) {
+	a, _ := nettest.NewNode(t, &nettest.Config{
+		Key:        newKey(t),
+		NodeRegion: "eu-west",
+		NodeTags:   map[string]string{"rack": "r1"},
+	})
+	b, _ := nettest.NewNode(t, &nettest.Config{Key: newKey(t)})
+	aID := a.Host.ID().String()
+	members := []string{aID, b.Host.ID().String()}
+	for _, node := range []*network.Node{a, b} {
+		manager, err := membership.NewManager("test", "", node.Host.ID().String(), members)
+		if err != nil {
+			t.Fatal(err)
+		}
+		node.SetMembershipManager(manager)
+	}
+	b.SetStatusProvider(fixedStatus{aID})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := nettest.Connect(ctx, a, b); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.WaitForState(ctx, network.RuntimeStateHealthy); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.BroadcastHeartbeat(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := b.ClusterStatus(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rec := range records {
+		if rec.PeerID != aID {
+			continue
+		}
+		if rec.Region != "eu-west" || rec.Tags["rack"] != "r1" {
+			t.Fatalf("record for a = region %q tags %v, want eu-west rack=r1", rec.Region, rec.Tags)
+		}
+		return
+	}
+	t.Fatalf("no record for a in %+v", records)
+}
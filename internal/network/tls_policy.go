@@ -0,0 +1,71 @@
+package network
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strings"
+)
+
+// tlsPolicy restricts the TLS parameters of the AutoTLS WebSocket listener.
+// Go does not allow configuring TLS 1.3 suites, so the cipher allowlist only
+// applies to TLS 1.2 handshakes.
+type tlsPolicy struct {
+	minVersion   uint16
+	cipherSuites []uint16
+}
+
+func parseTLSPolicy(minVersion string, cipherNames []string) (tlsPolicy, error) {
+	policy := tlsPolicy{}
+	switch strings.TrimSpace(minVersion) {
+	case "", "1.2":
+		policy.minVersion = tls.VersionTLS12
+	case "1.3":
+		policy.minVersion = tls.VersionTLS13
+	default:
+		return tlsPolicy{}, fmt.Errorf("invalid auto_tls.min_version %q, expected 1.2 or 1.3", minVersion)
+	}
+
+	if len(cipherNames) == 0 {
+		return policy, nil
+	}
+	known := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		known[suite.Name] = suite.ID
+	}
+	for _, raw := range cipherNames {
+		name := strings.TrimSpace(raw)
+		if name == "" {
+			continue
+		}
+		id, ok := known[name]
+		if !ok {
+			return tlsPolicy{}, fmt.Errorf("unsupported auto_tls cipher suite %q", name)
+		}
+		policy.cipherSuites = append(policy.cipherSuites, id)
+	}
+	return policy, nil
+}
+
+// apply returns a copy of base with the policy enforced.
+func (p tlsPolicy) apply(base *tls.Config) *tls.Config {
+	cfg := base.Clone()
+	if cfg == nil {
+		cfg = &tls.Config{}
+	}
+	cfg.MinVersion = p.minVersion
+	if len(p.cipherSuites) > 0 {
+		cfg.CipherSuites = append([]uint16(nil), p.cipherSuites...)
+	}
+	return cfg
+}
+
+func tlsVersionName(version uint16) string {
+	switch version {
+	case tls.VersionTLS13:
+		return "1.3"
+	case tls.VersionTLS12:
+		return "1.2"
+	default:
+		return fmt.Sprintf("0x%04x", version)
+	}
+}
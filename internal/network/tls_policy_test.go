@@ -0,0 +1,51 @@
+package network
+
+import (
+	"crypto/tls"
+	"testing"
+)
+
+func TestTLSPolicyApply(t *testing.T) {
+	getCert := func(*tls.ClientHelloInfo) (*tls.Certificate, error) { return nil, nil }
+	base := &tls.Config{GetCertificate: getCert, MinVersion: tls.VersionTLS10}
+
+	policy, err := parseTLSPolicy("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := policy.apply(base)
+	if cfg.MinVersion != tls.VersionTLS12 || cfg.CipherSuites != nil {
+		t.Fatalf("default policy: min %s, suites %v", tlsVersionName(cfg.MinVersion), cfg.CipherSuites)
+	}
+	if cfg.GetCertificate == nil {
+		t.Fatal("policy dropped the certificate callback")
+	}
+	if base.MinVersion != tls.VersionTLS10 {
+		t.Fatal("policy modified the base config")
+	}
+
+	policy, err = parseTLSPolicy("1.3", []string{" TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256 ", ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg = policy.apply(nil)
+	if cfg.MinVersion != tls.VersionTLS13 {
+		t.Fatalf("min version %s, want 1.3", tlsVersionName(cfg.MinVersion))
+	}
+	if len(cfg.CipherSuites) != 1 || cfg.CipherSuites[0] != tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256 {
+		t.Fatalf("cipher suites %v", cfg.CipherSuites)
+	}
+}
+
+func TestParseTLSPolicyRejectsInvalid(t *testing.T) {
+	if _, err := parseTLSPolicy("1.1", nil); err == nil {
+		t.Error("min_version 1.1 accepted")
+	}
+	// Insecure suites are not in tls.CipherSuites and must not be allowed.
+	if _, err := parseTLSPolicy("1.2", []string{"TLS_RSA_WITH_RC4_128_SHA"}); err == nil {
+		t.Error("insecure cipher suite accepted")
+	}
+	if _, err := parseTLSPolicy("1.2", []string{"TLS_MADE_UP"}); err == nil {
+		t.Error("unknown cipher suite accepted")
+	}
+}
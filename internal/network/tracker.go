@@ -39,3 +39,10 @@ func (t *Tracker) GetAll() []peerstore.AddrInfo {
 	}
 	return result
 }
+
+func (t *Tracker) Has(peerID peerstore.ID) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	_, ok := t.peers[peerID]
+	return ok
+}
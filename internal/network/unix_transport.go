@@ -0,0 +1,81 @@
+package network
+
+import (
+	"context"
+	"os"
+
+	libp2pnet "github.com/libp2p/go-libp2p/core/network"
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/transport"
+	multiaddr "github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+)
+
+// unixTransport carries libp2p connections over /unix/<path> sockets, for
+// co-located nodes that should not need a TCP port. Connections go through
+// the regular upgrader, so security, muxing and the gater still apply.
+type unixTransport struct {
+	upgrader transport.Upgrader
+	rcmgr    libp2pnet.ResourceManager
+}
+
+var _ transport.Transport = (*unixTransport)(nil)
+
+func newUnixTransport(upgrader transport.Upgrader, rcmgr libp2pnet.ResourceManager) (*unixTransport, error) {
+	if rcmgr == nil {
+		rcmgr = &libp2pnet.NullResourceManager{}
+	}
+	return &unixTransport{upgrader: upgrader, rcmgr: rcmgr}, nil
+}
+
+func (t *unixTransport) CanDial(addr multiaddr.Multiaddr) bool {
+	_, err := addr.ValueForProtocol(multiaddr.P_UNIX)
+	return err == nil
+}
+
+func (t *unixTransport) Dial(ctx context.Context, raddr multiaddr.Multiaddr, p peerstore.ID) (transport.CapableConn, error) {
+	connScope, err := t.rcmgr.OpenConnection(libp2pnet.DirOutbound, false, raddr)
+	if err != nil {
+		return nil, err
+	}
+	if err := connScope.SetPeer(p); err != nil {
+		connScope.Done()
+		return nil, err
+	}
+	var dialer manet.Dialer
+	conn, err := dialer.DialContext(ctx, raddr)
+	if err != nil {
+		connScope.Done()
+		return nil, err
+	}
+	// Upgrade releases connScope itself on failure.
+	return t.upgrader.Upgrade(ctx, t, conn, libp2pnet.DirOutbound, p, connScope)
+}
+
+// Listen binds laddr, first removing a stale socket left by a previous run.
+func (t *unixTransport) Listen(laddr multiaddr.Multiaddr) (transport.Listener, error) {
+	path, err := laddr.ValueForProtocol(multiaddr.P_UNIX)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		_ = os.Remove(path)
+	}
+	list, err := manet.Listen(laddr)
+	if err != nil {
+		return nil, err
+	}
+	return t.upgrader.UpgradeGatedMaListener(t, t.upgrader.GateMaListener(list)), nil
+}
+
+func (t *unixTransport) Protocols() []int {
+	return []int{multiaddr.P_UNIX}
+}
+
+func (t *unixTransport) Proxy() bool {
+	return false
+}
+
+func (t *unixTransport) String() string {
+	return "unix"
+}
@@ -0,0 +1,57 @@
+package network_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"p2pos/internal/membership"
+	"p2pos/internal/network"
+	"p2pos/internal/network/nettest"
+
+	multiaddr "github.com/multiformats/go-multiaddr"
+)
+
+func TestNodesExchangeStatusOverUnixSockets(t *testing.T) {
+	// t.TempDir can exceed the unix socket path limit on some systems.
+	dir, err := os.MkdirTemp("", "p2pos-unix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	a, _ := nettest.NewNode(t, &nettest.Config{Key: newKey(t), Unix: true, Listen: []string{"/unix" + filepath.Join(dir, "a.sock")}})
+	b, _ := nettest.NewNode(t, &nettest.Config{Key: newKey(t), Unix: true, Listen: []string{"/unix" + filepath.Join(dir, "b.sock")}})
+	members := []string{a.Host.ID().String(), b.Host.ID().String()}
+	for _, node := range []*network.Node{a, b} {
+		manager, err := membership.NewManager("test", "", node.Host.ID().String(), members)
+		if err != nil {
+			t.Fatal(err)
+		}
+		node.SetMembershipManager(manager)
+	}
+	b.SetStatusProvider(fixedStatus{a.Host.ID().String()})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := nettest.Connect(ctx, a, b); err != nil {
+		t.Fatal(err)
+	}
+	conns := a.Host.Network().ConnsToPeer(b.Host.ID())
+	if len(conns) == 0 {
+		t.Fatal("no connection to b")
+	}
+	if _, err := conns[0].RemoteMultiaddr().ValueForProtocol(multiaddr.P_UNIX); err != nil {
+		t.Fatalf("connected over %s, want a unix socket", conns[0].RemoteMultiaddr())
+	}
+
+	records, err := a.FetchStatus(ctx, b.Host.ID(), "local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].PeerID != a.Host.ID().String() {
+		t.Fatalf("status from b = %+v, want one record for a", records)
+	}
+}
@@ -0,0 +1,96 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"p2pos/internal/logging"
+
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+)
+
+// errIncompatibleHeartbeat marks heartbeat rejections that mean the sender
+// cannot interoperate with us (other cluster, bad signature), as opposed to
+// transient ones such as clock skew or membership lag.
+var errIncompatibleHeartbeat = errors.New("incompatible heartbeat")
+
+type incompatibleError struct {
+	err error
+}
+
+func (e incompatibleError) Error() string   { return e.err.Error() }
+func (e incompatibleError) Unwrap() []error { return []error{e.err, errIncompatibleHeartbeat} }
+
+func incompatibleHeartbeat(format string, args ...any) error {
+	return incompatibleError{err: fmt.Errorf(format, args...)}
+}
+
+// validationBans counts validation failures per remote peer. threshold
+// failures within window trip a ban of banFor; the count then starts over.
+type validationBans struct {
+	mu        sync.Mutex
+	threshold int
+	window    time.Duration
+	banFor    time.Duration
+	failures  map[string][]time.Time
+}
+
+func newValidationBans(threshold int, window, banFor time.Duration) *validationBans {
+	return &validationBans{
+		threshold: threshold,
+		window:    window,
+		banFor:    banFor,
+		failures:  make(map[string][]time.Time),
+	}
+}
+
+// fail records one failure and reports how many fall inside the window and
+// whether this one tripped the ban.
+func (b *validationBans) fail(peerID string, now time.Time) (int, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.threshold <= 0 {
+		return 0, false
+	}
+	cutoff := now.Add(-b.window)
+	recent := b.failures[peerID][:0]
+	for _, at := range b.failures[peerID] {
+		if at.After(cutoff) {
+			recent = append(recent, at)
+		}
+	}
+	recent = append(recent, now)
+	if len(recent) < b.threshold {
+		b.failures[peerID] = recent
+		return len(recent), false
+	}
+	delete(b.failures, peerID)
+	return len(recent), true
+}
+
+// recordValidationFailure charges a rejected snapshot or heartbeat to the
+// remote peer that delivered it. Once the peer crosses the threshold it is
+// disconnected and refused by the gater for the ban duration.
+func (n *Node) recordValidationFailure(peerID peerstore.ID, kind string, reason error) {
+	if n.validationBans == nil || peerID == "" || peerID == n.Host.ID() {
+		return
+	}
+	now := time.Now().UTC()
+	failures, banned := n.validationBans.fail(peerID.String(), now)
+	if !banned {
+		return
+	}
+	until := now.Add(n.validationBans.banFor)
+	n.gater.deny(peerID, until)
+	logging.Log("NODE", "incompatible_peer_denied", map[string]string{
+		"peer_id":  peerID.String(),
+		"kind":     kind,
+		"failures": fmt.Sprintf("%d", failures),
+		"window":   n.validationBans.window.String(),
+		"until":    until.Format(time.RFC3339Nano),
+		"reason":   reason.Error(),
+	})
+	_ = n.Host.Network().ClosePeer(peerID)
+}
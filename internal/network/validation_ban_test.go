@@ -0,0 +1,57 @@
+package network_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"p2pos/internal/membership"
+	"p2pos/internal/network"
+
+	libp2pnet "github.com/libp2p/go-libp2p/core/network"
+	peerstore "github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestRepeatedInvalidSnapshotsDisconnectSender(t *testing.T) {
+	c := newAdminCluster(t, func(c *adminCluster) membership.Snapshot {
+		return c.sign(t, c.keys[0], time.Now().UTC().Add(-time.Hour), c.members)
+	})
+	receiver, sender := c.Nodes[0], c.Nodes[1]
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	// nettest bans after five failures within a minute.
+	for i := 0; i < 5; i++ {
+		tampered := c.sign(t, c.keys[0], time.Now().UTC(), c.members)
+		tampered.Members = c.members[:2]
+		stream, err := sender.Host.NewStream(ctx, receiver.Host.ID(), network.MembershipPushProtocolID)
+		if err != nil {
+			t.Fatalf("push %d: %v", i, err)
+		}
+		if err := json.NewEncoder(stream).Encode(tampered); err != nil {
+			t.Fatal(err)
+		}
+		var resp struct {
+			Applied bool `json:"applied"`
+		}
+		if err := json.NewDecoder(stream).Decode(&resp); err == nil && resp.Applied {
+			t.Fatalf("push %d: tampered snapshot applied", i)
+		}
+		_ = stream.Close()
+		if i < 4 && receiver.Host.Network().Connectedness(sender.Host.ID()) != libp2pnet.Connected {
+			t.Fatalf("sender disconnected after %d invalid snapshots", i+1)
+		}
+	}
+
+	for receiver.Host.Network().Connectedness(sender.Host.ID()) == libp2pnet.Connected {
+		select {
+		case <-ctx.Done():
+			t.Fatal("sender still connected after repeated invalid snapshots")
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+	if err := receiver.Host.Connect(ctx, peerstore.AddrInfo{ID: sender.Host.ID(), Addrs: sender.Host.Addrs()}); err == nil {
+		t.Fatal("reconnected to a banned sender")
+	}
+}
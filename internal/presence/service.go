@@ -2,6 +2,8 @@ package presence
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"p2pos/internal/events"
 	"p2pos/internal/logging"
@@ -13,34 +15,117 @@ type PeerRepository interface {
 	MergeObservedState(ctx context.Context, state events.PeerStateObserved) error
 }
 
+// Options tunes how connection churn is turned into reachability writes.
+type Options struct {
+	// OfflineGrace delays the offline write after a disconnect; a reconnect
+	// within the grace suppresses the transition entirely.
+	OfflineGrace time.Duration
+	// FlapThreshold disconnects within FlapWindow mark a peer as flapping.
+	FlapThreshold int
+	FlapWindow    time.Duration
+}
+
+const (
+	defaultOfflineGrace  = 10 * time.Second
+	defaultFlapThreshold = 5
+	defaultFlapWindow    = 2 * time.Minute
+)
+
 type Service struct {
 	bus        *events.Bus
 	repo       PeerRepository
 	observerID string
+	opts       Options
+}
+
+type peerChurn struct {
+	pending     *time.Timer
+	generation  uint64
+	disconnects []time.Time
+	flapping    bool
+}
+
+type graceExpired struct {
+	peerID     string
+	generation uint64
 }
 
-func NewService(bus *events.Bus, repo PeerRepository, observerID string) *Service {
+func NewService(bus *events.Bus, repo PeerRepository, observerID string, opts Options) *Service {
+	if opts.OfflineGrace <= 0 {
+		opts.OfflineGrace = defaultOfflineGrace
+	}
+	if opts.FlapThreshold <= 0 {
+		opts.FlapThreshold = defaultFlapThreshold
+	}
+	if opts.FlapWindow <= 0 {
+		opts.FlapWindow = defaultFlapWindow
+	}
 	return &Service{
 		bus:        bus,
 		repo:       repo,
 		observerID: observerID,
+		opts:       opts,
 	}
 }
 
 func (s *Service) Start(ctx context.Context) {
 	eventCh, cancel := s.bus.Subscribe(64)
+	expiredCh := make(chan graceExpired, 64)
+	churn := make(map[string]*peerChurn)
 	go func() {
 		defer cancel()
+		defer func() {
+			for _, c := range churn {
+				if c.pending != nil {
+					c.pending.Stop()
+				}
+			}
+		}()
 		for {
 			select {
 			case <-ctx.Done():
 				return
+			case exp := <-expiredCh:
+				c, ok := churn[exp.peerID]
+				if !ok || c.pending == nil || c.generation != exp.generation {
+					continue
+				}
+				c.pending = nil
+				// A flapping peer stays flapping until its disconnects age
+				// out of the window; check again then.
+				if now := time.Now().UTC(); s.markFlapping(ctx, exp.peerID, c, now) {
+					c.pending = s.afterDelay(ctx, expiredCh, exp.peerID, c, s.flapEnds(c, now))
+					continue
+				}
+				if err := s.repo.UpdateReachability(ctx, exp.peerID, s.observerID, "offline"); err != nil {
+					logging.Log("PRESENCE", "update_failed", map[string]string{
+						"peer_id": exp.peerID,
+						"reason":  err.Error(),
+					})
+				}
 			case evt, ok := <-eventCh:
 				if !ok {
 					return
 				}
 				switch e := evt.(type) {
 				case events.PeerConnected:
+					c := churnFor(churn, e.PeerID)
+					wasFlapping := c.flapping
+					if c.pending != nil {
+						c.pending.Stop()
+						c.pending = nil
+						if s.markFlapping(ctx, e.PeerID, c, time.Now().UTC()) {
+							continue
+						}
+						if !wasFlapping {
+							logging.Log("PRESENCE", "offline_suppressed", map[string]string{
+								"peer_id": e.PeerID,
+							})
+							continue
+						}
+					} else if wasFlapping && s.markFlapping(ctx, e.PeerID, c, time.Now().UTC()) {
+						continue
+					}
 					if err := s.repo.UpsertLastSeen(ctx, e.PeerID, e.RemoteAddr, s.observerID, "online"); err != nil {
 						logging.Log("PRESENCE", "update_failed", map[string]string{
 							"peer_id": e.PeerID,
@@ -48,13 +133,17 @@ func (s *Service) Start(ctx context.Context) {
 						})
 					}
 				case events.PeerDisconnected:
-					if err := s.repo.UpdateReachability(ctx, e.PeerID, s.observerID, "offline"); err != nil {
-						logging.Log("PRESENCE", "update_failed", map[string]string{
-							"peer_id": e.PeerID,
-							"reason":  err.Error(),
-						})
+					c := churnFor(churn, e.PeerID)
+					now := time.Now().UTC()
+					c.disconnects = append(pruneBefore(c.disconnects, now.Add(-s.opts.FlapWindow)), now)
+					if c.pending != nil {
+						c.pending.Stop()
 					}
+					c.pending = s.afterDelay(ctx, expiredCh, e.PeerID, c, s.opts.OfflineGrace)
 				case events.PeerHeartbeat:
+					if c, ok := churn[e.PeerID]; ok && c.flapping && s.markFlapping(ctx, e.PeerID, c, time.Now().UTC()) {
+						continue
+					}
 					if err := s.repo.UpsertLastSeen(ctx, e.PeerID, e.RemoteAddr, s.observerID, "online"); err != nil {
 						logging.Log("PRESENCE", "heartbeat_failed", map[string]string{
 							"peer_id": e.PeerID,
@@ -73,3 +162,73 @@ func (s *Service) Start(ctx context.Context) {
 		}
 	}()
 }
+
+// afterDelay arms the peer's pending timer, superseding any earlier one, to
+// deliver a graceExpired on expiredCh after delay.
+func (s *Service) afterDelay(ctx context.Context, expiredCh chan<- graceExpired, peerID string, c *peerChurn, delay time.Duration) *time.Timer {
+	c.generation++
+	exp := graceExpired{peerID: peerID, generation: c.generation}
+	return time.AfterFunc(delay, func() {
+		select {
+		case expiredCh <- exp:
+		case <-ctx.Done():
+		}
+	})
+}
+
+// markFlapping writes the flapping reachability once a peer crosses the flap
+// threshold. It reports whether the peer is currently considered flapping;
+// the marker sticks until fewer than FlapThreshold disconnects remain within
+// FlapWindow, and callers then write the peer's actual reachability.
+func (s *Service) markFlapping(ctx context.Context, peerID string, c *peerChurn, now time.Time) bool {
+	c.disconnects = pruneBefore(c.disconnects, now.Add(-s.opts.FlapWindow))
+	if len(c.disconnects) < s.opts.FlapThreshold {
+		c.flapping = false
+		return false
+	}
+	if c.flapping {
+		return true
+	}
+	c.flapping = true
+	logging.Log("PRESENCE", "peer_flapping", map[string]string{
+		"peer_id":     peerID,
+		"disconnects": fmt.Sprintf("%d", len(c.disconnects)),
+		"window":      s.opts.FlapWindow.String(),
+	})
+	if err := s.repo.UpdateReachability(ctx, peerID, s.observerID, "flapping"); err != nil {
+		logging.Log("PRESENCE", "update_failed", map[string]string{
+			"peer_id": peerID,
+			"reason":  err.Error(),
+		})
+	}
+	return true
+}
+
+// flapEnds is how long until enough of c's disconnects leave the window for
+// the peer to stop counting as flapping.
+func (s *Service) flapEnds(c *peerChurn, now time.Time) time.Duration {
+	oldest := c.disconnects[len(c.disconnects)-s.opts.FlapThreshold]
+	if delay := oldest.Add(s.opts.FlapWindow).Sub(now); delay > 0 {
+		return delay
+	}
+	return 0
+}
+
+func churnFor(churn map[string]*peerChurn, peerID string) *peerChurn {
+	c, ok := churn[peerID]
+	if !ok {
+		c = &peerChurn{}
+		churn[peerID] = c
+	}
+	return c
+}
+
+func pruneBefore(in []time.Time, cutoff time.Time) []time.Time {
+	out := in[:0]
+	for _, ts := range in {
+		if ts.After(cutoff) {
+			out = append(out, ts)
+		}
+	}
+	return out
+}
@@ -0,0 +1,119 @@
+package presence
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"p2pos/internal/events"
+)
+
+// recordingRepo keeps every reachability write in order.
+type recordingRepo struct {
+	mu     sync.Mutex
+	writes []string
+}
+
+func (r *recordingRepo) record(reachability string) error {
+	r.mu.Lock()
+	r.writes = append(r.writes, reachability)
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *recordingRepo) UpsertLastSeen(_ context.Context, _, _, _, reachability string) error {
+	return r.record(reachability)
+}
+
+func (r *recordingRepo) UpdateReachability(_ context.Context, _, _, reachability string) error {
+	return r.record(reachability)
+}
+
+func (r *recordingRepo) MergeObservedState(context.Context, events.PeerStateObserved) error {
+	return nil
+}
+
+func (r *recordingRepo) snapshot() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.writes...)
+}
+
+func (r *recordingRepo) last() string {
+	writes := r.snapshot()
+	if len(writes) == 0 {
+		return ""
+	}
+	return writes[len(writes)-1]
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func startService(t *testing.T, opts Options) (*events.Bus, *recordingRepo) {
+	t.Helper()
+	bus := events.NewBus()
+	repo := &recordingRepo{}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	NewService(bus, repo, "observer", opts).Start(ctx)
+	return bus, repo
+}
+
+func TestReconnectWithinGraceSuppressesOffline(t *testing.T) {
+	bus, repo := startService(t, Options{OfflineGrace: 200 * time.Millisecond, FlapThreshold: 10, FlapWindow: time.Minute})
+
+	bus.Publish(events.PeerConnected{PeerID: "p"})
+	for i := 0; i < 3; i++ {
+		bus.Publish(events.PeerDisconnected{PeerID: "p"})
+		bus.Publish(events.PeerConnected{PeerID: "p"})
+	}
+	time.Sleep(400 * time.Millisecond)
+	if writes := repo.snapshot(); len(writes) != 1 || writes[0] != "online" {
+		t.Fatalf("writes %v, want the single initial online", writes)
+	}
+}
+
+func TestFlappingSticksUntilWindowExpires(t *testing.T) {
+	grace := 100 * time.Millisecond
+	window := time.Second
+	bus, repo := startService(t, Options{OfflineGrace: grace, FlapThreshold: 3, FlapWindow: window})
+
+	bus.Publish(events.PeerConnected{PeerID: "p"})
+	for i := 0; i < 3; i++ {
+		bus.Publish(events.PeerDisconnected{PeerID: "p"})
+		bus.Publish(events.PeerConnected{PeerID: "p"})
+	}
+	waitFor(t, "flapping", func() bool { return repo.last() == "flapping" })
+	flappedAt := time.Now()
+
+	// Going away while flapping must not overwrite the marker...
+	bus.Publish(events.PeerDisconnected{PeerID: "p"})
+	time.Sleep(3 * grace)
+	if got := repo.last(); got != "flapping" && time.Since(flappedAt) < window {
+		t.Fatalf("flapping overwritten by %q within the flap window", got)
+	}
+	// ...nor should a heartbeat or reconnect while still in the window.
+	bus.Publish(events.PeerHeartbeat{PeerID: "p"})
+	time.Sleep(20 * time.Millisecond)
+	if got := repo.last(); got != "flapping" && time.Since(flappedAt) < window {
+		t.Fatalf("flapping overwritten by %q within the flap window", got)
+	}
+
+	// Once the disconnects age out, the real state is written.
+	waitFor(t, "offline after the flap window", func() bool { return repo.last() == "offline" })
+	if time.Since(flappedAt) < window-grace {
+		t.Fatalf("offline written %s after flapping, before the %s window ended", time.Since(flappedAt), window)
+	}
+	bus.Publish(events.PeerConnected{PeerID: "p"})
+	waitFor(t, "online after reconnecting", func() bool { return repo.last() == "online" })
+}
@@ -17,15 +17,38 @@ type Task interface {
 	Run(ctx context.Context) error
 }
 
+// TimeoutTask is implemented by tasks that bound each run. The run's context
+// is cancelled after Timeout; a run that ignores cancellation keeps its slot
+// and later ticks are skipped until it returns.
+type TimeoutTask interface {
+	Timeout() time.Duration
+}
+
+// TaskStats reports the most recent invocation of a registered task.
+type TaskStats struct {
+	Name         string        `json:"name"`
+	Interval     time.Duration `json:"interval"`
+	LastRun      time.Time     `json:"last_run"`
+	LastDuration time.Duration `json:"last_duration"`
+	LastError    string        `json:"last_error,omitempty"`
+	RunCount     int64         `json:"run_count"`
+	TimeoutCount int64         `json:"timeout_count"`
+	SkipCount    int64         `json:"skip_count"`
+	Completed    bool          `json:"completed"`
+}
+
 type Scheduler struct {
 	mu      sync.Mutex
 	tasks   []Task
+	stats   map[string]*TaskStats
 	started bool
 	wg      sync.WaitGroup
 }
 
 func New() *Scheduler {
-	return &Scheduler{}
+	return &Scheduler{
+		stats: make(map[string]*TaskStats),
+	}
 }
 
 func (s *Scheduler) Register(task Task) error {
@@ -44,9 +67,63 @@ func (s *Scheduler) Register(task Task) error {
 	}
 
 	s.tasks = append(s.tasks, task)
+	s.stats[task.Name()] = &TaskStats{
+		Name:     task.Name(),
+		Interval: task.Interval(),
+	}
 	return nil
 }
 
+// Stats returns a copy of every registered task's stats in registration order.
+func (s *Scheduler) Stats() []TaskStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]TaskStats, 0, len(s.tasks))
+	for _, task := range s.tasks {
+		if st, ok := s.stats[task.Name()]; ok {
+			out = append(out, *st)
+		}
+	}
+	return out
+}
+
+func (s *Scheduler) recordRun(name string, startedAt time.Time, elapsed time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, ok := s.stats[name]
+	if !ok {
+		return
+	}
+	st.LastRun = startedAt.UTC()
+	st.LastDuration = elapsed
+	st.RunCount++
+	st.LastError = ""
+	if err != nil && !errors.Is(err, ErrTaskCompleted) {
+		st.LastError = err.Error()
+	}
+	if errors.Is(err, ErrTaskCompleted) {
+		st.Completed = true
+	}
+}
+
+func (s *Scheduler) recordSkip(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if st, ok := s.stats[name]; ok {
+		st.SkipCount++
+	}
+}
+
+func (s *Scheduler) recordTimeout(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if st, ok := s.stats[name]; ok {
+		st.TimeoutCount++
+	}
+}
+
 func (s *Scheduler) Start(ctx context.Context) {
 	s.mu.Lock()
 	if s.started {
@@ -70,8 +147,69 @@ func (s *Scheduler) Wait() {
 func (s *Scheduler) runTaskLoop(ctx context.Context, task Task) {
 	defer s.wg.Done()
 
+	var timeout time.Duration
+	if tt, ok := task.(TimeoutTask); ok {
+		timeout = tt.Timeout()
+	}
+
+	// inflight is non-nil while a run that outlived its timeout is still going.
+	var inflight chan error
+	skipNext := false
+
 	run := func() bool {
-		if err := task.Run(ctx); err != nil {
+		if inflight != nil {
+			select {
+			case <-inflight:
+				inflight = nil
+			default:
+				s.recordSkip(task.Name())
+				fmt.Printf("[SCHED] Task %s skipped: previous run still in progress\n", task.Name())
+				return true
+			}
+		}
+		if skipNext {
+			skipNext = false
+			s.recordSkip(task.Name())
+			fmt.Printf("[SCHED] Task %s skipped: previous run overran its interval\n", task.Name())
+			return true
+		}
+
+		runCtx, cancel := ctx, context.CancelFunc(func() {})
+		if timeout > 0 {
+			runCtx, cancel = context.WithTimeout(ctx, timeout)
+		}
+		defer cancel()
+
+		startedAt := time.Now()
+		done := make(chan error, 1)
+		go func() {
+			done <- task.Run(runCtx)
+		}()
+
+		var err error
+		select {
+		case err = <-done:
+		case <-runCtx.Done():
+			select {
+			case err = <-done:
+			case <-time.After(time.Second):
+				// The task ignored cancellation; keep tracking it so the
+				// next tick does not start an overlapping run.
+				inflight = done
+				err = runCtx.Err()
+			}
+		}
+		elapsed := time.Since(startedAt)
+		if timeout > 0 && ctx.Err() == nil && errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+			s.recordTimeout(task.Name())
+			fmt.Printf("[SCHED] Task %s timed out after %s\n", task.Name(), timeout)
+		}
+		if elapsed > task.Interval() {
+			skipNext = true
+			fmt.Printf("[SCHED] Task %s overran interval: took %s, interval %s\n", task.Name(), elapsed.Round(time.Millisecond), task.Interval())
+		}
+		s.recordRun(task.Name(), startedAt, elapsed, err)
+		if err != nil {
 			if errors.Is(err, ErrTaskCompleted) {
 				fmt.Printf("[SCHED] Task completed: %s\n", task.Name())
 				return false
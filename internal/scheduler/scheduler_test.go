@@ -0,0 +1,136 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type funcTask struct {
+	name     string
+	interval time.Duration
+	timeout  time.Duration
+	run      func(ctx context.Context) error
+}
+
+func (t *funcTask) Name() string                  { return t.name }
+func (t *funcTask) Interval() time.Duration       { return t.interval }
+func (t *funcTask) RunOnStart() bool              { return true }
+func (t *funcTask) Run(ctx context.Context) error { return t.run(ctx) }
+func (t *funcTask) Timeout() time.Duration        { return t.timeout }
+
+// runToCompletion starts s and waits for every task to return
+// ErrTaskCompleted.
+func runToCompletion(t *testing.T, s *Scheduler) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.Start(ctx)
+	done := make(chan struct{})
+	go func() {
+		s.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("tasks did not complete")
+	}
+}
+
+func TestStatsRecordRuns(t *testing.T) {
+	calls := 0
+	task := &funcTask{name: "probe", interval: 10 * time.Millisecond, run: func(context.Context) error {
+		calls++
+		switch calls {
+		case 1:
+			return errors.New("peer unreachable")
+		case 2:
+			return nil
+		}
+		return ErrTaskCompleted
+	}}
+	s := New()
+	if err := s.Register(task); err != nil {
+		t.Fatal(err)
+	}
+	if st := s.Stats(); len(st) != 1 || st[0].RunCount != 0 || !st[0].LastRun.IsZero() {
+		t.Fatalf("stats before start: %+v", st)
+	}
+
+	before := time.Now()
+	runToCompletion(t, s)
+
+	st := s.Stats()
+	if len(st) != 1 {
+		t.Fatalf("stats %+v, want one task", st)
+	}
+	got := st[0]
+	if got.Name != "probe" || got.Interval != task.interval {
+		t.Fatalf("stats describe %s every %s", got.Name, got.Interval)
+	}
+	if got.RunCount != 3 || !got.Completed {
+		t.Fatalf("run count %d completed %v, want 3 runs and completed", got.RunCount, got.Completed)
+	}
+	if got.LastError != "" {
+		t.Fatalf("last error %q survived a later run", got.LastError)
+	}
+	if got.LastRun.Before(before.UTC()) {
+		t.Fatalf("last run %s predates the start", got.LastRun)
+	}
+}
+
+func TestRecordRunKeepsLastError(t *testing.T) {
+	s := New()
+	if err := s.Register(&funcTask{name: "sync", interval: time.Minute}); err != nil {
+		t.Fatal(err)
+	}
+	startedAt := time.Now()
+	s.recordRun("sync", startedAt, 40*time.Millisecond, errors.New("sync failed"))
+	s.recordRun("unknown", startedAt, time.Second, nil)
+
+	st := s.Stats()[0]
+	if st.RunCount != 1 || st.LastError != "sync failed" || st.LastDuration != 40*time.Millisecond || st.Completed {
+		t.Fatalf("stats after a failed run: %+v", st)
+	}
+}
+
+func TestSlowTaskTimesOutAndSkipsOverlap(t *testing.T) {
+	release := make(chan struct{})
+	var calls atomic.Int32
+	task := &funcTask{name: "slow", interval: 20 * time.Millisecond, timeout: 50 * time.Millisecond, run: func(context.Context) error {
+		// Ignores cancellation, like a task stuck in a blocking call.
+		calls.Add(1)
+		<-release
+		return nil
+	}}
+	s := New()
+	if err := s.Register(task); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Start(ctx)
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		st := s.Stats()[0]
+		if st.TimeoutCount >= 1 && st.SkipCount >= 2 {
+			if st.LastError != context.DeadlineExceeded.Error() {
+				t.Fatalf("timed out run recorded error %q", st.LastError)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("stats %+v, want a timeout and skipped ticks", st)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("%d runs started while the first was stuck, want 1", got)
+	}
+	cancel()
+	close(release)
+	s.Wait()
+}
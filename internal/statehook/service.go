@@ -0,0 +1,145 @@
+package statehook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"p2pos/internal/logging"
+)
+
+const hookTimeout = 10 * time.Second
+const queueSize = 16
+
+// Transition is one runtime state change, as sent to the webhook.
+type Transition struct {
+	PeerID string    `json:"peer_id"`
+	Prev   string    `json:"prev"`
+	Next   string    `json:"next"`
+	Reason string    `json:"reason"`
+	At     time.Time `json:"at"`
+}
+
+// Service runs the configured webhook and command for every transition. It
+// is best effort: Notify never blocks, transitions are delivered in order by
+// one worker, and each delivery is bounded by hookTimeout.
+type Service struct {
+	webhook string
+	command []string
+	client  *http.Client
+	queue   chan Transition
+}
+
+// NewService returns nil when neither a webhook nor a command is configured.
+func NewService(webhook, command string) *Service {
+	webhook = strings.TrimSpace(webhook)
+	args := strings.Fields(command)
+	if webhook == "" && len(args) == 0 {
+		return nil
+	}
+	return &Service{
+		webhook: webhook,
+		command: args,
+		client:  &http.Client{Timeout: hookTimeout},
+		queue:   make(chan Transition, queueSize),
+	}
+}
+
+func (s *Service) Start(ctx context.Context) {
+	if s == nil {
+		return
+	}
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case t := <-s.queue:
+				s.deliver(ctx, t)
+			}
+		}
+	}()
+}
+
+// Notify queues t, dropping it when the hooks are too far behind.
+func (s *Service) Notify(t Transition) {
+	if s == nil {
+		return
+	}
+	select {
+	case s.queue <- t:
+	default:
+		logging.Log("HOOK", "state_change_dropped", map[string]string{
+			"prev": t.Prev,
+			"next": t.Next,
+		})
+	}
+}
+
+func (s *Service) deliver(ctx context.Context, t Transition) {
+	if s.webhook != "" {
+		if err := s.post(ctx, t); err != nil {
+			logging.Log("HOOK", "webhook_failed", map[string]string{
+				"next":   t.Next,
+				"reason": err.Error(),
+			})
+		}
+	}
+	if len(s.command) > 0 {
+		if err := s.run(ctx, t); err != nil {
+			logging.Log("HOOK", "command_failed", map[string]string{
+				"next":   t.Next,
+				"reason": err.Error(),
+			})
+		}
+	}
+}
+
+func (s *Service) post(ctx context.Context, t Transition) error {
+	body, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	reqCtx, cancel := context.WithTimeout(ctx, hookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, s.webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// run executes the command directly, without a shell, passing the
+// transition in P2POS_* environment variables.
+func (s *Service) run(ctx context.Context, t Transition) error {
+	runCtx, cancel := context.WithTimeout(ctx, hookTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(runCtx, s.command[0], s.command[1:]...)
+	cmd.Env = append(os.Environ(),
+		"P2POS_PEER_ID="+t.PeerID,
+		"P2POS_STATE_PREV="+t.Prev,
+		"P2POS_STATE_NEXT="+t.Next,
+		"P2POS_STATE_REASON="+t.Reason,
+		"P2POS_STATE_AT="+t.At.Format(time.RFC3339Nano),
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package statehook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWebhookReceivesTransition(t *testing.T) {
+	received := make(chan Transition, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("webhook called with %s %q", r.Method, r.Header.Get("Content-Type"))
+		}
+		var got Transition
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode payload: %v", err)
+		}
+		received <- got
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := NewService(srv.URL, "")
+	s.Start(ctx)
+	want := Transition{
+		PeerID: "12D3KooWTest",
+		Prev:   "degraded",
+		Next:   "healthy",
+		Reason: "peer-connected:quorum",
+		At:     time.Now().UTC().Truncate(time.Millisecond),
+	}
+	s.Notify(want)
+
+	select {
+	case got := <-received:
+		if got != want {
+			t.Fatalf("webhook got %+v, want %+v", got, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook never called")
+	}
+}
+
+func TestNewServiceWithoutHooks(t *testing.T) {
+	if s := NewService(" ", ""); s != nil {
+		t.Fatal("service created with no webhook or command")
+	}
+	// A nil service ignores every call.
+	var s *Service
+	s.Start(context.Background())
+	s.Notify(Transition{})
+}
@@ -13,6 +13,62 @@ type Record struct {
 	LastSeenAt     time.Time `json:"last_seen_at"`
 	Reachability   string    `json:"reachability"`
 	ObservedBy     string    `json:"observed_by"`
+	Pinned         bool      `json:"pinned,omitempty"`
+	// Region and Tags are advisory labels a member announces about itself.
+	Region string            `json:"region,omitempty"`
+	Tags   map[string]string `json:"tags,omitempty"`
+	// AppVersion is the p2pos version the peer advertised, if known.
+	AppVersion string `json:"app_version,omitempty"`
+	// RTTMs is the reporting observer's latency to the peer. RTT aggregates
+	// every observer's value in merged cluster views, since latency is
+	// path-dependent.
+	RTTMs *float64  `json:"rtt_ms,omitempty"`
+	RTT   *RTTStats `json:"rtt,omitempty"`
+	// DialBreaker is set while the observer is backing off redials.
+	DialBreaker *DialBreaker `json:"dial_breaker,omitempty"`
+	// Conn is what the observer's connection to the peer negotiated.
+	Conn *ConnInfo `json:"conn,omitempty"`
+	// Attestation is the peer's own signed statement of its labels.
+	// Identity is "verified" when it checks out against the peer ID and
+	// the labels shown come from it, "unverified" when labels are shown
+	// without one.
+	Attestation *Attestation `json:"attestation,omitempty"`
+	Identity    string       `json:"identity,omitempty"`
+}
+
+// Attestation is a document a node signs with its own key so that its region,
+// tags and version can be relayed by other peers without being forged.
+type Attestation struct {
+	PeerID     string            `json:"peer_id"`
+	Region     string            `json:"region,omitempty"`
+	Tags       map[string]string `json:"tags,omitempty"`
+	AppVersion string            `json:"app_version,omitempty"`
+	IssuedAt   time.Time         `json:"issued_at"`
+	Sig        string            `json:"sig"`
+}
+
+// ConnInfo names the transport, security protocol and stream muxer of one
+// connection. Security and Muxer are empty for transports that provide their
+// own (QUIC, WebTransport, WebRTC).
+type ConnInfo struct {
+	Transport string `json:"transport"`
+	Security  string `json:"security,omitempty"`
+	Muxer     string `json:"muxer,omitempty"`
+}
+
+// DialBreaker is the observer's redial throttling state for a peer.
+type DialBreaker struct {
+	State       string    `json:"state"`
+	Failures    int       `json:"failures"`
+	NextAttempt time.Time `json:"next_attempt"`
+}
+
+// RTTStats summarises latency samples from several observers.
+type RTTStats struct {
+	MinMs     float64 `json:"min_ms"`
+	MedianMs  float64 `json:"median_ms"`
+	MaxMs     float64 `json:"max_ms"`
+	Observers int     `json:"observers"`
 }
 
 type Repository interface {
@@ -23,6 +23,10 @@ func (t *HeartbeatTask) Interval() time.Duration {
 	return 30 * time.Second
 }
 
+func (t *HeartbeatTask) Timeout() time.Duration {
+	return 25 * time.Second
+}
+
 func (t *HeartbeatTask) RunOnStart() bool {
 	return false
 }
@@ -0,0 +1,39 @@
+package tasks
+
+import (
+	"context"
+	"time"
+
+	"p2pos/internal/network"
+)
+
+type MemberReconnectTask struct {
+	node *network.Node
+}
+
+func NewMemberReconnectTask(node *network.Node) *MemberReconnectTask {
+	return &MemberReconnectTask{node: node}
+}
+
+func (t *MemberReconnectTask) Name() string {
+	return "member-reconnect"
+}
+
+func (t *MemberReconnectTask) Interval() time.Duration {
+	return 30 * time.Second
+}
+
+func (t *MemberReconnectTask) Timeout() time.Duration {
+	return 25 * time.Second
+}
+
+func (t *MemberReconnectTask) RunOnStart() bool {
+	return false
+}
+
+func (t *MemberReconnectTask) Run(ctx context.Context) error {
+	if t.node == nil {
+		return nil
+	}
+	return t.node.ReconnectMembers(ctx)
+}
@@ -23,6 +23,10 @@ func (t *MembershipSyncTask) Interval() time.Duration {
 	return 30 * time.Second
 }
 
+func (t *MembershipSyncTask) Timeout() time.Duration {
+	return 25 * time.Second
+}
+
 func (t *MembershipSyncTask) RunOnStart() bool {
 	return false
 }
@@ -0,0 +1,35 @@
+package tasks
+
+import (
+	"context"
+	"time"
+
+	"p2pos/internal/network"
+)
+
+type PeerstoreReconcileTask struct {
+	node *network.Node
+}
+
+func NewPeerstoreReconcileTask(node *network.Node) *PeerstoreReconcileTask {
+	return &PeerstoreReconcileTask{node: node}
+}
+
+func (t *PeerstoreReconcileTask) Name() string {
+	return "peerstore-reconcile"
+}
+
+func (t *PeerstoreReconcileTask) Interval() time.Duration {
+	return 5 * time.Minute
+}
+
+func (t *PeerstoreReconcileTask) RunOnStart() bool {
+	return false
+}
+
+func (t *PeerstoreReconcileTask) Run(ctx context.Context) error {
+	if t.node == nil {
+		return nil
+	}
+	return t.node.ReconcilePeerstore(ctx)
+}
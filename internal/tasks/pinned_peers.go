@@ -0,0 +1,35 @@
+package tasks
+
+import (
+	"context"
+	"time"
+
+	"p2pos/internal/network"
+)
+
+type PinnedPeersTask struct {
+	node *network.Node
+}
+
+func NewPinnedPeersTask(node *network.Node) *PinnedPeersTask {
+	return &PinnedPeersTask{node: node}
+}
+
+func (t *PinnedPeersTask) Name() string {
+	return "pinned-peers"
+}
+
+func (t *PinnedPeersTask) Interval() time.Duration {
+	return 15 * time.Second
+}
+
+func (t *PinnedPeersTask) RunOnStart() bool {
+	return true
+}
+
+func (t *PinnedPeersTask) Run(ctx context.Context) error {
+	if t.node == nil {
+		return nil
+	}
+	return t.node.ReconnectPinnedPeers(ctx)
+}
@@ -0,0 +1,56 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"p2pos/internal/logging"
+)
+
+type RecordPruner interface {
+	PruneBefore(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// RecordRetentionTask deletes records older than the retention window.
+type RecordRetentionTask struct {
+	pruner    RecordPruner
+	retention time.Duration
+}
+
+func NewRecordRetentionTask(pruner RecordPruner, retention time.Duration) *RecordRetentionTask {
+	return &RecordRetentionTask{
+		pruner:    pruner,
+		retention: retention,
+	}
+}
+
+func (t *RecordRetentionTask) Name() string {
+	return "record-retention"
+}
+
+func (t *RecordRetentionTask) Interval() time.Duration {
+	return time.Hour
+}
+
+func (t *RecordRetentionTask) RunOnStart() bool {
+	return true
+}
+
+func (t *RecordRetentionTask) Run(ctx context.Context) error {
+	if t.pruner == nil || t.retention <= 0 {
+		return nil
+	}
+	cutoff := time.Now().UTC().Add(-t.retention)
+	pruned, err := t.pruner.PruneBefore(ctx, cutoff)
+	if err != nil {
+		return err
+	}
+	if pruned > 0 {
+		logging.Log("DB", "records_pruned", map[string]string{
+			"count":  fmt.Sprintf("%d", pruned),
+			"cutoff": cutoff.Format(time.RFC3339),
+		})
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package tasks
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type cutoffRecorder struct {
+	cutoffs []time.Time
+}
+
+func (r *cutoffRecorder) PruneBefore(_ context.Context, cutoff time.Time) (int64, error) {
+	r.cutoffs = append(r.cutoffs, cutoff)
+	return 0, nil
+}
+
+func TestRecordRetentionCutoff(t *testing.T) {
+	pruner := &cutoffRecorder{}
+	before := time.Now().UTC()
+	if err := NewRecordRetentionTask(pruner, 30*24*time.Hour).Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(pruner.cutoffs) != 1 {
+		t.Fatalf("pruned %d times, want once", len(pruner.cutoffs))
+	}
+	if age := before.Sub(pruner.cutoffs[0]); age < 30*24*time.Hour-time.Second || age > 30*24*time.Hour+time.Second {
+		t.Fatalf("cutoff %s is %s old, want the 30 day retention", pruner.cutoffs[0], age)
+	}
+
+	if err := NewRecordRetentionTask(pruner, 0).Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(pruner.cutoffs) != 1 {
+		t.Fatal("a zero retention pruned records")
+	}
+}
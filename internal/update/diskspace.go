@@ -0,0 +1,35 @@
+package update
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+)
+
+var ErrInsufficientDiskSpace = errors.New("insufficient disk space for update")
+
+// freeSpace reports the bytes available to unprivileged users on the
+// filesystem holding path. It is a variable so the check can be faked.
+var freeSpace = diskFreeBytes
+
+const diskCheckEveryBytes = 8 << 20
+
+// checkFreeSpace fails when writing need more bytes next to targetPath would
+// leave less than margin free.
+func checkFreeSpace(targetPath string, need, margin int64) error {
+	if margin <= 0 && need <= 0 {
+		return nil
+	}
+	free, err := freeSpace(filepath.Dir(targetPath))
+	if err != nil {
+		// Not every platform/filesystem reports space; don't block updates on it.
+		return nil
+	}
+	if need < 0 {
+		need = 0
+	}
+	if uint64(need+margin) > free {
+		return fmt.Errorf("%w: need %d bytes plus %d margin, %d free", ErrInsufficientDiskSpace, need, margin, free)
+	}
+	return nil
+}
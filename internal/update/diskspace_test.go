@@ -0,0 +1,55 @@
+package update
+
+import (
+	"errors"
+	"testing"
+)
+
+func fakeFreeSpace(t *testing.T, free uint64, err error) {
+	t.Helper()
+	prev := freeSpace
+	freeSpace = func(string) (uint64, error) { return free, err }
+	t.Cleanup(func() { freeSpace = prev })
+}
+
+func TestCheckFreeSpace(t *testing.T) {
+	cases := []struct {
+		free         uint64
+		need, margin int64
+		ok           bool
+	}{
+		{free: 1000, need: 400, margin: 500, ok: true},
+		{free: 1000, need: 600, margin: 500, ok: false},
+		{free: 100, need: 0, margin: 0, ok: true},
+		{free: 100, need: -1, margin: 100, ok: true},
+		{free: 99, need: 0, margin: 100, ok: false},
+	}
+	for _, tc := range cases {
+		fakeFreeSpace(t, tc.free, nil)
+		err := checkFreeSpace("/opt/p2pos/p2pos", tc.need, tc.margin)
+		if tc.ok && err != nil {
+			t.Errorf("free=%d need=%d margin=%d: %v", tc.free, tc.need, tc.margin, err)
+		}
+		if !tc.ok && !errors.Is(err, ErrInsufficientDiskSpace) {
+			t.Errorf("free=%d need=%d margin=%d: got %v, want ErrInsufficientDiskSpace", tc.free, tc.need, tc.margin, err)
+		}
+	}
+}
+
+func TestCheckFreeSpaceIgnoresUnknownSpace(t *testing.T) {
+	fakeFreeSpace(t, 0, errors.New("statfs unsupported"))
+	if err := checkFreeSpace("/opt/p2pos/p2pos", 1<<30, 1<<30); err != nil {
+		t.Fatalf("unknown free space blocked the update: %v", err)
+	}
+}
+
+func TestDownloadBinaryRefusesWithoutSpace(t *testing.T) {
+	fakeFreeSpace(t, 10000, nil)
+	target := writeTarget(t)
+	server := serveBinary(t, 8000, true)
+	err := DownloadBinary(server.URL, target, 0, 4000)
+	if !errors.Is(err, ErrInsufficientDiskSpace) {
+		t.Fatalf("got %v, want ErrInsufficientDiskSpace", err)
+	}
+	assertUntouched(t, target)
+}
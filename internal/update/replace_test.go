@@ -0,0 +1,98 @@
+package update
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func writeSwapFiles(t *testing.T) (newPath, target string) {
+	t.Helper()
+	dir := t.TempDir()
+	newPath = filepath.Join(dir, "p2pos.tmp")
+	target = filepath.Join(dir, "p2pos")
+	if err := os.WriteFile(newPath, []byte("new"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(target, []byte("old"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return newPath, target
+}
+
+func assertContent(t *testing.T, path, want string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != want {
+		t.Fatalf("%s holds %q (%v), want %q", filepath.Base(path), data, err, want)
+	}
+}
+
+func TestReplaceBinaryInPlace(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix replaces the binary in place")
+	}
+	newPath, target := writeSwapFiles(t)
+	if err := replaceBinary(newPath, target); err != nil {
+		t.Fatal(err)
+	}
+	assertContent(t, target, "new")
+	if _, err := os.Stat(target + ".old"); !os.IsNotExist(err) {
+		t.Fatalf("in-place swap left a .old file: %v", err)
+	}
+}
+
+func TestReplaceBinaryMovesRunningAside(t *testing.T) {
+	if runtime.GOOS != "windows" {
+		t.Skip("only Windows moves the running binary aside")
+	}
+	newPath, target := writeSwapFiles(t)
+	// A stale .old from an earlier update must not block the swap.
+	if err := os.WriteFile(target+".old", []byte("stale"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := replaceBinary(newPath, target); err != nil {
+		t.Fatal(err)
+	}
+	assertContent(t, target, "new")
+	assertContent(t, target+".old", "old")
+}
+
+func TestReplaceBinaryRestoresOnFailure(t *testing.T) {
+	if runtime.GOOS != "windows" {
+		t.Skip("only Windows moves the running binary aside")
+	}
+	newPath, target := writeSwapFiles(t)
+	if err := os.Remove(newPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := replaceBinary(newPath, target); err == nil {
+		t.Fatal("swap with a missing download succeeded")
+	}
+	assertContent(t, target, "old")
+}
+
+func TestDownloadBinarySwapsTarget(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("new"))
+	}))
+	t.Cleanup(server.Close)
+	target := filepath.Join(t.TempDir(), "p2pos")
+	if err := os.WriteFile(target, []byte("old"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := DownloadBinary(server.URL, target, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	assertContent(t, target, "new")
+	if _, err := os.Stat(target + ".tmp"); !os.IsNotExist(err) {
+		t.Fatalf("download left its .tmp file: %v", err)
+	}
+	if runtime.GOOS == "windows" {
+		assertContent(t, target+".old", "old")
+	}
+}
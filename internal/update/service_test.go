@@ -0,0 +1,154 @@
+package update
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"p2pos/internal/config"
+)
+
+type testProvider struct {
+	feedURL string
+	ceiling string
+	windows []string
+}
+
+func (p testProvider) UpdateFeedURL() (string, error) { return p.feedURL, nil }
+func (p testProvider) UpdateChannel() string          { return "stable" }
+func (p testProvider) MaxUpdateSizeBytes() int64      { return 0 }
+func (p testProvider) UpdateMinFreeBytes() int64      { return 0 }
+func (p testProvider) UpdateWindows() []string        { return p.windows }
+func (p testProvider) MaxUpdateVersion() string       { return p.ceiling }
+
+// releaseFeed serves a single release tagged latest. Its binary always
+// answers 404 so an allowed update stops before touching the test binary.
+type releaseFeed struct {
+	*httptest.Server
+	checks    atomic.Int32
+	downloads atomic.Int32
+	// hold, when set, blocks feed requests until it is closed.
+	hold chan struct{}
+}
+
+func newReleaseFeed(t *testing.T, latest string) *releaseFeed {
+	t.Helper()
+	feed := &releaseFeed{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/latest", func(w http.ResponseWriter, _ *http.Request) {
+		feed.checks.Add(1)
+		if feed.hold != nil {
+			<-feed.hold
+		}
+		release := GithubRelease{TagName: latest}
+		release.Assets = append(release.Assets, struct {
+			Name               string `json:"name"`
+			BrowserDownloadURL string `json:"browser_download_url"`
+		}{getBinaryName(), feed.URL + "/binary"})
+		_ = json.NewEncoder(w).Encode(release)
+	})
+	mux.HandleFunc("/binary", func(w http.ResponseWriter, _ *http.Request) {
+		feed.downloads.Add(1)
+		http.NotFound(w, nil)
+	})
+	feed.Server = httptest.NewServer(mux)
+	t.Cleanup(feed.Close)
+	return feed
+}
+
+func setAppVersion(t *testing.T, version string) {
+	t.Helper()
+	prev := config.AppVersion
+	config.AppVersion = version
+	t.Cleanup(func() { config.AppVersion = prev })
+}
+
+func TestRunOnceSkipsOverlappingRun(t *testing.T) {
+	setAppVersion(t, "20260101-0000")
+	feed := newReleaseFeed(t, "20260101-0000")
+	feed.hold = make(chan struct{})
+	service := NewService(testProvider{feedURL: feed.URL + "/latest"}, nil)
+
+	first := make(chan error, 1)
+	go func() { first <- service.RunOnce(context.Background()) }()
+	deadline := time.Now().Add(5 * time.Second)
+	for feed.checks.Load() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("first run never reached the feed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	second := make(chan error, 1)
+	go func() { second <- service.RunOnce(context.Background()) }()
+	select {
+	case err := <-second:
+		if err != nil {
+			t.Fatalf("overlapping run: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("overlapping run queued behind the first")
+	}
+	if got := feed.checks.Load(); got != 1 {
+		t.Fatalf("feed checked %d times, want only the first run", got)
+	}
+
+	close(feed.hold)
+	if err := <-first; err != nil {
+		t.Fatal(err)
+	}
+	// The guard is released once the first run returns.
+	if err := service.RunOnce(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := feed.checks.Load(); got != 2 {
+		t.Fatalf("feed checked %d times after the first run finished, want 2", got)
+	}
+}
+
+func TestAboveCeiling(t *testing.T) {
+	cases := []struct {
+		version, ceiling string
+		want             bool
+	}{
+		{"v20260301-1200", "", false},
+		{"v20260301-1200", "  ", false},
+		{"v20260301-1200", "20260301-1200", false},
+		{"v20260301-1200", "v20260401-0000", false},
+		{"v20260301-1200", "20260301-1159", true},
+		{"20260301-1200", "20260301-1200-dev", true},
+	}
+	for _, tc := range cases {
+		if got := aboveCeiling(tc.version, tc.ceiling); got != tc.want {
+			t.Errorf("aboveCeiling(%q, %q) = %v, want %v", tc.version, tc.ceiling, got, tc.want)
+		}
+	}
+}
+
+func TestRunOnceMaxUpdateVersion(t *testing.T) {
+	setAppVersion(t, "20260101-0000")
+	cases := []struct {
+		ceiling  string
+		download bool
+	}{
+		{"", true},
+		{"v20260301-1200", true},
+		{"20260201-0000", false},
+	}
+	for _, tc := range cases {
+		feed := newReleaseFeed(t, "v20260301-1200")
+		service := NewService(testProvider{feedURL: feed.URL + "/latest", ceiling: tc.ceiling}, nil)
+		err := service.RunOnce(context.Background())
+		if got := feed.downloads.Load() > 0; got != tc.download {
+			t.Errorf("ceiling %q: downloaded=%v, want %v", tc.ceiling, got, tc.download)
+		}
+		// The fake binary is missing, so only a held update returns cleanly.
+		if tc.download != (err != nil) {
+			t.Errorf("ceiling %q: err=%v", tc.ceiling, err)
+		}
+	}
+}
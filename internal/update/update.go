@@ -3,6 +3,7 @@ package update
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -13,29 +14,47 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"p2pos/internal/audit"
 	"p2pos/internal/config"
 	"p2pos/internal/logging"
 )
 
 var versionPattern = regexp.MustCompile(`^(\d{8})-(\d{4})(-dev)?$`)
 
+// ErrUpdateTooLarge is returned when a download exceeds the configured size cap.
+var ErrUpdateTooLarge = errors.New("update exceeds maximum download size")
+
 type Service struct {
 	configProvider FeedURLProvider
 	shutdown       ShutdownRequester
+	auditor        AdminAuditor
 	mu             sync.Mutex
+	// running is set while RunOnce checks or downloads, so an overlapping
+	// scheduled run returns at once instead of queuing behind it.
+	running atomic.Bool
 }
 
 type FeedURLProvider interface {
 	UpdateFeedURL() (string, error)
 	UpdateChannel() string
+	MaxUpdateSizeBytes() int64
+	UpdateMinFreeBytes() int64
+	UpdateWindows() []string
+	MaxUpdateVersion() string
 }
 
 type ShutdownRequester interface {
 	RequestShutdown(reason string)
 }
 
+// AdminAuditor records applied updates in the admin audit chain.
+type AdminAuditor interface {
+	RecordAdminAction(ctx context.Context, action string, detail map[string]string)
+}
+
 func NewService(configProvider FeedURLProvider, shutdown ShutdownRequester) *Service {
 	return &Service{
 		configProvider: configProvider,
@@ -43,6 +62,12 @@ func NewService(configProvider FeedURLProvider, shutdown ShutdownRequester) *Ser
 	}
 }
 
+func (s *Service) SetAuditor(auditor AdminAuditor) {
+	s.mu.Lock()
+	s.auditor = auditor
+	s.mu.Unlock()
+}
+
 // GithubRelease represents a GitHub release
 type GithubRelease struct {
 	TagName    string `json:"tag_name"`
@@ -209,8 +234,9 @@ func getBinaryName() string {
 	}
 }
 
-// DownloadBinary downloads the binary from the given URL
-func DownloadBinary(url, targetPath string) error {
+// DownloadBinary downloads the binary from the given URL. Downloads larger
+// than maxBytes are aborted with ErrUpdateTooLarge; maxBytes <= 0 disables the cap.
+func DownloadBinary(url, targetPath string, maxBytes, minFreeBytes int64) error {
 	resp, err := http.Get(url)
 	if err != nil {
 		return fmt.Errorf("failed to download binary: %w", err)
@@ -221,6 +247,15 @@ func DownloadBinary(url, targetPath string) error {
 		return fmt.Errorf("download returned status %d", resp.StatusCode)
 	}
 
+	if maxBytes > 0 && resp.ContentLength > maxBytes {
+		return fmt.Errorf("%w: content-length %d > %d", ErrUpdateTooLarge, resp.ContentLength, maxBytes)
+	}
+	if resp.ContentLength > 0 {
+		if err := checkFreeSpace(targetPath, resp.ContentLength, minFreeBytes); err != nil {
+			return err
+		}
+	}
+
 	// Write to temporary file first
 	tmpFile := targetPath + ".tmp"
 	f, err := os.Create(tmpFile)
@@ -233,17 +268,32 @@ func DownloadBinary(url, targetPath string) error {
 	var downloaded int64
 	nextPercent := int64(5)
 	nextUnknownLogBytes := int64(5 * 1024 * 1024) // 5 MiB
+	nextDiskCheck := int64(diskCheckEveryBytes)
 	startTime := time.Now().UTC()
 	buf := make([]byte, 32*1024)
 
 	for {
 		n, readErr := resp.Body.Read(buf)
 		if n > 0 {
+			if maxBytes > 0 && downloaded+int64(n) > maxBytes {
+				f.Close()
+				os.Remove(tmpFile)
+				return fmt.Errorf("%w: received more than %d bytes", ErrUpdateTooLarge, maxBytes)
+			}
 			if _, err := f.Write(buf[:n]); err != nil {
+				f.Close()
 				os.Remove(tmpFile)
 				return fmt.Errorf("failed to write binary: %w", err)
 			}
 			downloaded += int64(n)
+			if totalSize <= 0 && downloaded >= nextDiskCheck {
+				if err := checkFreeSpace(targetPath, 0, minFreeBytes); err != nil {
+					f.Close()
+					os.Remove(tmpFile)
+					return err
+				}
+				nextDiskCheck += diskCheckEveryBytes
+			}
 
 			elapsed := time.Since(startTime).Seconds()
 			if elapsed <= 0 {
@@ -275,11 +325,18 @@ func DownloadBinary(url, targetPath string) error {
 			if readErr == io.EOF {
 				break
 			}
+			f.Close()
 			os.Remove(tmpFile)
 			return fmt.Errorf("failed to read download stream: %w", readErr)
 		}
 	}
 
+	// Close before the swap: Windows cannot rename a file that is still open.
+	if err := f.Close(); err != nil {
+		os.Remove(tmpFile)
+		return fmt.Errorf("failed to write binary: %w", err)
+	}
+
 	// Make executable on Unix-like systems
 	if runtime.GOOS != "windows" {
 		if err := os.Chmod(tmpFile, 0755); err != nil {
@@ -288,9 +345,7 @@ func DownloadBinary(url, targetPath string) error {
 		}
 	}
 
-	// Replace old binary with new one
-	// On Windows, we need to stop the process first
-	if err := os.Rename(tmpFile, targetPath); err != nil {
+	if err := replaceBinary(tmpFile, targetPath); err != nil {
 		os.Remove(tmpFile)
 		return fmt.Errorf("failed to replace binary: %w", err)
 	}
@@ -298,11 +353,57 @@ func DownloadBinary(url, targetPath string) error {
 	return nil
 }
 
+// replaceBinary moves newPath over targetPath. Windows refuses to overwrite a
+// running executable but does allow renaming it, so there the current binary
+// is first moved aside to <target>.old; the restarted process removes it via
+// CleanupPreviousBinary. Unix replaces in place.
+func replaceBinary(newPath, targetPath string) error {
+	if runtime.GOOS != "windows" {
+		return os.Rename(newPath, targetPath)
+	}
+
+	oldPath := targetPath + ".old"
+	_ = os.Remove(oldPath)
+	if err := os.Rename(targetPath, oldPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("move running binary aside: %w", err)
+	}
+	if err := os.Rename(newPath, targetPath); err != nil {
+		// Put the original back so the service can still restart.
+		_ = os.Rename(oldPath, targetPath)
+		return err
+	}
+	return nil
+}
+
+// CleanupPreviousBinary removes the <exe>.old left behind by a Windows update.
+func CleanupPreviousBinary() {
+	exePath, err := os.Executable()
+	if err != nil {
+		return
+	}
+	oldPath := exePath + ".old"
+	if err := os.Remove(oldPath); err == nil {
+		logging.Log("UPDATE", "cleanup_previous_binary", map[string]string{
+			"path": oldPath,
+		})
+	}
+}
+
 // CheckAndUpdate checks for updates and applies them if available.
-func CheckAndUpdate(feedURL, channel string) (bool, error) {
+func CheckAndUpdate(feedURL, channel string, maxBytes, minFreeBytes int64) (bool, error) {
+	latestVersion, downloadURL, newer, err := checkLatest(feedURL, channel)
+	if err != nil || !newer {
+		return false, err
+	}
+	return applyUpdate(latestVersion, downloadURL, maxBytes, minFreeBytes)
+}
+
+// checkLatest reports the latest release for channel and whether it is newer
+// than the running version.
+func checkLatest(feedURL, channel string) (string, string, bool, error) {
 	latestVersion, downloadURL, err := GetLatestVersion(feedURL, channel)
 	if err != nil {
-		return false, fmt.Errorf("failed to check for updates: %w", err)
+		return "", "", false, fmt.Errorf("failed to check for updates: %w", err)
 	}
 
 	// Remove 'v' prefix if present for comparison
@@ -314,13 +415,27 @@ func CheckAndUpdate(feedURL, channel string) (bool, error) {
 		logging.Log("UPDATE", "already_latest", map[string]string{
 			"version": config.AppVersion,
 		})
-		return false, nil
+		return latestVersion, downloadURL, false, nil
 	}
 
 	logging.Log("UPDATE", "new_version", map[string]string{
 		"latest":  latestVersion,
 		"current": config.AppVersion,
 	})
+	return latestVersion, downloadURL, true, nil
+}
+
+// aboveCeiling reports whether version is past max_update_version. An empty
+// ceiling allows every version.
+func aboveCeiling(version, ceiling string) bool {
+	ceiling = strings.TrimSpace(ceiling)
+	if ceiling == "" {
+		return false
+	}
+	return compareVersion(strings.TrimPrefix(version, "v"), strings.TrimPrefix(ceiling, "v")) > 0
+}
+
+func applyUpdate(latestVersion, downloadURL string, maxBytes, minFreeBytes int64) (bool, error) {
 	logging.Log("UPDATE", "download_from", map[string]string{
 		"url": downloadURL,
 	})
@@ -333,7 +448,7 @@ func CheckAndUpdate(feedURL, channel string) (bool, error) {
 
 	// Download the new binary
 	logging.Log("UPDATE", "download_start", nil)
-	if err := DownloadBinary(downloadURL, exePath); err != nil {
+	if err := DownloadBinary(downloadURL, exePath, maxBytes, minFreeBytes); err != nil {
 		return false, fmt.Errorf("failed to update binary: %w", err)
 	}
 
@@ -415,8 +530,13 @@ func compareVersion(a, b string) int {
 }
 
 func (s *Service) RunOnce(ctx context.Context) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	if !s.running.CompareAndSwap(false, true) {
+		logging.Log("UPDATE", "skip", map[string]string{
+			"reason": "update already in progress",
+		})
+		return nil
+	}
+	defer s.running.Store(false)
 
 	feedURL, err := s.configProvider.UpdateFeedURL()
 	if err != nil {
@@ -424,10 +544,38 @@ func (s *Service) RunOnce(ctx context.Context) error {
 	}
 	channel := s.configProvider.UpdateChannel()
 
+	windows, err := ParseWindows(s.configProvider.UpdateWindows())
+	if err != nil {
+		return fmt.Errorf("load update windows failed: %w", err)
+	}
+
 	logging.Log("UPDATE", "check", map[string]string{
 		"channel": channel,
 	})
-	updated, err := CheckAndUpdate(feedURL, channel)
+	latestVersion, downloadURL, newer, err := checkLatest(feedURL, channel)
+	if err != nil {
+		return fmt.Errorf("check failed: %w", err)
+	}
+	if !newer {
+		return nil
+	}
+	if ceiling := s.configProvider.MaxUpdateVersion(); aboveCeiling(latestVersion, ceiling) {
+		logging.Log("UPDATE", "held_by_ceiling", map[string]string{
+			"latest":  latestVersion,
+			"ceiling": ceiling,
+			"current": config.AppVersion,
+		})
+		return nil
+	}
+	if !InWindows(windows, time.Now()) {
+		logging.Log("UPDATE", "deferred", map[string]string{
+			"latest":  latestVersion,
+			"reason":  "outside_update_window",
+			"windows": strings.Join(s.configProvider.UpdateWindows(), ";"),
+		})
+		return nil
+	}
+	updated, err := applyUpdate(latestVersion, downloadURL, s.configProvider.MaxUpdateSizeBytes(), s.configProvider.UpdateMinFreeBytes())
 	if err != nil {
 		return fmt.Errorf("check failed: %w", err)
 	}
@@ -435,6 +583,16 @@ func (s *Service) RunOnce(ctx context.Context) error {
 		return nil
 	}
 
+	s.mu.Lock()
+	auditor := s.auditor
+	s.mu.Unlock()
+	if auditor != nil {
+		auditor.RecordAdminAction(ctx, audit.ActionUpdateApply, map[string]string{
+			"from":    config.AppVersion,
+			"to":      latestVersion,
+			"channel": channel,
+		})
+	}
 	logging.Log("UPDATE", "applied_shutdown", nil)
 	if s.shutdown != nil {
 		s.shutdown.RequestShutdown("update-applied")
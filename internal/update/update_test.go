@@ -0,0 +1,83 @@
+package update
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// serveBinary serves size bytes, announcing the length only when
+// withLength is set.
+func serveBinary(t *testing.T, size int, withLength bool) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if withLength {
+			w.Header().Set("Content-Length", strconv.Itoa(size))
+		}
+		chunk := make([]byte, 4096)
+		for sent := 0; sent < size; sent += len(chunk) {
+			if rest := size - sent; rest < len(chunk) {
+				chunk = chunk[:rest]
+			}
+			if _, err := w.Write(chunk); err != nil {
+				return
+			}
+			if f, ok := w.(http.Flusher); ok {
+				f.Flush()
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func writeTarget(t *testing.T) string {
+	t.Helper()
+	target := filepath.Join(t.TempDir(), "p2pos")
+	if err := os.WriteFile(target, []byte("current"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return target
+}
+
+func assertUntouched(t *testing.T, target string) {
+	t.Helper()
+	data, err := os.ReadFile(target)
+	if err != nil || string(data) != "current" {
+		t.Fatalf("running binary changed: %q, %v", data, err)
+	}
+	if _, err := os.Stat(target + ".tmp"); !os.IsNotExist(err) {
+		t.Fatalf("partial download left behind: %v", err)
+	}
+}
+
+func TestDownloadBinaryAbortsOverMaxSize(t *testing.T) {
+	const limit = 64 << 10
+	for name, withLength := range map[string]bool{"streamed": false, "content-length": true} {
+		t.Run(name, func(t *testing.T) {
+			target := writeTarget(t)
+			server := serveBinary(t, 4*limit, withLength)
+			err := DownloadBinary(server.URL, target, limit, 0)
+			if !errors.Is(err, ErrUpdateTooLarge) {
+				t.Fatalf("got %v, want ErrUpdateTooLarge", err)
+			}
+			assertUntouched(t, target)
+		})
+	}
+}
+
+func TestDownloadBinaryWithinMaxSize(t *testing.T) {
+	target := writeTarget(t)
+	server := serveBinary(t, 10000, false)
+	if err := DownloadBinary(server.URL, target, 10000, 0); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(target)
+	if err != nil || info.Size() != 10000 {
+		t.Fatalf("replaced binary: %v, %v", info, err)
+	}
+}
@@ -0,0 +1,12 @@
+package update
+
+// CompareVersion compares two p2pos versions (YYYYMMDD-HHMM[-dev]) and
+// returns -1, 0 or 1.
+func CompareVersion(a, b string) int {
+	return compareVersion(a, b)
+}
+
+// ValidVersion reports whether v is a well-formed p2pos version.
+func ValidVersion(v string) bool {
+	return parseVersion(v).ok
+}
@@ -0,0 +1,144 @@
+package update
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Window is a daily time-of-day range, optionally restricted to some weekdays.
+// End before Start wraps past midnight; the day set applies to the start day.
+type Window struct {
+	days  [7]bool
+	start int // minutes since midnight
+	end   int
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ParseWindow parses "[DAYS ]HH:MM-HH:MM", where DAYS is a comma-separated
+// list of weekdays or weekday ranges, e.g. "Mon-Fri 02:00-04:00" or
+// "Sat,Sun 22:00-06:00". Without DAYS the window applies every day.
+func ParseWindow(raw string) (Window, error) {
+	fields := strings.Fields(raw)
+	var daysPart, timePart string
+	switch len(fields) {
+	case 1:
+		timePart = fields[0]
+	case 2:
+		daysPart, timePart = fields[0], fields[1]
+	default:
+		return Window{}, fmt.Errorf("invalid update window %q", raw)
+	}
+
+	w := Window{}
+	if daysPart == "" {
+		for i := range w.days {
+			w.days[i] = true
+		}
+	} else if err := parseWindowDays(daysPart, &w.days); err != nil {
+		return Window{}, fmt.Errorf("invalid update window %q: %w", raw, err)
+	}
+
+	startRaw, endRaw, ok := strings.Cut(timePart, "-")
+	if !ok {
+		return Window{}, fmt.Errorf("invalid update window %q: expected HH:MM-HH:MM", raw)
+	}
+	var err error
+	if w.start, err = parseClock(startRaw); err != nil {
+		return Window{}, fmt.Errorf("invalid update window %q: %w", raw, err)
+	}
+	if w.end, err = parseClock(endRaw); err != nil {
+		return Window{}, fmt.Errorf("invalid update window %q: %w", raw, err)
+	}
+	if w.start == w.end {
+		return Window{}, fmt.Errorf("invalid update window %q: empty range", raw)
+	}
+	return w, nil
+}
+
+func ParseWindows(raw []string) ([]Window, error) {
+	out := make([]Window, 0, len(raw))
+	for _, entry := range raw {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		w, err := ParseWindow(entry)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, w)
+	}
+	return out, nil
+}
+
+// Contains reports whether t falls inside the window, in t's location.
+func (w Window) Contains(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	day := t.Weekday()
+	if w.start < w.end {
+		return w.days[day] && minute >= w.start && minute < w.end
+	}
+	// Wraps midnight: the late part belongs to today, the early part to yesterday.
+	if minute >= w.start {
+		return w.days[day]
+	}
+	if minute < w.end {
+		return w.days[(day+6)%7]
+	}
+	return false
+}
+
+// InWindows reports whether t falls in any window. No windows means always.
+func InWindows(windows []Window, t time.Time) bool {
+	if len(windows) == 0 {
+		return true
+	}
+	for _, w := range windows {
+		if w.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseWindowDays(raw string, days *[7]bool) error {
+	for _, part := range strings.Split(raw, ",") {
+		from, to, isRange := strings.Cut(part, "-")
+		start, ok := weekdayNames[strings.ToLower(strings.TrimSpace(from))]
+		if !ok {
+			return fmt.Errorf("unknown weekday %q", from)
+		}
+		if !isRange {
+			days[start] = true
+			continue
+		}
+		end, ok := weekdayNames[strings.ToLower(strings.TrimSpace(to))]
+		if !ok {
+			return fmt.Errorf("unknown weekday %q", to)
+		}
+		for d := start; ; d = (d + 1) % 7 {
+			days[d] = true
+			if d == end {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func parseClock(raw string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(raw))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q", raw)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
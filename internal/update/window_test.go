@@ -0,0 +1,78 @@
+package update
+
+import (
+	"testing"
+	"time"
+)
+
+// 2026-10-12 is a Monday.
+func at(day, hour, minute int) time.Time {
+	return time.Date(2026, 10, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestWindowContains(t *testing.T) {
+	cases := []struct {
+		window string
+		at     time.Time
+		want   bool
+	}{
+		{"02:00-04:00", at(12, 3, 0), true},
+		{"02:00-04:00", at(12, 2, 0), true},
+		{"02:00-04:00", at(12, 4, 0), false},
+		{"02:00-04:00", at(12, 12, 0), false},
+		{"Mon-Fri 02:00-04:00", at(16, 3, 0), true},
+		{"Mon-Fri 02:00-04:00", at(17, 3, 0), false},
+		{"Fri-Mon 02:00-04:00", at(12, 3, 0), true},
+		// Wrapping windows keep the early hours of the next day.
+		{"Sat 22:00-06:00", at(17, 23, 0), true},
+		{"Sat 22:00-06:00", at(18, 5, 59), true},
+		{"Sat 22:00-06:00", at(18, 6, 0), false},
+		{"Sat 22:00-06:00", at(18, 23, 0), false},
+		{"Sat 22:00-06:00", at(17, 5, 0), false},
+	}
+	for _, tc := range cases {
+		w, err := ParseWindow(tc.window)
+		if err != nil {
+			t.Fatalf("ParseWindow(%q): %v", tc.window, err)
+		}
+		if got := w.Contains(tc.at); got != tc.want {
+			t.Errorf("%q contains %s = %v, want %v", tc.window, tc.at.Format("Mon 15:04"), got, tc.want)
+		}
+	}
+}
+
+func TestParseWindowRejectsInvalid(t *testing.T) {
+	for _, raw := range []string{"", "02:00", "25:00-04:00", "Mon 02:00-02:00", "Funday 02:00-04:00", "Mon 02:00-04:00 extra"} {
+		if _, err := ParseWindow(raw); err == nil {
+			t.Errorf("ParseWindow(%q) accepted", raw)
+		}
+	}
+}
+
+func TestInWindowsDefersUntilBoundary(t *testing.T) {
+	windows, err := ParseWindows([]string{"Mon 02:00-04:00", "", "Wed 02:00-04:00"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !InWindows(nil, at(12, 12, 0)) {
+		t.Fatal("no windows must always allow updates")
+	}
+
+	// Hourly checks from Monday 00:00: deferred until the window opens at
+	// 02:00, applied inside it, deferred again from 04:00.
+	var applied []int
+	for hour := 0; hour < 6; hour++ {
+		if InWindows(windows, at(12, hour, 0)) {
+			applied = append(applied, hour)
+		}
+	}
+	if len(applied) != 2 || applied[0] != 2 || applied[1] != 3 {
+		t.Fatalf("applied at hours %v, want [2 3]", applied)
+	}
+	if InWindows(windows, at(13, 3, 0)) {
+		t.Fatal("Tuesday is outside every window")
+	}
+	if !InWindows(windows, at(14, 3, 0)) {
+		t.Fatal("Wednesday's window is ignored")
+	}
+}
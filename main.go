@@ -16,6 +16,60 @@ func main() {
 		return
 	}
 
+	if len(os.Args) > 1 && os.Args[1] == "backup" {
+		if err := app.RunBackup(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, "backup failed:", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "restore" {
+		if err := app.RunRestore(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, "restore failed:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "export-membership" {
+		if err := app.RunExportMembership(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, "export-membership failed:", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "import-membership" {
+		if err := app.RunImportMembership(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, "import-membership failed:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "sign-snapshot" {
+		if err := app.RunSignSnapshot(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, "sign-snapshot failed:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "sign-bootstrap" {
+		if err := app.RunSignBootstrap(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, "sign-bootstrap failed:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "dns-records" {
+		if err := app.RunDNSRecords(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, "dns-records failed:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	if err := app.Run(os.Args[1:]); err != nil {
 		panic(fmt.Errorf("app startup failed: %w", err))
 	}